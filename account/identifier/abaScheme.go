@@ -0,0 +1,47 @@
+package identifier
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// US ABA routing number followed by the account number, e.g. "021000021 123456789"
+var abaPattern = regexp.MustCompile("^([0-9]{9})[ /]([0-9]{4,17})$")
+
+var abaWeights = []int{3, 7, 1, 3, 7, 1, 3, 7, 1}
+
+type abaScheme struct {
+}
+
+func NewAbaScheme() Scheme {
+	return &abaScheme{}
+}
+
+func (as *abaScheme) Name() string {
+	return "ABA"
+}
+
+func (as *abaScheme) Accepts(value string) bool {
+	return abaPattern.MatchString(value)
+}
+
+func (as *abaScheme) Normalize(value string) (string, error) {
+
+	parts := abaPattern.FindStringSubmatch(value)
+
+	sum := 0
+
+	for index, digit := range parts[1] {
+		sum += int(digit-'0') * abaWeights[index]
+	}
+
+	if sum%10 != 0 {
+		return "", fmt.Errorf("'%s' is not a valid ABA account: wrong routing number check digit", value)
+	}
+
+	return fmt.Sprintf("%s %s", parts[1], parts[2]), nil
+}
+
+func (as *abaScheme) Format(normalized string) string {
+	return normalized
+}
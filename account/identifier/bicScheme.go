@@ -0,0 +1,32 @@
+package identifier
+
+import (
+	"regexp"
+	"strings"
+)
+
+// bank (4 letters), country (2 letters), location (2) and optional branch (3)
+var bicPattern = regexp.MustCompile("^[A-Z]{6}[A-Z0-9]{2}([A-Z0-9]{3})?$")
+
+type bicScheme struct {
+}
+
+func NewBicScheme() Scheme {
+	return &bicScheme{}
+}
+
+func (bs *bicScheme) Name() string {
+	return "BIC"
+}
+
+func (bs *bicScheme) Accepts(value string) bool {
+	return bicPattern.MatchString(strings.Replace(value, " ", "", -1))
+}
+
+func (bs *bicScheme) Normalize(value string) (string, error) {
+	return strings.Replace(value, " ", "", -1), nil
+}
+
+func (bs *bicScheme) Format(normalized string) string {
+	return normalized
+}
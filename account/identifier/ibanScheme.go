@@ -0,0 +1,104 @@
+package identifier
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+var ibanPattern = regexp.MustCompile("^[A-Z]{2}[0-9]{2}[A-Z0-9]{8,30}$")
+
+var ibanLengths = map[string]int{
+	"AD": 24, "AE": 23, "AL": 28, "AT": 20, "AZ": 28, "BA": 20, "BE": 16, "BG": 22, "BH": 22, "BI": 27,
+	"BR": 29, "BY": 28, "CH": 21, "CR": 22, "CY": 28, "CZ": 24, "DE": 22, "DJ": 27, "DK": 18, "DO": 28,
+	"EE": 20, "EG": 29, "ES": 24, "FI": 18, "FK": 18, "FO": 18, "FR": 27, "GB": 22, "GE": 22, "GI": 23,
+	"GL": 18, "GR": 27, "GT": 28, "HR": 21, "HU": 28, "IE": 22, "IL": 23, "IQ": 23, "IS": 26, "IT": 27,
+	"JO": 30, "KW": 30, "KZ": 20, "LB": 28, "LC": 32, "LI": 21, "LT": 20, "LU": 20, "LV": 21, "LY": 25,
+	"MC": 27, "MD": 24, "ME": 22, "MK": 19, "MN": 20, "MR": 27, "MT": 31, "MU": 30, "NI": 28, "NL": 18,
+	"NO": 15, "OM": 23, "PK": 24, "PL": 28, "PS": 29, "PT": 25, "QA": 29, "RO": 24, "RS": 22, "RU": 33,
+	"SA": 24, "SC": 31, "SD": 18, "SE": 24, "SI": 19, "SK": 24, "SM": 27, "SO": 23, "ST": 25, "SV": 28,
+	"TL": 23, "TN": 24, "TR": 26, "UA": 29, "VA": 22, "VG": 24, "XK": 20, "YE": 30,
+}
+
+type ibanScheme struct {
+}
+
+func NewIbanScheme() Scheme {
+	return &ibanScheme{}
+}
+
+func (is *ibanScheme) Name() string {
+	return "IBAN"
+}
+
+func (is *ibanScheme) Accepts(value string) bool {
+	return ibanPattern.MatchString(strings.Replace(value, " ", "", -1))
+}
+
+func (is *ibanScheme) Normalize(value string) (string, error) {
+
+	iban := strings.Replace(value, " ", "", -1)
+
+	length, found := ibanLengths[iban[:2]]
+
+	if !found {
+		return "", fmt.Errorf("'%s' is not a valid IBAN: unknown country '%s'", value, iban[:2])
+	}
+
+	if len(iban) != length {
+		return "", fmt.Errorf("'%s' is not a valid IBAN: %s IBANs have %d characters", value, iban[:2], length)
+	}
+
+	if !ibanChecksumValid(iban) {
+		return "", errors.New("'" + value + "' is not a valid IBAN: wrong check digits")
+	}
+
+	return iban, nil
+}
+
+func (is *ibanScheme) Format(normalized string) string {
+
+	var groups []string
+
+	for start := 0; start < len(normalized); start += 4 {
+
+		end := start + 4
+
+		if end > len(normalized) {
+			end = len(normalized)
+		}
+
+		groups = append(groups, normalized[start:end])
+	}
+
+	return strings.Join(groups, " ")
+}
+
+//
+// private functions
+
+func ibanChecksumValid(iban string) bool {
+
+	rearranged := iban[4:] + iban[:4]
+
+	var digits strings.Builder
+
+	for _, character := range rearranged {
+
+		if character >= 'A' && character <= 'Z' {
+			digits.WriteString(fmt.Sprint(int(character-'A') + 10))
+		} else {
+			digits.WriteRune(character)
+		}
+	}
+
+	number, ok := new(big.Int).SetString(digits.String(), 10)
+
+	if !ok {
+		return false
+	}
+
+	return new(big.Int).Mod(number, big.NewInt(97)).Int64() == 1
+}
@@ -0,0 +1,124 @@
+package identifier
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+type Scheme interface {
+	Name() string
+	Accepts(value string) bool
+	Normalize(value string) (string, error)
+	Format(normalized string) string
+}
+
+type Registry struct {
+	schemes []Scheme
+}
+
+var (
+	defaultRegistry = NewRegistry(NewIbanScheme(), NewBicScheme(), NewSortCodeScheme(), NewAbaScheme())
+	defaultMutex    sync.RWMutex
+)
+
+func NewRegistry(schemes ...Scheme) *Registry {
+
+	return &Registry{
+		schemes: schemes,
+	}
+}
+
+func NewRegistryFor(names []string) (*Registry, error) {
+
+	available := map[string]Scheme{}
+
+	for _, scheme := range []Scheme{NewIbanScheme(), NewBicScheme(), NewSortCodeScheme(), NewAbaScheme()} {
+		available[scheme.Name()] = scheme
+	}
+
+	var schemes []Scheme
+
+	for _, name := range names {
+
+		scheme, found := available[strings.ToUpper(strings.TrimSpace(name))]
+
+		if !found {
+			return nil, fmt.Errorf("unknown account identifier scheme '%s'", name)
+		}
+
+		schemes = append(schemes, scheme)
+	}
+
+	return NewRegistry(schemes...), nil
+}
+
+func SetDefault(registry *Registry) {
+
+	defaultMutex.Lock()
+	defer defaultMutex.Unlock()
+
+	defaultRegistry = registry
+}
+
+func Default() *Registry {
+
+	defaultMutex.RLock()
+	defer defaultMutex.RUnlock()
+
+	return defaultRegistry
+}
+
+// Normalize validates the identifier against the first scheme accepting its shape and returns its stored form.
+func (registry *Registry) Normalize(value string) (string, error) {
+
+	cleaned := clean(value)
+
+	for _, scheme := range registry.schemes {
+
+		if scheme.Accepts(cleaned) {
+			return scheme.Normalize(cleaned)
+		}
+	}
+
+	return "", fmt.Errorf("'%s' is not a valid %s", value, registry.description())
+}
+
+// Format returns the display form of a stored identifier, leaving unrecognized ones untouched.
+func (registry *Registry) Format(normalized string) string {
+
+	for _, scheme := range registry.schemes {
+
+		if scheme.Accepts(normalized) {
+			return scheme.Format(normalized)
+		}
+	}
+
+	return normalized
+}
+
+func Normalize(value string) (string, error) {
+	return Default().Normalize(value)
+}
+
+func Format(normalized string) string {
+	return Default().Format(normalized)
+}
+
+//
+// private functions
+
+func (registry *Registry) description() string {
+
+	var names []string
+
+	for _, scheme := range registry.schemes {
+		names = append(names, scheme.Name())
+	}
+
+	return strings.Join(names, ", ") + " account identifier"
+}
+
+func clean(value string) string {
+	return strings.Join(strings.Fields(strings.ToUpper(value)), " ")
+}
@@ -0,0 +1,70 @@
+package identifier
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+//
+// tests
+
+func TestNormalizeValid(t *testing.T) {
+
+	expectations := map[string]string{
+		"gb29 nwbk 6016 1331 9268 19": "GB29NWBK60161331926819",
+		"DE89370400440532013000":      "DE89370400440532013000",
+		"NO9386011117947":             "NO9386011117947",
+		"deutdeff500":                 "DEUTDEFF500",
+		"NWBKGB2L":                    "NWBKGB2L",
+		"601613 31926819":             "60-16-13 31926819",
+		"60-16-13/31926819":           "60-16-13 31926819",
+		"021000021 123456789":         "021000021 123456789",
+	}
+
+	for value, expected := range expectations {
+
+		normalized, errorNormalize := Normalize(value)
+
+		assert.Nil(t, errorNormalize, value)
+		assert.Equal(t, expected, normalized, value)
+	}
+}
+
+func TestNormalizeInvalid(t *testing.T) {
+
+	for _, value := range []string{
+		"myAccount",
+		"GB29NWBK60161331926818",
+		"GB29NWBK6016133192681",
+		"ZZ29NWBK60161331926819",
+		"60-16-13 3192681",
+		"021000022 123456789",
+	} {
+
+		_, errorNormalize := Normalize(value)
+
+		assert.NotNil(t, errorNormalize, value)
+	}
+}
+
+func TestFormat(t *testing.T) {
+
+	assert.Equal(t, "GB29 NWBK 6016 1331 9268 19", Format("GB29NWBK60161331926819"))
+	assert.Equal(t, "60-16-13 31926819", Format("60-16-13 31926819"))
+	assert.Equal(t, "legacy account", Format("legacy account"))
+}
+
+func TestNewRegistryFor(t *testing.T) {
+
+	registry, errorRegistry := NewRegistryFor([]string{"iban"})
+
+	assert.Nil(t, errorRegistry)
+
+	_, errorNormalize := registry.Normalize("60-16-13 31926819")
+
+	assert.NotNil(t, errorNormalize)
+
+	_, errorRegistry = NewRegistryFor([]string{"IBAN", "CLABE"})
+
+	assert.NotNil(t, errorRegistry)
+}
@@ -0,0 +1,35 @@
+package identifier
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// UK sort code (optionally dashed) and 8-digit account number, e.g. "60-16-13 31926819"
+var sortCodePattern = regexp.MustCompile("^([0-9]{2})-?([0-9]{2})-?([0-9]{2})[ /]([0-9]{8})$")
+
+type sortCodeScheme struct {
+}
+
+func NewSortCodeScheme() Scheme {
+	return &sortCodeScheme{}
+}
+
+func (scs *sortCodeScheme) Name() string {
+	return "SORT_CODE"
+}
+
+func (scs *sortCodeScheme) Accepts(value string) bool {
+	return sortCodePattern.MatchString(value)
+}
+
+func (scs *sortCodeScheme) Normalize(value string) (string, error) {
+
+	parts := sortCodePattern.FindStringSubmatch(value)
+
+	return fmt.Sprintf("%s-%s-%s %s", parts[1], parts[2], parts[3], parts[4]), nil
+}
+
+func (scs *sortCodeScheme) Format(normalized string) string {
+	return normalized
+}
@@ -2,14 +2,77 @@ package main
 
 import (
 	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/account/identifier"
+	approvalHandler "github.com/javierjmgits/go-payment-api/approval/handler"
+	approvalModel "github.com/javierjmgits/go-payment-api/approval/model"
+	approvalPolicy "github.com/javierjmgits/go-payment-api/approval/policy"
+	approvalRepository "github.com/javierjmgits/go-payment-api/approval/repository"
+	approvalService "github.com/javierjmgits/go-payment-api/approval/service"
+	authorizationHandler "github.com/javierjmgits/go-payment-api/authorization/handler"
+	authorizationRepository "github.com/javierjmgits/go-payment-api/authorization/repository"
+	authorizationService "github.com/javierjmgits/go-payment-api/authorization/service"
 	"github.com/javierjmgits/go-payment-api/base/config"
+	batchHandler "github.com/javierjmgits/go-payment-api/batch/handler"
+	batchModel "github.com/javierjmgits/go-payment-api/batch/model"
+	batchRepository "github.com/javierjmgits/go-payment-api/batch/repository"
+	beneficiaryHandler "github.com/javierjmgits/go-payment-api/beneficiary/handler"
+	beneficiaryModel "github.com/javierjmgits/go-payment-api/beneficiary/model"
+	beneficiaryPayee "github.com/javierjmgits/go-payment-api/beneficiary/payee"
+	beneficiaryRepository "github.com/javierjmgits/go-payment-api/beneficiary/repository"
+	beneficiaryService "github.com/javierjmgits/go-payment-api/beneficiary/service"
+	feeHandler "github.com/javierjmgits/go-payment-api/fee/handler"
+	feeSchedule "github.com/javierjmgits/go-payment-api/fee/schedule"
+	feeService "github.com/javierjmgits/go-payment-api/fee/service"
+	fxHandler "github.com/javierjmgits/go-payment-api/fx/handler"
+	fxModel "github.com/javierjmgits/go-payment-api/fx/model"
+	fxProvider "github.com/javierjmgits/go-payment-api/fx/provider"
+	fxRepository "github.com/javierjmgits/go-payment-api/fx/repository"
+	fxService "github.com/javierjmgits/go-payment-api/fx/service"
+	limitHandler "github.com/javierjmgits/go-payment-api/limit/handler"
+	limitModel "github.com/javierjmgits/go-payment-api/limit/model"
+	limitRepository "github.com/javierjmgits/go-payment-api/limit/repository"
+	limitService "github.com/javierjmgits/go-payment-api/limit/service"
+	paymentGraph "github.com/javierjmgits/go-payment-api/payment/graph"
 	"github.com/javierjmgits/go-payment-api/payment/handler"
 	"github.com/javierjmgits/go-payment-api/payment/model"
+	paymentOpenapi "github.com/javierjmgits/go-payment-api/payment/openapi"
 	"github.com/javierjmgits/go-payment-api/payment/repository"
+	paymentRpc "github.com/javierjmgits/go-payment-api/payment/rpc"
+	paymentStream "github.com/javierjmgits/go-payment-api/payment/stream"
+	paymentWatch "github.com/javierjmgits/go-payment-api/payment/watch"
+	processingHandler "github.com/javierjmgits/go-payment-api/processing/handler"
+	processingModel "github.com/javierjmgits/go-payment-api/processing/model"
+	processingProcessor "github.com/javierjmgits/go-payment-api/processing/processor"
+	processingRepository "github.com/javierjmgits/go-payment-api/processing/repository"
+	processingService "github.com/javierjmgits/go-payment-api/processing/service"
+	railConnector "github.com/javierjmgits/go-payment-api/rail/connector"
+	railHandler "github.com/javierjmgits/go-payment-api/rail/handler"
+	railRepository "github.com/javierjmgits/go-payment-api/rail/repository"
+	railService "github.com/javierjmgits/go-payment-api/rail/service"
+	reconciliationHandler "github.com/javierjmgits/go-payment-api/reconciliation/handler"
+	reconciliationModel "github.com/javierjmgits/go-payment-api/reconciliation/model"
+	reconciliationRepository "github.com/javierjmgits/go-payment-api/reconciliation/repository"
+	refundHandler "github.com/javierjmgits/go-payment-api/refund/handler"
+	refundModel "github.com/javierjmgits/go-payment-api/refund/model"
+	refundRepository "github.com/javierjmgits/go-payment-api/refund/repository"
+	riskEngine "github.com/javierjmgits/go-payment-api/risk/engine"
+	riskHandler "github.com/javierjmgits/go-payment-api/risk/handler"
+	riskRepository "github.com/javierjmgits/go-payment-api/risk/repository"
+	riskService "github.com/javierjmgits/go-payment-api/risk/service"
+	schedulerHandler "github.com/javierjmgits/go-payment-api/scheduler/handler"
+	schedulerRepository "github.com/javierjmgits/go-payment-api/scheduler/repository"
+	schedulerService "github.com/javierjmgits/go-payment-api/scheduler/service"
+	standingOrderHandler "github.com/javierjmgits/go-payment-api/standingorder/handler"
+	standingOrderModel "github.com/javierjmgits/go-payment-api/standingorder/model"
+	standingOrderRepository "github.com/javierjmgits/go-payment-api/standingorder/repository"
+	standingOrderService "github.com/javierjmgits/go-payment-api/standingorder/service"
 	"github.com/jinzhu/gorm"
 	_ "github.com/jinzhu/gorm/dialects/mysql"
+	"google.golang.org/grpc"
 	"log"
+	"net"
 	"net/http"
 )
 
@@ -17,6 +80,17 @@ type app struct {
 	config *config.Config
 }
 
+// paymentServices are the subsystems every new payment goes through, whether it comes from the API, a batch or a
+// standing order
+type paymentServices struct {
+	fx            *fxService.FxService
+	fee           *feeService.FeeService
+	risk          *riskService.RiskService
+	approval      *approvalService.ApprovalService
+	beneficiary   *beneficiaryService.BeneficiaryService
+	authorization *authorizationService.AuthorizationService
+}
+
 type AppStarter interface {
 	Start()
 }
@@ -32,32 +106,97 @@ func (app *app) Start() {
 
 	log.Println("Starting the application...")
 
+	configureAccountIdentifiers(app.config)
+
 	//
 	// DB
 
-	dbURL := fmt.Sprintf("%s:%s@/%s?charset=utf8&parseTime=True",
-		app.config.DB.Username,
-		app.config.DB.Password,
-		app.config.DB.Name)
+	db := openDB(app.config)
 
-	db, err := gorm.Open("mysql", dbURL)
+	defer db.Close()
 
-	if err != nil {
-		log.Fatal("Error connecting to DB", err)
+	//
+	// Routing
+
+	router := mux.NewRouter()
+
+	services := newPaymentServices(app.config, db)
+
+	paymentRepository := repository.NewPaymentRepositoryImpl(db)
+
+	paymentWatcher := paymentWatch.NewWatcher(paymentRepository, app.config.Payment.WatchInterval)
+
+	paymentHandler := newPaymentHandler(app.config, db, paymentRepository, services)
+	paymentHandler.Register(router)
+
+	paymentSpec := paymentOpenapi.NewSpec()
+	paymentOpenapi.NewDocsHandler(paymentSpec).Register(router)
+	router.Use(newSpecValidator(app.config, paymentSpec).Middleware)
+
+	paymentGraph.NewGraphHandler(paymentHandler, paymentRepository, paymentWatcher).Register(router)
+	paymentStream.NewStreamHandler(paymentWatcher, app.config.Payment.StreamHeartbeat).Register(router)
+
+	fxHandler.NewFxHandler(services.fx).Register(router)
+	feeHandler.NewFeeHandler(services.fee).Register(router)
+	riskHandler.NewReviewHandler(services.risk).Register(router)
+	approvalHandler.NewApprovalHandler(services.approval).Register(router)
+	authorizationHandler.NewAuthorizationHandler(services.authorization).Register(router)
+	beneficiaryHandler.NewBeneficiaryHandler(services.beneficiary).Register(router)
+	limitHandler.NewLimitHandler(limitRepository.NewLimitRepositoryImpl(db)).Register(router)
+	batchHandler.NewBatchHandler(batchRepository.NewBatchRepositoryImpl(db), paymentHandler).Register(router)
+	reconciliationHandler.NewReconciliationHandler(reconciliationRepository.NewReconciliationRepositoryImpl(db)).Register(router)
+	refundHandler.NewRefundHandler(refundRepository.NewRefundRepositoryImpl(db)).Register(router)
+	standingOrderHandler.NewStandingOrderHandler(standingOrderRepository.NewStandingOrderRepositoryImpl(db)).Register(router)
+
+	//
+	// Processing
+
+	var paymentProcessor processingProcessor.PaymentProcessor = processingProcessor.NewInstantProcessor()
+
+	if rail := newRailService(app.config, db); rail != nil {
+		paymentProcessor = rail
+		railHandler.NewRailHandler(rail).Register(router)
 	}
 
-	log.Println("Connection established with DB")
+	processing := processingService.NewProcessingService(processingRepository.NewJobRepositoryImpl(db), paymentProcessor, app.config.Processing)
 
-	defer db.Close()
+	// payments flagged by hand or found due by the scheduler go through the workers too
+	paymentHandler.SetEnqueuer(processing)
 
-	db = model.SetUp(db)
+	processing.Start()
+
+	defer processing.Stop()
+
+	processingHandler.NewProcessingHandler(processing).Register(router)
 
 	//
-	// Routing
+	// Scheduler
 
-	router := mux.NewRouter()
+	scheduler := schedulerService.NewSchedulerService(schedulerRepository.NewSchedulerRepositoryImpl(db), processing, app.config.Scheduler)
+
+	scheduler.AddSweeper(services.approval)
+	scheduler.AddSweeper(services.authorization)
+	scheduler.AddGenerator(standingOrderService.NewStandingOrderService(standingOrderRepository.NewStandingOrderRepositoryImpl(db), paymentHandler))
+
+	scheduler.Start()
+
+	defer scheduler.Stop()
+
+	schedulerHandler.NewSchedulerHandler(scheduler).Register(router)
+
+	//
+	// gRPC
+
+	if app.config.Grpc.Enabled {
+
+		grpcServer := grpc.NewServer()
 
-	handler.NewPaymentHandler(repository.NewPaymentRepositoryImpl(db)).Register(router)
+		paymentRpc.NewPaymentServer(paymentHandler, paymentRepository, paymentWatcher).Register(grpcServer)
+
+		go serveGrpc(grpcServer, fmt.Sprintf("%v:%v", app.config.Server.Host, app.config.Grpc.Port))
+
+		defer grpcServer.Stop()
+	}
 
 	//
 	// Server
@@ -70,3 +209,223 @@ func (app *app) Start() {
 
 	log.Fatal(errorListenAndServe)
 }
+
+//
+// private functions
+
+func openDB(config *config.Config) *gorm.DB {
+
+	dbURL := fmt.Sprintf("%s:%s@/%s?charset=utf8&parseTime=True",
+		config.DB.Username,
+		config.DB.Password,
+		config.DB.Name)
+
+	db, err := gorm.Open("mysql", dbURL)
+
+	if err != nil {
+		log.Fatal("Error connecting to DB", err)
+	}
+
+	log.Println("Connection established with DB")
+
+	db = model.SetUp(db)
+	db = batchModel.SetUp(db)
+	db = standingOrderModel.SetUp(db)
+	db = refundModel.SetUp(db)
+	db = fxModel.SetUp(db)
+	db = approvalModel.SetUp(db)
+	db = limitModel.SetUp(db)
+	db = processingModel.SetUp(db)
+	db = reconciliationModel.SetUp(db)
+	db = beneficiaryModel.SetUp(db)
+
+	return db
+}
+
+func newPaymentServices(config *config.Config, db *gorm.DB) *paymentServices {
+
+	services := &paymentServices{
+		fx:            fxService.NewFxService(newRateProvider(config), fxRepository.NewQuoteRepositoryImpl(db), config.FX.QuoteTTL),
+		fee:           feeService.NewFeeService(newFeeSchedule(config)),
+		risk:          riskService.NewRiskService(newRiskEngine(config), riskRepository.NewRiskRepositoryImpl(db)),
+		approval:      approvalService.NewApprovalService(newApprovalPolicy(config), approvalRepository.NewApprovalRepositoryImpl(db), config.Approval.TTL),
+		beneficiary:   beneficiaryService.NewBeneficiaryService(beneficiaryRepository.NewBeneficiaryRepositoryImpl(db), newPayeeChecker(config), config.Beneficiary.CoolingOff),
+		authorization: authorizationService.NewAuthorizationService(authorizationRepository.NewAuthorizationRepositoryImpl(db), config.Authorization.TTL),
+	}
+
+	services.authorization.AddAmendHook(services.fee)
+
+	return services
+}
+
+func newPaymentHandler(config *config.Config, db *gorm.DB, paymentRepository repository.PaymentRepository, services *paymentServices) *handler.PaymentHandler {
+
+	limits := limitService.NewLimitService(limitRepository.NewLimitRepositoryImpl(db))
+
+	// risk rules and approvals run after FX so that they see the converted amounts
+	paymentHandler := handler.NewPaymentHandler(paymentRepository)
+	paymentHandler.AddCreateHook(services.fx)
+	paymentHandler.AddCreateHook(services.fee)
+	paymentHandler.AddCreateHook(services.risk)
+	paymentHandler.AddCreateHook(services.approval)
+	paymentHandler.AddCreateHook(services.authorization)
+	paymentHandler.AddRecheckHook(services.risk)
+	paymentHandler.AddRecheckHook(services.approval)
+	paymentHandler.AddAmendHook(services.fee)
+	paymentHandler.SetTargetResolver(services.beneficiary)
+	paymentHandler.SetCreator(limits)
+	paymentHandler.SetAmender(limits)
+	paymentHandler.SetCancelReasons(config.Payment.CancelReasons)
+	paymentHandler.SetDeleteScope(config.Payment.DeleteScope)
+
+	return paymentHandler
+}
+
+func serveGrpc(grpcServer *grpc.Server, address string) {
+
+	listener, errorListen := net.Listen("tcp", address)
+
+	if errorListen != nil {
+		log.Fatal("Error listening for gRPC: ", errorListen)
+	}
+
+	log.Printf("gRPC server listening at: %v\n", address)
+
+	if errorServe := grpcServer.Serve(listener); errorServe != nil {
+		log.Fatal("Error serving gRPC: ", errorServe)
+	}
+}
+
+func configureAccountIdentifiers(config *config.Config) {
+
+	registry, errorRegistry := identifier.NewRegistryFor(config.Account.Schemes)
+
+	if errorRegistry != nil {
+		log.Fatal("Error configuring account identifiers: ", errorRegistry)
+	}
+
+	identifier.SetDefault(registry)
+}
+
+func newRateProvider(config *config.Config) fxProvider.RateProvider {
+
+	if config.FX.RatesFile == "" {
+		log.Println("No FX rates file configured, only same-currency payments are possible")
+		return fxProvider.NewStaticRateProvider(model.DEFAULT_CURRENCY, nil)
+	}
+
+	rateProvider, errorRates := fxProvider.NewStaticRateProviderFromFile(config.FX.RatesFile)
+
+	if errorRates != nil {
+		log.Fatal("Error loading FX rates: ", errorRates)
+	}
+
+	return rateProvider
+}
+
+func newRiskEngine(config *config.Config) *riskEngine.Engine {
+
+	if config.Risk.RulesFile == "" {
+		log.Println("No risk rules file configured, every payment is allowed")
+		return riskEngine.NewEngine()
+	}
+
+	engine, errorRules := riskEngine.NewEngineFromFile(config.Risk.RulesFile)
+
+	if errorRules != nil {
+		log.Fatal("Error loading risk rules: ", errorRules)
+	}
+
+	return engine
+}
+
+func newApprovalPolicy(config *config.Config) *approvalPolicy.Policy {
+
+	if config.Approval.ThresholdsFile == "" {
+		log.Println("No approval thresholds file configured, payments need no approval")
+		policy, _ := approvalPolicy.NewPolicy()
+		return policy
+	}
+
+	policy, errorPolicy := approvalPolicy.NewPolicyFromFile(config.Approval.ThresholdsFile)
+
+	if errorPolicy != nil {
+		log.Fatal("Error loading approval thresholds: ", errorPolicy)
+	}
+
+	return policy
+}
+
+func newFeeSchedule(config *config.Config) *feeSchedule.Schedule {
+
+	if config.Fee.ScheduleFile == "" {
+		log.Println("No fee schedule file configured, payments are free")
+		schedule, _ := feeSchedule.NewSchedule()
+		return schedule
+	}
+
+	schedule, errorSchedule := feeSchedule.NewScheduleFromFile(config.Fee.ScheduleFile)
+
+	if errorSchedule != nil {
+		log.Fatal("Error loading fee schedule: ", errorSchedule)
+	}
+
+	return schedule
+}
+
+func newSpecValidator(config *config.Config, spec *openapi3.T) *paymentOpenapi.Validator {
+
+	if config.OpenAPI.ValidateResponses {
+		log.Println("Validating responses against the OpenAPI document, which is meant for tests only")
+	}
+
+	validator, errorValidator := paymentOpenapi.NewValidator(spec, config.OpenAPI.ValidateRequests, config.OpenAPI.ValidateResponses)
+
+	if errorValidator != nil {
+		log.Fatal("Error loading the OpenAPI document: ", errorValidator)
+	}
+
+	return validator
+}
+
+// newPayeeChecker returns the fake checker, which only knows the account holders of its file
+func newPayeeChecker(config *config.Config) beneficiaryPayee.Checker {
+
+	if config.Beneficiary.PayeeNamesFile == "" {
+		log.Println("No payee names file configured, beneficiary names are not checked")
+		checker, _ := beneficiaryPayee.NewFakeChecker(nil)
+		return checker
+	}
+
+	checker, errorChecker := beneficiaryPayee.NewFakeCheckerFromFile(config.Beneficiary.PayeeNamesFile)
+
+	if errorChecker != nil {
+		log.Fatal("Error loading payee names: ", errorChecker)
+	}
+
+	return checker
+}
+
+// newRailService returns nil when no rail is configured, payments are then settled at once
+func newRailService(config *config.Config, db *gorm.DB) *railService.RailService {
+
+	switch config.Rail.Connector {
+
+	case "":
+		log.Println("No rail connector configured, payments are settled at once")
+		return nil
+
+	case "simulator":
+		log.Println("Settling payments through the rail simulator")
+
+		simulator := railConnector.NewSimulator(config.Rail)
+		rail := railService.NewRailService(railRepository.NewRailRepositoryImpl(db), simulator)
+		simulator.SetCallback(rail.OnStatus)
+
+		return rail
+	}
+
+	log.Fatalf("Unknown rail connector '%s'", config.Rail.Connector)
+
+	return nil
+}
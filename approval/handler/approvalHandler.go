@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/approval/model"
+	"github.com/javierjmgits/go-payment-api/approval/service"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	paymentHandler "github.com/javierjmgits/go-payment-api/payment/handler"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"net/http"
+	"time"
+)
+
+type ApprovalHandler struct {
+	approvalService *service.ApprovalService
+}
+
+type ApprovalView struct {
+	Level    int       `json:"level"`
+	Approver string    `json:"approver"`
+	Decision string    `json:"decision"`
+	Note     string    `json:"note,omitempty"`
+	Date     time.Time `json:"date"`
+}
+
+type ApprovalDecision struct {
+	Note string `json:"note"`
+}
+
+func NewApprovalHandler(approvalService *service.ApprovalService) *ApprovalHandler {
+
+	return &ApprovalHandler{
+		approvalService: approvalService,
+	}
+}
+
+func (ah *ApprovalHandler) Register(router *mux.Router) {
+	router.HandleFunc("/api/v1/approvals", ah.GetPendingApprovals).Methods("GET")
+	router.HandleFunc("/api/v1/approvals/uid/{uid}", ah.GetApprovalsByPaymentUid).Methods("GET")
+	router.HandleFunc("/api/v1/approvals/uid/{uid}/approve", ah.ApprovePayment).Methods("POST")
+	router.HandleFunc("/api/v1/approvals/uid/{uid}/reject", ah.RejectPayment).Methods("POST")
+}
+
+func (ah *ApprovalHandler) GetPendingApprovals(w http.ResponseWriter, r *http.Request) {
+
+	payments, errorDB := ah.approvalService.GetPending(auth.FromRequest(r))
+
+	if errorDB != nil {
+		util.WriteError(w, http.StatusInternalServerError, errorDB.Error())
+		return
+	}
+
+	var results []paymentHandler.PaymentView
+
+	for index := range payments {
+		results = append(results, *paymentHandler.NewPaymentView(&payments[index]))
+	}
+
+	util.WritePayload(w, http.StatusOK, results)
+}
+
+func (ah *ApprovalHandler) GetApprovalsByPaymentUid(w http.ResponseWriter, r *http.Request) {
+
+	approvals, errorDB := ah.approvalService.GetApprovals(mux.Vars(r)["uid"])
+
+	if errorDB != nil {
+		util.WriteError(w, http.StatusInternalServerError, errorDB.Error())
+		return
+	}
+
+	var results []ApprovalView
+
+	for index := range approvals {
+		results = append(results, *newApprovalView(&approvals[index]))
+	}
+
+	util.WritePayload(w, http.StatusOK, results)
+}
+
+func (ah *ApprovalHandler) ApprovePayment(w http.ResponseWriter, r *http.Request) {
+	ah.decide(w, r, ah.approvalService.Approve)
+}
+
+func (ah *ApprovalHandler) RejectPayment(w http.ResponseWriter, r *http.Request) {
+	ah.decide(w, r, ah.approvalService.Reject)
+}
+
+//
+// private functions
+
+func (ah *ApprovalHandler) decide(w http.ResponseWriter, r *http.Request, decision func(paymentUid string, principal *auth.Principal, note string) (*paymentModel.Payment, error)) {
+
+	var approvalDecision ApprovalDecision
+
+	// the body is optional, it only carries a note
+	if r.ContentLength != 0 {
+
+		if errorJson := json.NewDecoder(r.Body).Decode(&approvalDecision); errorJson != nil {
+			util.WriteError(w, http.StatusBadRequest, errorJson.Error())
+			return
+		}
+	}
+
+	payment, errorDecision := decision(mux.Vars(r)["uid"], auth.FromRequest(r), approvalDecision.Note)
+
+	if errorDecision != nil {
+		util.WriteErrorFor(w, errorDecision)
+		return
+	}
+
+	util.WritePayload(w, http.StatusOK, paymentHandler.NewPaymentView(payment))
+}
+
+func newApprovalView(approval *model.Approval) *ApprovalView {
+
+	return &ApprovalView{
+		Level:    approval.Level,
+		Approver: approval.Approver,
+		Decision: approval.Decision,
+		Note:     approval.Note,
+		Date:     approval.CreatedAt,
+	}
+}
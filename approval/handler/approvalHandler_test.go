@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/approval/model"
+	"github.com/javierjmgits/go-payment-api/approval/policy"
+	"github.com/javierjmgits/go-payment-api/approval/service"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	paymentHandler "github.com/javierjmgits/go-payment-api/payment/handler"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+//
+// mocks
+
+type approvalRepositoryImplMock struct {
+	payment   *paymentModel.Payment
+	approvals []model.Approval
+}
+
+func (mock *approvalRepositoryImplMock) GetPending(tenant string) ([]paymentModel.Payment, error) {
+	return []paymentModel.Payment{*mock.payment}, nil
+}
+
+func (mock *approvalRepositoryImplMock) GetByPaymentUid(paymentUid string) ([]model.Approval, error) {
+	return mock.approvals, nil
+}
+
+func (mock *approvalRepositoryImplMock) Decide(paymentUid string, decide func(*paymentModel.Payment, []model.Approval) (*model.Approval, error)) (*paymentModel.Payment, error) {
+
+	payment := *mock.payment
+
+	approval, errorDecide := decide(&payment, mock.approvals)
+
+	if errorDecide != nil {
+		return nil, errorDecide
+	}
+
+	mock.payment = &payment
+	mock.approvals = append(mock.approvals, *approval)
+
+	return &payment, nil
+}
+
+func (mock *approvalRepositoryImplMock) ExpireStale(now time.Time) (int, error) {
+	return 0, nil
+}
+
+//
+// tests
+
+func TestApprovePaymentKoMissingUser(t *testing.T) {
+
+	router, _ := setUp(1)
+
+	resp := postDecision(router, "approve", "", "acme")
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestApprovePaymentKoCreator(t *testing.T) {
+
+	router, _ := setUp(1)
+
+	resp := postDecision(router, "approve", "maker", "acme")
+
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestApprovePaymentKoOtherTenant(t *testing.T) {
+
+	router, _ := setUp(1)
+
+	resp := postDecision(router, "approve", "checker", "other")
+
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestApprovePaymentKoExpired(t *testing.T) {
+
+	router, mockRepository := setUp(1)
+
+	expiresAt := time.Now().Add(-time.Minute)
+	mockRepository.payment.ApprovalExpiresAt = &expiresAt
+
+	resp := postDecision(router, "approve", "checker", "acme")
+
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestApprovePaymentMultiLevel(t *testing.T) {
+
+	router, mockRepository := setUp(2)
+
+	resp := postDecision(router, "approve", "checker", "acme")
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, paymentModel.APPROVAL_STATUS_PENDING, readPayment(resp).ApprovalStatus)
+
+	// the same person cannot approve the second level
+	resp = postDecision(router, "approve", "checker", "acme")
+
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = postDecision(router, "approve", "supervisor", "acme")
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, paymentModel.APPROVAL_STATUS_APPROVED, readPayment(resp).ApprovalStatus)
+	assert.Equal(t, 2, len(mockRepository.approvals))
+	assert.Equal(t, 2, mockRepository.approvals[1].Level)
+
+	// nothing left to decide
+	resp = postDecision(router, "reject", "auditor", "acme")
+
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestRejectPayment(t *testing.T) {
+
+	router, _ := setUp(2)
+
+	resp := postDecision(router, "reject", "checker", "acme")
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, paymentModel.APPROVAL_STATUS_REJECTED, readPayment(resp).ApprovalStatus)
+}
+
+func TestBeforeCreateOpensApproval(t *testing.T) {
+
+	approvalPolicy, _ := policy.NewPolicy(policy.Threshold{Currency: "EUR", Amounts: []float64{10000, 100000}})
+	approvalService := service.NewApprovalService(approvalPolicy, &approvalRepositoryImplMock{}, time.Hour)
+
+	payment := &paymentModel.Payment{Amount: 150000, Currency: "EUR", CreatedBy: "maker"}
+
+	errorHook := approvalService.BeforeCreate(payment, &paymentHandler.PaymentCreate{})
+
+	assert.Nil(t, errorHook)
+	assert.Equal(t, paymentModel.APPROVAL_STATUS_PENDING, payment.ApprovalStatus)
+	assert.Equal(t, 2, payment.ApprovalLevels)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *payment.ApprovalExpiresAt, 2*time.Second)
+	assert.True(t, payment.IsHeld())
+}
+
+//
+// private functions
+
+func setUp(levels int) (*mux.Router, *approvalRepositoryImplMock) {
+
+	var router = mux.NewRouter()
+
+	expiresAt := time.Now().Add(time.Hour)
+
+	mockRepository := &approvalRepositoryImplMock{
+		payment: &paymentModel.Payment{
+			Uid:               "0b6a3f7e-4a8e-4f55-9d0c-58f9c1e2b7d4",
+			AccountOrigin:     "GB29NWBK60161331926819",
+			AccountTarget:     "DE89370400440532013000",
+			Amount:            150000,
+			Currency:          "EUR",
+			CreatedBy:         "maker",
+			Tenant:            "acme",
+			ApprovalStatus:    paymentModel.APPROVAL_STATUS_PENDING,
+			ApprovalLevels:    levels,
+			ApprovalExpiresAt: &expiresAt,
+		},
+	}
+
+	approvalPolicy, _ := policy.NewPolicy()
+
+	NewApprovalHandler(service.NewApprovalService(approvalPolicy, mockRepository, time.Hour)).Register(router)
+
+	return router, mockRepository
+}
+
+func postDecision(router *mux.Router, decision string, user string, tenant string) *http.Response {
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/approvals/uid/0b6a3f7e-4a8e-4f55-9d0c-58f9c1e2b7d4/"+decision, strings.NewReader(`{"note": "checked"}`))
+	req.Header.Set(auth.HEADER_USER, user)
+	req.Header.Set(auth.HEADER_TENANT, tenant)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	return w.Result()
+}
+
+func readPayment(resp *http.Response) *paymentHandler.PaymentView {
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	var payment paymentHandler.PaymentView
+
+	json.Unmarshal(body, &payment)
+
+	return &payment
+}
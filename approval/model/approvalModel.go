@@ -0,0 +1,27 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	DECISION_APPROVED = "APPROVED"
+	DECISION_REJECTED = "REJECTED"
+)
+
+// Approval is a single decision on a payment; a payment needs one approval per required level.
+type Approval struct {
+	gorm.Model
+	PaymentUid string `gorm:"not null;index"`
+	Level      int    `gorm:"not null"`
+	Approver   string `gorm:"not null"`
+	Decision   string `gorm:"not null"`
+	Note       string `gorm:"type:text"`
+}
+
+func SetUp(db *gorm.DB) *gorm.DB {
+
+	db.AutoMigrate(&Approval{})
+
+	return db
+}
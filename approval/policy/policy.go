@@ -0,0 +1,98 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Threshold lists, in increasing order, the amounts from which one more approval level is required.
+// An empty tenant or currency matches any.
+type Threshold struct {
+	Tenant   string    `json:"tenant"`
+	Currency string    `json:"currency"`
+	Amounts  []float64 `json:"amounts"`
+}
+
+type Policy struct {
+	thresholds []Threshold
+}
+
+func NewPolicy(thresholds ...Threshold) (*Policy, error) {
+
+	for index := range thresholds {
+
+		thresholds[index].Currency = strings.ToUpper(thresholds[index].Currency)
+
+		amounts := thresholds[index].Amounts
+
+		if len(amounts) == 0 || !sort.Float64sAreSorted(amounts) || amounts[0] <= 0 {
+			return nil, fmt.Errorf("threshold amounts for tenant '%s' and currency '%s' must be positive and increasing", thresholds[index].Tenant, thresholds[index].Currency)
+		}
+	}
+
+	return &Policy{
+		thresholds: thresholds,
+	}, nil
+}
+
+func NewPolicyFromFile(path string) (*Policy, error) {
+
+	file, errorOpen := os.Open(path)
+
+	if errorOpen != nil {
+		return nil, errorOpen
+	}
+
+	defer file.Close()
+
+	var content struct {
+		Thresholds []Threshold `json:"thresholds"`
+	}
+
+	if errorJson := json.NewDecoder(file).Decode(&content); errorJson != nil {
+		return nil, fmt.Errorf("invalid approval thresholds file %s: %v", path, errorJson)
+	}
+
+	return NewPolicy(content.Thresholds...)
+}
+
+// RequiredLevels returns how many different people must approve the payment, using the most specific threshold.
+func (policy *Policy) RequiredLevels(tenant string, currency string, amount float64) int {
+
+	threshold := policy.match(tenant, currency)
+
+	if threshold == nil {
+		return 0
+	}
+
+	levels := 0
+
+	for _, limit := range threshold.Amounts {
+
+		if amount >= limit {
+			levels++
+		}
+	}
+
+	return levels
+}
+
+//
+// private functions
+
+func (policy *Policy) match(tenant string, currency string) *Threshold {
+
+	index := util.MostSpecific(len(policy.thresholds), func(index int) (string, string) {
+		return policy.thresholds[index].Tenant, policy.thresholds[index].Currency
+	}, tenant, currency)
+
+	if index < 0 {
+		return nil
+	}
+
+	return &policy.thresholds[index]
+}
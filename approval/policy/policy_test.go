@@ -0,0 +1,33 @@
+package policy
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRequiredLevels(t *testing.T) {
+
+	policy, errorPolicy := NewPolicy(
+		Threshold{Currency: "eur", Amounts: []float64{10000, 100000}},
+		Threshold{Tenant: "acme", Currency: "EUR", Amounts: []float64{5000}},
+		Threshold{Tenant: "acme", Amounts: []float64{1000}},
+	)
+
+	assert.Nil(t, errorPolicy)
+
+	assert.Equal(t, 0, policy.RequiredLevels("", "EUR", 9999.99))
+	assert.Equal(t, 1, policy.RequiredLevels("", "EUR", 10000))
+	assert.Equal(t, 2, policy.RequiredLevels("other", "EUR", 250000))
+	assert.Equal(t, 0, policy.RequiredLevels("", "USD", 250000))
+
+	// the most specific threshold wins
+	assert.Equal(t, 1, policy.RequiredLevels("acme", "EUR", 250000))
+	assert.Equal(t, 1, policy.RequiredLevels("acme", "USD", 2000))
+}
+
+func TestNewPolicyKoUnsortedAmounts(t *testing.T) {
+
+	_, errorPolicy := NewPolicy(Threshold{Currency: "EUR", Amounts: []float64{100000, 10000}})
+
+	assert.NotNil(t, errorPolicy)
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"github.com/javierjmgits/go-payment-api/approval/model"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	paymentRepository "github.com/javierjmgits/go-payment-api/payment/repository"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+type ApprovalRepository interface {
+	GetPending(tenant string) ([]paymentModel.Payment, error)
+	GetByPaymentUid(paymentUid string) ([]model.Approval, error)
+	Decide(paymentUid string, decide func(*paymentModel.Payment, []model.Approval) (*model.Approval, error)) (*paymentModel.Payment, error)
+	ExpireStale(now time.Time) (int, error)
+}
+
+type approvalRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewApprovalRepositoryImpl(db *gorm.DB) ApprovalRepository {
+	return &approvalRepositoryImpl{
+		db: db,
+	}
+}
+
+func (ari *approvalRepositoryImpl) GetPending(tenant string) ([]paymentModel.Payment, error) {
+
+	var payments []paymentModel.Payment
+	errorDB := ari.db.Where("approval_status = ? AND tenant = ? AND cancelled_at IS NULL", paymentModel.APPROVAL_STATUS_PENDING, tenant).
+		Order("approval_expires_at, id").
+		Find(&payments).Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return payments, nil
+}
+
+func (ari *approvalRepositoryImpl) GetByPaymentUid(paymentUid string) ([]model.Approval, error) {
+
+	var approvals []model.Approval
+	errorDB := ari.db.Where("payment_uid = ?", paymentUid).Order("level, id").Find(&approvals).Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return approvals, nil
+}
+
+func (ari *approvalRepositoryImpl) Decide(paymentUid string, decide func(*paymentModel.Payment, []model.Approval) (*model.Approval, error)) (*paymentModel.Payment, error) {
+
+	tx := ari.db.Begin()
+
+	// the payment stays locked so two approvers cannot fill the same level
+	var payment paymentModel.Payment
+	errorDB := tx.Set("gorm:query_option", "FOR UPDATE").Where("uid = ?", paymentUid).First(&payment).Error
+
+	if errorDB != nil {
+		tx.Rollback()
+		return nil, errorDB
+	}
+
+	var approvals []model.Approval
+	errorDB = tx.Where("payment_uid = ?", paymentUid).Order("level, id").Find(&approvals).Error
+
+	if errorDB != nil {
+		tx.Rollback()
+		return nil, errorDB
+	}
+
+	approval, errorDecide := decide(&payment, approvals)
+
+	if errorDecide != nil {
+		tx.Rollback()
+		return nil, errorDecide
+	}
+
+	for _, value := range []interface{}{&payment, approval} {
+
+		if errorDB := tx.Save(value).Error; errorDB != nil {
+			tx.Rollback()
+			return nil, errorDB
+		}
+	}
+
+	errorDB = tx.Commit().Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return &payment, nil
+}
+
+func (ari *approvalRepositoryImpl) ExpireStale(now time.Time) (int, error) {
+
+	return paymentRepository.UpdateMatching(ari.db, "approval_status", paymentModel.APPROVAL_STATUS_EXPIRED,
+		"approval_status = ? AND approval_expires_at <= ?", paymentModel.APPROVAL_STATUS_PENDING, now)
+}
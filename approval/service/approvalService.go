@@ -0,0 +1,139 @@
+package service
+
+import (
+	"fmt"
+	"github.com/javierjmgits/go-payment-api/approval/model"
+	"github.com/javierjmgits/go-payment-api/approval/policy"
+	"github.com/javierjmgits/go-payment-api/approval/repository"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	paymentHandler "github.com/javierjmgits/go-payment-api/payment/handler"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"time"
+)
+
+type ApprovalService struct {
+	policy             *policy.Policy
+	approvalRepository repository.ApprovalRepository
+	ttl                time.Duration
+	now                func() time.Time
+}
+
+func NewApprovalService(policy *policy.Policy, approvalRepository repository.ApprovalRepository, ttl time.Duration) *ApprovalService {
+
+	return &ApprovalService{
+		policy:             policy,
+		approvalRepository: approvalRepository,
+		ttl:                ttl,
+		now:                time.Now,
+	}
+}
+
+// BeforeCreate opens an approval request when the payment is above the thresholds of its tenant and currency.
+func (as *ApprovalService) BeforeCreate(payment *paymentModel.Payment, paymentCreate *paymentHandler.PaymentCreate) error {
+
+	// an amended payment keeps the request it has, whose amount can only decrease
+	if payment.ApprovalStatus != "" {
+		return nil
+	}
+
+	levels := as.policy.RequiredLevels(payment.Tenant, payment.Currency, payment.Amount)
+
+	if levels == 0 {
+		return nil
+	}
+
+	if payment.CreatedBy == "" {
+		return &util.InputError{Message: fmt.Sprintf("payment requires approval, the creator must be given in the %s header", auth.HEADER_USER)}
+	}
+
+	expiresAt := as.now().UTC().Add(as.ttl).Truncate(time.Second)
+
+	payment.ApprovalStatus = paymentModel.APPROVAL_STATUS_PENDING
+	payment.ApprovalLevels = levels
+	payment.ApprovalExpiresAt = &expiresAt
+
+	return nil
+}
+
+// Sweep expires the approval requests nobody decided on in time.
+func (as *ApprovalService) Sweep(now time.Time) (int, error) {
+	return as.approvalRepository.ExpireStale(now.UTC())
+}
+
+func (as *ApprovalService) GetPending(principal *auth.Principal) ([]paymentModel.Payment, error) {
+	return as.approvalRepository.GetPending(principal.Tenant)
+}
+
+func (as *ApprovalService) GetApprovals(paymentUid string) ([]model.Approval, error) {
+	return as.approvalRepository.GetByPaymentUid(paymentUid)
+}
+
+func (as *ApprovalService) Approve(paymentUid string, principal *auth.Principal, note string) (*paymentModel.Payment, error) {
+	return as.decide(paymentUid, principal, note, model.DECISION_APPROVED)
+}
+
+func (as *ApprovalService) Reject(paymentUid string, principal *auth.Principal, note string) (*paymentModel.Payment, error) {
+	return as.decide(paymentUid, principal, note, model.DECISION_REJECTED)
+}
+
+//
+// private functions
+
+func (as *ApprovalService) decide(paymentUid string, principal *auth.Principal, note string, decision string) (*paymentModel.Payment, error) {
+
+	if principal.User == "" {
+		return nil, &util.InputError{Message: fmt.Sprintf("approver must be given in the %s header", auth.HEADER_USER)}
+	}
+
+	now := as.now().UTC()
+
+	return as.approvalRepository.Decide(paymentUid, func(payment *paymentModel.Payment, approvals []model.Approval) (*model.Approval, error) {
+
+		if payment.Tenant != principal.Tenant {
+			return nil, &util.ForbiddenError{Message: "payment belongs to another tenant"}
+		}
+
+		if payment.IsCancelled() {
+			return nil, &util.ConflictError{Message: "payment is cancelled"}
+		}
+
+		if payment.ApprovalStatus != paymentModel.APPROVAL_STATUS_PENDING {
+			return nil, &util.ConflictError{Message: fmt.Sprintf("payment is not pending approval, its approval status is '%s'", payment.ApprovalStatus)}
+		}
+
+		if payment.ApprovalExpiresAt != nil && !now.Before(*payment.ApprovalExpiresAt) {
+			return nil, &util.ConflictError{Message: "approval request has expired"}
+		}
+
+		if payment.CreatedBy == principal.User {
+			return nil, &util.ForbiddenError{Message: "the creator of a payment cannot approve it"}
+		}
+
+		for _, approval := range approvals {
+
+			if approval.Approver == principal.User {
+				return nil, &util.ForbiddenError{Message: "each approval level needs a different approver"}
+			}
+		}
+
+		level := len(approvals) + 1
+
+		switch {
+
+		case decision == model.DECISION_REJECTED:
+			payment.ApprovalStatus = paymentModel.APPROVAL_STATUS_REJECTED
+
+		case level >= payment.ApprovalLevels:
+			payment.ApprovalStatus = paymentModel.APPROVAL_STATUS_APPROVED
+		}
+
+		return &model.Approval{
+			PaymentUid: payment.Uid,
+			Level:      level,
+			Approver:   principal.User,
+			Decision:   decision,
+			Note:       note,
+		}, nil
+	})
+}
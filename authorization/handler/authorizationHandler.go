@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/authorization/service"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	paymentHandler "github.com/javierjmgits/go-payment-api/payment/handler"
+	"io"
+	"net/http"
+)
+
+type AuthorizationHandler struct {
+	authorizationService *service.AuthorizationService
+}
+
+// PaymentCapture takes the whole authorized amount when no amount is given.
+type PaymentCapture struct {
+	Amount *float64 `json:"amount"`
+}
+
+func NewAuthorizationHandler(authorizationService *service.AuthorizationService) *AuthorizationHandler {
+
+	return &AuthorizationHandler{
+		authorizationService: authorizationService,
+	}
+}
+
+func (ah *AuthorizationHandler) Register(router *mux.Router) {
+	router.HandleFunc("/api/v1/payments/uid/{uid}/authorize", ah.AuthorizePayment).Methods("POST")
+	router.HandleFunc("/api/v1/payments/uid/{uid}/capture", ah.CapturePayment).Methods("POST")
+	router.HandleFunc("/api/v1/payments/uid/{uid}/void", ah.VoidPayment).Methods("POST")
+}
+
+func (ah *AuthorizationHandler) AuthorizePayment(w http.ResponseWriter, r *http.Request) {
+
+	payment, errorAuthorize := ah.authorizationService.Authorize(mux.Vars(r)["uid"], auth.FromRequest(r).User)
+
+	if errorAuthorize != nil {
+		util.WriteErrorFor(w, errorAuthorize)
+		return
+	}
+
+	util.WritePayload(w, http.StatusOK, paymentHandler.NewPaymentView(payment))
+}
+
+func (ah *AuthorizationHandler) CapturePayment(w http.ResponseWriter, r *http.Request) {
+
+	var paymentCapture PaymentCapture
+
+	// the body is optional
+	errorJson := json.NewDecoder(r.Body).Decode(&paymentCapture)
+
+	if errorJson != nil && errorJson != io.EOF {
+		util.WriteError(w, http.StatusBadRequest, errorJson.Error())
+		return
+	}
+
+	payment, errorCapture := ah.authorizationService.Capture(mux.Vars(r)["uid"], paymentCapture.Amount, auth.FromRequest(r).User)
+
+	if errorCapture != nil {
+		util.WriteErrorFor(w, errorCapture)
+		return
+	}
+
+	util.WritePayload(w, http.StatusOK, paymentHandler.NewPaymentView(payment))
+}
+
+func (ah *AuthorizationHandler) VoidPayment(w http.ResponseWriter, r *http.Request) {
+
+	payment, errorVoid := ah.authorizationService.Void(mux.Vars(r)["uid"], auth.FromRequest(r).User)
+
+	if errorVoid != nil {
+		util.WriteErrorFor(w, errorVoid)
+		return
+	}
+
+	util.WritePayload(w, http.StatusOK, paymentHandler.NewPaymentView(payment))
+}
@@ -0,0 +1,260 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/authorization/service"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	paymentHandler "github.com/javierjmgits/go-payment-api/payment/handler"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+//
+// mocks
+
+type authorizationRepositoryImplMock struct {
+	payment    *paymentModel.Payment
+	amendments []paymentModel.PaymentAmendment
+}
+
+func (mock *authorizationRepositoryImplMock) Amend(uid string, amend func(*paymentModel.Payment, int) ([]paymentModel.PaymentAmendment, error)) (*paymentModel.Payment, error) {
+
+	payment := *mock.payment
+
+	amendments, errorAmend := amend(&payment, len(mock.amendments)+1)
+
+	if errorAmend != nil {
+		return nil, errorAmend
+	}
+
+	mock.payment = &payment
+	mock.amendments = append(mock.amendments, amendments...)
+
+	return &payment, nil
+}
+
+func (mock *authorizationRepositoryImplMock) ExpireStale(now time.Time) (int, error) {
+	return 0, nil
+}
+
+type feeHookMock struct {
+	amounts []float64
+}
+
+func (mock *feeHookMock) AfterAmend(payment *paymentModel.Payment) error {
+
+	mock.amounts = append(mock.amounts, payment.Amount)
+	payment.FeeAmount = payment.Amount / 100
+
+	return nil
+}
+
+//
+// tests
+
+func TestAuthorizeAndCapturePartially(t *testing.T) {
+
+	router, mockRepository, feeHook := setUp()
+
+	resp := postAuthorization(router, "authorize", "")
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	payment := readPayment(resp)
+
+	assert.Equal(t, paymentModel.AUTHORIZATION_STATUS_AUTHORIZED, payment.AuthorizationStatus)
+	assert.Equal(t, 100.0, payment.AuthorizedAmount)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *payment.AuthorizationExpiresAt, 2*time.Second)
+	assert.True(t, mockRepository.payment.IsHeld())
+
+	resp = postAuthorization(router, "capture", `{"amount": 60}`)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	payment = readPayment(resp)
+
+	// verify
+
+	assert.Equal(t, paymentModel.AUTHORIZATION_STATUS_CAPTURED, payment.AuthorizationStatus)
+	assert.Equal(t, 100.0, payment.AuthorizedAmount)
+	assert.Equal(t, 60.0, payment.CapturedAmount)
+	assert.Equal(t, 60.0, payment.Amount)
+	assert.Equal(t, 66.0, payment.TargetAmount)
+	assert.Equal(t, 0.6, payment.FeeAmount)
+	assert.Equal(t, []float64{60}, feeHook.amounts)
+	assert.False(t, mockRepository.payment.IsHeld())
+	assert.Len(t, mockRepository.amendments, 3)
+	assert.Equal(t, "amount", mockRepository.amendments[2].Field)
+
+	// only one capture
+	resp = postAuthorization(router, "capture", "")
+
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestCaptureFully(t *testing.T) {
+
+	router, mockRepository, feeHook := setUp()
+
+	postAuthorization(router, "authorize", "")
+
+	resp := postAuthorization(router, "capture", "")
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	payment := readPayment(resp)
+
+	assert.Equal(t, 100.0, payment.CapturedAmount)
+	assert.Equal(t, 100.0, payment.Amount)
+	assert.Empty(t, feeHook.amounts)
+	assert.Len(t, mockRepository.amendments, 2)
+}
+
+func TestCapturePaymentKoAboveAuthorized(t *testing.T) {
+
+	router, _, _ := setUp()
+
+	postAuthorization(router, "authorize", "")
+
+	resp := postAuthorization(router, "capture", `{"amount": 100.01}`)
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestCapturePaymentKoNotAuthorized(t *testing.T) {
+
+	router, _, _ := setUp()
+
+	resp := postAuthorization(router, "capture", "")
+
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestCapturePaymentKoExpired(t *testing.T) {
+
+	router, mockRepository, _ := setUp()
+
+	postAuthorization(router, "authorize", "")
+
+	expiresAt := time.Now().Add(-time.Minute)
+	mockRepository.payment.AuthorizationExpiresAt = &expiresAt
+
+	resp := postAuthorization(router, "capture", "")
+
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestVoidPayment(t *testing.T) {
+
+	router, mockRepository, _ := setUp()
+
+	postAuthorization(router, "authorize", "")
+
+	resp := postAuthorization(router, "void", "")
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, paymentModel.AUTHORIZATION_STATUS_VOIDED, readPayment(resp).AuthorizationStatus)
+	assert.True(t, mockRepository.payment.IsHeld())
+
+	// a voided hold can not be captured
+	resp = postAuthorization(router, "capture", "")
+
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestAuthorizePaymentKoProcessed(t *testing.T) {
+
+	router, mockRepository, _ := setUp()
+	mockRepository.payment.Processed = true
+
+	resp := postAuthorization(router, "authorize", "")
+
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestAuthorizeOnCreate(t *testing.T) {
+
+	router, mockRepository, _ := setUp()
+	authorizationService := service.NewAuthorizationService(mockRepository, time.Hour)
+
+	errorHook := authorizationService.BeforeCreate(mockRepository.payment, &paymentHandler.PaymentCreate{})
+
+	assert.Nil(t, errorHook)
+	assert.Equal(t, "", mockRepository.payment.AuthorizationStatus)
+
+	errorHook = authorizationService.BeforeCreate(mockRepository.payment, &paymentHandler.PaymentCreate{Authorize: true})
+
+	// verify
+
+	assert.Nil(t, errorHook)
+	assert.Equal(t, paymentModel.AUTHORIZATION_STATUS_AUTHORIZED, mockRepository.payment.AuthorizationStatus)
+	assert.Equal(t, 100.0, mockRepository.payment.AuthorizedAmount)
+	assert.NotNil(t, mockRepository.payment.AuthorizationExpiresAt)
+	assert.True(t, mockRepository.payment.IsHeld())
+
+	// the hold placed on creation is captured like any other
+	resp := postAuthorization(router, "capture", "")
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, paymentModel.AUTHORIZATION_STATUS_CAPTURED, readPayment(resp).AuthorizationStatus)
+}
+
+//
+// private functions
+
+func setUp() (*mux.Router, *authorizationRepositoryImplMock, *feeHookMock) {
+
+	var router = mux.NewRouter()
+
+	mockRepository := &authorizationRepositoryImplMock{
+		payment: &paymentModel.Payment{
+			Uid:            "5c1c7c9e-9a4e-4d2b-8f3a-2d7f1b0e6a11",
+			AccountOrigin:  "GB29NWBK60161331926819",
+			AccountTarget:  "DE89370400440532013000",
+			Amount:         100,
+			Currency:       "EUR",
+			TargetCurrency: "USD",
+			TargetAmount:   110,
+			FxRate:         1.1,
+			Date:           time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	feeHook := &feeHookMock{}
+
+	authorizationService := service.NewAuthorizationService(mockRepository, time.Hour)
+	authorizationService.AddAmendHook(feeHook)
+
+	NewAuthorizationHandler(authorizationService).Register(router)
+
+	return router, mockRepository, feeHook
+}
+
+func postAuthorization(router *mux.Router, action string, body string) *http.Response {
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/payments/uid/5c1c7c9e-9a4e-4d2b-8f3a-2d7f1b0e6a11/"+action, strings.NewReader(body))
+	req.Header.Set(auth.HEADER_USER, "merchant")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	return w.Result()
+}
+
+func readPayment(resp *http.Response) *paymentHandler.PaymentView {
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	var payment paymentHandler.PaymentView
+
+	json.Unmarshal(body, &payment)
+
+	return &payment
+}
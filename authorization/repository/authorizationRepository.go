@@ -0,0 +1,36 @@
+package repository
+
+import (
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	paymentRepository "github.com/javierjmgits/go-payment-api/payment/repository"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+type AuthorizationRepository interface {
+	Amend(uid string, amend func(*paymentModel.Payment, int) ([]paymentModel.PaymentAmendment, error)) (*paymentModel.Payment, error)
+	ExpireStale(now time.Time) (int, error)
+}
+
+type authorizationRepositoryImpl struct {
+	db       *gorm.DB
+	payments paymentRepository.PaymentRepository
+}
+
+func NewAuthorizationRepositoryImpl(db *gorm.DB) AuthorizationRepository {
+	return &authorizationRepositoryImpl{
+		db:       db,
+		payments: paymentRepository.NewPaymentRepositoryImpl(db),
+	}
+}
+
+// Amend changes the authorization of a locked payment, recording the changes in its history.
+func (ari *authorizationRepositoryImpl) Amend(uid string, amend func(*paymentModel.Payment, int) ([]paymentModel.PaymentAmendment, error)) (*paymentModel.Payment, error) {
+	return ari.payments.Amend(uid, amend)
+}
+
+func (ari *authorizationRepositoryImpl) ExpireStale(now time.Time) (int, error) {
+
+	return paymentRepository.UpdateMatching(ari.db, "authorization_status", paymentModel.AUTHORIZATION_STATUS_EXPIRED,
+		"authorization_status = ? AND authorization_expires_at <= ?", paymentModel.AUTHORIZATION_STATUS_AUTHORIZED, now)
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"fmt"
+	"github.com/javierjmgits/go-payment-api/authorization/repository"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	paymentHandler "github.com/javierjmgits/go-payment-api/payment/handler"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"math"
+	"strconv"
+	"time"
+)
+
+// AuthorizationService reserves the amount of a payment on its origin account until it is captured or voided.
+type AuthorizationService struct {
+	authorizationRepository repository.AuthorizationRepository
+	amendHooks              []paymentHandler.AmendHook
+	ttl                     time.Duration
+	now                     func() time.Time
+}
+
+func NewAuthorizationService(authorizationRepository repository.AuthorizationRepository, ttl time.Duration) *AuthorizationService {
+
+	return &AuthorizationService{
+		authorizationRepository: authorizationRepository,
+		ttl:                     ttl,
+		now:                     time.Now,
+	}
+}
+
+// AddAmendHook registers a hook run when a partial capture lowers the amount of the payment.
+func (as *AuthorizationService) AddAmendHook(amendHook paymentHandler.AmendHook) {
+
+	as.amendHooks = append(as.amendHooks, amendHook)
+}
+
+// BeforeCreate authorizes the payments created with the authorize flag, so that no worker can process them before the
+// hold is placed.
+func (as *AuthorizationService) BeforeCreate(payment *paymentModel.Payment, paymentCreate *paymentHandler.PaymentCreate) error {
+
+	if !paymentCreate.Authorize {
+		return nil
+	}
+
+	as.authorize(payment)
+
+	return nil
+}
+
+// Authorize places a hold for the amount of an unprocessed payment; it is not processed until captured.
+func (as *AuthorizationService) Authorize(paymentUid string, user string) (*paymentModel.Payment, error) {
+
+	return as.authorizationRepository.Amend(paymentUid, func(payment *paymentModel.Payment, revision int) ([]paymentModel.PaymentAmendment, error) {
+
+		if errorCheck := checkOpen(payment); errorCheck != nil {
+			return nil, errorCheck
+		}
+
+		if payment.AuthorizationStatus != "" {
+			return nil, &util.ConflictError{Message: fmt.Sprintf("payment authorization is already %s", payment.AuthorizationStatus)}
+		}
+
+		as.authorize(payment)
+
+		return []paymentModel.PaymentAmendment{newAmendment(payment, revision, user, "", paymentModel.AUTHORIZATION_STATUS_AUTHORIZED)}, nil
+	})
+}
+
+// Capture takes the whole authorized amount, or a part of it, releasing the rest of the hold. Only one capture is possible.
+func (as *AuthorizationService) Capture(paymentUid string, amount *float64, user string) (*paymentModel.Payment, error) {
+
+	if amount != nil && *amount <= 0 {
+		return nil, &util.InputError{Message: "amount must be a positive number"}
+	}
+
+	return as.authorizationRepository.Amend(paymentUid, func(payment *paymentModel.Payment, revision int) ([]paymentModel.PaymentAmendment, error) {
+
+		if errorCheck := as.checkAuthorized(payment); errorCheck != nil {
+			return nil, errorCheck
+		}
+
+		captured := payment.AuthorizedAmount
+
+		if amount != nil {
+			captured = *amount
+		}
+
+		if captured > payment.AuthorizedAmount {
+			return nil, &util.InputError{Message: fmt.Sprintf("amount can not exceed the authorized %s", formatAmount(payment.AuthorizedAmount))}
+		}
+
+		if captured != payment.Amount && payment.FxQuoteUid != nil {
+			return nil, &util.ConflictError{Message: "amount is fixed by the FX quote of the payment, only a full capture is possible"}
+		}
+
+		amendments := []paymentModel.PaymentAmendment{
+			newAmendment(payment, revision, user, payment.AuthorizationStatus, paymentModel.AUTHORIZATION_STATUS_CAPTURED),
+		}
+
+		payment.AuthorizationStatus = paymentModel.AUTHORIZATION_STATUS_CAPTURED
+		payment.CapturedAmount = captured
+
+		if captured == payment.Amount {
+			return amendments, nil
+		}
+
+		amountAmendment := newAmendment(payment, revision, user, formatAmount(payment.Amount), formatAmount(captured))
+		amountAmendment.Field = "amount"
+
+		payment.Amount = captured
+		payment.TargetAmount = math.Round(captured*payment.FxRate*100) / 100
+
+		for _, amendHook := range as.amendHooks {
+
+			if errorHook := amendHook.AfterAmend(payment); errorHook != nil {
+				return nil, errorHook
+			}
+		}
+
+		return append(amendments, amountAmendment), nil
+	})
+}
+
+// Void releases the hold; the payment is never processed.
+func (as *AuthorizationService) Void(paymentUid string, user string) (*paymentModel.Payment, error) {
+
+	return as.authorizationRepository.Amend(paymentUid, func(payment *paymentModel.Payment, revision int) ([]paymentModel.PaymentAmendment, error) {
+
+		if errorCheck := as.checkAuthorized(payment); errorCheck != nil {
+			return nil, errorCheck
+		}
+
+		amendment := newAmendment(payment, revision, user, payment.AuthorizationStatus, paymentModel.AUTHORIZATION_STATUS_VOIDED)
+
+		payment.AuthorizationStatus = paymentModel.AUTHORIZATION_STATUS_VOIDED
+
+		return []paymentModel.PaymentAmendment{amendment}, nil
+	})
+}
+
+// Sweep expires the holds nobody captured in time.
+func (as *AuthorizationService) Sweep(now time.Time) (int, error) {
+	return as.authorizationRepository.ExpireStale(now.UTC())
+}
+
+//
+// private functions
+
+func (as *AuthorizationService) checkAuthorized(payment *paymentModel.Payment) error {
+
+	if errorCheck := checkOpen(payment); errorCheck != nil {
+		return errorCheck
+	}
+
+	if payment.AuthorizationStatus == "" {
+		return &util.ConflictError{Message: "payment is not authorized"}
+	}
+
+	if payment.AuthorizationStatus != paymentModel.AUTHORIZATION_STATUS_AUTHORIZED {
+		return &util.ConflictError{Message: fmt.Sprintf("payment authorization is already %s", payment.AuthorizationStatus)}
+	}
+
+	// the sweeper may not have run yet
+	if !as.now().Before(*payment.AuthorizationExpiresAt) {
+		return &util.ConflictError{Message: "payment authorization expired"}
+	}
+
+	return nil
+}
+
+func (as *AuthorizationService) authorize(payment *paymentModel.Payment) {
+
+	expiresAt := as.now().UTC().Add(as.ttl).Truncate(time.Second)
+
+	payment.AuthorizationStatus = paymentModel.AUTHORIZATION_STATUS_AUTHORIZED
+	payment.AuthorizedAmount = payment.Amount
+	payment.AuthorizationExpiresAt = &expiresAt
+}
+
+func checkOpen(payment *paymentModel.Payment) error {
+
+	if payment.Processed {
+		return &util.ConflictError{Message: "Payment already processed"}
+	}
+
+	if payment.IsCancelled() {
+		return &util.ConflictError{Message: "Payment cancelled"}
+	}
+
+	if payment.IsProcessing() {
+		return &util.ConflictError{Message: "Payment is being processed"}
+	}
+
+	return nil
+}
+
+func newAmendment(payment *paymentModel.Payment, revision int, user string, oldValue string, newValue string) paymentModel.PaymentAmendment {
+
+	return paymentModel.PaymentAmendment{
+		PaymentUid: payment.Uid,
+		Revision:   revision,
+		Field:      "authorizationStatus",
+		OldValue:   oldValue,
+		NewValue:   newValue,
+		AmendedBy:  user,
+	}
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', -1, 64)
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+)
+
+// the service sits behind a gateway that authenticates the caller and asserts its identity in these headers
+const (
+	HEADER_USER   = "X-User"
+	HEADER_TENANT = "X-Tenant"
+	HEADER_SCOPES = "X-Scopes"
+)
+
+type Principal struct {
+	User   string
+	Tenant string
+	Scopes []string
+}
+
+func FromRequest(r *http.Request) *Principal {
+
+	return FromHeader(r.Header)
+}
+
+// FromHeader reads the principal from any set of headers, e.g. the metadata of a gRPC call.
+func FromHeader(header http.Header) *Principal {
+
+	principal := &Principal{
+		User:   strings.TrimSpace(header.Get(HEADER_USER)),
+		Tenant: strings.TrimSpace(header.Get(HEADER_TENANT)),
+	}
+
+	for _, scope := range strings.Split(header.Get(HEADER_SCOPES), ",") {
+
+		if scope = strings.TrimSpace(scope); scope != "" {
+			principal.Scopes = append(principal.Scopes, scope)
+		}
+	}
+
+	return principal
+}
+
+func (principal *Principal) HasScope(scope string) bool {
+
+	for _, item := range principal.Scopes {
+
+		if item == scope {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,6 +1,12 @@
 package config
 
-import "os"
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
 
 const (
 	DEFAULT_DB_NAME     = "payment_db"
@@ -9,11 +15,70 @@ const (
 
 	DEFAULT_SERVER_HOST = "localhost"
 	DEFAULT_SERVER_PORT = "8080"
+
+	DEFAULT_GRPC_ENABLED = "true"
+	DEFAULT_GRPC_PORT    = "9090"
+
+	DEFAULT_OPENAPI_VALIDATE_REQUESTS  = "true"
+	DEFAULT_OPENAPI_VALIDATE_RESPONSES = "false"
+
+	DEFAULT_SCHEDULER_ENABLED    = "true"
+	DEFAULT_SCHEDULER_INTERVAL   = "1m"
+	DEFAULT_SCHEDULER_BATCH_SIZE = "100"
+
+	DEFAULT_ACCOUNT_SCHEMES = "IBAN,BIC,SORT_CODE,ABA"
+
+	DEFAULT_FX_RATES_FILE = ""
+	DEFAULT_FX_QUOTE_TTL  = "5m"
+
+	DEFAULT_RISK_RULES_FILE = ""
+
+	DEFAULT_APPROVAL_THRESHOLDS_FILE = ""
+	DEFAULT_APPROVAL_TTL             = "72h"
+
+	// ISO 20022 cancellation reasons: duplicate, requested by customer, fraud, technical problem, incorrect agent, undue payment
+	DEFAULT_PAYMENT_CANCEL_REASONS   = "DUPL,CUST,FRAD,TECH,AGNT,UPAY"
+	DEFAULT_PAYMENT_DELETE_SCOPE     = ""
+	DEFAULT_PAYMENT_WATCH_INTERVAL   = "1s"
+	DEFAULT_PAYMENT_STREAM_HEARTBEAT = "15s"
+
+	DEFAULT_PROCESSING_WORKERS       = "4"
+	DEFAULT_PROCESSING_POLL_INTERVAL = "1s"
+	DEFAULT_PROCESSING_TIMEOUT       = "30s"
+	DEFAULT_PROCESSING_MAX_ATTEMPTS  = "5"
+	DEFAULT_PROCESSING_BACKOFF       = "5s"
+	DEFAULT_PROCESSING_MAX_BACKOFF   = "10m"
+
+	DEFAULT_RAIL_CONNECTOR        = ""
+	DEFAULT_RAIL_LATENCY          = "200ms"
+	DEFAULT_RAIL_FAILURE_RATE     = "0"
+	DEFAULT_RAIL_REJECTION_RATE   = "0"
+	DEFAULT_RAIL_SETTLEMENT_DELAY = "5s"
+
+	DEFAULT_FEE_SCHEDULE_FILE = ""
+
+	DEFAULT_AUTHORIZATION_TTL = "168h"
+
+	DEFAULT_BENEFICIARY_COOLING_OFF      = "24h"
+	DEFAULT_BENEFICIARY_PAYEE_NAMES_FILE = ""
 )
 
 type Config struct {
-	DB     *DBConfig
-	Server *ServerConfig
+	DB            *DBConfig
+	Server        *ServerConfig
+	Grpc          *GrpcConfig
+	OpenAPI       *OpenAPIConfig
+	Scheduler     *SchedulerConfig
+	Account       *AccountConfig
+	FX            *FXConfig
+	Risk          *RiskConfig
+	Approval      *ApprovalConfig
+	Payment       *PaymentConfig
+	Processing    *ProcessingConfig
+	Rail          *RailConfig
+	Fee           *FeeConfig
+	Authorization *AuthorizationConfig
+	Beneficiary   *BeneficiaryConfig
 }
 
 type DBConfig struct {
@@ -27,6 +92,82 @@ type ServerConfig struct {
 	Port string
 }
 
+type GrpcConfig struct {
+	Enabled bool
+	Port    string
+}
+
+// OpenAPIConfig sets what is checked against the OpenAPI document; responses are only meant to be checked in tests.
+type OpenAPIConfig struct {
+	ValidateRequests  bool
+	ValidateResponses bool
+}
+
+type SchedulerConfig struct {
+	Enabled   bool
+	Interval  time.Duration
+	BatchSize int
+}
+
+type AccountConfig struct {
+	Schemes []string
+}
+
+type FXConfig struct {
+	RatesFile string
+	QuoteTTL  time.Duration
+}
+
+type RiskConfig struct {
+	RulesFile string
+}
+
+type ApprovalConfig struct {
+	ThresholdsFile string
+	TTL            time.Duration
+}
+
+// PaymentConfig also sets how often the payments are polled for changes by the streaming APIs, and how often the
+// stream of changes sends a heartbeat to keep idle connections open.
+type PaymentConfig struct {
+	CancelReasons   []string
+	DeleteScope     string
+	WatchInterval   time.Duration
+	StreamHeartbeat time.Duration
+}
+
+type ProcessingConfig struct {
+	Workers      int
+	PollInterval time.Duration
+	Timeout      time.Duration
+	MaxAttempts  int
+	Backoff      time.Duration
+	MaxBackoff   time.Duration
+}
+
+// RailConfig selects the connector payments are settled through; the other values tune the simulator.
+type RailConfig struct {
+	Connector       string
+	Latency         time.Duration
+	FailureRate     float64
+	RejectionRate   float64
+	SettlementDelay time.Duration
+}
+
+type FeeConfig struct {
+	ScheduleFile string
+}
+
+type AuthorizationConfig struct {
+	TTL time.Duration
+}
+
+// BeneficiaryConfig sets how long new beneficiaries wait before they can be paid and the names the fake payee check knows.
+type BeneficiaryConfig struct {
+	CoolingOff     time.Duration
+	PayeeNamesFile string
+}
+
 func NewConfig() *Config {
 
 	dbName := getEnvParamOrDefault("DB_NAME", DEFAULT_DB_NAME)
@@ -39,6 +180,70 @@ func NewConfig() *Config {
 
 	dbServerPort := getEnvParamOrDefault("SERVER_PORT", DEFAULT_SERVER_PORT)
 
+	grpcEnabled := getEnvParamAsBoolOrDefault("GRPC_ENABLED", DEFAULT_GRPC_ENABLED)
+
+	grpcPort := getEnvParamOrDefault("GRPC_PORT", DEFAULT_GRPC_PORT)
+
+	openAPIValidateRequests := getEnvParamAsBoolOrDefault("OPENAPI_VALIDATE_REQUESTS", DEFAULT_OPENAPI_VALIDATE_REQUESTS)
+
+	openAPIValidateResponses := getEnvParamAsBoolOrDefault("OPENAPI_VALIDATE_RESPONSES", DEFAULT_OPENAPI_VALIDATE_RESPONSES)
+
+	schedulerEnabled := getEnvParamAsBoolOrDefault("SCHEDULER_ENABLED", DEFAULT_SCHEDULER_ENABLED)
+
+	schedulerInterval := getEnvParamAsPositiveDurationOrDefault("SCHEDULER_INTERVAL", DEFAULT_SCHEDULER_INTERVAL)
+
+	schedulerBatchSize := getEnvParamAsIntOrDefault("SCHEDULER_BATCH_SIZE", DEFAULT_SCHEDULER_BATCH_SIZE)
+
+	accountSchemes := strings.Split(getEnvParamOrDefault("ACCOUNT_SCHEMES", DEFAULT_ACCOUNT_SCHEMES), ",")
+
+	fxRatesFile := getEnvParamOrDefault("FX_RATES_FILE", DEFAULT_FX_RATES_FILE)
+
+	fxQuoteTTL := getEnvParamAsDurationOrDefault("FX_QUOTE_TTL", DEFAULT_FX_QUOTE_TTL)
+
+	riskRulesFile := getEnvParamOrDefault("RISK_RULES_FILE", DEFAULT_RISK_RULES_FILE)
+
+	approvalThresholdsFile := getEnvParamOrDefault("APPROVAL_THRESHOLDS_FILE", DEFAULT_APPROVAL_THRESHOLDS_FILE)
+
+	approvalTTL := getEnvParamAsDurationOrDefault("APPROVAL_TTL", DEFAULT_APPROVAL_TTL)
+
+	paymentCancelReasons := strings.Split(getEnvParamOrDefault("PAYMENT_CANCEL_REASONS", DEFAULT_PAYMENT_CANCEL_REASONS), ",")
+
+	paymentDeleteScope := getEnvParamOrDefault("PAYMENT_DELETE_SCOPE", DEFAULT_PAYMENT_DELETE_SCOPE)
+
+	paymentWatchInterval := getEnvParamAsPositiveDurationOrDefault("PAYMENT_WATCH_INTERVAL", DEFAULT_PAYMENT_WATCH_INTERVAL)
+
+	paymentStreamHeartbeat := getEnvParamAsPositiveDurationOrDefault("PAYMENT_STREAM_HEARTBEAT", DEFAULT_PAYMENT_STREAM_HEARTBEAT)
+
+	processingWorkers := getEnvParamAsIntOrDefault("PROCESSING_WORKERS", DEFAULT_PROCESSING_WORKERS)
+
+	processingPollInterval := getEnvParamAsDurationOrDefault("PROCESSING_POLL_INTERVAL", DEFAULT_PROCESSING_POLL_INTERVAL)
+
+	processingTimeout := getEnvParamAsDurationOrDefault("PROCESSING_TIMEOUT", DEFAULT_PROCESSING_TIMEOUT)
+
+	processingMaxAttempts := getEnvParamAsIntOrDefault("PROCESSING_MAX_ATTEMPTS", DEFAULT_PROCESSING_MAX_ATTEMPTS)
+
+	processingBackoff := getEnvParamAsDurationOrDefault("PROCESSING_BACKOFF", DEFAULT_PROCESSING_BACKOFF)
+
+	processingMaxBackoff := getEnvParamAsDurationOrDefault("PROCESSING_MAX_BACKOFF", DEFAULT_PROCESSING_MAX_BACKOFF)
+
+	railConnector := getEnvParamOrDefault("RAIL_CONNECTOR", DEFAULT_RAIL_CONNECTOR)
+
+	railLatency := getEnvParamAsDurationOrDefault("RAIL_LATENCY", DEFAULT_RAIL_LATENCY)
+
+	railFailureRate := getEnvParamAsFloatOrDefault("RAIL_FAILURE_RATE", DEFAULT_RAIL_FAILURE_RATE)
+
+	railRejectionRate := getEnvParamAsFloatOrDefault("RAIL_REJECTION_RATE", DEFAULT_RAIL_REJECTION_RATE)
+
+	railSettlementDelay := getEnvParamAsDurationOrDefault("RAIL_SETTLEMENT_DELAY", DEFAULT_RAIL_SETTLEMENT_DELAY)
+
+	feeScheduleFile := getEnvParamOrDefault("FEE_SCHEDULE_FILE", DEFAULT_FEE_SCHEDULE_FILE)
+
+	authorizationTTL := getEnvParamAsDurationOrDefault("AUTHORIZATION_TTL", DEFAULT_AUTHORIZATION_TTL)
+
+	beneficiaryCoolingOff := getEnvParamAsDurationOrDefault("BENEFICIARY_COOLING_OFF", DEFAULT_BENEFICIARY_COOLING_OFF)
+
+	beneficiaryPayeeNamesFile := getEnvParamOrDefault("BENEFICIARY_PAYEE_NAMES_FILE", DEFAULT_BENEFICIARY_PAYEE_NAMES_FILE)
+
 	return &Config{
 
 		DB: &DBConfig{
@@ -51,6 +256,77 @@ func NewConfig() *Config {
 			Host: dbServerHost,
 			Port: dbServerPort,
 		},
+
+		Grpc: &GrpcConfig{
+			Enabled: grpcEnabled,
+			Port:    grpcPort,
+		},
+
+		OpenAPI: &OpenAPIConfig{
+			ValidateRequests:  openAPIValidateRequests,
+			ValidateResponses: openAPIValidateResponses,
+		},
+
+		Scheduler: &SchedulerConfig{
+			Enabled:   schedulerEnabled,
+			Interval:  schedulerInterval,
+			BatchSize: schedulerBatchSize,
+		},
+
+		Account: &AccountConfig{
+			Schemes: accountSchemes,
+		},
+
+		FX: &FXConfig{
+			RatesFile: fxRatesFile,
+			QuoteTTL:  fxQuoteTTL,
+		},
+
+		Risk: &RiskConfig{
+			RulesFile: riskRulesFile,
+		},
+
+		Approval: &ApprovalConfig{
+			ThresholdsFile: approvalThresholdsFile,
+			TTL:            approvalTTL,
+		},
+
+		Payment: &PaymentConfig{
+			CancelReasons:   paymentCancelReasons,
+			DeleteScope:     paymentDeleteScope,
+			WatchInterval:   paymentWatchInterval,
+			StreamHeartbeat: paymentStreamHeartbeat,
+		},
+
+		Processing: &ProcessingConfig{
+			Workers:      processingWorkers,
+			PollInterval: processingPollInterval,
+			Timeout:      processingTimeout,
+			MaxAttempts:  processingMaxAttempts,
+			Backoff:      processingBackoff,
+			MaxBackoff:   processingMaxBackoff,
+		},
+
+		Rail: &RailConfig{
+			Connector:       railConnector,
+			Latency:         railLatency,
+			FailureRate:     railFailureRate,
+			RejectionRate:   railRejectionRate,
+			SettlementDelay: railSettlementDelay,
+		},
+
+		Fee: &FeeConfig{
+			ScheduleFile: feeScheduleFile,
+		},
+
+		Authorization: &AuthorizationConfig{
+			TTL: authorizationTTL,
+		},
+
+		Beneficiary: &BeneficiaryConfig{
+			CoolingOff:     beneficiaryCoolingOff,
+			PayeeNamesFile: beneficiaryPayeeNamesFile,
+		},
 	}
 }
 
@@ -64,3 +340,67 @@ func getEnvParamOrDefault(envParamName string, defaultValue string) string {
 
 	return value
 }
+
+func getEnvParamAsBoolOrDefault(envParamName string, defaultValue string) bool {
+
+	value := getEnvParamOrDefault(envParamName, defaultValue)
+
+	result, errorParse := strconv.ParseBool(value)
+
+	if errorParse != nil {
+		log.Fatalf("Invalid value '%s' for %s: %v", value, envParamName, errorParse)
+	}
+
+	return result
+}
+
+func getEnvParamAsIntOrDefault(envParamName string, defaultValue string) int {
+
+	value := getEnvParamOrDefault(envParamName, defaultValue)
+
+	result, errorParse := strconv.Atoi(value)
+
+	if errorParse != nil {
+		log.Fatalf("Invalid value '%s' for %s: %v", value, envParamName, errorParse)
+	}
+
+	return result
+}
+
+func getEnvParamAsFloatOrDefault(envParamName string, defaultValue string) float64 {
+
+	value := getEnvParamOrDefault(envParamName, defaultValue)
+
+	result, errorParse := strconv.ParseFloat(value, 64)
+
+	if errorParse != nil {
+		log.Fatalf("Invalid value '%s' for %s: %v", value, envParamName, errorParse)
+	}
+
+	return result
+}
+
+func getEnvParamAsDurationOrDefault(envParamName string, defaultValue string) time.Duration {
+
+	value := getEnvParamOrDefault(envParamName, defaultValue)
+
+	result, errorParse := time.ParseDuration(value)
+
+	if errorParse != nil {
+		log.Fatalf("Invalid value '%s' for %s: %v", value, envParamName, errorParse)
+	}
+
+	return result
+}
+
+// tickers panic on durations that are not positive, so these are refused at start-up
+func getEnvParamAsPositiveDurationOrDefault(envParamName string, defaultValue string) time.Duration {
+
+	result := getEnvParamAsDurationOrDefault(envParamName, defaultValue)
+
+	if result <= 0 {
+		log.Fatalf("Invalid value '%s' for %s: must be positive", result, envParamName)
+	}
+
+	return result
+}
@@ -0,0 +1,114 @@
+package util
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// CSVRow is a row of a CSV file, with the line it starts at; Error is set instead of the values for a row that is not
+// valid CSV.
+type CSVRow struct {
+	Line    int
+	Error   error
+	fields  []string
+	columns map[string]int
+}
+
+// Value is the trimmed value of a column of the row, empty when the file or the row has no such column.
+func (row *CSVRow) Value(column string) string {
+
+	index, found := row.columns[column]
+
+	if !found || index >= len(row.fields) {
+		return ""
+	}
+
+	return strings.TrimSpace(row.fields[index])
+}
+
+// ReadCSV hands each row of a CSV file, whose header names its columns, the required ones among them, to the consumer.
+// A row that is not valid CSV comes with its error and the reading goes on; any other error, e.g. of the reader,
+// stops it and is returned.
+func ReadCSV(reader io.Reader, required []string, consumer func(row *CSVRow)) error {
+
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	header, errorHeader := csvReader.Read()
+
+	if errorHeader != nil {
+		return fmt.Errorf("invalid CSV header: %v", errorHeader)
+	}
+
+	columns := map[string]int{}
+
+	for index, name := range header {
+		columns[strings.TrimSpace(name)] = index
+	}
+
+	for _, name := range required {
+
+		if _, found := columns[name]; !found {
+			return fmt.Errorf("missing CSV column '%s'", name)
+		}
+	}
+
+	for {
+
+		fields, errorRead := csvReader.Read()
+
+		if errorRead == io.EOF {
+			return nil
+		}
+
+		var errorParse *csv.ParseError
+
+		if errors.As(errorRead, &errorParse) {
+			consumer(&CSVRow{Line: parseErrorLine(errorParse), Error: errorRead})
+			continue
+		}
+
+		if errorRead != nil {
+			return errorRead
+		}
+
+		line, _ := csvReader.FieldPos(0)
+
+		consumer(&CSVRow{Line: line, fields: fields, columns: columns})
+	}
+}
+
+// ParseDate reads a date of an imported file, either RFC 3339 or YYYY-MM-DD.
+func ParseDate(value string) (time.Time, error) {
+
+	value = strings.TrimSpace(value)
+
+	if date, errorParse := time.Parse(time.RFC3339, value); errorParse == nil {
+		return date, nil
+	}
+
+	date, errorParse := time.Parse("2006-01-02", value)
+
+	if errorParse != nil {
+		return time.Time{}, fmt.Errorf("invalid date '%s'", value)
+	}
+
+	return date, nil
+}
+
+//
+// private functions
+
+func parseErrorLine(errorParse *csv.ParseError) int {
+
+	if errorParse.StartLine > 0 {
+		return errorParse.StartLine
+	}
+
+	return errorParse.Line
+}
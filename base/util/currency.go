@@ -0,0 +1,13 @@
+package util
+
+import (
+	"regexp"
+)
+
+var currencyPattern = regexp.MustCompile("^[A-Z]{3}$")
+
+// IsCurrencyCode tells whether the value is an uppercase ISO 4217 code, as currencies are stored.
+func IsCurrencyCode(value string) bool {
+
+	return currencyPattern.MatchString(value)
+}
@@ -0,0 +1,78 @@
+package util
+
+import (
+	"net/http"
+	"strings"
+)
+
+type InputError struct {
+	Message string
+}
+
+func (ie *InputError) Error() string {
+	return ie.Message
+}
+
+type ConflictError struct {
+	Message string
+}
+
+func (ce *ConflictError) Error() string {
+	return ce.Message
+}
+
+// RejectedError is a valid request refused by a business rule; Details, when set, tells the client why.
+type RejectedError struct {
+	Message string
+	Details interface{}
+}
+
+func (re *RejectedError) Error() string {
+	return re.Message
+}
+
+type ForbiddenError struct {
+	Message string
+}
+
+func (fe *ForbiddenError) Error() string {
+	return fe.Message
+}
+
+// IsRefusal tells a request refused as invalid or against a rule from a failure of the service itself, e.g. of the DB
+func IsRefusal(err error) bool {
+
+	switch err.(type) {
+
+	case *InputError, *ConflictError, *RejectedError, *ForbiddenError:
+		return true
+	}
+
+	return false
+}
+
+func WriteErrorFor(w http.ResponseWriter, err error) {
+
+	switch err.(type) {
+
+	case *InputError:
+		WriteError(w, http.StatusBadRequest, err.Error())
+
+	case *ConflictError:
+		WriteError(w, http.StatusConflict, err.Error())
+
+	case *RejectedError:
+		WriteErrorWithDetails(w, http.StatusUnprocessableEntity, err.Error(), err.(*RejectedError).Details)
+
+	case *ForbiddenError:
+		WriteError(w, http.StatusForbidden, err.Error())
+
+	default:
+		if strings.Contains(err.Error(), "not found") {
+			WriteError(w, http.StatusNotFound, err.Error())
+			return
+		}
+
+		WriteError(w, http.StatusInternalServerError, err.Error())
+	}
+}
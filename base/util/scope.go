@@ -0,0 +1,36 @@
+package util
+
+// MostSpecific returns the index of the rule that fits a tenant and currency best, or -1 when none fits. An empty tenant
+// or currency of a rule matches any, a tenant match is more specific than a currency match, and among rules as
+// specific as each other the first one wins. It is shared by the fee schedule and the approval policy.
+func MostSpecific(rules int, scopeOf func(index int) (string, string), tenant string, currency string) int {
+
+	best := -1
+	bestScore := -1
+
+	for index := 0; index < rules; index++ {
+
+		ruleTenant, ruleCurrency := scopeOf(index)
+
+		if (ruleTenant != "" && ruleTenant != tenant) || (ruleCurrency != "" && ruleCurrency != currency) {
+			continue
+		}
+
+		score := 0
+
+		if ruleTenant != "" {
+			score += 2
+		}
+
+		if ruleCurrency != "" {
+			score++
+		}
+
+		if score > bestScore {
+			best = index
+			bestScore = score
+		}
+	}
+
+	return best
+}
@@ -27,3 +27,17 @@ func WriteError(w http.ResponseWriter, code int, message string) {
 		"error": message,
 	})
 }
+
+func WriteErrorWithDetails(w http.ResponseWriter, code int, message string, details interface{}) {
+
+	if details == nil {
+		WriteError(w, code, message)
+		return
+	}
+
+	WritePayload(w, code, map[string]interface{}{
+		"code":    code,
+		"error":   message,
+		"details": details,
+	})
+}
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/batch/importer"
+	"github.com/javierjmgits/go-payment-api/batch/model"
+	"github.com/javierjmgits/go-payment-api/batch/repository"
+	"net/http"
+	"strings"
+)
+
+type BatchHandler struct {
+	batchRepository repository.BatchRepository
+	batchImporter   *importer.BatchImporter
+}
+
+type BatchView struct {
+	Uid          string           `json:"uid"`
+	Name         string           `json:"name"`
+	Format       string           `json:"format"`
+	Status       string           `json:"status"`
+	TotalCount   int              `json:"totalCount"`
+	ValidCount   int              `json:"validCount"`
+	InvalidCount int              `json:"invalidCount"`
+	Failure      string           `json:"failure,omitempty"`
+	Errors       []BatchErrorView `json:"errors,omitempty"`
+}
+
+type BatchErrorView struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+func NewBatchHandler(batchRepository repository.BatchRepository, paymentCreator importer.PaymentCreator) *BatchHandler {
+
+	return &BatchHandler{
+		batchRepository: batchRepository,
+		batchImporter:   importer.NewBatchImporter(batchRepository, paymentCreator),
+	}
+}
+
+func (bh *BatchHandler) Register(router *mux.Router) {
+	router.HandleFunc("/api/v1/batches", bh.GetBatches).Methods("GET")
+	router.HandleFunc("/api/v1/batches/uid/{uid}", bh.GetBatchByUid).Methods("GET")
+	router.HandleFunc("/api/v1/batches", bh.ImportBatch).Methods("POST")
+}
+
+func (bh *BatchHandler) GetBatches(w http.ResponseWriter, r *http.Request) {
+
+	batches, errorDB := bh.batchRepository.GetAll()
+
+	if errorDB != nil {
+		util.WriteError(w, http.StatusInternalServerError, errorDB.Error())
+		return
+	}
+
+	var results []BatchView
+
+	for index := range batches {
+		results = append(results, *newBatchView(&batches[index]))
+	}
+
+	util.WritePayload(w, http.StatusOK, results)
+}
+
+func (bh *BatchHandler) GetBatchByUid(w http.ResponseWriter, r *http.Request) {
+
+	uid := mux.Vars(r)["uid"]
+
+	batch, errorDB := bh.batchRepository.GetByUid(uid)
+
+	if errorDB != nil {
+
+		if strings.Contains(errorDB.Error(), "not found") {
+			util.WriteError(w, http.StatusNotFound, errorDB.Error())
+
+		} else {
+			util.WriteError(w, http.StatusInternalServerError, errorDB.Error())
+		}
+
+		return
+	}
+
+	util.WritePayload(w, http.StatusOK, newBatchView(batch))
+}
+
+func (bh *BatchHandler) ImportBatch(w http.ResponseWriter, r *http.Request) {
+
+	name := r.URL.Query().Get("name")
+	format := getFormat(r)
+
+	batch, errorImport := bh.batchImporter.Import(name, format, r.Body, auth.FromRequest(r))
+
+	if errorImport != nil {
+		util.WriteErrorFor(w, errorImport)
+		return
+	}
+
+	util.WritePayload(w, http.StatusCreated, newBatchView(batch))
+}
+
+//
+// private functions
+
+func getFormat(r *http.Request) string {
+
+	format := r.URL.Query().Get("format")
+
+	if format != "" {
+		return format
+	}
+
+	contentType := r.Header.Get("Content-Type")
+
+	if strings.Contains(contentType, "csv") {
+		return model.FORMAT_CSV
+	}
+
+	if strings.Contains(contentType, "xml") {
+		return model.FORMAT_PAIN001
+	}
+
+	return ""
+}
+
+func newBatchView(batch *model.Batch) *BatchView {
+
+	view := &BatchView{
+		Uid:          batch.Uid,
+		Name:         batch.Name,
+		Format:       batch.Format,
+		Status:       batch.Status,
+		TotalCount:   batch.TotalCount,
+		ValidCount:   batch.ValidCount,
+		InvalidCount: batch.InvalidCount,
+		Failure:      batch.Failure,
+	}
+
+	for _, batchError := range batch.Errors {
+		view.Errors = append(view.Errors, BatchErrorView{Line: batchError.Line, Message: batchError.Message})
+	}
+
+	return view
+}
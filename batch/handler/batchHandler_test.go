@@ -0,0 +1,450 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/batch/model"
+	paymentHandler "github.com/javierjmgits/go-payment-api/payment/handler"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	paymentRepository "github.com/javierjmgits/go-payment-api/payment/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+//
+// mock data
+
+const csvContent = `accountOrigin,accountTarget,amount,currency,date
+GB29 NWBK 6016 1331 9268 19,DE89 3704 0044 0532 0130 00,25,gbp,2026-01-15
+GB29 NWBK 6016 1331 9268 19,,10,,2026-01-15
+GB29 NWBK 6016 1331 9268 19,DE89 3704 0044 0532 0130 00,abc,,2026-01-15
+`
+
+const pain001Content = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03">
+  <CstmrCdtTrfInitn>
+    <PmtInf>
+      <ReqdExctnDt>2026-01-15</ReqdExctnDt>
+      <DbtrAcct><Id><IBAN>GB29NWBK60161331926819</IBAN></Id></DbtrAcct>
+      <CdtTrfTxInf>
+        <Amt><InstdAmt Ccy="USD">25.50</InstdAmt></Amt>
+        <CdtrAcct><Id><IBAN>DE89370400440532013000</IBAN></Id></CdtrAcct>
+      </CdtTrfTxInf>
+      <CdtTrfTxInf>
+        <Amt><InstdAmt Ccy="EUR">-1</InstdAmt></Amt>
+        <CdtrAcct><Id><IBAN>DE89370400440532013000</IBAN></Id></CdtrAcct>
+      </CdtTrfTxInf>
+    </PmtInf>
+  </CstmrCdtTrfInitn>
+</Document>
+`
+
+//
+// mocks
+
+type batchRepositoryImplMock struct {
+	mock.Mock
+}
+
+func (mock *batchRepositoryImplMock) GetAll() ([]model.Batch, error) {
+
+	args := mock.Mock.Called(nil)
+
+	results := args.Get(0)
+
+	if results != nil {
+		return results.([]model.Batch), nil
+	}
+
+	return nil, args.Get(1).(error)
+}
+
+func (mock *batchRepositoryImplMock) GetByUid(uid string) (*model.Batch, error) {
+
+	args := mock.Mock.Called(uid)
+
+	result := args.Get(0)
+
+	if result != nil {
+		return result.(*model.Batch), nil
+	}
+
+	return nil, args.Get(1).(error)
+}
+
+func (mock *batchRepositoryImplMock) GetByName(name string) (*model.Batch, error) {
+
+	args := mock.Mock.Called(name)
+
+	result := args.Get(0)
+
+	if result != nil {
+		return result.(*model.Batch), nil
+	}
+
+	return nil, nil
+}
+
+func (mock *batchRepositoryImplMock) Create(batch *model.Batch) (*model.Batch, error) {
+
+	mock.Mock.Called(batch)
+
+	return batch, nil
+}
+
+func (mock *batchRepositoryImplMock) Restart(batch *model.Batch) (bool, error) {
+
+	args := mock.Mock.Called(batch)
+
+	return args.Bool(0), nil
+}
+
+func (mock *batchRepositoryImplMock) Update(batch *model.Batch) (*model.Batch, error) {
+
+	mock.Mock.Called(batch)
+
+	return batch, nil
+}
+
+// paymentRepositoryMock knows no payment yet, for the idempotency keys of the batch payments
+type paymentRepositoryMock struct {
+	paymentRepository.PaymentRepository
+}
+
+func (mock *paymentRepositoryMock) GetByIdempotencyKey(tenant string, key string) (*paymentModel.Payment, error) {
+
+	return nil, nil
+}
+
+// feeHookMock charges a flat fee, to tell that the create hooks ran
+type feeHookMock struct{}
+
+func (hook *feeHookMock) BeforeCreate(payment *paymentModel.Payment, paymentCreate *paymentHandler.PaymentCreate) error {
+
+	payment.FeeAmount = 0.5
+
+	return nil
+}
+
+type creatorMock struct {
+	mock.Mock
+}
+
+func (mock *creatorMock) Create(payment *paymentModel.Payment) (*paymentModel.Payment, error) {
+
+	args := mock.Mock.Called(payment)
+
+	if args.Get(0) != nil {
+		return nil, args.Get(0).(error)
+	}
+
+	return payment, nil
+}
+
+//
+// tests
+
+func TestGetBatchByUidKoNotFound(t *testing.T) {
+
+	router, mockRepository, _ := setUp()
+	mockRepository.On("GetByUid", "unknown").Return(nil, errors.New("record not found"))
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/api/v1/batches/uid/unknown", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestGetBatchByUid(t *testing.T) {
+
+	router, mockRepository, _ := setUp()
+	expectedBatch := &model.Batch{Uid: "myUid", Name: "myBatch", Status: model.STATUS_IMPORTED_WITH_ERRORS,
+		Errors: []model.BatchError{{Line: 3, Message: "amount must be a positive number"}}}
+	mockRepository.On("GetByUid", "myUid").Return(expectedBatch, nil)
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/api/v1/batches/uid/myUid", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	batch := readBatchView(resp)
+
+	// verify
+
+	assert.Equal(t, expectedBatch.Name, batch.Name)
+	assert.Equal(t, expectedBatch.Status, batch.Status)
+	assert.Equal(t, []BatchErrorView{{Line: 3, Message: "amount must be a positive number"}}, batch.Errors)
+}
+
+func TestImportBatchKoMissingName(t *testing.T) {
+
+	router, mockRepository, _ := setUp()
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/batches?format=csv", strings.NewReader(csvContent))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestImportBatchKoUnknownFormat(t *testing.T) {
+
+	router, mockRepository, _ := setUp()
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/batches?name=myBatch&format=xls", strings.NewReader(csvContent))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestImportBatchKoAlreadyExists(t *testing.T) {
+
+	router, mockRepository, _ := setUp()
+	mockRepository.On("GetByName", "myBatch").Return(&model.Batch{Name: "myBatch", Status: model.STATUS_IMPORTED})
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/batches?name=myBatch&format=csv", strings.NewReader(csvContent))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestImportBatchCsv(t *testing.T) {
+
+	router, mockRepository, mockCreator := setUp()
+	mockRepository.On("GetByName", "myBatch").Return(nil)
+	mockRepository.On("Create", mock.Anything).Return(nil)
+	mockRepository.On("Update", mock.Anything).Return(nil)
+	mockCreator.On("Create", mock.MatchedBy(func(passed *paymentModel.Payment) bool {
+		return passed.Uid != "" && passed.Amount == 25 && passed.Currency == "GBP" && passed.AccountOrigin == "GB29NWBK60161331926819" &&
+			passed.BatchUid != nil && *passed.BatchUid != ""
+	})).Return(nil)
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/batches?name=myBatch", strings.NewReader(csvContent))
+	req.Header.Set("Content-Type", "text/csv")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	mockCreator.AssertExpectations(t)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	batch := readBatchView(resp)
+
+	// verify
+
+	assert.NotEmpty(t, batch.Uid)
+	assert.Equal(t, model.FORMAT_CSV, batch.Format)
+	assert.Equal(t, model.STATUS_IMPORTED_WITH_ERRORS, batch.Status)
+	assert.Equal(t, 3, batch.TotalCount)
+	assert.Equal(t, 1, batch.ValidCount)
+	assert.Equal(t, 2, batch.InvalidCount)
+	assert.Equal(t, []BatchErrorView{
+		{Line: 3, Message: "account target is mandatory"},
+		{Line: 4, Message: "invalid amount 'abc'"},
+	}, batch.Errors)
+}
+
+func TestImportBatchPain001(t *testing.T) {
+
+	router, mockRepository, mockCreator := setUp()
+	mockRepository.On("GetByName", "myBatch").Return(nil)
+	mockRepository.On("Create", mock.Anything).Return(nil)
+	mockRepository.On("Update", mock.Anything).Return(nil)
+	mockCreator.On("Create", mock.MatchedBy(func(passed *paymentModel.Payment) bool {
+		return passed.AccountOrigin == "GB29NWBK60161331926819" &&
+			passed.AccountTarget == "DE89370400440532013000" &&
+			passed.Amount == 25.5 &&
+			passed.Currency == "USD" &&
+			passed.FeeAmount == 0.5 &&
+			passed.CreatedBy == "alice" &&
+			passed.Tenant == "acme"
+	})).Return(nil)
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/batches?name=myBatch&format=pain.001", strings.NewReader(pain001Content))
+	req.Header.Set(auth.HEADER_USER, "alice")
+	req.Header.Set(auth.HEADER_TENANT, "acme")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	mockCreator.AssertExpectations(t)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	batch := readBatchView(resp)
+
+	// verify
+
+	assert.Equal(t, model.STATUS_IMPORTED_WITH_ERRORS, batch.Status)
+	assert.Equal(t, 1, batch.ValidCount)
+	assert.Equal(t, []BatchErrorView{{Line: 11, Message: "amount must be a positive number"}}, batch.Errors)
+}
+
+func TestImportBatchKoRefusedPayment(t *testing.T) {
+
+	router, mockRepository, mockCreator := setUp()
+	mockRepository.On("GetByName", "myBatch").Return(nil)
+	mockRepository.On("Create", mock.Anything).Return(nil)
+	mockRepository.On("Update", mock.MatchedBy(func(passed *model.Batch) bool {
+		return passed.Status == model.STATUS_REJECTED
+	})).Return(nil)
+	mockCreator.On("Create", mock.Anything).Return(&util.RejectedError{Message: "daily limit exceeded"})
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/batches?name=myBatch&format=pain.001", strings.NewReader(pain001Content))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	mockCreator.AssertExpectations(t)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	batch := readBatchView(resp)
+
+	// verify
+
+	assert.Equal(t, 0, batch.ValidCount)
+	assert.Equal(t, []BatchErrorView{
+		{Line: 7, Message: "daily limit exceeded"},
+		{Line: 11, Message: "amount must be a positive number"},
+	}, batch.Errors)
+}
+
+func TestImportBatchKoCreateFailure(t *testing.T) {
+
+	router, mockRepository, mockCreator := setUp()
+	mockRepository.On("GetByName", "myBatch").Return(nil)
+	mockRepository.On("Create", mock.Anything).Return(nil)
+	mockRepository.On("Update", mock.MatchedBy(func(passed *model.Batch) bool {
+		return passed.Status == model.STATUS_FAILED && passed.Failure == "connection refused"
+	})).Return(nil)
+	mockCreator.On("Create", mock.Anything).Return(errors.New("connection refused"))
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/batches?name=myBatch&format=pain.001", strings.NewReader(pain001Content))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+}
+
+func TestImportBatchRetriesFailed(t *testing.T) {
+
+	router, mockRepository, mockCreator := setUp()
+	failedBatch := &model.Batch{Uid: "failedUid", Name: "myBatch", Status: model.STATUS_FAILED, Failure: "connection refused"}
+	mockRepository.On("GetByName", "myBatch").Return(failedBatch)
+	mockRepository.On("Restart", failedBatch).Return(true)
+	mockRepository.On("Update", mock.Anything).Return(nil)
+	mockCreator.On("Create", mock.MatchedBy(func(passed *paymentModel.Payment) bool {
+		return *passed.BatchUid == "failedUid" && *passed.IdempotencyKey == "batch:failedUid:7"
+	})).Return(nil)
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/batches?name=myBatch&format=pain.001", strings.NewReader(pain001Content))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	mockCreator.AssertExpectations(t)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	batch := readBatchView(resp)
+
+	// verify
+
+	assert.Equal(t, "failedUid", batch.Uid)
+	assert.Equal(t, model.FORMAT_PAIN001, batch.Format)
+	assert.Equal(t, 1, batch.ValidCount)
+}
+
+func TestImportBatchKoFailedRestartedElsewhere(t *testing.T) {
+
+	router, mockRepository, _ := setUp()
+	failedBatch := &model.Batch{Uid: "failedUid", Name: "myBatch", Status: model.STATUS_FAILED}
+	mockRepository.On("GetByName", "myBatch").Return(failedBatch)
+	mockRepository.On("Restart", failedBatch).Return(false)
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/batches?name=myBatch&format=pain.001", strings.NewReader(pain001Content))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+//
+// private functions
+
+func setUp() (*mux.Router, *batchRepositoryImplMock, *creatorMock) {
+
+	var router = mux.NewRouter()
+	var mockRepository batchRepositoryImplMock
+	var mockCreator creatorMock
+
+	// the payments go through a real payment handler, whose creator is the mock
+	paymentCreator := paymentHandler.NewPaymentHandler(&paymentRepositoryMock{})
+	paymentCreator.AddCreateHook(&feeHookMock{})
+	paymentCreator.SetCreator(&mockCreator)
+
+	NewBatchHandler(&mockRepository, paymentCreator).Register(router)
+
+	return router, &mockRepository, &mockCreator
+}
+
+func readBatchView(resp *http.Response) *BatchView {
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	var batch BatchView
+
+	json.Unmarshal(body, &batch)
+
+	return &batch
+}
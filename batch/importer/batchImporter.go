@@ -0,0 +1,177 @@
+package importer
+
+import (
+	"fmt"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/batch/model"
+	"github.com/javierjmgits/go-payment-api/batch/parser"
+	"github.com/javierjmgits/go-payment-api/batch/repository"
+	"github.com/javierjmgits/go-payment-api/payment/handler"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/satori/go.uuid"
+	"io"
+	"log"
+	"strings"
+)
+
+type BatchImporter struct {
+	batchRepository repository.BatchRepository
+	paymentCreator  PaymentCreator
+}
+
+// PaymentCreator creates a payment the way the API does, e.g. the payment handler, so that the payments of a batch go
+// through the same hooks and limits as any other.
+type PaymentCreator interface {
+	Create(paymentCreate *handler.PaymentCreate, principal *auth.Principal) (*paymentModel.Payment, error)
+}
+
+func NewBatchImporter(batchRepository repository.BatchRepository, paymentCreator PaymentCreator) *BatchImporter {
+
+	return &BatchImporter{
+		batchRepository: batchRepository,
+		paymentCreator:  paymentCreator,
+	}
+}
+
+// Import creates the payments of the valid records one by one on behalf of the principal; a record that is invalid,
+// or whose payment a hook or a limit refuses, becomes an error of the batch. Any other error stops the import and
+// leaves the batch FAILED, and importing the file again under the same name takes the failed batch over. The payments
+// are created with the line as idempotency key, so the lines of the failed import are not paid twice.
+func (bi *BatchImporter) Import(name string, format string, reader io.Reader, principal *auth.Principal) (*model.Batch, error) {
+
+	name = strings.TrimSpace(name)
+
+	if name == "" {
+		return nil, &util.InputError{Message: "batch name is mandatory"}
+	}
+
+	recordParser, errorFormat := parser.NewParser(format)
+
+	if errorFormat != nil {
+		return nil, &util.InputError{Message: errorFormat.Error()}
+	}
+
+	existing, errorDB := bi.batchRepository.GetByName(name)
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	if existing != nil && existing.Status != model.STATUS_FAILED {
+		return nil, &util.ConflictError{Message: fmt.Sprintf("batch '%s' already exists", name)}
+	}
+
+	records, errorParse := recordParser.Parse(reader)
+
+	if errorParse != nil {
+		return nil, &util.InputError{Message: errorParse.Error()}
+	}
+
+	// the batch comes first so that a concurrent import of the same name fails before creating any payment
+	batch, errorBatch := bi.startBatch(existing, name, strings.ToLower(format), len(records))
+
+	if errorBatch != nil {
+		return nil, errorBatch
+	}
+
+	for _, record := range records {
+
+		errorRecord := bi.createPayment(batch, record, principal)
+
+		if errorRecord == nil {
+			batch.ValidCount++
+			continue
+		}
+
+		if !util.IsRefusal(errorRecord) {
+			return nil, bi.fail(batch, errorRecord)
+		}
+
+		batch.Errors = append(batch.Errors, model.BatchError{Line: record.Line, Message: errorRecord.Error()})
+	}
+
+	batch.InvalidCount = len(batch.Errors)
+	batch.Status = newBatchStatus(batch)
+
+	return bi.batchRepository.Update(batch)
+}
+
+//
+// private functions
+
+func (bi *BatchImporter) startBatch(failed *model.Batch, name string, format string, totalCount int) (*model.Batch, error) {
+
+	if failed != nil {
+
+		failed.Format = format
+		failed.TotalCount = totalCount
+
+		restarted, errorDB := bi.batchRepository.Restart(failed)
+
+		if errorDB != nil {
+			return nil, errorDB
+		}
+
+		if !restarted {
+			return nil, &util.ConflictError{Message: fmt.Sprintf("batch '%s' already exists", name)}
+		}
+
+		return failed, nil
+	}
+
+	uuidResult, errorUuid := uuid.NewV4()
+
+	if errorUuid != nil {
+		return nil, errorUuid
+	}
+
+	return bi.batchRepository.Create(&model.Batch{
+		Uid:        uuidResult.String(),
+		Name:       name,
+		Format:     format,
+		Status:     model.STATUS_IMPORTING,
+		TotalCount: totalCount,
+	})
+}
+
+// fail marks the batch FAILED with the error that stopped its import, which is returned
+func (bi *BatchImporter) fail(batch *model.Batch, cause error) error {
+
+	batch.InvalidCount = len(batch.Errors)
+	batch.Status = model.STATUS_FAILED
+	batch.Failure = cause.Error()
+
+	if _, errorDB := bi.batchRepository.Update(batch); errorDB != nil {
+		log.Printf("Error marking batch %s as failed: %v\n", batch.Uid, errorDB)
+	}
+
+	return cause
+}
+
+func (bi *BatchImporter) createPayment(batch *model.Batch, record parser.Record, principal *auth.Principal) error {
+
+	if record.Error != nil {
+		return &util.InputError{Message: record.Error.Error()}
+	}
+
+	record.PaymentCreate.BatchUid = batch.Uid
+	record.PaymentCreate.IdempotencyKey = fmt.Sprintf("batch:%s:%d", batch.Uid, record.Line)
+
+	_, errorCreate := bi.paymentCreator.Create(record.PaymentCreate, principal)
+
+	return errorCreate
+}
+
+func newBatchStatus(batch *model.Batch) string {
+
+	if batch.ValidCount == 0 {
+		return model.STATUS_REJECTED
+	}
+
+	if batch.InvalidCount > 0 {
+		return model.STATUS_IMPORTED_WITH_ERRORS
+	}
+
+	return model.STATUS_IMPORTED
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	FORMAT_CSV     = "csv"
+	FORMAT_PAIN001 = "pain.001"
+
+	STATUS_IMPORTING            = "IMPORTING"
+	STATUS_IMPORTED             = "IMPORTED"
+	STATUS_IMPORTED_WITH_ERRORS = "IMPORTED_WITH_ERRORS"
+	STATUS_REJECTED             = "REJECTED"
+	STATUS_FAILED               = "FAILED"
+)
+
+type Batch struct {
+	gorm.Model
+	Uid          string       `gorm:"unique;not null"`
+	Name         string       `gorm:"unique;not null"`
+	Format       string       `gorm:"not null"`
+	Status       string       `gorm:"not null"`
+	TotalCount   int          `gorm:"not null"`
+	ValidCount   int          `gorm:"not null"`
+	InvalidCount int          `gorm:"not null"`
+	Failure      string       `gorm:"type:text"`
+	Errors       []BatchError `gorm:"foreignkey:BatchID"`
+}
+
+type BatchError struct {
+	gorm.Model
+	BatchID uint   `gorm:"not null;index"`
+	Line    int    `gorm:"not null"`
+	Message string `gorm:"not null"`
+}
+
+func SetUp(db *gorm.DB) *gorm.DB {
+
+	db.AutoMigrate(&Batch{}, &BatchError{})
+
+	return db
+}
@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"fmt"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/payment/handler"
+	"io"
+	"strconv"
+)
+
+const (
+	CSV_COLUMN_ACCOUNT_ORIGIN = "accountOrigin"
+	CSV_COLUMN_ACCOUNT_TARGET = "accountTarget"
+	CSV_COLUMN_AMOUNT         = "amount"
+	CSV_COLUMN_DATE           = "date"
+
+	// optional columns
+	CSV_COLUMN_CURRENCY    = "currency"
+	CSV_COLUMN_REFERENCE   = "reference"
+	CSV_COLUMN_DESCRIPTION = "description"
+)
+
+type csvParser struct {
+}
+
+func (cp *csvParser) Parse(reader io.Reader) ([]Record, error) {
+
+	var records []Record
+
+	errorRead := util.ReadCSV(reader, []string{CSV_COLUMN_ACCOUNT_ORIGIN, CSV_COLUMN_ACCOUNT_TARGET, CSV_COLUMN_AMOUNT, CSV_COLUMN_DATE}, func(row *util.CSVRow) {
+
+		if row.Error != nil {
+			records = append(records, Record{Line: row.Line, Error: row.Error})
+			return
+		}
+
+		paymentCreate, errorRecord := newPaymentCreateFromCSV(row)
+
+		records = append(records, Record{Line: row.Line, PaymentCreate: paymentCreate, Error: errorRecord})
+	})
+
+	if errorRead != nil {
+		return nil, errorRead
+	}
+
+	return records, nil
+}
+
+//
+// private functions
+
+func newPaymentCreateFromCSV(row *util.CSVRow) (*handler.PaymentCreate, error) {
+
+	amount, errorAmount := strconv.ParseFloat(row.Value(CSV_COLUMN_AMOUNT), 64)
+
+	if errorAmount != nil {
+		return nil, fmt.Errorf("invalid amount '%s'", row.Value(CSV_COLUMN_AMOUNT))
+	}
+
+	date, errorDate := util.ParseDate(row.Value(CSV_COLUMN_DATE))
+
+	if errorDate != nil {
+		return nil, errorDate
+	}
+
+	return &handler.PaymentCreate{
+		AccountOrigin: row.Value(CSV_COLUMN_ACCOUNT_ORIGIN),
+		AccountTarget: row.Value(CSV_COLUMN_ACCOUNT_TARGET),
+		Amount:        amount,
+		Currency:      row.Value(CSV_COLUMN_CURRENCY),
+		Date:          date,
+		Reference:     row.Value(CSV_COLUMN_REFERENCE),
+		Description:   row.Value(CSV_COLUMN_DESCRIPTION),
+	}, nil
+}
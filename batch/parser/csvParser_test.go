@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+)
+
+//
+// mocks
+
+type failingReader struct {
+}
+
+func (fr *failingReader) Read(p []byte) (int, error) {
+
+	return 0, errors.New("connection reset")
+}
+
+//
+// tests
+
+func TestCsvParse(t *testing.T) {
+
+	records, errorParse := (&csvParser{}).Parse(strings.NewReader("accountOrigin,accountTarget,amount,date\n" +
+		"GB29NWBK60161331926819,DE89370400440532013000,25,2026-01-15\n" +
+		"GB29NWBK60161331926819,DE89370400440532013000,abc,2026-01-15\n"))
+
+	// verify
+
+	assert.Nil(t, errorParse)
+	assert.Len(t, records, 2)
+	assert.Equal(t, 2, records[0].Line)
+	assert.Nil(t, records[0].Error)
+	assert.Equal(t, 25.0, records[0].PaymentCreate.Amount)
+	assert.Equal(t, 3, records[1].Line)
+	assert.EqualError(t, records[1].Error, "invalid amount 'abc'")
+}
+
+func TestCsvParseKoQuoteInFirstField(t *testing.T) {
+
+	records, errorParse := (&csvParser{}).Parse(strings.NewReader("accountOrigin,accountTarget,amount,date\n" +
+		"\"a\"b,c,1,2026-01-01\n" +
+		"GB29NWBK60161331926819,DE89370400440532013000,25,2026-01-15\n"))
+
+	// verify
+
+	assert.Nil(t, errorParse)
+	assert.Len(t, records, 2)
+	assert.Equal(t, 2, records[0].Line)
+	assert.NotNil(t, records[0].Error)
+	assert.Equal(t, 3, records[1].Line)
+	assert.Nil(t, records[1].Error)
+}
+
+func TestCsvParseKoReadFailure(t *testing.T) {
+
+	reader := io.MultiReader(strings.NewReader("accountOrigin,accountTarget,amount,date\n"+
+		"GB29NWBK60161331926819,DE89370400440532013000,25,2026-01-15\n"), &failingReader{})
+
+	records, errorParse := (&csvParser{}).Parse(reader)
+
+	// verify
+
+	assert.EqualError(t, errorParse, "connection reset")
+	assert.Nil(t, records)
+}
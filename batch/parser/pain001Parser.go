@@ -0,0 +1,155 @@
+package parser
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/payment/handler"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type pain001Parser struct {
+}
+
+type pain001Account struct {
+	IBAN  string `xml:"Id>IBAN"`
+	Other string `xml:"Id>Othr>Id"`
+}
+
+type pain001ExecutionDate struct {
+	Value string `xml:",chardata"`
+	Date  string `xml:"Dt"`
+}
+
+type pain001Amount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+type pain001Transaction struct {
+	EndToEndId string         `xml:"PmtId>EndToEndId"`
+	Amount     pain001Amount  `xml:"Amt>InstdAmt"`
+	Account    pain001Account `xml:"CdtrAcct"`
+	Remittance []string       `xml:"RmtInf>Ustrd"`
+}
+
+func (pp *pain001Parser) Parse(reader io.Reader) ([]Record, error) {
+
+	decoder := xml.NewDecoder(reader)
+
+	var records []Record
+	var debtorAccount pain001Account
+	var executionDate pain001ExecutionDate
+	documentFound := false
+
+	for {
+
+		token, errorToken := decoder.Token()
+
+		if errorToken == io.EOF {
+			break
+		}
+
+		if errorToken != nil {
+			return nil, fmt.Errorf("invalid pain.001 document: %v", errorToken)
+		}
+
+		start, isStart := token.(xml.StartElement)
+
+		if !isStart {
+			continue
+		}
+
+		switch start.Name.Local {
+
+		case "CstmrCdtTrfInitn":
+			documentFound = true
+
+		case "PmtInf":
+			debtorAccount = pain001Account{}
+			executionDate = pain001ExecutionDate{}
+
+		case "ReqdExctnDt":
+			if errorDecode := decoder.DecodeElement(&executionDate, &start); errorDecode != nil {
+				return nil, fmt.Errorf("invalid pain.001 document: %v", errorDecode)
+			}
+
+		case "DbtrAcct":
+			if errorDecode := decoder.DecodeElement(&debtorAccount, &start); errorDecode != nil {
+				return nil, fmt.Errorf("invalid pain.001 document: %v", errorDecode)
+			}
+
+		case "CdtTrfTxInf":
+			line, _ := decoder.InputPos()
+
+			var transaction pain001Transaction
+
+			if errorDecode := decoder.DecodeElement(&transaction, &start); errorDecode != nil {
+				return nil, fmt.Errorf("invalid pain.001 document: %v", errorDecode)
+			}
+
+			paymentCreate, errorRecord := newPaymentCreateFromPain001(debtorAccount, executionDate, transaction)
+
+			records = append(records, Record{Line: line, PaymentCreate: paymentCreate, Error: errorRecord})
+		}
+	}
+
+	if !documentFound {
+		return nil, errors.New("invalid pain.001 document: CstmrCdtTrfInitn not found")
+	}
+
+	return records, nil
+}
+
+//
+// private functions
+
+func newPaymentCreateFromPain001(debtorAccount pain001Account, executionDate pain001ExecutionDate, transaction pain001Transaction) (*handler.PaymentCreate, error) {
+
+	amount, errorAmount := strconv.ParseFloat(strings.TrimSpace(transaction.Amount.Value), 64)
+
+	if errorAmount != nil {
+		return nil, fmt.Errorf("invalid amount '%s'", transaction.Amount.Value)
+	}
+
+	dateValue := executionDate.Date
+
+	if dateValue == "" {
+		dateValue = executionDate.Value
+	}
+
+	date, errorDate := util.ParseDate(dateValue)
+
+	if errorDate != nil {
+		return nil, errorDate
+	}
+
+	reference := strings.TrimSpace(transaction.EndToEndId)
+
+	// NOTPROVIDED is the placeholder ISO 20022 asks for when there is no end-to-end reference
+	if reference == "NOTPROVIDED" {
+		reference = ""
+	}
+
+	return &handler.PaymentCreate{
+		AccountOrigin: debtorAccount.identifier(),
+		AccountTarget: transaction.Account.identifier(),
+		Amount:        amount,
+		Currency:      strings.TrimSpace(transaction.Amount.Currency),
+		Date:          date,
+		Reference:     reference,
+		Description:   strings.TrimSpace(strings.Join(transaction.Remittance, " ")),
+	}, nil
+}
+
+func (pa pain001Account) identifier() string {
+
+	if pa.IBAN != "" {
+		return strings.TrimSpace(pa.IBAN)
+	}
+
+	return strings.TrimSpace(pa.Other)
+}
@@ -0,0 +1,34 @@
+package parser
+
+import (
+	"fmt"
+	"github.com/javierjmgits/go-payment-api/batch/model"
+	"github.com/javierjmgits/go-payment-api/payment/handler"
+	"io"
+	"strings"
+)
+
+type Record struct {
+	Line          int
+	PaymentCreate *handler.PaymentCreate
+	Error         error
+}
+
+type Parser interface {
+	Parse(reader io.Reader) ([]Record, error)
+}
+
+func NewParser(format string) (Parser, error) {
+
+	switch strings.ToLower(format) {
+
+	case model.FORMAT_CSV:
+		return &csvParser{}, nil
+
+	case model.FORMAT_PAIN001:
+		return &pain001Parser{}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported format '%s'", format)
+	}
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"github.com/javierjmgits/go-payment-api/batch/model"
+	"github.com/jinzhu/gorm"
+)
+
+type BatchRepository interface {
+	GetAll() ([]model.Batch, error)
+	GetByUid(uid string) (*model.Batch, error)
+	GetByName(name string) (*model.Batch, error)
+	Create(batch *model.Batch) (*model.Batch, error)
+	Restart(batch *model.Batch) (bool, error)
+	Update(batch *model.Batch) (*model.Batch, error)
+}
+
+type batchRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewBatchRepositoryImpl(db *gorm.DB) BatchRepository {
+	return &batchRepositoryImpl{
+		db: db,
+	}
+}
+
+func (bri *batchRepositoryImpl) GetAll() ([]model.Batch, error) {
+
+	var batches []model.Batch
+	errorDB := bri.db.Find(&batches).Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return batches, nil
+}
+
+func (bri *batchRepositoryImpl) GetByUid(uid string) (*model.Batch, error) {
+
+	var batch model.Batch
+	errorFind := bri.db.Preload("Errors").Where("uid = ?", uid).First(&batch).Error
+
+	if errorFind != nil {
+		return nil, errorFind
+	}
+
+	return &batch, nil
+}
+
+// GetByName returns the batch of that name, or nil when there is none
+func (bri *batchRepositoryImpl) GetByName(name string) (*model.Batch, error) {
+
+	var batch model.Batch
+	errorFind := bri.db.Where("name = ?", name).First(&batch).Error
+
+	if gorm.IsRecordNotFoundError(errorFind) {
+		return nil, nil
+	}
+
+	if errorFind != nil {
+		return nil, errorFind
+	}
+
+	return &batch, nil
+}
+
+func (bri *batchRepositoryImpl) Create(batch *model.Batch) (*model.Batch, error) {
+
+	errorDB := bri.db.Create(batch).Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return batch, nil
+}
+
+// Restart takes a FAILED batch back to IMPORTING, with the format and total count set on it and its errors dropped; it
+// is false when the batch is no longer FAILED, e.g. as another import restarted it first.
+func (bri *batchRepositoryImpl) Restart(batch *model.Batch) (bool, error) {
+
+	tx := bri.db.Begin()
+
+	result := tx.Model(&model.Batch{}).Where("id = ? AND status = ?", batch.ID, model.STATUS_FAILED).Updates(map[string]interface{}{
+		"status":        model.STATUS_IMPORTING,
+		"format":        batch.Format,
+		"total_count":   batch.TotalCount,
+		"valid_count":   0,
+		"invalid_count": 0,
+		"failure":       "",
+	})
+
+	if result.Error != nil || result.RowsAffected == 0 {
+		tx.Rollback()
+		return false, result.Error
+	}
+
+	if errorDB := tx.Unscoped().Where("batch_id = ?", batch.ID).Delete(&model.BatchError{}).Error; errorDB != nil {
+		tx.Rollback()
+		return false, errorDB
+	}
+
+	if errorDB := tx.Commit().Error; errorDB != nil {
+		return false, errorDB
+	}
+
+	batch.Status = model.STATUS_IMPORTING
+	batch.ValidCount = 0
+	batch.InvalidCount = 0
+	batch.Failure = ""
+	batch.Errors = nil
+
+	return true, nil
+}
+
+// Update saves the counts, status and errors of a batch once its payments are created
+func (bri *batchRepositoryImpl) Update(batch *model.Batch) (*model.Batch, error) {
+
+	errorDB := bri.db.Save(batch).Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return batch, nil
+}
@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/account/identifier"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/beneficiary/model"
+	"github.com/javierjmgits/go-payment-api/beneficiary/service"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	MAX_NAME_LENGTH     = 140
+	MAX_NICKNAME_LENGTH = 35
+)
+
+type BeneficiaryHandler struct {
+	beneficiaryService *service.BeneficiaryService
+}
+
+type BeneficiaryView struct {
+	Uid            string    `json:"uid"`
+	Name           string    `json:"name"`
+	Account        string    `json:"account"`
+	Nickname       string    `json:"nickname,omitempty"`
+	Status         string    `json:"status"`
+	UsableFrom     time.Time `json:"usableFrom"`
+	PayeeCheck     string    `json:"payeeCheck,omitempty"`
+	PayeeCheckName string    `json:"payeeCheckName,omitempty"`
+}
+
+// BeneficiaryCreate saves a payee; ConfirmMismatch keeps a name the bank of the account did not confirm.
+type BeneficiaryCreate struct {
+	Name            string `json:"name"`
+	Account         string `json:"account"`
+	Nickname        string `json:"nickname"`
+	ConfirmMismatch bool   `json:"confirmMismatch"`
+}
+
+type BeneficiaryUpdate struct {
+	Nickname *string `json:"nickname"`
+	Status   *string `json:"status"`
+}
+
+func NewBeneficiaryHandler(beneficiaryService *service.BeneficiaryService) *BeneficiaryHandler {
+
+	return &BeneficiaryHandler{
+		beneficiaryService: beneficiaryService,
+	}
+}
+
+func (bh *BeneficiaryHandler) Register(router *mux.Router) {
+	router.HandleFunc("/api/v1/beneficiaries", bh.GetBeneficiaries).Methods("GET")
+	router.HandleFunc("/api/v1/beneficiaries/uid/{uid}", bh.GetBeneficiaryByUid).Methods("GET")
+	router.HandleFunc("/api/v1/beneficiaries", bh.CreateBeneficiary).Methods("POST")
+	router.HandleFunc("/api/v1/beneficiaries/uid/{uid}", bh.UpdateBeneficiaryByUid).Methods("PATCH")
+	router.HandleFunc("/api/v1/beneficiaries/uid/{uid}", bh.DeleteBeneficiaryByUid).Methods("DELETE")
+}
+
+func (bh *BeneficiaryHandler) GetBeneficiaries(w http.ResponseWriter, r *http.Request) {
+
+	beneficiaries, errorGet := bh.beneficiaryService.GetAll(auth.FromRequest(r))
+
+	if errorGet != nil {
+		util.WriteErrorFor(w, errorGet)
+		return
+	}
+
+	now := time.Now()
+	results := []BeneficiaryView{}
+
+	for index := range beneficiaries {
+		results = append(results, *newBeneficiaryView(&beneficiaries[index], now))
+	}
+
+	util.WritePayload(w, http.StatusOK, results)
+}
+
+func (bh *BeneficiaryHandler) GetBeneficiaryByUid(w http.ResponseWriter, r *http.Request) {
+
+	beneficiary, errorGet := bh.beneficiaryService.Get(mux.Vars(r)["uid"], auth.FromRequest(r))
+
+	if errorGet != nil {
+		util.WriteErrorFor(w, errorGet)
+		return
+	}
+
+	util.WritePayload(w, http.StatusOK, newBeneficiaryView(beneficiary, time.Now()))
+}
+
+func (bh *BeneficiaryHandler) CreateBeneficiary(w http.ResponseWriter, r *http.Request) {
+
+	var beneficiaryCreate BeneficiaryCreate
+
+	errorJson := json.NewDecoder(r.Body).Decode(&beneficiaryCreate)
+
+	if errorJson != nil {
+		util.WriteError(w, http.StatusBadRequest, errorJson.Error())
+		return
+	}
+
+	if errorValidation := validateBeneficiaryCreate(&beneficiaryCreate); errorValidation != nil {
+		util.WriteError(w, http.StatusBadRequest, errorValidation.Error())
+		return
+	}
+
+	beneficiary := &model.Beneficiary{
+		Name:     beneficiaryCreate.Name,
+		Account:  beneficiaryCreate.Account,
+		Nickname: beneficiaryCreate.Nickname,
+	}
+
+	beneficiary, errorCreate := bh.beneficiaryService.Create(r.Context(), beneficiary, auth.FromRequest(r), beneficiaryCreate.ConfirmMismatch)
+
+	if errorCreate != nil {
+		util.WriteErrorFor(w, errorCreate)
+		return
+	}
+
+	util.WritePayload(w, http.StatusCreated, newBeneficiaryView(beneficiary, time.Now()))
+}
+
+// UpdateBeneficiaryByUid changes the nickname or the status; the name and the account are kept, save a new beneficiary instead.
+func (bh *BeneficiaryHandler) UpdateBeneficiaryByUid(w http.ResponseWriter, r *http.Request) {
+
+	var beneficiaryUpdate BeneficiaryUpdate
+
+	errorJson := json.NewDecoder(r.Body).Decode(&beneficiaryUpdate)
+
+	if errorJson != nil {
+		util.WriteError(w, http.StatusBadRequest, errorJson.Error())
+		return
+	}
+
+	if beneficiaryUpdate.Nickname != nil && utf8.RuneCountInString(*beneficiaryUpdate.Nickname) > MAX_NICKNAME_LENGTH {
+		util.WriteError(w, http.StatusBadRequest, fmt.Sprintf("nickname can not be longer than %d characters", MAX_NICKNAME_LENGTH))
+		return
+	}
+
+	if beneficiaryUpdate.Status != nil {
+		status := strings.ToUpper(*beneficiaryUpdate.Status)
+		beneficiaryUpdate.Status = &status
+	}
+
+	beneficiary, errorUpdate := bh.beneficiaryService.Update(mux.Vars(r)["uid"], auth.FromRequest(r), beneficiaryUpdate.Nickname, beneficiaryUpdate.Status)
+
+	if errorUpdate != nil {
+		util.WriteErrorFor(w, errorUpdate)
+		return
+	}
+
+	util.WritePayload(w, http.StatusOK, newBeneficiaryView(beneficiary, time.Now()))
+}
+
+func (bh *BeneficiaryHandler) DeleteBeneficiaryByUid(w http.ResponseWriter, r *http.Request) {
+
+	if errorDelete := bh.beneficiaryService.Delete(mux.Vars(r)["uid"], auth.FromRequest(r)); errorDelete != nil {
+		util.WriteErrorFor(w, errorDelete)
+		return
+	}
+
+	util.WritePayload(w, http.StatusNoContent, map[string]string{})
+}
+
+//
+// private functions
+
+func validateBeneficiaryCreate(beneficiaryCreate *BeneficiaryCreate) error {
+
+	beneficiaryCreate.Name = strings.TrimSpace(beneficiaryCreate.Name)
+
+	if beneficiaryCreate.Name == "" {
+		return errors.New("name is mandatory")
+	}
+
+	if utf8.RuneCountInString(beneficiaryCreate.Name) > MAX_NAME_LENGTH {
+		return fmt.Errorf("name can not be longer than %d characters", MAX_NAME_LENGTH)
+	}
+
+	if utf8.RuneCountInString(beneficiaryCreate.Nickname) > MAX_NICKNAME_LENGTH {
+		return fmt.Errorf("nickname can not be longer than %d characters", MAX_NICKNAME_LENGTH)
+	}
+
+	if beneficiaryCreate.Account == "" {
+		return errors.New("account is mandatory")
+	}
+
+	account, errorAccount := identifier.Normalize(beneficiaryCreate.Account)
+
+	if errorAccount != nil {
+		return errors.New("account " + errorAccount.Error())
+	}
+
+	beneficiaryCreate.Account = account
+
+	return nil
+}
+
+func newBeneficiaryView(beneficiary *model.Beneficiary, now time.Time) *BeneficiaryView {
+
+	status := beneficiary.Status
+
+	if status == model.STATUS_ACTIVE && beneficiary.IsCoolingOff(now) {
+		status = model.STATUS_COOLING_OFF
+	}
+
+	return &BeneficiaryView{
+		Uid:            beneficiary.Uid,
+		Name:           beneficiary.Name,
+		Account:        identifier.Format(beneficiary.Account),
+		Nickname:       beneficiary.Nickname,
+		Status:         status,
+		UsableFrom:     beneficiary.UsableFrom,
+		PayeeCheck:     beneficiary.PayeeCheck,
+		PayeeCheckName: beneficiary.PayeeCheckName,
+	}
+}
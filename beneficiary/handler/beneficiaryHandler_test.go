@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	"github.com/javierjmgits/go-payment-api/beneficiary/model"
+	"github.com/javierjmgits/go-payment-api/beneficiary/payee"
+	"github.com/javierjmgits/go-payment-api/beneficiary/service"
+	paymentHandler "github.com/javierjmgits/go-payment-api/payment/handler"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+//
+// mocks
+
+type beneficiaryRepositoryImplMock struct {
+	beneficiaries []*model.Beneficiary
+}
+
+func (mock *beneficiaryRepositoryImplMock) GetAll(tenant string, owner string) ([]model.Beneficiary, error) {
+
+	var results []model.Beneficiary
+
+	for _, beneficiary := range mock.beneficiaries {
+
+		if beneficiary.Tenant == tenant && beneficiary.Owner == owner {
+			results = append(results, *beneficiary)
+		}
+	}
+
+	return results, nil
+}
+
+func (mock *beneficiaryRepositoryImplMock) GetByUid(uid string) (*model.Beneficiary, error) {
+
+	for _, beneficiary := range mock.beneficiaries {
+
+		if beneficiary.Uid == uid {
+			copied := *beneficiary
+			return &copied, nil
+		}
+	}
+
+	return nil, errors.New("record not found")
+}
+
+func (mock *beneficiaryRepositoryImplMock) GetByAccount(tenant string, owner string, account string) (*model.Beneficiary, error) {
+
+	for _, beneficiary := range mock.beneficiaries {
+
+		if beneficiary.Tenant == tenant && beneficiary.Owner == owner && beneficiary.Account == account {
+			return beneficiary, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (mock *beneficiaryRepositoryImplMock) Create(beneficiary *model.Beneficiary) (*model.Beneficiary, error) {
+
+	mock.beneficiaries = append(mock.beneficiaries, beneficiary)
+
+	return beneficiary, nil
+}
+
+func (mock *beneficiaryRepositoryImplMock) Update(beneficiary *model.Beneficiary) (*model.Beneficiary, error) {
+
+	for index := range mock.beneficiaries {
+
+		if mock.beneficiaries[index].Uid == beneficiary.Uid {
+			mock.beneficiaries[index] = beneficiary
+		}
+	}
+
+	return beneficiary, nil
+}
+
+func (mock *beneficiaryRepositoryImplMock) Delete(beneficiary *model.Beneficiary) error {
+	return nil
+}
+
+//
+// tests
+
+func TestCreateBeneficiary(t *testing.T) {
+
+	router, _ := setUp()
+
+	resp := sendBeneficiary(router, "POST", "", `{"name": "jane doe", "account": "GB29 NWBK 6016 1331 9268 19", "nickname": "Jane"}`, "alice")
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	beneficiary := readBeneficiary(resp)
+
+	// verify
+
+	assert.NotEmpty(t, beneficiary.Uid)
+	assert.Equal(t, "GB29 NWBK 6016 1331 9268 19", beneficiary.Account)
+	assert.Equal(t, model.STATUS_COOLING_OFF, beneficiary.Status)
+	assert.Equal(t, payee.RESULT_MATCH, beneficiary.PayeeCheck)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), beneficiary.UsableFrom, 2*time.Second)
+}
+
+func TestCreateBeneficiaryKoNameMismatch(t *testing.T) {
+
+	router, mockRepository := setUp()
+
+	resp := sendBeneficiary(router, "POST", "", `{"name": "Jane Do", "account": "GB29 NWBK 6016 1331 9268 19"}`, "alice")
+
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	assert.Contains(t, string(body), payee.RESULT_CLOSE_MATCH)
+	assert.Contains(t, string(body), "Jane Doe")
+	assert.Len(t, mockRepository.beneficiaries, 1)
+
+	// the owner can keep the name anyway
+	resp = sendBeneficiary(router, "POST", "", `{"name": "Jane Do", "account": "GB29 NWBK 6016 1331 9268 19", "confirmMismatch": true}`, "alice")
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "Jane Doe", readBeneficiary(resp).PayeeCheckName)
+}
+
+func TestCreateBeneficiaryKoDuplicate(t *testing.T) {
+
+	router, _ := setUp()
+
+	resp := sendBeneficiary(router, "POST", "", `{"name": "Sam Roe", "account": "DE89370400440532013000"}`, "bob")
+
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestCreateBeneficiaryKoMissingOwner(t *testing.T) {
+
+	router, _ := setUp()
+
+	resp := sendBeneficiary(router, "POST", "", `{"name": "Sam Roe", "account": "DE89370400440532013000"}`, "")
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestGetBeneficiaryKoOtherOwner(t *testing.T) {
+
+	router, _ := setUp()
+
+	resp := sendBeneficiary(router, "GET", "/uid/payee-1", "", "alice")
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestUpdateBeneficiary(t *testing.T) {
+
+	router, _ := setUp()
+
+	resp := sendBeneficiary(router, "PATCH", "/uid/payee-1", `{"nickname": "Sam", "status": "blocked"}`, "bob")
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	beneficiary := readBeneficiary(resp)
+
+	assert.Equal(t, "Sam", beneficiary.Nickname)
+	assert.Equal(t, model.STATUS_BLOCKED, beneficiary.Status)
+
+	// unblocking starts a new cooling-off
+	resp = sendBeneficiary(router, "PATCH", "/uid/payee-1", `{"status": "ACTIVE"}`, "bob")
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, model.STATUS_COOLING_OFF, readBeneficiary(resp).Status)
+}
+
+func TestResolveTarget(t *testing.T) {
+
+	beneficiaryService, mockRepository := newService()
+	bob := &auth.Principal{User: "bob", Tenant: "acme"}
+
+	paymentCreate := &paymentHandler.PaymentCreate{BeneficiaryUid: "payee-1"}
+
+	assert.Nil(t, beneficiaryService.ResolveTarget(paymentCreate, bob))
+	assert.Equal(t, "DE89370400440532013000", paymentCreate.AccountTarget)
+
+	// someone else's beneficiary
+	errorResolve := beneficiaryService.ResolveTarget(&paymentHandler.PaymentCreate{BeneficiaryUid: "payee-1"}, &auth.Principal{User: "alice", Tenant: "acme"})
+
+	assert.Equal(t, "unknown beneficiary 'payee-1'", errorResolve.Error())
+
+	mockRepository.beneficiaries[0].UsableFrom = time.Now().Add(time.Hour)
+
+	assert.NotNil(t, beneficiaryService.ResolveTarget(&paymentHandler.PaymentCreate{BeneficiaryUid: "payee-1"}, bob))
+
+	mockRepository.beneficiaries[0].UsableFrom = time.Now().Add(-time.Hour)
+	mockRepository.beneficiaries[0].Status = model.STATUS_BLOCKED
+
+	assert.NotNil(t, beneficiaryService.ResolveTarget(&paymentHandler.PaymentCreate{BeneficiaryUid: "payee-1"}, bob))
+}
+
+//
+// private functions
+
+func newService() (*service.BeneficiaryService, *beneficiaryRepositoryImplMock) {
+
+	mockRepository := &beneficiaryRepositoryImplMock{
+		beneficiaries: []*model.Beneficiary{{
+			Uid:        "payee-1",
+			Tenant:     "acme",
+			Owner:      "bob",
+			Name:       "Sam Roe",
+			Account:    "DE89370400440532013000",
+			Status:     model.STATUS_ACTIVE,
+			UsableFrom: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		}},
+	}
+
+	checker, _ := payee.NewFakeChecker(map[string]string{"GB29 NWBK 6016 1331 9268 19": "Jane Doe"})
+
+	return service.NewBeneficiaryService(mockRepository, checker, 24*time.Hour), mockRepository
+}
+
+func setUp() (*mux.Router, *beneficiaryRepositoryImplMock) {
+
+	var router = mux.NewRouter()
+
+	beneficiaryService, mockRepository := newService()
+
+	NewBeneficiaryHandler(beneficiaryService).Register(router)
+
+	return router, mockRepository
+}
+
+func sendBeneficiary(router *mux.Router, method string, path string, body string, user string) *http.Response {
+
+	req := httptest.NewRequest(method, "http://localhost:8080/api/v1/beneficiaries"+path, strings.NewReader(body))
+	req.Header.Set(auth.HEADER_USER, user)
+	req.Header.Set(auth.HEADER_TENANT, "acme")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	return w.Result()
+}
+
+func readBeneficiary(resp *http.Response) *BeneficiaryView {
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	var beneficiary BeneficiaryView
+
+	json.Unmarshal(body, &beneficiary)
+
+	return &beneficiary
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+const (
+	STATUS_ACTIVE  = "ACTIVE"
+	STATUS_BLOCKED = "BLOCKED"
+
+	// shown for active beneficiaries that cannot be paid yet, never stored
+	STATUS_COOLING_OFF = "COOLING_OFF"
+)
+
+// Beneficiary is a payee an owner saved to pay by reference; it can only be paid once its cooling-off is over.
+type Beneficiary struct {
+	gorm.Model
+	Uid            string    `gorm:"unique;not null"`
+	Tenant         string    `gorm:"not null;default:'';index:idx_beneficiary_owner"`
+	Owner          string    `gorm:"not null;index:idx_beneficiary_owner"`
+	Name           string    `gorm:"size:140;not null"`
+	Account        string    `gorm:"not null"`
+	Nickname       string    `gorm:"size:35;not null;default:''"`
+	Status         string    `gorm:"not null"`
+	UsableFrom     time.Time `gorm:"not null"`
+	PayeeCheck     string    `gorm:"not null;default:''"`
+	PayeeCheckName string    `gorm:"not null;default:''"`
+}
+
+func SetUp(db *gorm.DB) *gorm.DB {
+
+	db.AutoMigrate(&Beneficiary{})
+
+	return db
+}
+
+func (beneficiary *Beneficiary) IsCoolingOff(now time.Time) bool {
+	return now.Before(beneficiary.UsableFrom)
+}
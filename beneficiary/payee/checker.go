@@ -0,0 +1,24 @@
+package payee
+
+import (
+	"context"
+)
+
+// results of a confirmation of payee, as the UK scheme names them
+const (
+	RESULT_MATCH       = "MATCH"
+	RESULT_CLOSE_MATCH = "CLOSE_MATCH"
+	RESULT_NO_MATCH    = "NO_MATCH"
+	RESULT_UNAVAILABLE = "UNAVAILABLE"
+)
+
+// Check is the answer of the bank of an account; on a close match Name is the name it holds.
+type Check struct {
+	Result string `json:"result"`
+	Name   string `json:"name,omitempty"`
+}
+
+// Checker asks the bank of an account whether it belongs to the given name before the account is saved.
+type Checker interface {
+	Check(ctx context.Context, account string, name string) (*Check, error)
+}
@@ -0,0 +1,143 @@
+package payee
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/javierjmgits/go-payment-api/account/identifier"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// names differing in up to this many letters are a close match
+const CLOSE_MATCH_DISTANCE = 2
+
+// FakeChecker answers from a fixed list of account holders; it cannot tell anything of the other accounts.
+type FakeChecker struct {
+	holders map[string]string
+}
+
+func NewFakeChecker(holders map[string]string) (*FakeChecker, error) {
+
+	normalized := map[string]string{}
+
+	for account, name := range holders {
+
+		normalizedAccount, errorAccount := identifier.Normalize(account)
+
+		if errorAccount != nil {
+			return nil, fmt.Errorf("account '%s' %v", account, errorAccount)
+		}
+
+		normalized[normalizedAccount] = name
+	}
+
+	return &FakeChecker{
+		holders: normalized,
+	}, nil
+}
+
+func NewFakeCheckerFromFile(path string) (*FakeChecker, error) {
+
+	file, errorOpen := os.Open(path)
+
+	if errorOpen != nil {
+		return nil, errorOpen
+	}
+
+	defer file.Close()
+
+	var content struct {
+		Accounts map[string]string `json:"accounts"`
+	}
+
+	if errorJson := json.NewDecoder(file).Decode(&content); errorJson != nil {
+		return nil, fmt.Errorf("invalid payee names file %s: %v", path, errorJson)
+	}
+
+	return NewFakeChecker(content.Accounts)
+}
+
+// Check compares the names ignoring case, punctuation and the order of the words.
+func (fc *FakeChecker) Check(ctx context.Context, account string, name string) (*Check, error) {
+
+	holder, found := fc.holders[account]
+
+	if !found {
+		return &Check{Result: RESULT_UNAVAILABLE}, nil
+	}
+
+	expected, given := words(holder), words(name)
+
+	if strings.Join(expected, " ") == strings.Join(given, " ") {
+		return &Check{Result: RESULT_MATCH}, nil
+	}
+
+	if distance(strings.Join(expected, ""), strings.Join(given, "")) <= CLOSE_MATCH_DISTANCE {
+		return &Check{Result: RESULT_CLOSE_MATCH, Name: holder}, nil
+	}
+
+	return &Check{Result: RESULT_NO_MATCH}, nil
+}
+
+//
+// private functions
+
+func words(name string) []string {
+
+	result := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	sort.Strings(result)
+
+	return result
+}
+
+// distance is the Levenshtein distance of two strings
+func distance(a string, b string) int {
+
+	first, second := []rune(a), []rune(b)
+	previous := make([]int, len(second)+1)
+
+	for index := range previous {
+		previous[index] = index
+	}
+
+	for i := 1; i <= len(first); i++ {
+
+		current := make([]int, len(second)+1)
+		current[0] = i
+
+		for j := 1; j <= len(second); j++ {
+
+			cost := 1
+
+			if first[i-1] == second[j-1] {
+				cost = 0
+			}
+
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+
+		previous = current
+	}
+
+	return previous[len(second)]
+}
+
+func min(values ...int) int {
+
+	result := values[0]
+
+	for _, value := range values[1:] {
+
+		if value < result {
+			result = value
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,32 @@
+package payee
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFakeCheck(t *testing.T) {
+
+	checker, errorChecker := NewFakeChecker(map[string]string{"GB29 NWBK 6016 1331 9268 19": "Jane Q. Doe"})
+
+	assert.Nil(t, errorChecker)
+
+	for name, expected := range map[string]Check{
+		"jane q doe":  {Result: RESULT_MATCH},
+		"Doe, Jane Q": {Result: RESULT_MATCH},
+		"Jane Doe":    {Result: RESULT_CLOSE_MATCH, Name: "Jane Q. Doe"},
+		"Jayne Q Do":  {Result: RESULT_CLOSE_MATCH, Name: "Jane Q. Doe"},
+		"John Smith":  {Result: RESULT_NO_MATCH},
+	} {
+
+		check, errorCheck := checker.Check(context.Background(), "GB29NWBK60161331926819", name)
+
+		assert.Nil(t, errorCheck)
+		assert.Equal(t, expected, *check, name)
+	}
+
+	check, _ := checker.Check(context.Background(), "DE89370400440532013000", "Jane Q Doe")
+
+	assert.Equal(t, RESULT_UNAVAILABLE, check.Result)
+}
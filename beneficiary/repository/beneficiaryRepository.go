@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"github.com/javierjmgits/go-payment-api/beneficiary/model"
+	"github.com/jinzhu/gorm"
+)
+
+type BeneficiaryRepository interface {
+	GetAll(tenant string, owner string) ([]model.Beneficiary, error)
+	GetByUid(uid string) (*model.Beneficiary, error)
+	GetByAccount(tenant string, owner string, account string) (*model.Beneficiary, error)
+	Create(beneficiary *model.Beneficiary) (*model.Beneficiary, error)
+	Update(beneficiary *model.Beneficiary) (*model.Beneficiary, error)
+	Delete(beneficiary *model.Beneficiary) error
+}
+
+type beneficiaryRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewBeneficiaryRepositoryImpl(db *gorm.DB) BeneficiaryRepository {
+	return &beneficiaryRepositoryImpl{
+		db: db,
+	}
+}
+
+func (bri *beneficiaryRepositoryImpl) GetAll(tenant string, owner string) ([]model.Beneficiary, error) {
+
+	var beneficiaries []model.Beneficiary
+	errorDB := bri.db.Where("tenant = ? AND owner = ?", tenant, owner).Order("name, id").Find(&beneficiaries).Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return beneficiaries, nil
+}
+
+func (bri *beneficiaryRepositoryImpl) GetByUid(uid string) (*model.Beneficiary, error) {
+
+	var beneficiary model.Beneficiary
+	errorFind := bri.db.Where("uid = ?", uid).First(&beneficiary).Error
+
+	if errorFind != nil {
+		return nil, errorFind
+	}
+
+	return &beneficiary, nil
+}
+
+// GetByAccount returns nil when the owner has not saved the account.
+func (bri *beneficiaryRepositoryImpl) GetByAccount(tenant string, owner string, account string) (*model.Beneficiary, error) {
+
+	var beneficiary model.Beneficiary
+	errorFind := bri.db.Where("tenant = ? AND owner = ? AND account = ?", tenant, owner, account).First(&beneficiary).Error
+
+	if gorm.IsRecordNotFoundError(errorFind) {
+		return nil, nil
+	}
+
+	if errorFind != nil {
+		return nil, errorFind
+	}
+
+	return &beneficiary, nil
+}
+
+func (bri *beneficiaryRepositoryImpl) Create(beneficiary *model.Beneficiary) (*model.Beneficiary, error) {
+
+	errorDB := bri.db.Create(beneficiary).Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return beneficiary, nil
+}
+
+func (bri *beneficiaryRepositoryImpl) Update(beneficiary *model.Beneficiary) (*model.Beneficiary, error) {
+
+	errorDB := bri.db.Save(beneficiary).Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return beneficiary, nil
+}
+
+func (bri *beneficiaryRepositoryImpl) Delete(beneficiary *model.Beneficiary) error {
+	return bri.db.Delete(beneficiary).Error
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/beneficiary/model"
+	"github.com/javierjmgits/go-payment-api/beneficiary/payee"
+	"github.com/javierjmgits/go-payment-api/beneficiary/repository"
+	paymentHandler "github.com/javierjmgits/go-payment-api/payment/handler"
+	"github.com/satori/go.uuid"
+	"log"
+	"strings"
+	"time"
+)
+
+type BeneficiaryService struct {
+	beneficiaryRepository repository.BeneficiaryRepository
+	checker               payee.Checker
+	coolingOff            time.Duration
+	now                   func() time.Time
+}
+
+func NewBeneficiaryService(beneficiaryRepository repository.BeneficiaryRepository, checker payee.Checker, coolingOff time.Duration) *BeneficiaryService {
+
+	return &BeneficiaryService{
+		beneficiaryRepository: beneficiaryRepository,
+		checker:               checker,
+		coolingOff:            coolingOff,
+		now:                   time.Now,
+	}
+}
+
+func (bs *BeneficiaryService) GetAll(principal *auth.Principal) ([]model.Beneficiary, error) {
+
+	if errorOwner := checkOwner(principal); errorOwner != nil {
+		return nil, errorOwner
+	}
+
+	return bs.beneficiaryRepository.GetAll(principal.Tenant, principal.User)
+}
+
+// Get returns a beneficiary of the caller; those of anyone else are not found.
+func (bs *BeneficiaryService) Get(uid string, principal *auth.Principal) (*model.Beneficiary, error) {
+
+	if errorOwner := checkOwner(principal); errorOwner != nil {
+		return nil, errorOwner
+	}
+
+	beneficiary, errorDB := bs.beneficiaryRepository.GetByUid(uid)
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	if beneficiary.Tenant != principal.Tenant || beneficiary.Owner != principal.User {
+		return nil, errors.New("beneficiary not found")
+	}
+
+	return beneficiary, nil
+}
+
+// Create checks the name with the bank of the account before saving it. A name that does not match, or
+// only nearly, is refused unless the caller confirms it; when the bank cannot answer it is saved anyway.
+func (bs *BeneficiaryService) Create(ctx context.Context, beneficiary *model.Beneficiary, principal *auth.Principal, confirmMismatch bool) (*model.Beneficiary, error) {
+
+	if errorOwner := checkOwner(principal); errorOwner != nil {
+		return nil, errorOwner
+	}
+
+	existing, errorDB := bs.beneficiaryRepository.GetByAccount(principal.Tenant, principal.User, beneficiary.Account)
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	if existing != nil {
+		return nil, &util.ConflictError{Message: fmt.Sprintf("account already saved as beneficiary %s", existing.Uid)}
+	}
+
+	check, errorCheck := bs.checker.Check(ctx, beneficiary.Account, beneficiary.Name)
+
+	if errorCheck != nil {
+		log.Printf("Confirmation of payee failed for account %s: %v\n", beneficiary.Account, errorCheck)
+		check = &payee.Check{Result: payee.RESULT_UNAVAILABLE}
+	}
+
+	if (check.Result == payee.RESULT_NO_MATCH || check.Result == payee.RESULT_CLOSE_MATCH) && !confirmMismatch {
+		return nil, &util.RejectedError{Message: "name does not match the account holder, confirm the mismatch to save it anyway", Details: check}
+	}
+
+	uuidResult, errorUuid := uuid.NewV4()
+
+	if errorUuid != nil {
+		return nil, errorUuid
+	}
+
+	beneficiary.Uid = uuidResult.String()
+	beneficiary.Tenant = principal.Tenant
+	beneficiary.Owner = principal.User
+	beneficiary.Status = model.STATUS_ACTIVE
+	beneficiary.UsableFrom = bs.now().UTC().Add(bs.coolingOff).Truncate(time.Second)
+	beneficiary.PayeeCheck = check.Result
+	beneficiary.PayeeCheckName = check.Name
+
+	return bs.beneficiaryRepository.Create(beneficiary)
+}
+
+// Update renames or blocks a beneficiary; unblocking it starts a new cooling-off.
+func (bs *BeneficiaryService) Update(uid string, principal *auth.Principal, nickname *string, status *string) (*model.Beneficiary, error) {
+
+	beneficiary, errorGet := bs.Get(uid, principal)
+
+	if errorGet != nil {
+		return nil, errorGet
+	}
+
+	if nickname != nil {
+		beneficiary.Nickname = *nickname
+	}
+
+	if status != nil && *status != beneficiary.Status {
+
+		switch *status {
+
+		case model.STATUS_ACTIVE:
+			beneficiary.UsableFrom = bs.now().UTC().Add(bs.coolingOff).Truncate(time.Second)
+
+		case model.STATUS_BLOCKED:
+
+		default:
+			return nil, &util.InputError{Message: fmt.Sprintf("status must be %s or %s", model.STATUS_ACTIVE, model.STATUS_BLOCKED)}
+		}
+
+		beneficiary.Status = *status
+	}
+
+	return bs.beneficiaryRepository.Update(beneficiary)
+}
+
+func (bs *BeneficiaryService) Delete(uid string, principal *auth.Principal) error {
+
+	beneficiary, errorGet := bs.Get(uid, principal)
+
+	if errorGet != nil {
+		return errorGet
+	}
+
+	return bs.beneficiaryRepository.Delete(beneficiary)
+}
+
+// ResolveTarget sends the payment to the account of the beneficiary, once its cooling-off is over.
+func (bs *BeneficiaryService) ResolveTarget(paymentCreate *paymentHandler.PaymentCreate, principal *auth.Principal) error {
+
+	beneficiary, errorGet := bs.Get(paymentCreate.BeneficiaryUid, principal)
+
+	if errorGet != nil {
+
+		if strings.Contains(errorGet.Error(), "not found") {
+			return &util.InputError{Message: fmt.Sprintf("unknown beneficiary '%s'", paymentCreate.BeneficiaryUid)}
+		}
+
+		return errorGet
+	}
+
+	if beneficiary.Status == model.STATUS_BLOCKED {
+		return &util.RejectedError{Message: "beneficiary is blocked"}
+	}
+
+	if beneficiary.IsCoolingOff(bs.now()) {
+		return &util.RejectedError{Message: fmt.Sprintf("beneficiary can be paid from %s", beneficiary.UsableFrom.Format(time.RFC3339))}
+	}
+
+	paymentCreate.AccountTarget = beneficiary.Account
+
+	return nil
+}
+
+//
+// private functions
+
+func checkOwner(principal *auth.Principal) error {
+
+	if principal.User == "" {
+		return &util.InputError{Message: fmt.Sprintf("beneficiaries belong to a user, it must be given in the %s header", auth.HEADER_USER)}
+	}
+
+	return nil
+}
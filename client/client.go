@@ -0,0 +1,204 @@
+// Package client is a typed Go client of the payments API, for the services that call it.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DEFAULT_TIMEOUT     = 30 * time.Second
+	DEFAULT_MAX_RETRIES = 3
+	DEFAULT_BACKOFF     = 200 * time.Millisecond
+	MAX_BACKOFF         = 5 * time.Second
+)
+
+// Client calls the payments API of one base URL, e.g. http://payments:8080, on behalf of one principal.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	header     http.Header
+	maxRetries int
+	backoff    time.Duration
+}
+
+func NewClient(baseURL string) *Client {
+
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: DEFAULT_TIMEOUT},
+		header:     http.Header{},
+		maxRetries: DEFAULT_MAX_RETRIES,
+		backoff:    DEFAULT_BACKOFF,
+	}
+}
+
+func (c *Client) SetHTTPClient(httpClient *http.Client) {
+
+	c.httpClient = httpClient
+}
+
+// SetPrincipal sends the identity headers the gateway would assert, for callers inside the gateway.
+func (c *Client) SetPrincipal(user string, tenant string, scopes ...string) {
+
+	c.header.Set(auth.HEADER_USER, user)
+	c.header.Set(auth.HEADER_TENANT, tenant)
+	c.header.Del(auth.HEADER_SCOPES)
+
+	if len(scopes) > 0 {
+		c.header.Set(auth.HEADER_SCOPES, strings.Join(scopes, ","))
+	}
+}
+
+// SetRetries sets how many times a call is retried after a network error, a 429 or a 502, 503 or 504, and the wait
+// before the first retry; the wait doubles on every retry, up to MAX_BACKOFF. Zero retries turns them off.
+func (c *Client) SetRetries(maxRetries int, backoff time.Duration) {
+
+	c.maxRetries = maxRetries
+	c.backoff = backoff
+}
+
+//
+// private functions
+
+// do sends the request, retrying it while it fails in a way worth retrying, and decodes the JSON response into result
+// or the error response into one of the errors of the package.
+func (c *Client) do(ctx context.Context, method string, path string, query url.Values, header http.Header, body interface{}, result interface{}) error {
+
+	var payload []byte
+
+	if body != nil {
+
+		var errorJson error
+
+		if payload, errorJson = json.Marshal(body); errorJson != nil {
+			return errorJson
+		}
+	}
+
+	target := c.baseURL + path
+
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	for attempt := 0; ; attempt++ {
+
+		resp, errorSend := c.send(ctx, method, target, header, payload)
+
+		if errorSend == nil && !isRetryable(resp.StatusCode) {
+			return decodeResponse(resp, result)
+		}
+
+		if attempt >= c.maxRetries || ctx.Err() != nil {
+
+			if errorSend != nil {
+				return errorSend
+			}
+
+			return decodeResponse(resp, result)
+		}
+
+		wait := c.wait(attempt)
+
+		if resp != nil {
+
+			if retryAfter, errorParse := strconv.Atoi(resp.Header.Get("Retry-After")); errorParse == nil && retryAfter >= 0 {
+				wait = time.Duration(retryAfter) * time.Second
+			}
+
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+
+		select {
+
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, method string, target string, header http.Header, payload []byte) (*http.Response, error) {
+
+	var body io.Reader
+
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, errorRequest := http.NewRequestWithContext(ctx, method, target, body)
+
+	if errorRequest != nil {
+		return nil, errorRequest
+	}
+
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return c.httpClient.Do(req)
+}
+
+// wait is the exponential backoff of the attempt, with some jitter so that clients failing together do not retry together
+func (c *Client) wait(attempt int) time.Duration {
+
+	wait := c.backoff << uint(attempt)
+
+	if wait <= 0 || wait > MAX_BACKOFF {
+		wait = MAX_BACKOFF
+	}
+
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+func isRetryable(status int) bool {
+
+	switch status {
+
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+func decodeResponse(resp *http.Response, result interface{}) error {
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return newError(resp)
+	}
+
+	if result == nil || resp.StatusCode == http.StatusNoContent {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,487 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/payment/handler"
+	"github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/payment/openapi"
+	"github.com/javierjmgits/go-payment-api/payment/repository"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+//
+// mocks
+
+type paymentRepositoryImplMock struct {
+	repository.PaymentRepository
+	mutex    sync.Mutex
+	payments []*model.Payment
+}
+
+func (mock *paymentRepositoryImplMock) Search(conditions []repository.Condition, offset int, limit int) ([]model.Payment, int, error) {
+
+	mock.mutex.Lock()
+	defer mock.mutex.Unlock()
+
+	var results []model.Payment
+
+	for index := offset; index < len(mock.payments) && index < offset+limit; index++ {
+		results = append(results, *mock.payments[index])
+	}
+
+	return results, len(mock.payments), nil
+}
+
+func (mock *paymentRepositoryImplMock) GetByUid(uid string) (*model.Payment, error) {
+
+	mock.mutex.Lock()
+	defer mock.mutex.Unlock()
+
+	for _, payment := range mock.payments {
+
+		if payment.Uid == uid {
+			copied := *payment
+			return &copied, nil
+		}
+	}
+
+	return nil, errors.New("record not found")
+}
+
+func (mock *paymentRepositoryImplMock) GetByIdempotencyKey(tenant string, key string) (*model.Payment, error) {
+
+	mock.mutex.Lock()
+	defer mock.mutex.Unlock()
+
+	for _, payment := range mock.payments {
+
+		if payment.Tenant == tenant && payment.IdempotencyKey != nil && *payment.IdempotencyKey == key {
+			copied := *payment
+			return &copied, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (mock *paymentRepositoryImplMock) Create(payment *model.Payment) (*model.Payment, error) {
+
+	mock.mutex.Lock()
+	defer mock.mutex.Unlock()
+
+	payment.ID = uint(len(mock.payments) + 1)
+	payment.CreatedAt = time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	payment.UpdatedAt = payment.CreatedAt
+
+	copied := *payment
+	mock.payments = append(mock.payments, &copied)
+
+	return payment, nil
+}
+
+func (mock *paymentRepositoryImplMock) Update(payment *model.Payment) (*model.Payment, error) {
+
+	mock.mutex.Lock()
+	defer mock.mutex.Unlock()
+
+	for index := range mock.payments {
+
+		if mock.payments[index].Uid == payment.Uid {
+			copied := *payment
+			mock.payments[index] = &copied
+		}
+	}
+
+	return payment, nil
+}
+
+func (mock *paymentRepositoryImplMock) Delete(payment *model.Payment) error {
+
+	mock.mutex.Lock()
+	defer mock.mutex.Unlock()
+
+	for index := range mock.payments {
+
+		if mock.payments[index].Uid == payment.Uid {
+			mock.payments = append(mock.payments[:index], mock.payments[index+1:]...)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+type amountLimitHook struct {
+}
+
+func (hook *amountLimitHook) BeforeCreate(payment *model.Payment, paymentCreate *handler.PaymentCreate) error {
+
+	if payment.Amount > 1000 {
+		return &util.RejectedError{Message: "Payment rejected", Details: map[string]interface{}{"limit": 1000}}
+	}
+
+	return nil
+}
+
+// flakyServer loses the response of the first requests after the handlers served them, as a timed out proxy would
+type flakyServer struct {
+	next     http.Handler
+	mutex    sync.Mutex
+	failures int
+	requests int
+}
+
+func (fs *flakyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	fs.mutex.Lock()
+	fs.requests++
+	fail := fs.failures > 0
+	fs.failures--
+	fs.mutex.Unlock()
+
+	if !fail {
+		fs.next.ServeHTTP(w, r)
+		return
+	}
+
+	fs.next.ServeHTTP(httptest.NewRecorder(), r)
+
+	util.WriteError(w, http.StatusServiceUnavailable, "upstream timed out")
+}
+
+//
+// tests
+
+func TestCreateAndGetPayment(t *testing.T) {
+
+	client, mockRepository, _ := setUp(t, 0)
+
+	payment, errorCreate := client.CreatePayment(context.Background(), newPaymentCreate(25))
+	paymentFound, errorGet := client.GetPayment(context.Background(), payment.Uid)
+
+	// verify
+
+	assert.Nil(t, errorCreate)
+	assert.Nil(t, errorGet)
+	assert.NotEmpty(t, payment.Uid)
+	assert.Equal(t, "alice", payment.CreatedBy)
+	assert.Equal(t, "acme", payment.Tenant)
+	assert.Equal(t, 25.0, paymentFound.Amount)
+	assert.Equal(t, payment.Uid, paymentFound.Uid)
+	assert.Len(t, mockRepository.payments, 1)
+}
+
+func TestCreatePaymentRetriedAfterLostResponse(t *testing.T) {
+
+	client, mockRepository, server := setUp(t, 2)
+
+	payment, errorCreate := client.CreatePayment(context.Background(), newPaymentCreate(25))
+
+	// verify
+
+	assert.Nil(t, errorCreate)
+	assert.Equal(t, 3, server.requests)
+	assert.Len(t, mockRepository.payments, 1)
+	assert.Equal(t, mockRepository.payments[0].Uid, payment.Uid)
+}
+
+func TestCreatePaymentWithIdempotencyKey(t *testing.T) {
+
+	client, mockRepository, _ := setUp(t, 0)
+
+	ctx := WithIdempotencyKey(context.Background(), "order-1234")
+
+	payment, errorCreate := client.CreatePayment(ctx, newPaymentCreate(25))
+	paymentAgain, errorAgain := client.CreatePayment(ctx, newPaymentCreate(25))
+
+	// verify
+
+	assert.Nil(t, errorCreate)
+	assert.Nil(t, errorAgain)
+	assert.Equal(t, payment.Uid, paymentAgain.Uid)
+	assert.Len(t, mockRepository.payments, 1)
+	assert.Equal(t, "order-1234", *mockRepository.payments[0].IdempotencyKey)
+}
+
+func TestCreatePaymentKoInput(t *testing.T) {
+
+	client, mockRepository, _ := setUp(t, 0)
+
+	paymentCreate := newPaymentCreate(25)
+	paymentCreate.AccountTarget = paymentCreate.AccountOrigin
+
+	_, errorCreate := client.CreatePayment(context.Background(), paymentCreate)
+
+	// verify
+
+	var inputError *InputError
+
+	assert.True(t, errors.As(errorCreate, &inputError))
+	assert.Equal(t, http.StatusBadRequest, inputError.StatusCode)
+	assert.Equal(t, "account origin and target must be different", inputError.Message)
+	assert.Empty(t, mockRepository.payments)
+}
+
+func TestCreatePaymentKoRejected(t *testing.T) {
+
+	client, mockRepository, _ := setUp(t, 0)
+
+	_, errorCreate := client.CreatePayment(context.Background(), newPaymentCreate(5000))
+
+	// verify
+
+	rejectedError, ok := errorCreate.(*RejectedError)
+
+	assert.True(t, ok)
+	assert.Equal(t, "Payment rejected", rejectedError.Message)
+	assert.JSONEq(t, `{"limit": 1000}`, string(rejectedError.Details))
+	assert.Equal(t, "payments API: 422 Payment rejected", errorCreate.Error())
+	assert.Empty(t, mockRepository.payments)
+}
+
+func TestGetPaymentKoNotFound(t *testing.T) {
+
+	client, _, _ := setUp(t, 0)
+
+	_, errorGet := client.GetPayment(context.Background(), "unknown")
+
+	// verify
+
+	_, ok := errorGet.(*NotFoundError)
+
+	assert.True(t, ok)
+}
+
+func TestMarkProcessed(t *testing.T) {
+
+	client, _, _ := setUp(t, 0)
+
+	payment, _ := client.CreatePayment(context.Background(), newPaymentCreate(25))
+
+	paymentProcessed, errorProcessed := client.MarkProcessed(context.Background(), payment.Uid)
+	_, errorAgain := client.MarkProcessed(context.Background(), payment.Uid)
+
+	// verify
+
+	assert.Nil(t, errorProcessed)
+	assert.True(t, paymentProcessed.Processed)
+	assert.NotNil(t, paymentProcessed.ProcessedDate)
+
+	conflictError, ok := errorAgain.(*ConflictError)
+
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusConflict, conflictError.StatusCode)
+}
+
+func TestDelete(t *testing.T) {
+
+	client, mockRepository, _ := setUp(t, 0)
+
+	payment, _ := client.CreatePayment(context.Background(), newPaymentCreate(25))
+
+	errorForbidden := client.Delete(context.Background(), payment.Uid, false)
+
+	client.SetPrincipal("alice", "acme", "payments:delete")
+
+	errorDelete := client.Delete(context.Background(), payment.Uid, false)
+
+	// verify
+
+	_, ok := errorForbidden.(*ForbiddenError)
+
+	assert.True(t, ok)
+	assert.Nil(t, errorDelete)
+	assert.Empty(t, mockRepository.payments)
+}
+
+func TestListPayments(t *testing.T) {
+
+	client, mockRepository, server := setUp(t, 0)
+
+	for index := 0; index < 250; index++ {
+		mockRepository.Create(newPayment(fmt.Sprintf("uid-%d", index)))
+	}
+
+	payments := client.ListPayments(context.Background(), "processed:false")
+
+	var uids []string
+
+	for payments.Next() {
+		uids = append(uids, payments.Payment().Uid)
+	}
+
+	// verify
+
+	assert.Nil(t, payments.Err())
+	assert.Equal(t, 250, payments.Total())
+	assert.Len(t, uids, 250)
+	assert.Equal(t, "uid-0", uids[0])
+	assert.Equal(t, "uid-249", uids[249])
+	assert.Equal(t, 3, server.requests)
+}
+
+func TestListPaymentsEmptyQuery(t *testing.T) {
+
+	client, mockRepository, _ := setUp(t, 0)
+
+	for index := 0; index < 3; index++ {
+		mockRepository.Create(newPayment(fmt.Sprintf("uid-%d", index)))
+	}
+
+	payments := client.ListPayments(context.Background(), "")
+
+	var uids []string
+
+	for payments.Next() {
+		uids = append(uids, payments.Payment().Uid)
+	}
+
+	// verify
+
+	assert.Nil(t, payments.Err())
+	assert.Equal(t, 3, payments.Total())
+	assert.Equal(t, []string{"uid-0", "uid-1", "uid-2"}, uids)
+}
+
+func TestListPaymentsKoInvalidQuery(t *testing.T) {
+
+	client, _, _ := setUp(t, 0)
+
+	payments := client.ListPayments(context.Background(), "amount>")
+
+	// verify
+
+	assert.False(t, payments.Next())
+
+	_, ok := payments.Err().(*InputError)
+
+	assert.True(t, ok)
+}
+
+func TestRetriesGiveUp(t *testing.T) {
+
+	client, mockRepository, server := setUp(t, 10)
+
+	_, errorCreate := client.CreatePayment(context.Background(), newPaymentCreate(25))
+
+	// verify
+
+	apiError, ok := errorCreate.(*APIError)
+
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusServiceUnavailable, apiError.StatusCode)
+	assert.Equal(t, "upstream timed out", apiError.Message)
+	assert.Equal(t, DEFAULT_MAX_RETRIES+1, server.requests)
+	assert.Len(t, mockRepository.payments, 1)
+}
+
+func TestRetriesStopWithContext(t *testing.T) {
+
+	client, _, server := setUp(t, 10)
+	client.SetRetries(DEFAULT_MAX_RETRIES, time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, errorGet := client.GetPayment(ctx, "unknown")
+
+	// verify
+
+	assert.Equal(t, context.DeadlineExceeded, errorGet)
+	assert.Equal(t, 1, server.requests)
+}
+
+func TestPayloadsMatchHandlers(t *testing.T) {
+
+	// verify
+
+	assert.Equal(t, jsonFields(reflect.TypeOf(handler.PaymentView{})), jsonFields(reflect.TypeOf(Payment{})))
+	assert.Equal(t, jsonFields(reflect.TypeOf(handler.RiskRule{})), jsonFields(reflect.TypeOf(RiskRule{})))
+	assert.Equal(t, jsonFields(reflect.TypeOf(handler.PaymentCreate{})), jsonFields(reflect.TypeOf(PaymentCreate{})))
+	assert.Equal(t, handler.HEADER_IDEMPOTENCY_KEY, HEADER_IDEMPOTENCY_KEY)
+	assert.Equal(t, handler.MAX_PAGE_SIZE, PAGE_SIZE)
+}
+
+//
+// private functions
+
+// setUp serves the real handlers, checked against the OpenAPI document, behind a server losing the first responses
+func setUp(t *testing.T, failures int) (*Client, *paymentRepositoryImplMock, *flakyServer) {
+
+	router := mux.NewRouter()
+
+	mockRepository := &paymentRepositoryImplMock{}
+
+	paymentHandler := handler.NewPaymentHandler(mockRepository)
+	paymentHandler.SetDeleteScope("payments:delete")
+	paymentHandler.AddCreateHook(&amountLimitHook{})
+	paymentHandler.Register(router)
+
+	validator, _ := openapi.NewValidator(openapi.NewSpec(), true, true)
+	router.Use(validator.Middleware)
+
+	server := &flakyServer{next: router, failures: failures}
+
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	client := NewClient(httpServer.URL + "/")
+	client.SetPrincipal("alice", "acme")
+	client.SetRetries(DEFAULT_MAX_RETRIES, time.Millisecond)
+
+	return client, mockRepository, server
+}
+
+func newPaymentCreate(amount float64) *PaymentCreate {
+
+	return &PaymentCreate{
+		AccountOrigin: "GB29NWBK60161331926819",
+		AccountTarget: "DE89370400440532013000",
+		Amount:        amount,
+		Currency:      "EUR",
+		Date:          time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func newPayment(uid string) *model.Payment {
+
+	return &model.Payment{
+		Uid:           uid,
+		AccountOrigin: "GB29NWBK60161331926819",
+		AccountTarget: "DE89370400440532013000",
+		Amount:        10,
+		Currency:      model.DEFAULT_CURRENCY,
+		Date:          time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+var typeNames = strings.NewReplacer("handler.", "", "client.", "")
+
+// jsonFields lists the JSON fields of a payload with their Go types, leaving out the ones never sent
+func jsonFields(payload reflect.Type) map[string]string {
+
+	fields := map[string]string{}
+
+	for index := 0; index < payload.NumField(); index++ {
+
+		field := payload.Field(index)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+
+		if name != "-" {
+			fields[name] = typeNames.Replace(field.Type.String())
+		}
+	}
+
+	return fields
+}
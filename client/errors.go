@@ -0,0 +1,93 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// APIError is an error response of the API; the statuses the API gives a meaning to come as the errors below, which
+// embed it, the rest, e.g. a 500, as an *APIError.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (ae *APIError) Error() string {
+	return fmt.Sprintf("payments API: %d %s", ae.StatusCode, ae.Message)
+}
+
+// InputError is a 400, the request is invalid.
+type InputError struct {
+	APIError
+}
+
+// ForbiddenError is a 403, the principal may not do it.
+type ForbiddenError struct {
+	APIError
+}
+
+// NotFoundError is a 404, there is no such payment.
+type NotFoundError struct {
+	APIError
+}
+
+// ConflictError is a 409, the payment is not in a state that allows it, e.g. already processed.
+type ConflictError struct {
+	APIError
+}
+
+// RejectedError is a 422, a valid request refused by a business rule; Details, when the API gives them, tell why.
+type RejectedError struct {
+	APIError
+	Details json.RawMessage
+}
+
+// errorView is what util.WriteError and util.WriteErrorWithDetails write
+type errorView struct {
+	Code    int             `json:"code"`
+	Error   string          `json:"error"`
+	Details json.RawMessage `json:"details"`
+}
+
+//
+// private functions
+
+func newError(resp *http.Response) error {
+
+	body, _ := io.ReadAll(resp.Body)
+
+	var view errorView
+
+	apiError := APIError{StatusCode: resp.StatusCode}
+
+	if json.Unmarshal(body, &view) == nil && view.Error != "" {
+		apiError.Message = view.Error
+	} else if message := strings.TrimSpace(string(body)); message != "" {
+		apiError.Message = message
+	} else {
+		apiError.Message = http.StatusText(resp.StatusCode)
+	}
+
+	switch resp.StatusCode {
+
+	case http.StatusBadRequest:
+		return &InputError{apiError}
+
+	case http.StatusForbidden:
+		return &ForbiddenError{apiError}
+
+	case http.StatusNotFound:
+		return &NotFoundError{apiError}
+
+	case http.StatusConflict:
+		return &ConflictError{apiError}
+
+	case http.StatusUnprocessableEntity:
+		return &RejectedError{APIError: apiError, Details: view.Details}
+	}
+
+	return &apiError
+}
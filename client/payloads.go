@@ -0,0 +1,94 @@
+package client
+
+import (
+	"time"
+)
+
+// the payloads mirror the JSON of the API rather than import the handlers, so that the client does not bring the
+// server with it; the tests run the client against the real handlers, which keeps them from drifting
+
+// Payment is a payment as the API returns it.
+type Payment struct {
+	Uid                    string            `json:"uid"`
+	AccountOrigin          string            `json:"accountOrigin"`
+	AccountTarget          string            `json:"accountTarget"`
+	Amount                 float64           `json:"amount"`
+	Currency               string            `json:"currency"`
+	TargetCurrency         string            `json:"targetCurrency"`
+	TargetAmount           float64           `json:"targetAmount"`
+	FxRate                 float64           `json:"fxRate"`
+	FxQuoteUid             *string           `json:"fxQuoteUid,omitempty"`
+	Date                   time.Time         `json:"date"`
+	Processed              bool              `json:"processed"`
+	ProcessedDate          *time.Time        `json:"processedDate"`
+	RefundedAmount         float64           `json:"refundedAmount"`
+	RefundableAmount       float64           `json:"refundableAmount"`
+	RefundOfUid            *string           `json:"refundOfUid,omitempty"`
+	RiskDecision           string            `json:"riskDecision,omitempty"`
+	RiskRules              []RiskRule        `json:"riskRules,omitempty"`
+	ReviewStatus           string            `json:"reviewStatus,omitempty"`
+	ReviewedBy             *string           `json:"reviewedBy,omitempty"`
+	ReviewedAt             *time.Time        `json:"reviewedAt,omitempty"`
+	CreatedBy              string            `json:"createdBy,omitempty"`
+	Tenant                 string            `json:"tenant,omitempty"`
+	ApprovalStatus         string            `json:"approvalStatus,omitempty"`
+	ApprovalLevels         int               `json:"approvalLevels,omitempty"`
+	ApprovalExpiresAt      *time.Time        `json:"approvalExpiresAt,omitempty"`
+	Reference              string            `json:"reference,omitempty"`
+	Description            string            `json:"description,omitempty"`
+	Metadata               map[string]string `json:"metadata,omitempty"`
+	CancelledAt            *time.Time        `json:"cancelledAt,omitempty"`
+	CancelledBy            string            `json:"cancelledBy,omitempty"`
+	CancelReasonCode       string            `json:"cancelReasonCode,omitempty"`
+	CancelNote             string            `json:"cancelNote,omitempty"`
+	ProcessingStatus       string            `json:"processingStatus,omitempty"`
+	ProcessingAttempts     int               `json:"processingAttempts,omitempty"`
+	ProcessingError        string            `json:"processingError,omitempty"`
+	NextAttemptAt          *time.Time        `json:"nextAttemptAt,omitempty"`
+	Rail                   string            `json:"rail,omitempty"`
+	RailReference          *string           `json:"railReference,omitempty"`
+	RailStatus             string            `json:"railStatus,omitempty"`
+	RailReason             string            `json:"railReason,omitempty"`
+	ReconciledAt           *time.Time        `json:"reconciledAt,omitempty"`
+	ReconciliationUid      *string           `json:"reconciliationUid,omitempty"`
+	FeeAmount              float64           `json:"feeAmount"`
+	FeeCurrency            string            `json:"feeCurrency,omitempty"`
+	FeeBearer              string            `json:"feeBearer,omitempty"`
+	AuthorizationStatus    string            `json:"authorizationStatus,omitempty"`
+	AuthorizedAmount       float64           `json:"authorizedAmount,omitempty"`
+	CapturedAmount         float64           `json:"capturedAmount,omitempty"`
+	AuthorizationExpiresAt *time.Time        `json:"authorizationExpiresAt,omitempty"`
+	BeneficiaryUid         *string           `json:"beneficiaryUid,omitempty"`
+}
+
+type RiskRule struct {
+	Rule     string `json:"rule"`
+	Decision string `json:"decision"`
+	Reason   string `json:"reason"`
+}
+
+// PaymentCreate is a payment to create.
+type PaymentCreate struct {
+	AccountOrigin  string            `json:"accountOrigin"`
+	AccountTarget  string            `json:"accountTarget"`
+	Amount         float64           `json:"amount"`
+	Currency       string            `json:"currency"`
+	TargetCurrency string            `json:"targetCurrency"`
+	FxQuoteUid     string            `json:"fxQuoteUid"`
+	Date           time.Time         `json:"date"`
+	Reference      string            `json:"reference"`
+	Description    string            `json:"description"`
+	Metadata       map[string]string `json:"metadata"`
+	FeeBearer      string            `json:"feeBearer"`
+	BeneficiaryUid string            `json:"beneficiaryUid"`
+	Authorize      bool              `json:"authorize"`
+}
+
+type searchResult struct {
+	Total int         `json:"total"`
+	Items []searchHit `json:"items"`
+}
+
+type searchHit struct {
+	Payment *Payment `json:"payment"`
+}
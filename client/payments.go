@@ -0,0 +1,215 @@
+package client
+
+import (
+	"context"
+	"github.com/satori/go.uuid"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const (
+	PAYMENTS_PATH = "/api/v1/payments"
+
+	HEADER_IDEMPOTENCY_KEY = "Idempotency-Key"
+
+	// the largest page the search returns
+	PAGE_SIZE = 100
+)
+
+type idempotencyKeyContextKey struct{}
+
+// PaymentIterator walks the pages of a search, fetching the next one when the current one is used up:
+//
+//	payments := c.ListPayments(ctx, "processed:false")
+//
+//	for payments.Next() {
+//		payment := payments.Payment()
+//	}
+//
+//	if errorList := payments.Err(); errorList != nil {
+//	}
+//
+// Pages are read by offset, newest payments first, so payments created while iterating can make a payment come twice.
+type PaymentIterator struct {
+	client   *Client
+	ctx      context.Context
+	query    string
+	page     int
+	total    int
+	payments []Payment
+	index    int
+	last     bool
+	err      error
+}
+
+// WithIdempotencyKey makes CreatePayment send the given key rather than one of its own, so that a caller retrying
+// on its side, e.g. after a restart, still creates the payment once.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+// CreatePayment sends one idempotency key on every attempt, so a retry after a lost response gets the payment back
+// rather than creating another.
+func (c *Client) CreatePayment(ctx context.Context, paymentCreate *PaymentCreate) (*Payment, error) {
+
+	idempotencyKey, _ := ctx.Value(idempotencyKeyContextKey{}).(string)
+
+	if idempotencyKey == "" {
+
+		uuidResult, errorUuid := uuid.NewV4()
+
+		if errorUuid != nil {
+			return nil, errorUuid
+		}
+
+		idempotencyKey = uuidResult.String()
+	}
+
+	header := http.Header{}
+	header.Set(HEADER_IDEMPOTENCY_KEY, idempotencyKey)
+
+	var payment Payment
+
+	if errorCreate := c.do(ctx, http.MethodPost, PAYMENTS_PATH, nil, header, paymentCreate, &payment); errorCreate != nil {
+		return nil, errorCreate
+	}
+
+	return &payment, nil
+}
+
+func (c *Client) GetPayment(ctx context.Context, uid string) (*Payment, error) {
+
+	var payment Payment
+
+	if errorGet := c.do(ctx, http.MethodGet, paymentPath(uid), nil, nil, nil, &payment); errorGet != nil {
+		return nil, errorGet
+	}
+
+	return &payment, nil
+}
+
+// ListPayments finds the payments matching a query of the search language, all of them for an empty one.
+func (c *Client) ListPayments(ctx context.Context, query string) *PaymentIterator {
+
+	return &PaymentIterator{
+		client: c,
+		ctx:    ctx,
+		query:  query,
+	}
+}
+
+// MarkProcessed queues an unprocessed payment for the processing workers, so the payment it returns is not processed
+// yet but queued, see its ProcessingStatus; retried after a lost response, it gets a *ConflictError as the payment is
+// queued already.
+func (c *Client) MarkProcessed(ctx context.Context, uid string) (*Payment, error) {
+
+	var payment Payment
+
+	if errorProcessed := c.do(ctx, http.MethodPatch, paymentPath(uid)+"/processed", nil, nil, nil, &payment); errorProcessed != nil {
+		return nil, errorProcessed
+	}
+
+	return &payment, nil
+}
+
+// Delete hides an unprocessed payment, or removes it for good when hard is set; retried after a lost response, it
+// gets a *NotFoundError as the payment is gone already.
+func (c *Client) Delete(ctx context.Context, uid string, hard bool) error {
+
+	var query url.Values
+
+	if hard {
+		query = url.Values{"hard": {"true"}}
+	}
+
+	return c.do(ctx, http.MethodDelete, paymentPath(uid), query, nil, nil, nil)
+}
+
+// Next moves to the next payment, false once there are no more or a page could not be fetched.
+func (pi *PaymentIterator) Next() bool {
+
+	if pi.err != nil {
+		return false
+	}
+
+	if pi.index+1 < len(pi.payments) {
+		pi.index++
+		return true
+	}
+
+	for !pi.last {
+
+		if pi.err = pi.fetch(); pi.err != nil {
+			return false
+		}
+
+		if len(pi.payments) > 0 {
+			pi.index = 0
+			return true
+		}
+	}
+
+	return false
+}
+
+func (pi *PaymentIterator) Payment() *Payment {
+
+	return &pi.payments[pi.index]
+}
+
+// Total is the number of payments matching the query, once the first page is fetched.
+func (pi *PaymentIterator) Total() int {
+
+	return pi.total
+}
+
+func (pi *PaymentIterator) Err() error {
+
+	return pi.err
+}
+
+//
+// private functions
+
+func (pi *PaymentIterator) fetch() error {
+
+	pi.page++
+
+	query := url.Values{
+		"page": {strconv.Itoa(pi.page)},
+		"size": {strconv.Itoa(PAGE_SIZE)},
+	}
+
+	if pi.query != "" {
+		query.Set("q", pi.query)
+	}
+
+	var result searchResult
+
+	if errorSearch := pi.client.do(pi.ctx, http.MethodGet, PAYMENTS_PATH+"/search", query, nil, nil, &result); errorSearch != nil {
+		return errorSearch
+	}
+
+	pi.total = result.Total
+
+	// a new slice, the payments of the previous page may still be referenced
+	pi.payments = make([]Payment, 0, len(result.Items))
+
+	for _, item := range result.Items {
+
+		if item.Payment != nil {
+			pi.payments = append(pi.payments, *item.Payment)
+		}
+	}
+
+	pi.last = len(result.Items) < PAGE_SIZE || pi.page*PAGE_SIZE >= result.Total
+
+	return nil
+}
+
+func paymentPath(uid string) string {
+
+	return PAYMENTS_PATH + "/uid/" + url.PathEscape(uid)
+}
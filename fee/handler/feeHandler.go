@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/fee/service"
+	paymentHandler "github.com/javierjmgits/go-payment-api/payment/handler"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"net/http"
+	"strings"
+)
+
+type FeeHandler struct {
+	feeService *service.FeeService
+}
+
+type FeeView struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+	Bearer   string  `json:"bearer"`
+}
+
+func NewFeeHandler(feeService *service.FeeService) *FeeHandler {
+
+	return &FeeHandler{
+		feeService: feeService,
+	}
+}
+
+func (fh *FeeHandler) Register(router *mux.Router) {
+	router.HandleFunc("/api/v1/fees/preview", fh.PreviewFee).Methods("POST")
+}
+
+// PreviewFee prices the payment of the body, the same as a payment create, without storing anything.
+func (fh *FeeHandler) PreviewFee(w http.ResponseWriter, r *http.Request) {
+
+	var paymentCreate paymentHandler.PaymentCreate
+
+	errorJson := json.NewDecoder(r.Body).Decode(&paymentCreate)
+
+	if errorJson != nil {
+		util.WriteError(w, http.StatusBadRequest, errorJson.Error())
+		return
+	}
+
+	if paymentCreate.Amount <= 0 {
+		util.WriteError(w, http.StatusBadRequest, "amount must be a positive number")
+		return
+	}
+
+	currency := strings.ToUpper(paymentCreate.Currency)
+
+	if currency == "" {
+		currency = paymentModel.DEFAULT_CURRENCY
+	}
+
+	if !util.IsCurrencyCode(currency) {
+		util.WriteError(w, http.StatusBadRequest, "currency must be an ISO 4217 code")
+		return
+	}
+
+	charge, errorCharge := fh.feeService.Compute(auth.FromRequest(r).Tenant, currency, paymentCreate.Amount, paymentCreate.FeeBearer)
+
+	if errorCharge != nil {
+		util.WriteErrorFor(w, errorCharge)
+		return
+	}
+
+	util.WritePayload(w, http.StatusOK, &FeeView{
+		Amount:   charge.Amount,
+		Currency: charge.Currency,
+		Bearer:   charge.Bearer,
+	})
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	"github.com/javierjmgits/go-payment-api/fee/schedule"
+	"github.com/javierjmgits/go-payment-api/fee/service"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+//
+// tests
+
+func TestPreviewFee(t *testing.T) {
+
+	router := setUp()
+
+	resp := postPreview(router, `{"amount": 1000, "currency": "eur"}`, "acme")
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	var fee FeeView
+
+	json.Unmarshal(body, &fee)
+
+	// verify
+
+	assert.Equal(t, 2.5, fee.Amount)
+	assert.Equal(t, "EUR", fee.Currency)
+	assert.Equal(t, "SHARED", fee.Bearer)
+}
+
+func TestPreviewFeeOtherTenantAndBearer(t *testing.T) {
+
+	router := setUp()
+
+	resp := postPreview(router, `{"amount": 1000, "feeBearer": "receiver"}`, "")
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	var fee FeeView
+
+	json.Unmarshal(body, &fee)
+
+	// verify
+
+	assert.Equal(t, 5.0, fee.Amount)
+	assert.Equal(t, "RECEIVER", fee.Bearer)
+}
+
+func TestPreviewFeeKoUnknownBearer(t *testing.T) {
+
+	router := setUp()
+
+	resp := postPreview(router, `{"amount": 1000, "feeBearer": "BANK"}`, "acme")
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestPreviewFeeKoAmount(t *testing.T) {
+
+	router := setUp()
+
+	resp := postPreview(router, `{"amount": 0}`, "acme")
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+//
+// private functions
+
+func setUp() *mux.Router {
+
+	var router = mux.NewRouter()
+
+	feeSchedule, _ := schedule.NewSchedule(
+		schedule.Fee{Currency: "EUR", Percentage: 0.5},
+		schedule.Fee{Tenant: "acme", Percentage: 0.25, Bearer: "SHARED"},
+	)
+
+	NewFeeHandler(service.NewFeeService(feeSchedule)).Register(router)
+
+	return router
+}
+
+func postPreview(router *mux.Router, body string, tenant string) *http.Response {
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/fees/preview", strings.NewReader(body))
+	req.Header.Set(auth.HEADER_TENANT, tenant)
+
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	return w.Result()
+}
@@ -0,0 +1,161 @@
+package schedule
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/payment/model"
+	"math"
+	"os"
+	"strings"
+)
+
+// Tier replaces the flat fee and percentage of its fee from an amount on.
+type Tier struct {
+	From       float64 `json:"from"`
+	Flat       float64 `json:"flat"`
+	Percentage float64 `json:"percentage"`
+}
+
+// Fee is charged on the amount of a payment: a flat part plus a percentage, kept between min and max
+// when they are set. Tiers, in increasing order, apply to the amounts from their own on.
+// An empty tenant or currency matches any, and the sender bears the fee unless another bearer is given.
+type Fee struct {
+	Tenant     string  `json:"tenant"`
+	Currency   string  `json:"currency"`
+	Flat       float64 `json:"flat"`
+	Percentage float64 `json:"percentage"`
+	Tiers      []Tier  `json:"tiers"`
+	Min        float64 `json:"min"`
+	Max        float64 `json:"max"`
+	Bearer     string  `json:"bearer"`
+}
+
+type Schedule struct {
+	fees []Fee
+}
+
+func NewSchedule(fees ...Fee) (*Schedule, error) {
+
+	for index := range fees {
+
+		fee := &fees[index]
+
+		fee.Currency = strings.ToUpper(fee.Currency)
+		fee.Bearer = strings.ToUpper(fee.Bearer)
+
+		if fee.Bearer == "" {
+			fee.Bearer = model.FEE_BEARER_SENDER
+		}
+
+		if errorFee := validate(fee); errorFee != nil {
+			return nil, fmt.Errorf("fee for tenant '%s' and currency '%s' %v", fee.Tenant, fee.Currency, errorFee)
+		}
+	}
+
+	return &Schedule{
+		fees: fees,
+	}, nil
+}
+
+func NewScheduleFromFile(path string) (*Schedule, error) {
+
+	file, errorOpen := os.Open(path)
+
+	if errorOpen != nil {
+		return nil, errorOpen
+	}
+
+	defer file.Close()
+
+	var content struct {
+		Fees []Fee `json:"fees"`
+	}
+
+	if errorJson := json.NewDecoder(file).Decode(&content); errorJson != nil {
+		return nil, fmt.Errorf("invalid fee schedule file %s: %v", path, errorJson)
+	}
+
+	return NewSchedule(content.Fees...)
+}
+
+// ValidBearer tells whether the value is one of the parties that can bear a fee.
+func ValidBearer(bearer string) bool {
+	return bearer == model.FEE_BEARER_SENDER || bearer == model.FEE_BEARER_RECEIVER || bearer == model.FEE_BEARER_SHARED
+}
+
+// Compute returns the fee, rounded to cents, and its default bearer using the most specific fee of the schedule.
+// Without a fee for the tenant and currency the payment is free and the sender is the bearer.
+func (schedule *Schedule) Compute(tenant string, currency string, amount float64) (float64, string) {
+
+	fee := schedule.match(tenant, currency)
+
+	if fee == nil {
+		return 0, model.FEE_BEARER_SENDER
+	}
+
+	flat, percentage := fee.Flat, fee.Percentage
+
+	for _, tier := range fee.Tiers {
+
+		if amount >= tier.From {
+			flat, percentage = tier.Flat, tier.Percentage
+		}
+	}
+
+	result := flat + amount*percentage/100
+
+	if result < fee.Min {
+		result = fee.Min
+	}
+
+	if fee.Max > 0 && result > fee.Max {
+		result = fee.Max
+	}
+
+	return math.Round(result*100) / 100, fee.Bearer
+}
+
+//
+// private functions
+
+func validate(fee *Fee) error {
+
+	if fee.Flat < 0 || fee.Percentage < 0 || fee.Percentage > 100 {
+		return fmt.Errorf("must have a non-negative flat fee and a percentage up to 100")
+	}
+
+	if fee.Min < 0 || fee.Max < 0 || (fee.Max > 0 && fee.Max < fee.Min) {
+		return fmt.Errorf("must have non-negative caps, max not below min")
+	}
+
+	if !ValidBearer(fee.Bearer) {
+		return fmt.Errorf("has an unknown bearer '%s'", fee.Bearer)
+	}
+
+	for index, tier := range fee.Tiers {
+
+		if tier.From <= 0 || (index > 0 && tier.From <= fee.Tiers[index-1].From) {
+			return fmt.Errorf("must have tiers starting at positive, increasing amounts")
+		}
+
+		if tier.Flat < 0 || tier.Percentage < 0 || tier.Percentage > 100 {
+			return fmt.Errorf("must have tiers with a non-negative flat fee and a percentage up to 100")
+		}
+	}
+
+	return nil
+}
+
+func (schedule *Schedule) match(tenant string, currency string) *Fee {
+
+	index := util.MostSpecific(len(schedule.fees), func(index int) (string, string) {
+		return schedule.fees[index].Tenant, schedule.fees[index].Currency
+	}, tenant, currency)
+
+	if index < 0 {
+		return nil
+	}
+
+	return &schedule.fees[index]
+}
@@ -0,0 +1,67 @@
+package schedule
+
+import (
+	"github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCompute(t *testing.T) {
+
+	schedule, errorSchedule := NewSchedule(
+		Fee{Flat: 1},
+		Fee{Currency: "eur", Percentage: 0.5, Min: 2, Max: 50},
+		Fee{Tenant: "acme", Currency: "EUR", Flat: 0.25, Tiers: []Tier{{From: 1000, Percentage: 0.2}, {From: 10000, Flat: 15}}, Bearer: "shared"},
+	)
+
+	assert.Nil(t, errorSchedule)
+
+	fee, bearer := schedule.Compute("", "USD", 500)
+	assert.Equal(t, 1.0, fee)
+	assert.Equal(t, model.FEE_BEARER_SENDER, bearer)
+
+	// percentage within the caps
+	fee, _ = schedule.Compute("", "EUR", 100)
+	assert.Equal(t, 2.0, fee)
+
+	fee, _ = schedule.Compute("", "EUR", 1234.56)
+	assert.Equal(t, 6.17, fee)
+
+	fee, _ = schedule.Compute("", "EUR", 100000)
+	assert.Equal(t, 50.0, fee)
+
+	// the most specific fee wins and its tiers replace the base fee
+	fee, bearer = schedule.Compute("acme", "EUR", 999.99)
+	assert.Equal(t, 0.25, fee)
+	assert.Equal(t, model.FEE_BEARER_SHARED, bearer)
+
+	fee, _ = schedule.Compute("acme", "EUR", 1000)
+	assert.Equal(t, 2.0, fee)
+
+	fee, _ = schedule.Compute("acme", "EUR", 25000)
+	assert.Equal(t, 15.0, fee)
+}
+
+func TestComputeNoFee(t *testing.T) {
+
+	schedule, _ := NewSchedule(Fee{Tenant: "acme", Flat: 1})
+
+	fee, bearer := schedule.Compute("other", "EUR", 100)
+
+	assert.Equal(t, 0.0, fee)
+	assert.Equal(t, model.FEE_BEARER_SENDER, bearer)
+}
+
+func TestNewScheduleKoUnsortedTiers(t *testing.T) {
+
+	_, errorSchedule := NewSchedule(Fee{Currency: "EUR", Tiers: []Tier{{From: 10000}, {From: 1000}}})
+
+	assert.NotNil(t, errorSchedule)
+}
+
+func TestNewScheduleKoUnknownBearer(t *testing.T) {
+
+	_, errorSchedule := NewSchedule(Fee{Currency: "EUR", Flat: 1, Bearer: "BANK"})
+
+	assert.NotNil(t, errorSchedule)
+}
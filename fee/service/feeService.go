@@ -0,0 +1,79 @@
+package service
+
+import (
+	"fmt"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/fee/schedule"
+	paymentHandler "github.com/javierjmgits/go-payment-api/payment/handler"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"strings"
+)
+
+// Charge is the fee of a payment, in the currency of the payment.
+type Charge struct {
+	Amount   float64
+	Currency string
+	Bearer   string
+}
+
+type FeeService struct {
+	schedule *schedule.Schedule
+}
+
+func NewFeeService(schedule *schedule.Schedule) *FeeService {
+
+	return &FeeService{
+		schedule: schedule,
+	}
+}
+
+// Compute prices a payment; the bearer is the one asked for, or else the default of the schedule.
+func (fs *FeeService) Compute(tenant string, currency string, amount float64, bearer string) (*Charge, error) {
+
+	bearer = strings.ToUpper(bearer)
+
+	if bearer != "" && !schedule.ValidBearer(bearer) {
+		return nil, &util.InputError{Message: fmt.Sprintf("fee bearer must be one of %s, %s or %s",
+			paymentModel.FEE_BEARER_SENDER, paymentModel.FEE_BEARER_RECEIVER, paymentModel.FEE_BEARER_SHARED)}
+	}
+
+	fee, defaultBearer := fs.schedule.Compute(tenant, currency, amount)
+
+	if bearer == "" {
+		bearer = defaultBearer
+	}
+
+	return &Charge{
+		Amount:   fee,
+		Currency: currency,
+		Bearer:   bearer,
+	}, nil
+}
+
+// BeforeCreate stores the fee of the payment.
+func (fs *FeeService) BeforeCreate(payment *paymentModel.Payment, paymentCreate *paymentHandler.PaymentCreate) error {
+	return fs.apply(payment, paymentCreate.FeeBearer)
+}
+
+// AfterAmend prices the payment again, as its amount may have changed, keeping its bearer.
+func (fs *FeeService) AfterAmend(payment *paymentModel.Payment) error {
+	return fs.apply(payment, payment.FeeBearer)
+}
+
+//
+// private functions
+
+func (fs *FeeService) apply(payment *paymentModel.Payment, bearer string) error {
+
+	charge, errorCharge := fs.Compute(payment.Tenant, payment.Currency, payment.Amount, bearer)
+
+	if errorCharge != nil {
+		return errorCharge
+	}
+
+	payment.FeeAmount = charge.Amount
+	payment.FeeCurrency = charge.Currency
+	payment.FeeBearer = charge.Bearer
+
+	return nil
+}
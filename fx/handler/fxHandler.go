@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/fx/model"
+	"github.com/javierjmgits/go-payment-api/fx/service"
+	"net/http"
+	"time"
+)
+
+type FxHandler struct {
+	fxService *service.FxService
+}
+
+type RateView struct {
+	SourceCurrency string  `json:"sourceCurrency"`
+	TargetCurrency string  `json:"targetCurrency"`
+	Rate           float64 `json:"rate"`
+}
+
+type QuoteView struct {
+	Uid            string    `json:"uid"`
+	SourceCurrency string    `json:"sourceCurrency"`
+	TargetCurrency string    `json:"targetCurrency"`
+	Rate           float64   `json:"rate"`
+	SourceAmount   float64   `json:"sourceAmount"`
+	TargetAmount   float64   `json:"targetAmount"`
+	ExpiresAt      time.Time `json:"expiresAt"`
+	PaymentUid     *string   `json:"paymentUid"`
+}
+
+type QuoteCreate struct {
+	SourceCurrency string  `json:"sourceCurrency"`
+	TargetCurrency string  `json:"targetCurrency"`
+	SourceAmount   float64 `json:"sourceAmount"`
+}
+
+func NewFxHandler(fxService *service.FxService) *FxHandler {
+
+	return &FxHandler{
+		fxService: fxService,
+	}
+}
+
+func (fh *FxHandler) Register(router *mux.Router) {
+	router.HandleFunc("/api/v1/fx/rates", fh.GetRate).Methods("GET")
+	router.HandleFunc("/api/v1/fx/quotes", fh.CreateQuote).Methods("POST")
+	router.HandleFunc("/api/v1/fx/quotes/uid/{uid}", fh.GetQuoteByUid).Methods("GET")
+}
+
+func (fh *FxHandler) GetRate(w http.ResponseWriter, r *http.Request) {
+
+	sourceCurrency := r.URL.Query().Get("source")
+	targetCurrency := r.URL.Query().Get("target")
+
+	rate, errorRate := fh.fxService.Rate(sourceCurrency, targetCurrency)
+
+	if errorRate != nil {
+		util.WriteErrorFor(w, errorRate)
+		return
+	}
+
+	util.WritePayload(w, http.StatusOK, &RateView{
+		SourceCurrency: sourceCurrency,
+		TargetCurrency: targetCurrency,
+		Rate:           rate,
+	})
+}
+
+func (fh *FxHandler) CreateQuote(w http.ResponseWriter, r *http.Request) {
+
+	var quoteCreate QuoteCreate
+
+	errorJson := json.NewDecoder(r.Body).Decode(&quoteCreate)
+
+	if errorJson != nil {
+		util.WriteError(w, http.StatusBadRequest, errorJson.Error())
+		return
+	}
+
+	quote, errorQuote := fh.fxService.Quote(quoteCreate.SourceCurrency, quoteCreate.TargetCurrency, quoteCreate.SourceAmount)
+
+	if errorQuote != nil {
+		util.WriteErrorFor(w, errorQuote)
+		return
+	}
+
+	util.WritePayload(w, http.StatusCreated, newQuoteView(quote))
+}
+
+func (fh *FxHandler) GetQuoteByUid(w http.ResponseWriter, r *http.Request) {
+
+	quote, errorDB := fh.fxService.GetQuote(mux.Vars(r)["uid"])
+
+	if errorDB != nil {
+		util.WriteErrorFor(w, errorDB)
+		return
+	}
+
+	util.WritePayload(w, http.StatusOK, newQuoteView(quote))
+}
+
+//
+// private functions
+
+func newQuoteView(quote *model.Quote) *QuoteView {
+
+	return &QuoteView{
+		Uid:            quote.Uid,
+		SourceCurrency: quote.SourceCurrency,
+		TargetCurrency: quote.TargetCurrency,
+		Rate:           quote.Rate,
+		SourceAmount:   quote.SourceAmount,
+		TargetAmount:   quote.TargetAmount,
+		ExpiresAt:      quote.ExpiresAt,
+		PaymentUid:     quote.PaymentUid,
+	}
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/fx/model"
+	"github.com/javierjmgits/go-payment-api/fx/provider"
+	"github.com/javierjmgits/go-payment-api/fx/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+//
+// mocks
+
+type quoteRepositoryImplMock struct {
+	mock.Mock
+}
+
+func (mock *quoteRepositoryImplMock) GetByUid(uid string) (*model.Quote, error) {
+
+	args := mock.Mock.Called(uid)
+
+	result := args.Get(0)
+
+	if result != nil {
+		return result.(*model.Quote), nil
+	}
+
+	return nil, args.Get(1).(error)
+}
+
+func (mock *quoteRepositoryImplMock) Create(quote *model.Quote) (*model.Quote, error) {
+
+	mock.Mock.Called(quote)
+
+	return quote, nil
+}
+
+func (mock *quoteRepositoryImplMock) Consume(uid string, paymentUid string, now time.Time) (bool, error) {
+
+	args := mock.Mock.Called(uid, paymentUid)
+
+	return args.Bool(0), nil
+}
+
+func (mock *quoteRepositoryImplMock) Release(uid string, paymentUid string) error {
+
+	mock.Mock.Called(uid, paymentUid)
+
+	return nil
+}
+
+//
+// tests
+
+func TestGetRateKoUnknownCurrency(t *testing.T) {
+
+	router, mockRepository := setUp()
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/api/v1/fx/rates?source=EUR&target=JPY", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestGetRateCross(t *testing.T) {
+
+	router, mockRepository := setUp()
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/api/v1/fx/rates?source=GBP&target=USD", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	var rate RateView
+
+	json.Unmarshal(body, &rate)
+
+	// verify
+
+	assert.InDelta(t, 1.25, rate.Rate, 0.0001)
+}
+
+func TestCreateQuote(t *testing.T) {
+
+	router, mockRepository := setUp()
+	mockRepository.On("Create", mock.Anything).Return(nil)
+
+	quoteCreate := QuoteCreate{SourceCurrency: "eur", TargetCurrency: "USD", SourceAmount: 100}
+	quoteCreateAsBytes, _ := json.Marshal(quoteCreate)
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/fx/quotes", bytes.NewReader(quoteCreateAsBytes))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	var quote QuoteView
+
+	json.Unmarshal(body, &quote)
+
+	// verify
+
+	assert.NotEmpty(t, quote.Uid)
+	assert.Equal(t, "EUR", quote.SourceCurrency)
+	assert.Equal(t, 1.1, quote.Rate)
+	assert.Equal(t, 110.0, quote.TargetAmount)
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), quote.ExpiresAt, 2*time.Second)
+	assert.Nil(t, quote.PaymentUid)
+}
+
+//
+// private functions
+
+func setUp() (*mux.Router, *quoteRepositoryImplMock) {
+
+	var router = mux.NewRouter()
+	var mockRepository quoteRepositoryImplMock
+
+	rateProvider := provider.NewStaticRateProvider("EUR", map[string]float64{"USD": 1.1, "GBP": 0.88})
+
+	NewFxHandler(service.NewFxService(rateProvider, &mockRepository, 5*time.Minute)).Register(router)
+
+	return router, &mockRepository
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+type Quote struct {
+	gorm.Model
+	Uid            string    `gorm:"unique;not null"`
+	SourceCurrency string    `gorm:"not null"`
+	TargetCurrency string    `gorm:"not null"`
+	Rate           float64   `gorm:"not null"`
+	SourceAmount   float64   `gorm:"not null"`
+	TargetAmount   float64   `gorm:"not null"`
+	ExpiresAt      time.Time `gorm:"not null"`
+	PaymentUid     *string   `gorm:"null"`
+}
+
+func SetUp(db *gorm.DB) *gorm.DB {
+
+	db.AutoMigrate(&Quote{})
+
+	return db
+}
@@ -0,0 +1,5 @@
+package provider
+
+type RateProvider interface {
+	Rate(sourceCurrency string, targetCurrency string) (float64, error)
+}
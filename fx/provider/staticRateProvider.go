@@ -0,0 +1,80 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// staticRateProvider serves fixed rates against a base currency, deriving cross rates through it.
+type staticRateProvider struct {
+	base  string
+	rates map[string]float64
+}
+
+type staticRatesFile struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+func NewStaticRateProvider(base string, rates map[string]float64) RateProvider {
+
+	normalized := map[string]float64{strings.ToUpper(base): 1}
+
+	for currency, rate := range rates {
+		normalized[strings.ToUpper(currency)] = rate
+	}
+
+	return &staticRateProvider{
+		base:  strings.ToUpper(base),
+		rates: normalized,
+	}
+}
+
+// NewStaticRateProviderFromFile reads a JSON file like {"base": "EUR", "rates": {"USD": 1.0842, "GBP": 0.8571}}.
+func NewStaticRateProviderFromFile(path string) (RateProvider, error) {
+
+	file, errorOpen := os.Open(path)
+
+	if errorOpen != nil {
+		return nil, errorOpen
+	}
+
+	defer file.Close()
+
+	var ratesFile staticRatesFile
+
+	if errorJson := json.NewDecoder(file).Decode(&ratesFile); errorJson != nil {
+		return nil, fmt.Errorf("invalid rates file %s: %v", path, errorJson)
+	}
+
+	if ratesFile.Base == "" {
+		return nil, fmt.Errorf("invalid rates file %s: base currency is mandatory", path)
+	}
+
+	for currency, rate := range ratesFile.Rates {
+
+		if rate <= 0 {
+			return nil, fmt.Errorf("invalid rates file %s: rate for %s must be positive", path, currency)
+		}
+	}
+
+	return NewStaticRateProvider(ratesFile.Base, ratesFile.Rates), nil
+}
+
+func (srp *staticRateProvider) Rate(sourceCurrency string, targetCurrency string) (float64, error) {
+
+	if sourceCurrency == targetCurrency {
+		return 1, nil
+	}
+
+	sourceRate, sourceFound := srp.rates[sourceCurrency]
+	targetRate, targetFound := srp.rates[targetCurrency]
+
+	if !sourceFound || !targetFound {
+		return 0, fmt.Errorf("no exchange rate available for %s/%s", sourceCurrency, targetCurrency)
+	}
+
+	return targetRate / sourceRate, nil
+}
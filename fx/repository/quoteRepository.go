@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"github.com/javierjmgits/go-payment-api/fx/model"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+type QuoteRepository interface {
+	GetByUid(uid string) (*model.Quote, error)
+	Create(*model.Quote) (*model.Quote, error)
+	Consume(uid string, paymentUid string, now time.Time) (bool, error)
+	Release(uid string, paymentUid string) error
+}
+
+type quoteRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewQuoteRepositoryImpl(db *gorm.DB) QuoteRepository {
+	return &quoteRepositoryImpl{
+		db: db,
+	}
+}
+
+func (qri *quoteRepositoryImpl) GetByUid(uid string) (*model.Quote, error) {
+
+	var quote model.Quote
+	errorFind := qri.db.Where("uid = ?", uid).First(&quote).Error
+
+	if errorFind != nil {
+		return nil, errorFind
+	}
+
+	return &quote, nil
+}
+
+func (qri *quoteRepositoryImpl) Create(quote *model.Quote) (*model.Quote, error) {
+
+	errorDB := qri.db.Create(quote).Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return quote, nil
+}
+
+func (qri *quoteRepositoryImpl) Consume(uid string, paymentUid string, now time.Time) (bool, error) {
+
+	// conditional update, so a quote is only ever used by a single payment
+	result := qri.db.Model(&model.Quote{}).
+		Where("uid = ? AND payment_uid IS NULL AND expires_at > ?", uid, now).
+		Update("payment_uid", paymentUid)
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// Release makes a quote consumed by a payment that was not created usable again, until it expires.
+func (qri *quoteRepositoryImpl) Release(uid string, paymentUid string) error {
+
+	return qri.db.Model(&model.Quote{}).
+		Where("uid = ? AND payment_uid = ?", uid, paymentUid).
+		Update("payment_uid", gorm.Expr("NULL")).Error
+}
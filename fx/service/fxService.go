@@ -0,0 +1,160 @@
+package service
+
+import (
+	"fmt"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/fx/model"
+	"github.com/javierjmgits/go-payment-api/fx/provider"
+	"github.com/javierjmgits/go-payment-api/fx/repository"
+	paymentHandler "github.com/javierjmgits/go-payment-api/payment/handler"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/satori/go.uuid"
+	"log"
+	"math"
+	"strings"
+	"time"
+)
+
+// amounts are tolerated to differ from the quoted one by rounding noise only
+const AMOUNT_TOLERANCE = 0.001
+
+type FxService struct {
+	rateProvider    provider.RateProvider
+	quoteRepository repository.QuoteRepository
+	quoteTTL        time.Duration
+	now             func() time.Time
+}
+
+func NewFxService(rateProvider provider.RateProvider, quoteRepository repository.QuoteRepository, quoteTTL time.Duration) *FxService {
+
+	return &FxService{
+		rateProvider:    rateProvider,
+		quoteRepository: quoteRepository,
+		quoteTTL:        quoteTTL,
+		now:             time.Now,
+	}
+}
+
+func (fs *FxService) Rate(sourceCurrency string, targetCurrency string) (float64, error) {
+
+	rate, errorRate := fs.rateProvider.Rate(strings.ToUpper(sourceCurrency), strings.ToUpper(targetCurrency))
+
+	if errorRate != nil {
+		return 0, &util.InputError{Message: errorRate.Error()}
+	}
+
+	return rate, nil
+}
+
+func (fs *FxService) Quote(sourceCurrency string, targetCurrency string, sourceAmount float64) (*model.Quote, error) {
+
+	if sourceAmount <= 0 {
+		return nil, &util.InputError{Message: "amount must be a positive number"}
+	}
+
+	rate, errorRate := fs.Rate(sourceCurrency, targetCurrency)
+
+	if errorRate != nil {
+		return nil, errorRate
+	}
+
+	uuidResult, errorUuid := uuid.NewV4()
+
+	if errorUuid != nil {
+		return nil, errorUuid
+	}
+
+	return fs.quoteRepository.Create(&model.Quote{
+		Uid:            uuidResult.String(),
+		SourceCurrency: strings.ToUpper(sourceCurrency),
+		TargetCurrency: strings.ToUpper(targetCurrency),
+		Rate:           rate,
+		SourceAmount:   sourceAmount,
+		TargetAmount:   roundAmount(sourceAmount * rate),
+		ExpiresAt:      fs.now().UTC().Add(fs.quoteTTL).Truncate(time.Second),
+	})
+}
+
+func (fs *FxService) GetQuote(uid string) (*model.Quote, error) {
+	return fs.quoteRepository.GetByUid(uid)
+}
+
+// BeforeCreate converts the payment amount, honouring the locked rate of a quote when one is given.
+func (fs *FxService) BeforeCreate(payment *paymentModel.Payment, paymentCreate *paymentHandler.PaymentCreate) error {
+
+	if paymentCreate.FxQuoteUid != "" {
+		return fs.applyQuote(payment, paymentCreate.FxQuoteUid)
+	}
+
+	if payment.Currency == payment.TargetCurrency {
+		return nil
+	}
+
+	rate, errorRate := fs.Rate(payment.Currency, payment.TargetCurrency)
+
+	if errorRate != nil {
+		return errorRate
+	}
+
+	payment.FxRate = rate
+	payment.TargetAmount = roundAmount(payment.Amount * rate)
+
+	return nil
+}
+
+// AbortCreate releases the quote a payment consumed when the payment is refused after the FX hook, e.g. by a limit.
+func (fs *FxService) AbortCreate(payment *paymentModel.Payment) {
+
+	if payment.FxQuoteUid == nil {
+		return
+	}
+
+	if errorDB := fs.quoteRepository.Release(*payment.FxQuoteUid, payment.Uid); errorDB != nil {
+		log.Printf("Error releasing quote %s of payment %s: %v\n", *payment.FxQuoteUid, payment.Uid, errorDB)
+	}
+}
+
+//
+// private functions
+
+func (fs *FxService) applyQuote(payment *paymentModel.Payment, quoteUid string) error {
+
+	quote, errorDB := fs.quoteRepository.GetByUid(quoteUid)
+
+	if errorDB != nil {
+
+		if strings.Contains(errorDB.Error(), "not found") {
+			return &util.InputError{Message: fmt.Sprintf("quote '%s' not found", quoteUid)}
+		}
+
+		return errorDB
+	}
+
+	if quote.SourceCurrency != payment.Currency || quote.TargetCurrency != payment.TargetCurrency {
+		return &util.InputError{Message: fmt.Sprintf("quote is for %s/%s, not %s/%s", quote.SourceCurrency, quote.TargetCurrency, payment.Currency, payment.TargetCurrency)}
+	}
+
+	if math.Abs(quote.SourceAmount-payment.Amount) > AMOUNT_TOLERANCE {
+		return &util.InputError{Message: fmt.Sprintf("quote is for an amount of %v", quote.SourceAmount)}
+	}
+
+	consumed, errorDB := fs.quoteRepository.Consume(quoteUid, payment.Uid, fs.now().UTC())
+
+	if errorDB != nil {
+		return errorDB
+	}
+
+	if !consumed {
+		return &util.ConflictError{Message: "quote has expired or was already used"}
+	}
+
+	payment.FxRate = quote.Rate
+	payment.TargetAmount = quote.TargetAmount
+	payment.FxQuoteUid = &quote.Uid
+
+	return nil
+}
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/fx/model"
+	"github.com/javierjmgits/go-payment-api/fx/provider"
+	paymentHandler "github.com/javierjmgits/go-payment-api/payment/handler"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+//
+// mocks
+
+type quoteRepositoryImplMock struct {
+	quote    *model.Quote
+	consumed bool
+}
+
+func (mock *quoteRepositoryImplMock) GetByUid(uid string) (*model.Quote, error) {
+	return mock.quote, nil
+}
+
+func (mock *quoteRepositoryImplMock) Create(quote *model.Quote) (*model.Quote, error) {
+	return quote, nil
+}
+
+func (mock *quoteRepositoryImplMock) Consume(uid string, paymentUid string, now time.Time) (bool, error) {
+
+	if mock.consumed || !mock.quote.ExpiresAt.After(now) {
+		return false, nil
+	}
+
+	mock.consumed = true
+
+	return true, nil
+}
+
+func (mock *quoteRepositoryImplMock) Release(uid string, paymentUid string) error {
+
+	mock.consumed = false
+
+	return nil
+}
+
+//
+// tests
+
+func TestBeforeCreateSpotRate(t *testing.T) {
+
+	fxService, _ := setUp()
+	payment := expectedPayment("USD")
+
+	errorHook := fxService.BeforeCreate(payment, &paymentHandler.PaymentCreate{})
+
+	assert.Nil(t, errorHook)
+	assert.Equal(t, 1.1, payment.FxRate)
+	assert.Equal(t, 110.0, payment.TargetAmount)
+	assert.Nil(t, payment.FxQuoteUid)
+}
+
+func TestBeforeCreateKoNoRate(t *testing.T) {
+
+	fxService, _ := setUp()
+	payment := expectedPayment("JPY")
+
+	errorHook := fxService.BeforeCreate(payment, &paymentHandler.PaymentCreate{})
+
+	assert.IsType(t, &util.InputError{}, errorHook)
+}
+
+func TestBeforeCreateLockedQuote(t *testing.T) {
+
+	fxService, mockRepository := setUp()
+	mockRepository.quote = expectedQuote(time.Now().Add(time.Minute))
+	payment := expectedPayment("USD")
+
+	errorHook := fxService.BeforeCreate(payment, &paymentHandler.PaymentCreate{FxQuoteUid: "myQuote"})
+
+	assert.Nil(t, errorHook)
+	assert.Equal(t, 1.05, payment.FxRate)
+	assert.Equal(t, 105.0, payment.TargetAmount)
+	assert.Equal(t, "myQuote", *payment.FxQuoteUid)
+
+	errorReuse := fxService.BeforeCreate(expectedPayment("USD"), &paymentHandler.PaymentCreate{FxQuoteUid: "myQuote"})
+
+	assert.IsType(t, &util.ConflictError{}, errorReuse)
+}
+
+func TestAbortCreateReleasesQuote(t *testing.T) {
+
+	fxService, mockRepository := setUp()
+	mockRepository.quote = expectedQuote(time.Now().Add(time.Minute))
+	payment := expectedPayment("USD")
+
+	fxService.BeforeCreate(payment, &paymentHandler.PaymentCreate{FxQuoteUid: "myQuote"})
+
+	// e.g. a limit refused the payment
+	fxService.AbortCreate(payment)
+
+	errorReuse := fxService.BeforeCreate(expectedPayment("USD"), &paymentHandler.PaymentCreate{FxQuoteUid: "myQuote"})
+
+	assert.Nil(t, errorReuse)
+	assert.True(t, mockRepository.consumed)
+}
+
+func TestBeforeCreateKoExpiredQuote(t *testing.T) {
+
+	fxService, mockRepository := setUp()
+	mockRepository.quote = expectedQuote(time.Now().Add(-time.Minute))
+
+	errorHook := fxService.BeforeCreate(expectedPayment("USD"), &paymentHandler.PaymentCreate{FxQuoteUid: "myQuote"})
+
+	assert.IsType(t, &util.ConflictError{}, errorHook)
+}
+
+func TestBeforeCreateKoQuoteMismatch(t *testing.T) {
+
+	fxService, mockRepository := setUp()
+	mockRepository.quote = expectedQuote(time.Now().Add(time.Minute))
+	payment := expectedPayment("USD")
+	payment.Amount = 50
+
+	errorHook := fxService.BeforeCreate(payment, &paymentHandler.PaymentCreate{FxQuoteUid: "myQuote"})
+
+	assert.IsType(t, &util.InputError{}, errorHook)
+	assert.False(t, mockRepository.consumed)
+}
+
+//
+// private functions
+
+func setUp() (*FxService, *quoteRepositoryImplMock) {
+
+	var mockRepository quoteRepositoryImplMock
+
+	rateProvider := provider.NewStaticRateProvider("EUR", map[string]float64{"USD": 1.1})
+
+	return NewFxService(rateProvider, &mockRepository, time.Minute), &mockRepository
+}
+
+func expectedPayment(targetCurrency string) *paymentModel.Payment {
+
+	return &paymentModel.Payment{
+		Uid:            "myUid",
+		Amount:         100,
+		Currency:       "EUR",
+		TargetCurrency: targetCurrency,
+		TargetAmount:   100,
+		FxRate:         1,
+	}
+}
+
+func expectedQuote(expiresAt time.Time) *model.Quote {
+
+	return &model.Quote{
+		Uid:            "myQuote",
+		SourceCurrency: "EUR",
+		TargetCurrency: "USD",
+		Rate:           1.05,
+		SourceAmount:   100,
+		TargetAmount:   105,
+		ExpiresAt:      expiresAt,
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	"github.com/javierjmgits/go-payment-api/base/config"
+	"github.com/javierjmgits/go-payment-api/batch/importer"
+	batchModel "github.com/javierjmgits/go-payment-api/batch/model"
+	batchRepository "github.com/javierjmgits/go-payment-api/batch/repository"
+	"github.com/javierjmgits/go-payment-api/payment/repository"
+	"log"
+	"os"
+)
+
+func runImportCommand(config *config.Config, args []string) {
+
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	name := flags.String("name", "", "name of the batch")
+	format := flags.String("format", batchModel.FORMAT_CSV, "format of the file (csv or pain.001)")
+	user := flags.String("user", "", "user on whose behalf the payments are created")
+	tenant := flags.String("tenant", "", "tenant of the payments")
+
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: go-payment-api import -name <name> -user <user> [-tenant <tenant>] [-format csv|pain.001] <file>")
+		flags.PrintDefaults()
+	}
+
+	flags.Parse(args)
+
+	// approvals and reviews tell the creator of a payment apart from its reviewers
+	if flags.NArg() != 1 || *user == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	file, errorOpen := os.Open(flags.Arg(0))
+
+	if errorOpen != nil {
+		log.Fatal("Error opening file: ", errorOpen)
+	}
+
+	defer file.Close()
+
+	configureAccountIdentifiers(config)
+
+	db := openDB(config)
+
+	defer db.Close()
+
+	// the payments of the batch go through the same hooks and limits as the ones of the API
+	paymentHandler := newPaymentHandler(config, db, repository.NewPaymentRepositoryImpl(db), newPaymentServices(config, db))
+
+	batch, errorImport := importer.NewBatchImporter(batchRepository.NewBatchRepositoryImpl(db), paymentHandler).Import(*name, *format, file, &auth.Principal{User: *user, Tenant: *tenant})
+
+	if errorImport != nil {
+		log.Fatal("Error importing batch: ", errorImport)
+	}
+
+	fmt.Printf("Batch %s (%s): %s, %d valid, %d invalid\n", batch.Name, batch.Uid, batch.Status, batch.ValidCount, batch.InvalidCount)
+
+	for _, batchError := range batch.Errors {
+		fmt.Printf("  line %d: %s\n", batchError.Line, batchError.Message)
+	}
+}
@@ -0,0 +1,263 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/account/identifier"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/limit/model"
+	"github.com/javierjmgits/go-payment-api/limit/repository"
+	"github.com/satori/go.uuid"
+	"net/http"
+	"strings"
+)
+
+type LimitHandler struct {
+	limitRepository repository.LimitRepository
+}
+
+type LimitView struct {
+	Uid            string  `json:"uid"`
+	Account        string  `json:"account"`
+	Currency       string  `json:"currency"`
+	PerTransaction float64 `json:"perTransaction"`
+	Daily          float64 `json:"daily"`
+	Monthly        float64 `json:"monthly"`
+	DailyCount     int     `json:"dailyCount"`
+	MonthlyCount   int     `json:"monthlyCount"`
+}
+
+type LimitCreate struct {
+	Account        string  `json:"account"`
+	Currency       string  `json:"currency"`
+	PerTransaction float64 `json:"perTransaction"`
+	Daily          float64 `json:"daily"`
+	Monthly        float64 `json:"monthly"`
+	DailyCount     int     `json:"dailyCount"`
+	MonthlyCount   int     `json:"monthlyCount"`
+}
+
+func NewLimitHandler(limitRepository repository.LimitRepository) *LimitHandler {
+
+	return &LimitHandler{
+		limitRepository: limitRepository,
+	}
+}
+
+func (lh *LimitHandler) Register(router *mux.Router) {
+	router.HandleFunc("/api/v1/limits", lh.GetLimits).Methods("GET")
+	router.HandleFunc("/api/v1/limits/uid/{uid}", lh.GetLimitByUid).Methods("GET")
+	router.HandleFunc("/api/v1/limits", lh.CreateLimit).Methods("POST")
+	router.HandleFunc("/api/v1/limits/uid/{uid}", lh.UpdateLimitByUid).Methods("PUT")
+	router.HandleFunc("/api/v1/limits/uid/{uid}", lh.DeleteLimitByUid).Methods("DELETE")
+}
+
+func (lh *LimitHandler) GetLimits(w http.ResponseWriter, r *http.Request) {
+
+	limits, errorDB := lh.limitRepository.GetAll()
+
+	if errorDB != nil {
+		util.WriteError(w, http.StatusInternalServerError, errorDB.Error())
+		return
+	}
+
+	var results []LimitView
+
+	for index := range limits {
+		results = append(results, *newLimitView(&limits[index]))
+	}
+
+	util.WritePayload(w, http.StatusOK, results)
+}
+
+func (lh *LimitHandler) GetLimitByUid(w http.ResponseWriter, r *http.Request) {
+
+	limit, errorDB := lh.limitRepository.GetByUid(mux.Vars(r)["uid"])
+
+	if errorDB != nil {
+		util.WriteErrorFor(w, errorDB)
+		return
+	}
+
+	util.WritePayload(w, http.StatusOK, newLimitView(limit))
+}
+
+func (lh *LimitHandler) CreateLimit(w http.ResponseWriter, r *http.Request) {
+
+	var limitCreate LimitCreate
+
+	errorJson := json.NewDecoder(r.Body).Decode(&limitCreate)
+
+	if errorJson != nil {
+		util.WriteError(w, http.StatusBadRequest, errorJson.Error())
+		return
+	}
+
+	errorValidation := validateLimitCreate(&limitCreate)
+
+	if errorValidation != nil {
+		util.WriteError(w, http.StatusBadRequest, errorValidation.Error())
+		return
+	}
+
+	existing, errorDB := lh.limitRepository.GetFor(limitCreate.Account, limitCreate.Currency)
+
+	if errorDB != nil {
+		util.WriteError(w, http.StatusInternalServerError, errorDB.Error())
+		return
+	}
+
+	// GetFor falls back to the default limit, which only conflicts when creating the default itself
+	if existing != nil && existing.Account == limitCreate.Account {
+		util.WriteError(w, http.StatusConflict, fmt.Sprintf("a %s limit already exists for this account", limitCreate.Currency))
+		return
+	}
+
+	uuidResult, errorUuid := uuid.NewV4()
+
+	if errorUuid != nil {
+		util.WriteError(w, http.StatusInternalServerError, errorUuid.Error())
+		return
+	}
+
+	limit := &model.Limit{
+		Uid:      uuidResult.String(),
+		Account:  limitCreate.Account,
+		Currency: limitCreate.Currency,
+	}
+
+	applyValues(limit, &limitCreate)
+
+	limit, errorDB = lh.limitRepository.Create(limit)
+
+	if errorDB != nil {
+		util.WriteError(w, http.StatusInternalServerError, errorDB.Error())
+		return
+	}
+
+	util.WritePayload(w, http.StatusCreated, newLimitView(limit))
+}
+
+func (lh *LimitHandler) UpdateLimitByUid(w http.ResponseWriter, r *http.Request) {
+
+	limit, errorDB := lh.limitRepository.GetByUid(mux.Vars(r)["uid"])
+
+	if errorDB != nil {
+		util.WriteErrorFor(w, errorDB)
+		return
+	}
+
+	var limitUpdate LimitCreate
+
+	errorJson := json.NewDecoder(r.Body).Decode(&limitUpdate)
+
+	if errorJson != nil {
+		util.WriteError(w, http.StatusBadRequest, errorJson.Error())
+		return
+	}
+
+	// account and currency identify the limit and cannot change
+	limitUpdate.Account = limit.Account
+	limitUpdate.Currency = limit.Currency
+
+	errorValidation := validateLimitCreate(&limitUpdate)
+
+	if errorValidation != nil {
+		util.WriteError(w, http.StatusBadRequest, errorValidation.Error())
+		return
+	}
+
+	applyValues(limit, &limitUpdate)
+
+	limit, errorDB = lh.limitRepository.Update(limit)
+
+	if errorDB != nil {
+		util.WriteError(w, http.StatusInternalServerError, errorDB.Error())
+		return
+	}
+
+	util.WritePayload(w, http.StatusOK, newLimitView(limit))
+}
+
+func (lh *LimitHandler) DeleteLimitByUid(w http.ResponseWriter, r *http.Request) {
+
+	limit, errorDB := lh.limitRepository.GetByUid(mux.Vars(r)["uid"])
+
+	if errorDB != nil {
+		util.WriteErrorFor(w, errorDB)
+		return
+	}
+
+	errorDB = lh.limitRepository.Delete(limit)
+
+	if errorDB != nil {
+		util.WriteError(w, http.StatusInternalServerError, errorDB.Error())
+		return
+	}
+
+	util.WritePayload(w, http.StatusNoContent, map[string]string{})
+}
+
+//
+// private functions
+
+func validateLimitCreate(limitCreate *LimitCreate) error {
+
+	if limitCreate.Account != "" {
+
+		account, errorAccount := identifier.Normalize(limitCreate.Account)
+
+		if errorAccount != nil {
+			return errors.New("account " + errorAccount.Error())
+		}
+
+		limitCreate.Account = account
+	}
+
+	limitCreate.Currency = strings.ToUpper(limitCreate.Currency)
+
+	if !util.IsCurrencyCode(limitCreate.Currency) {
+		return errors.New("currency must be an ISO 4217 code")
+	}
+
+	if limitCreate.PerTransaction < 0 || limitCreate.Daily < 0 || limitCreate.Monthly < 0 || limitCreate.DailyCount < 0 || limitCreate.MonthlyCount < 0 {
+		return errors.New("limits must not be negative")
+	}
+
+	if limitCreate.PerTransaction == 0 && limitCreate.Daily == 0 && limitCreate.Monthly == 0 && limitCreate.DailyCount == 0 && limitCreate.MonthlyCount == 0 {
+		return errors.New("at least one limit must be set")
+	}
+
+	return nil
+}
+
+func applyValues(limit *model.Limit, limitCreate *LimitCreate) {
+
+	limit.PerTransaction = limitCreate.PerTransaction
+	limit.Daily = limitCreate.Daily
+	limit.Monthly = limitCreate.Monthly
+	limit.DailyCount = limitCreate.DailyCount
+	limit.MonthlyCount = limitCreate.MonthlyCount
+}
+
+func newLimitView(limit *model.Limit) *LimitView {
+
+	account := limit.Account
+
+	if account != "" {
+		account = identifier.Format(account)
+	}
+
+	return &LimitView{
+		Uid:            limit.Uid,
+		Account:        account,
+		Currency:       limit.Currency,
+		PerTransaction: limit.PerTransaction,
+		Daily:          limit.Daily,
+		Monthly:        limit.Monthly,
+		DailyCount:     limit.DailyCount,
+		MonthlyCount:   limit.MonthlyCount,
+	}
+}
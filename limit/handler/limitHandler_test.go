@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/limit/model"
+	"github.com/javierjmgits/go-payment-api/limit/repository"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+//
+// mocks
+
+type limitRepositoryImplMock struct {
+	mock.Mock
+}
+
+func (mock *limitRepositoryImplMock) GetAll() ([]model.Limit, error) {
+
+	args := mock.Mock.Called()
+
+	return args.Get(0).([]model.Limit), nil
+}
+
+func (mock *limitRepositoryImplMock) GetByUid(uid string) (*model.Limit, error) {
+
+	args := mock.Mock.Called(uid)
+
+	result := args.Get(0)
+
+	if result != nil {
+		return result.(*model.Limit), nil
+	}
+
+	return nil, args.Get(1).(error)
+}
+
+func (mock *limitRepositoryImplMock) GetFor(account string, currency string) (*model.Limit, error) {
+
+	args := mock.Mock.Called(account, currency)
+
+	result := args.Get(0)
+
+	if result != nil {
+		return result.(*model.Limit), nil
+	}
+
+	return nil, nil
+}
+
+func (mock *limitRepositoryImplMock) Create(limit *model.Limit) (*model.Limit, error) {
+
+	mock.Mock.Called(limit)
+
+	return limit, nil
+}
+
+func (mock *limitRepositoryImplMock) Update(limit *model.Limit) (*model.Limit, error) {
+
+	mock.Mock.Called(limit)
+
+	return limit, nil
+}
+
+func (mock *limitRepositoryImplMock) Delete(limit *model.Limit) error {
+
+	mock.Mock.Called(limit)
+
+	return nil
+}
+
+func (mock *limitRepositoryImplMock) CreatePayment(payment *paymentModel.Payment, daySince time.Time, monthSince time.Time, check func(*model.Limit, *repository.Usage) error) (*paymentModel.Payment, error) {
+	return payment, nil
+}
+
+func (mock *limitRepositoryImplMock) AmendPayment(uid string, daySince time.Time, monthSince time.Time, amend func(*paymentModel.Payment, int) ([]paymentModel.PaymentAmendment, error), check func(*paymentModel.Payment, *model.Limit, *repository.Usage) error) (*paymentModel.Payment, error) {
+	return nil, nil
+}
+
+//
+// tests
+
+func TestCreateLimitKoNoValues(t *testing.T) {
+
+	router, mockRepository := setUp()
+
+	resp := sendLimit(router, "POST", "http://localhost:8080/api/v1/limits", LimitCreate{Currency: "EUR"})
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestCreateLimitKoAlreadyExists(t *testing.T) {
+
+	router, mockRepository := setUp()
+	mockRepository.On("GetFor", "GB29NWBK60161331926819", "EUR").Return(&model.Limit{Account: "GB29NWBK60161331926819", Currency: "EUR"})
+
+	resp := sendLimit(router, "POST", "http://localhost:8080/api/v1/limits", LimitCreate{Account: "GB29 NWBK 6016 1331 9268 19", Currency: "eur", Daily: 1000})
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestCreateLimit(t *testing.T) {
+
+	router, mockRepository := setUp()
+
+	// only the default limit exists, the account can have its own
+	mockRepository.On("GetFor", "GB29NWBK60161331926819", "EUR").Return(&model.Limit{Currency: "EUR"})
+	mockRepository.On("Create", mock.Anything).Return(nil)
+
+	resp := sendLimit(router, "POST", "http://localhost:8080/api/v1/limits", LimitCreate{Account: "GB29 NWBK 6016 1331 9268 19", Currency: "eur", Daily: 1000, MonthlyCount: 20})
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	var limit LimitView
+
+	json.Unmarshal(body, &limit)
+
+	// verify
+
+	assert.NotEmpty(t, limit.Uid)
+	assert.Equal(t, "EUR", limit.Currency)
+	assert.Equal(t, 1000.0, limit.Daily)
+	assert.Equal(t, 20, limit.MonthlyCount)
+}
+
+func TestUpdateLimitKeepsAccount(t *testing.T) {
+
+	router, mockRepository := setUp()
+	mockRepository.On("GetByUid", "myUid").Return(&model.Limit{Uid: "myUid", Currency: "EUR", Daily: 1000})
+	mockRepository.On("Update", mock.Anything).Return(nil)
+
+	resp := sendLimit(router, "PUT", "http://localhost:8080/api/v1/limits/uid/myUid", LimitCreate{Account: "GB29 NWBK 6016 1331 9268 19", Currency: "USD", Monthly: 5000})
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	var limit LimitView
+
+	json.Unmarshal(body, &limit)
+
+	// verify
+
+	assert.Equal(t, "", limit.Account)
+	assert.Equal(t, "EUR", limit.Currency)
+	assert.Equal(t, 0.0, limit.Daily)
+	assert.Equal(t, 5000.0, limit.Monthly)
+}
+
+func TestDeleteLimitKoNotFound(t *testing.T) {
+
+	router, mockRepository := setUp()
+	mockRepository.On("GetByUid", "unknown").Return(nil, errors.New("record not found"))
+
+	resp := sendLimit(router, "DELETE", "http://localhost:8080/api/v1/limits/uid/unknown", nil)
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+//
+// private functions
+
+func setUp() (*mux.Router, *limitRepositoryImplMock) {
+
+	var router = mux.NewRouter()
+	var mockRepository limitRepositoryImplMock
+
+	NewLimitHandler(&mockRepository).Register(router)
+
+	return router, &mockRepository
+}
+
+func sendLimit(router *mux.Router, method string, url string, limit interface{}) *http.Response {
+
+	limitAsBytes, _ := json.Marshal(limit)
+
+	req := httptest.NewRequest(method, url, bytes.NewReader(limitAsBytes))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	return w.Result()
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// Limit caps what an origin account can spend in a currency; an empty account makes it the default for every
+// account without its own limit, and a zero value leaves that dimension unlimited.
+type Limit struct {
+	gorm.Model
+	Uid            string  `gorm:"unique;not null"`
+	Account        string  `gorm:"not null;default:'';unique_index:idx_limit_account_currency"`
+	Currency       string  `gorm:"not null;unique_index:idx_limit_account_currency"`
+	PerTransaction float64 `gorm:"not null;default:0"`
+	Daily          float64 `gorm:"not null;default:0"`
+	Monthly        float64 `gorm:"not null;default:0"`
+	DailyCount     int     `gorm:"not null;default:0"`
+	MonthlyCount   int     `gorm:"not null;default:0"`
+}
+
+// AccountLock is a row per origin account, locked while a payment is checked against its limits and created.
+type AccountLock struct {
+	Account string `gorm:"primary_key"`
+}
+
+func SetUp(db *gorm.DB) *gorm.DB {
+
+	db.AutoMigrate(&Limit{}, &AccountLock{})
+
+	return db
+}
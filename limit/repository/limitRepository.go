@@ -0,0 +1,292 @@
+package repository
+
+import (
+	"github.com/javierjmgits/go-payment-api/limit/model"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+// Usage is what an origin account has already spent in a currency.
+type Usage struct {
+	DailyAmount   float64
+	DailyCount    int
+	MonthlyAmount float64
+	MonthlyCount  int
+}
+
+type LimitRepository interface {
+	GetAll() ([]model.Limit, error)
+	GetByUid(uid string) (*model.Limit, error)
+	GetFor(account string, currency string) (*model.Limit, error)
+	Create(*model.Limit) (*model.Limit, error)
+	Update(*model.Limit) (*model.Limit, error)
+	Delete(*model.Limit) error
+	CreatePayment(payment *paymentModel.Payment, daySince time.Time, monthSince time.Time, check func(*model.Limit, *Usage) error) (*paymentModel.Payment, error)
+	AmendPayment(uid string, daySince time.Time, monthSince time.Time, amend func(*paymentModel.Payment, int) ([]paymentModel.PaymentAmendment, error), check func(*paymentModel.Payment, *model.Limit, *Usage) error) (*paymentModel.Payment, error)
+}
+
+type limitRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewLimitRepositoryImpl(db *gorm.DB) LimitRepository {
+	return &limitRepositoryImpl{
+		db: db,
+	}
+}
+
+func (lri *limitRepositoryImpl) GetAll() ([]model.Limit, error) {
+
+	var limits []model.Limit
+	errorDB := lri.db.Order("account, currency").Find(&limits).Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return limits, nil
+}
+
+func (lri *limitRepositoryImpl) GetByUid(uid string) (*model.Limit, error) {
+
+	var limit model.Limit
+	errorFind := lri.db.Where("uid = ?", uid).First(&limit).Error
+
+	if errorFind != nil {
+		return nil, errorFind
+	}
+
+	return &limit, nil
+}
+
+func (lri *limitRepositoryImpl) GetFor(account string, currency string) (*model.Limit, error) {
+	return getFor(lri.db, account, currency)
+}
+
+func (lri *limitRepositoryImpl) Create(limit *model.Limit) (*model.Limit, error) {
+
+	errorDB := lri.db.Create(limit).Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return limit, nil
+}
+
+func (lri *limitRepositoryImpl) Update(limit *model.Limit) (*model.Limit, error) {
+
+	errorDB := lri.db.Save(limit).Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return limit, nil
+}
+
+func (lri *limitRepositoryImpl) Delete(limit *model.Limit) error {
+	return lri.db.Delete(limit).Error
+}
+
+func (lri *limitRepositoryImpl) CreatePayment(payment *paymentModel.Payment, daySince time.Time, monthSince time.Time, check func(*model.Limit, *Usage) error) (*paymentModel.Payment, error) {
+
+	tx := lri.db.Begin()
+
+	// payments from the same account are serialized on its lock row, so two of them cannot both fit the last headroom
+	errorDB := lockAccount(tx, payment.AccountOrigin)
+
+	if errorDB != nil {
+		tx.Rollback()
+		return nil, errorDB
+	}
+
+	limit, errorDB := getFor(tx, payment.AccountOrigin, payment.Currency)
+
+	if errorDB != nil {
+		tx.Rollback()
+		return nil, errorDB
+	}
+
+	if limit != nil {
+
+		usage, errorDB := usageOf(tx, payment, daySince, monthSince)
+
+		if errorDB != nil {
+			tx.Rollback()
+			return nil, errorDB
+		}
+
+		if errorCheck := check(limit, usage); errorCheck != nil {
+			tx.Rollback()
+			return nil, errorCheck
+		}
+	}
+
+	errorDB = tx.Create(payment).Error
+
+	if errorDB != nil {
+		tx.Rollback()
+		return nil, errorDB
+	}
+
+	errorDB = tx.Commit().Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return payment, nil
+}
+
+// AmendPayment amends the payment as the payment repository does, checking the amended payment against the limits of
+// its origin account under the lock of the account. The usage leaves the payment itself out.
+func (lri *limitRepositoryImpl) AmendPayment(uid string, daySince time.Time, monthSince time.Time, amend func(*paymentModel.Payment, int) ([]paymentModel.PaymentAmendment, error), check func(*paymentModel.Payment, *model.Limit, *Usage) error) (*paymentModel.Payment, error) {
+
+	tx := lri.db.Begin()
+
+	var payment paymentModel.Payment
+	errorDB := tx.Set("gorm:query_option", "FOR UPDATE").Where("uid = ?", uid).First(&payment).Error
+
+	if errorDB != nil {
+		tx.Rollback()
+		return nil, errorDB
+	}
+
+	var revision struct{ Last int }
+	errorDB = tx.Model(&paymentModel.PaymentAmendment{}).Select("COALESCE(MAX(revision), 0) AS last").Where("payment_uid = ?", uid).Scan(&revision).Error
+
+	if errorDB != nil {
+		tx.Rollback()
+		return nil, errorDB
+	}
+
+	amendments, errorAmend := amend(&payment, revision.Last+1)
+
+	if errorAmend != nil {
+		tx.Rollback()
+		return nil, errorAmend
+	}
+
+	if len(amendments) == 0 {
+		tx.Rollback()
+		return &payment, nil
+	}
+
+	// the account may be another one than before the amendment, so it is only known, and locked, now
+	errorDB = lockAccount(tx, payment.AccountOrigin)
+
+	if errorDB != nil {
+		tx.Rollback()
+		return nil, errorDB
+	}
+
+	limit, errorDB := getFor(tx, payment.AccountOrigin, payment.Currency)
+
+	if errorDB != nil {
+		tx.Rollback()
+		return nil, errorDB
+	}
+
+	if limit != nil {
+
+		usage, errorDB := usageOf(tx, &payment, daySince, monthSince)
+
+		if errorDB != nil {
+			tx.Rollback()
+			return nil, errorDB
+		}
+
+		if errorCheck := check(&payment, limit, usage); errorCheck != nil {
+			tx.Rollback()
+			return nil, errorCheck
+		}
+	}
+
+	if errorDB := tx.Save(&payment).Error; errorDB != nil {
+		tx.Rollback()
+		return nil, errorDB
+	}
+
+	for index := range amendments {
+
+		if errorDB := tx.Create(&amendments[index]).Error; errorDB != nil {
+			tx.Rollback()
+			return nil, errorDB
+		}
+	}
+
+	errorDB = tx.Commit().Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return &payment, nil
+}
+
+//
+// private functions
+
+// lockAccount locks the lock row of an account, creating it first when needed: locking a row that does not exist yet
+// only takes a gap lock, which two transactions can hold at once before both inserting it and deadlocking.
+func lockAccount(tx *gorm.DB, account string) error {
+
+	tableName := tx.NewScope(&model.AccountLock{}).TableName()
+
+	if errorDB := tx.Exec("INSERT IGNORE INTO "+tableName+" (account) VALUES (?)", account).Error; errorDB != nil {
+		return errorDB
+	}
+
+	var lock model.AccountLock
+
+	return tx.Set("gorm:query_option", "FOR UPDATE").Where("account = ?", account).First(&lock).Error
+}
+
+// getFor returns the limit of the account, falling back to the default one, or nil when there is none.
+func getFor(db *gorm.DB, account string, currency string) (*model.Limit, error) {
+
+	var limits []model.Limit
+	errorDB := db.Where("account IN (?) AND currency = ?", []string{account, ""}, currency).
+		Order("account DESC").
+		Limit(1).
+		Find(&limits).Error
+
+	if errorDB != nil || len(limits) == 0 {
+		return nil, errorDB
+	}
+
+	return &limits[0], nil
+}
+
+func usageOf(tx *gorm.DB, payment *paymentModel.Payment, daySince time.Time, monthSince time.Time) (*Usage, error) {
+
+	var usage Usage
+
+	for _, period := range []struct {
+		since  time.Time
+		amount *float64
+		count  *int
+	}{
+		{daySince, &usage.DailyAmount, &usage.DailyCount},
+		{monthSince, &usage.MonthlyAmount, &usage.MonthlyCount},
+	} {
+
+		row := tx.Model(&paymentModel.Payment{}).
+			Select("COALESCE(SUM(amount), 0), COUNT(*)").
+			Where("account_origin = ? AND currency = ? AND created_at >= ?", payment.AccountOrigin, payment.Currency, period.since).
+			Where("uid <> ?", payment.Uid).
+			Where("review_status <> ? AND approval_status NOT IN (?)", paymentModel.REVIEW_STATUS_REJECTED,
+				[]string{paymentModel.APPROVAL_STATUS_REJECTED, paymentModel.APPROVAL_STATUS_EXPIRED}).
+			Where("authorization_status NOT IN (?)", []string{paymentModel.AUTHORIZATION_STATUS_VOIDED, paymentModel.AUTHORIZATION_STATUS_EXPIRED}).
+			Where("cancelled_at IS NULL").
+			Row()
+
+		if errorScan := row.Scan(period.amount, period.count); errorScan != nil {
+			return nil, errorScan
+		}
+	}
+
+	return &usage, nil
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"fmt"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/limit/model"
+	"github.com/javierjmgits/go-payment-api/limit/repository"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	LIMIT_PER_TRANSACTION = "perTransaction"
+	LIMIT_DAILY           = "daily"
+	LIMIT_MONTHLY         = "monthly"
+	LIMIT_DAILY_COUNT     = "dailyCount"
+	LIMIT_MONTHLY_COUNT   = "monthlyCount"
+)
+
+// Breach tells which limit a payment would exceed and how much of it is still available.
+type Breach struct {
+	Limit     string  `json:"limit"`
+	Value     float64 `json:"value"`
+	Used      float64 `json:"used"`
+	Remaining float64 `json:"remaining"`
+}
+
+type LimitService struct {
+	limitRepository repository.LimitRepository
+	now             func() time.Time
+}
+
+func NewLimitService(limitRepository repository.LimitRepository) *LimitService {
+
+	return &LimitService{
+		limitRepository: limitRepository,
+		now:             time.Now,
+	}
+}
+
+// Create persists the payment only when it fits the limits of its origin account, checked under a lock on the account.
+func (ls *LimitService) Create(payment *paymentModel.Payment) (*paymentModel.Payment, error) {
+
+	daySince, monthSince := ls.since()
+
+	return ls.limitRepository.CreatePayment(payment, daySince, monthSince, func(limit *model.Limit, usage *repository.Usage) error {
+		return newLimitError(payment, Check(limit, usage, payment.Amount))
+	})
+}
+
+// Amend saves an amendment only when the amended payment still fits the limits of its origin account; one that neither
+// raises the amount nor moves the payment to another account only frees headroom, so it is never refused.
+func (ls *LimitService) Amend(uid string, amend func(*paymentModel.Payment, int) ([]paymentModel.PaymentAmendment, error)) (*paymentModel.Payment, error) {
+
+	daySince, monthSince := ls.since()
+
+	var accountOrigin string
+	var amount float64
+
+	return ls.limitRepository.AmendPayment(uid, daySince, monthSince, func(payment *paymentModel.Payment, revision int) ([]paymentModel.PaymentAmendment, error) {
+
+		accountOrigin = payment.AccountOrigin
+		amount = payment.Amount
+
+		return amend(payment, revision)
+
+	}, func(payment *paymentModel.Payment, limit *model.Limit, usage *repository.Usage) error {
+
+		if payment.AccountOrigin == accountOrigin && payment.Amount <= amount {
+			return nil
+		}
+
+		return newLimitError(payment, Check(limit, usage, payment.Amount))
+	})
+}
+
+// Check returns the limits that a payment of the given amount would exceed on top of the usage.
+func Check(limit *model.Limit, usage *repository.Usage, amount float64) []Breach {
+
+	var breaches []Breach
+
+	if limit.PerTransaction > 0 && amount > limit.PerTransaction {
+		breaches = append(breaches, newBreach(LIMIT_PER_TRANSACTION, limit.PerTransaction, 0))
+	}
+
+	if limit.Daily > 0 && usage.DailyAmount+amount > limit.Daily {
+		breaches = append(breaches, newBreach(LIMIT_DAILY, limit.Daily, usage.DailyAmount))
+	}
+
+	if limit.Monthly > 0 && usage.MonthlyAmount+amount > limit.Monthly {
+		breaches = append(breaches, newBreach(LIMIT_MONTHLY, limit.Monthly, usage.MonthlyAmount))
+	}
+
+	if limit.DailyCount > 0 && usage.DailyCount+1 > limit.DailyCount {
+		breaches = append(breaches, newBreach(LIMIT_DAILY_COUNT, float64(limit.DailyCount), float64(usage.DailyCount)))
+	}
+
+	if limit.MonthlyCount > 0 && usage.MonthlyCount+1 > limit.MonthlyCount {
+		breaches = append(breaches, newBreach(LIMIT_MONTHLY_COUNT, float64(limit.MonthlyCount), float64(usage.MonthlyCount)))
+	}
+
+	return breaches
+}
+
+//
+// private functions
+
+// since gives the start of the rolling window for the day and of the calendar month (UTC) for the month
+func (ls *LimitService) since() (time.Time, time.Time) {
+
+	now := ls.now().UTC()
+
+	return now.Add(-24 * time.Hour), time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func newLimitError(payment *paymentModel.Payment, breaches []Breach) error {
+
+	if len(breaches) == 0 {
+		return nil
+	}
+
+	var names []string
+
+	for _, breach := range breaches {
+		names = append(names, breach.Limit)
+	}
+
+	return &util.RejectedError{
+		Message: fmt.Sprintf("payment exceeds the %s limit(s) of account %s", strings.Join(names, ", "), payment.AccountOrigin),
+		Details: breaches,
+	}
+}
+
+func newBreach(limit string, value float64, used float64) Breach {
+
+	return Breach{
+		Limit:     limit,
+		Value:     value,
+		Used:      used,
+		Remaining: math.Max(0, math.Round((value-used)*100)/100),
+	}
+}
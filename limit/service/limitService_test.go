@@ -0,0 +1,170 @@
+package service
+
+import (
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/limit/model"
+	"github.com/javierjmgits/go-payment-api/limit/repository"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+//
+// mocks
+
+type limitRepositoryImplMock struct {
+	repository.LimitRepository
+	limit   *model.Limit
+	usage   *repository.Usage
+	created []*paymentModel.Payment
+	payment *paymentModel.Payment
+	amended bool
+}
+
+func (mock *limitRepositoryImplMock) CreatePayment(payment *paymentModel.Payment, daySince time.Time, monthSince time.Time, check func(*model.Limit, *repository.Usage) error) (*paymentModel.Payment, error) {
+
+	if mock.limit != nil {
+
+		if errorCheck := check(mock.limit, mock.usage); errorCheck != nil {
+			return nil, errorCheck
+		}
+	}
+
+	mock.created = append(mock.created, payment)
+
+	return payment, nil
+}
+
+func (mock *limitRepositoryImplMock) AmendPayment(uid string, daySince time.Time, monthSince time.Time, amend func(*paymentModel.Payment, int) ([]paymentModel.PaymentAmendment, error), check func(*paymentModel.Payment, *model.Limit, *repository.Usage) error) (*paymentModel.Payment, error) {
+
+	if _, errorAmend := amend(mock.payment, 1); errorAmend != nil {
+		return nil, errorAmend
+	}
+
+	if mock.limit != nil {
+
+		if errorCheck := check(mock.payment, mock.limit, mock.usage); errorCheck != nil {
+			return nil, errorCheck
+		}
+	}
+
+	mock.amended = true
+
+	return mock.payment, nil
+}
+
+//
+// tests
+
+func TestCheck(t *testing.T) {
+
+	limit := &model.Limit{PerTransaction: 1000, Daily: 2000, Monthly: 10000, DailyCount: 5}
+
+	assert.Empty(t, Check(limit, &repository.Usage{DailyAmount: 1000, DailyCount: 4}, 1000))
+
+	breaches := Check(limit, &repository.Usage{DailyAmount: 1500.5, DailyCount: 5, MonthlyAmount: 3000}, 600)
+
+	assert.Equal(t, 2, len(breaches))
+	assert.Equal(t, LIMIT_DAILY, breaches[0].Limit)
+	assert.Equal(t, 499.5, breaches[0].Remaining)
+	assert.Equal(t, LIMIT_DAILY_COUNT, breaches[1].Limit)
+	assert.Equal(t, 0.0, breaches[1].Remaining)
+}
+
+func TestCreateWithoutLimit(t *testing.T) {
+
+	mockRepository := &limitRepositoryImplMock{}
+
+	_, errorCreate := NewLimitService(mockRepository).Create(expectedPayment(5000))
+
+	assert.Nil(t, errorCreate)
+	assert.Equal(t, 1, len(mockRepository.created))
+}
+
+func TestCreateKoLimitBreached(t *testing.T) {
+
+	mockRepository := &limitRepositoryImplMock{
+		limit: &model.Limit{PerTransaction: 1000},
+		usage: &repository.Usage{},
+	}
+
+	_, errorCreate := NewLimitService(mockRepository).Create(expectedPayment(5000))
+
+	rejected, isRejected := errorCreate.(*util.RejectedError)
+
+	assert.True(t, isRejected)
+	assert.Equal(t, LIMIT_PER_TRANSACTION, rejected.Details.([]Breach)[0].Limit)
+	assert.Empty(t, mockRepository.created)
+}
+
+func TestAmendKoLimitBreached(t *testing.T) {
+
+	mockRepository := &limitRepositoryImplMock{
+		limit:   &model.Limit{PerTransaction: 1000},
+		usage:   &repository.Usage{},
+		payment: expectedPayment(800),
+	}
+
+	_, errorAmend := NewLimitService(mockRepository).Amend(mockRepository.payment.Uid, amendAmount(1200))
+
+	_, isRejected := errorAmend.(*util.RejectedError)
+
+	assert.True(t, isRejected)
+	assert.False(t, mockRepository.amended)
+}
+
+func TestAmendKoLimitOfNewOrigin(t *testing.T) {
+
+	mockRepository := &limitRepositoryImplMock{
+		limit:   &model.Limit{Daily: 1000},
+		usage:   &repository.Usage{DailyAmount: 900},
+		payment: expectedPayment(500),
+	}
+
+	_, errorAmend := NewLimitService(mockRepository).Amend(mockRepository.payment.Uid, func(payment *paymentModel.Payment, revision int) ([]paymentModel.PaymentAmendment, error) {
+		payment.AccountOrigin = "GB33BUKB20201555555555"
+		return []paymentModel.PaymentAmendment{{Field: "accountOrigin"}}, nil
+	})
+
+	_, isRejected := errorAmend.(*util.RejectedError)
+
+	assert.True(t, isRejected)
+	assert.False(t, mockRepository.amended)
+}
+
+func TestAmendLowerAmountNotChecked(t *testing.T) {
+
+	mockRepository := &limitRepositoryImplMock{
+		limit:   &model.Limit{PerTransaction: 100},
+		usage:   &repository.Usage{},
+		payment: expectedPayment(800),
+	}
+
+	_, errorAmend := NewLimitService(mockRepository).Amend(mockRepository.payment.Uid, amendAmount(500))
+
+	assert.Nil(t, errorAmend)
+	assert.True(t, mockRepository.amended)
+}
+
+//
+// private functions
+
+func amendAmount(amount float64) func(*paymentModel.Payment, int) ([]paymentModel.PaymentAmendment, error) {
+
+	return func(payment *paymentModel.Payment, revision int) ([]paymentModel.PaymentAmendment, error) {
+		payment.Amount = amount
+		return []paymentModel.PaymentAmendment{{Field: "amount"}}, nil
+	}
+}
+
+func expectedPayment(amount float64) *paymentModel.Payment {
+
+	return &paymentModel.Payment{
+		Uid:           "c1f0e5b2-8f7a-4d1e-9a36-5e2d0c7b4a91",
+		AccountOrigin: "GB29NWBK60161331926819",
+		AccountTarget: "DE89370400440532013000",
+		Amount:        amount,
+		Currency:      "EUR",
+	}
+}
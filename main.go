@@ -2,12 +2,23 @@ package main
 
 import (
 	"github.com/javierjmgits/go-payment-api/base/config"
+	"os"
 )
 
 func main() {
 
 	configuration := config.NewConfig()
 
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		runImportCommand(configuration, os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "reconcile" {
+		runReconcileCommand(configuration, os.Args[2:])
+		return
+	}
+
 	app := NewAppStarter(configuration)
 
 	app.Start()
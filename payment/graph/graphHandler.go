@@ -0,0 +1,151 @@
+package graph
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/payment/handler"
+	"github.com/javierjmgits/go-payment-api/payment/repository"
+	"github.com/javierjmgits/go-payment-api/payment/watch"
+	"net/http"
+	"strings"
+)
+
+// nested refunds and accounts are cheap with the loaders, but not without end
+const MAX_QUERY_DEPTH = 8
+
+//go:embed schema.graphql
+var schemaGraphql string
+
+type GraphHandler struct {
+	schema            *graphql.Schema
+	paymentRepository repository.PaymentRepository
+}
+
+// GraphRequest is a GraphQL request as sent in the body of a POST, or in the query string of a GET.
+type GraphRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+func NewGraphHandler(paymentHandler *handler.PaymentHandler, paymentRepository repository.PaymentRepository, watcher *watch.Watcher) *GraphHandler {
+
+	resolver := &resolver{
+		paymentHandler:    paymentHandler,
+		paymentRepository: paymentRepository,
+		watcher:           watcher,
+	}
+
+	return &GraphHandler{
+		schema:            graphql.MustParseSchema(schemaGraphql, resolver, graphql.UseFieldResolvers(), graphql.MaxDepth(MAX_QUERY_DEPTH)),
+		paymentRepository: paymentRepository,
+	}
+}
+
+func (gh *GraphHandler) Register(router *mux.Router) {
+	router.HandleFunc("/graphql", gh.Query).Methods("GET", "POST")
+}
+
+// Query runs a query or a mutation and writes the result as JSON; asked for text/event-stream, it also runs
+// subscriptions and sends every result as a `next` event, then a `complete` one. A GET only runs queries, so that a link
+// can not change payments.
+func (gh *GraphHandler) Query(w http.ResponseWriter, r *http.Request) {
+
+	graphRequest, errorRequest := decodeGraphRequest(r)
+
+	if errorRequest != nil {
+		util.WriteError(w, http.StatusBadRequest, errorRequest.Error())
+		return
+	}
+
+	if r.Method == http.MethodGet && operationType(graphRequest.Query, graphRequest.OperationName) != OPERATION_QUERY {
+		w.Header().Set("Allow", http.MethodPost)
+		util.WriteError(w, http.StatusMethodNotAllowed, "only queries can be sent by GET, mutations and subscriptions are sent by POST")
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), principalKey, auth.FromRequest(r))
+	ctx = context.WithValue(ctx, loadersKey, newLoaders(gh.paymentRepository))
+
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		gh.stream(ctx, w, graphRequest)
+		return
+	}
+
+	response := gh.schema.Exec(ctx, graphRequest.Query, graphRequest.OperationName, graphRequest.Variables)
+
+	util.WritePayload(w, http.StatusOK, response)
+}
+
+//
+// private functions
+
+func (gh *GraphHandler) stream(ctx context.Context, w http.ResponseWriter, graphRequest *GraphRequest) {
+
+	flusher, canFlush := w.(http.Flusher)
+
+	if !canFlush {
+		util.WriteError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	responses, errorSubscribe := gh.schema.Subscribe(ctx, graphRequest.Query, graphRequest.OperationName, graphRequest.Variables)
+
+	if errorSubscribe != nil {
+		util.WriteError(w, http.StatusInternalServerError, errorSubscribe.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for response := range responses {
+
+		responseJson, errorJson := json.Marshal(response)
+
+		if errorJson != nil {
+			break
+		}
+
+		fmt.Fprintf(w, "event: next\ndata: %s\n\n", responseJson)
+		flusher.Flush()
+	}
+
+	fmt.Fprint(w, "event: complete\ndata:\n\n")
+	flusher.Flush()
+}
+
+func decodeGraphRequest(r *http.Request) (*GraphRequest, error) {
+
+	graphRequest := &GraphRequest{}
+
+	if r.Method == http.MethodGet {
+
+		graphRequest.Query = r.URL.Query().Get("query")
+		graphRequest.OperationName = r.URL.Query().Get("operationName")
+
+		if variables := r.URL.Query().Get("variables"); variables != "" {
+
+			if errorJson := json.Unmarshal([]byte(variables), &graphRequest.Variables); errorJson != nil {
+				return nil, errorJson
+			}
+		}
+
+	} else if errorJson := json.NewDecoder(r.Body).Decode(graphRequest); errorJson != nil {
+		return nil, errorJson
+	}
+
+	if strings.TrimSpace(graphRequest.Query) == "" {
+		return nil, fmt.Errorf("query is mandatory")
+	}
+
+	return graphRequest, nil
+}
@@ -0,0 +1,404 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	"github.com/javierjmgits/go-payment-api/payment/handler"
+	"github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/payment/repository"
+	"github.com/javierjmgits/go-payment-api/payment/watch"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+//
+// mocks
+
+type paymentRepositoryImplMock struct {
+	repository.PaymentRepository
+	lock          sync.Mutex
+	payments      []*model.Payment
+	searches      int
+	totalsLookups [][]string
+}
+
+func (mock *paymentRepositoryImplMock) Search(conditions []repository.Condition, offset int, limit int) ([]model.Payment, int, error) {
+
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+
+	mock.searches++
+
+	var matching []model.Payment
+
+	for _, payment := range mock.payments {
+
+		// the loader of refunded payments looks them up by uid
+		if len(conditions) == 1 && conditions[0].Query == "uid IN (?)" && !contains(conditions[0].Args[0].([]string), payment.Uid) {
+			continue
+		}
+
+		matching = append(matching, *payment)
+	}
+
+	var results []model.Payment
+
+	for index := offset; index < len(matching) && index < offset+limit; index++ {
+		results = append(results, matching[index])
+	}
+
+	return results, len(matching), nil
+}
+
+func (mock *paymentRepositoryImplMock) GetByUid(uid string) (*model.Payment, error) {
+
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+
+	for _, payment := range mock.payments {
+
+		if payment.Uid == uid {
+			copied := *payment
+			return &copied, nil
+		}
+	}
+
+	return nil, errors.New("record not found")
+}
+
+func (mock *paymentRepositoryImplMock) Create(payment *model.Payment) (*model.Payment, error) {
+
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+
+	payment.ID = uint(len(mock.payments) + 1)
+	payment.CreatedAt = time.Now().UTC()
+	payment.UpdatedAt = payment.CreatedAt
+
+	mock.payments = append(mock.payments, payment)
+
+	return payment, nil
+}
+
+func (mock *paymentRepositoryImplMock) GetAccountTotals(accounts []string) ([]repository.AccountTotal, error) {
+
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+
+	mock.totalsLookups = append(mock.totalsLookups, accounts)
+
+	var totals []repository.AccountTotal
+
+	for _, account := range accounts {
+		totals = append(totals, repository.AccountTotal{Account: account, Currency: "EUR", SentCount: 1, SentAmount: 10})
+	}
+
+	return totals, nil
+}
+
+func (mock *paymentRepositoryImplMock) GetChangedSince(afterId uint, limit int) ([]model.PaymentChange, error) {
+
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+
+	var results []model.PaymentChange
+
+	for index := range mock.payments {
+
+		// one change per payment, its last one, with the id of the payment
+		if change := changeOf(*mock.payments[index]); change.ID > afterId {
+			results = append(results, change)
+		}
+	}
+
+	return results, nil
+}
+
+func (mock *paymentRepositoryImplMock) GetLastChangeIdBefore(at time.Time) (uint, error) {
+
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+
+	var id uint
+
+	for index := range mock.payments {
+
+		if change := changeOf(*mock.payments[index]); change.CreatedAt.Before(at) && change.ID > id {
+			id = change.ID
+		}
+	}
+
+	return id, nil
+}
+
+//
+// tests
+
+func TestQueryPayments(t *testing.T) {
+
+	router, mockRepository := setUp()
+
+	refundOfUid := "uid-1"
+
+	mockRepository.Create(&model.Payment{Uid: "uid-1", AccountOrigin: "GB29NWBK60161331926819", AccountTarget: "DE89370400440532013000", Amount: 10})
+	mockRepository.Create(&model.Payment{Uid: "uid-2", AccountOrigin: "DE89370400440532013000", AccountTarget: "GB29NWBK60161331926819", Amount: 4, RefundOfUid: &refundOfUid})
+	mockRepository.Create(&model.Payment{Uid: "uid-3", AccountOrigin: "GB29NWBK60161331926819", AccountTarget: "FR1420041010050500013M02606", Amount: 7})
+
+	result := sendQuery(router, `{
+		payments(first: 2) {
+			totalCount
+			pageInfo { hasNextPage endCursor }
+			edges { node { uid accountOrigin origin { account totals { currency sentCount } } target { account } refundOf { uid } } }
+		}
+	}`, nil)
+
+	// verify
+
+	assert.Nil(t, result["errors"])
+
+	connection := result["data"].(map[string]interface{})["payments"].(map[string]interface{})
+
+	assert.Equal(t, float64(3), connection["totalCount"])
+	assert.Equal(t, true, connection["pageInfo"].(map[string]interface{})["hasNextPage"])
+
+	edges := connection["edges"].([]interface{})
+
+	assert.Len(t, edges, 2)
+
+	second := edges[1].(map[string]interface{})["node"].(map[string]interface{})
+
+	assert.Equal(t, "DE89 3704 0044 0532 0130 00", second["accountOrigin"])
+	assert.Equal(t, "uid-1", second["refundOf"].(map[string]interface{})["uid"])
+	assert.Equal(t, "DE89 3704 0044 0532 0130 00", second["origin"].(map[string]interface{})["account"])
+
+	// the accounts of the whole page are summed up at once
+	assert.Len(t, mockRepository.totalsLookups, 1)
+	assert.ElementsMatch(t, []string{"GB29NWBK60161331926819", "DE89370400440532013000"}, mockRepository.totalsLookups[0])
+
+	// the next page starts after the end cursor
+	endCursor := connection["pageInfo"].(map[string]interface{})["endCursor"]
+
+	result = sendQuery(router, `query($after: String) { payments(first: 2, after: $after) { edges { node { uid } } pageInfo { hasNextPage } } }`, map[string]interface{}{"after": endCursor})
+
+	connection = result["data"].(map[string]interface{})["payments"].(map[string]interface{})
+
+	assert.Equal(t, "uid-3", connection["edges"].([]interface{})[0].(map[string]interface{})["node"].(map[string]interface{})["uid"])
+	assert.Equal(t, false, connection["pageInfo"].(map[string]interface{})["hasNextPage"])
+}
+
+func TestQueryPaymentNotFound(t *testing.T) {
+
+	router, _ := setUp()
+
+	result := sendQuery(router, `{ payment(uid: "missing") { uid } }`, nil)
+
+	// verify
+
+	assert.Nil(t, result["errors"])
+	assert.Nil(t, result["data"].(map[string]interface{})["payment"])
+}
+
+func TestCreatePayment(t *testing.T) {
+
+	router, mockRepository := setUp()
+
+	result := sendQuery(router, `mutation {
+		createPayment(input: {accountOrigin: "GB29NWBK60161331926819", accountTarget: "DE89370400440532013000", amount: 25, metadata: [{key: "orderId", value: "1234"}]}) {
+			uid createdBy currency metadata { key value }
+		}
+	}`, nil)
+
+	// verify
+
+	assert.Nil(t, result["errors"])
+
+	payment := result["data"].(map[string]interface{})["createPayment"].(map[string]interface{})
+
+	assert.Equal(t, "alice", payment["createdBy"])
+	assert.Equal(t, model.DEFAULT_CURRENCY, payment["currency"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"key": "orderId", "value": "1234"}}, payment["metadata"])
+	assert.Len(t, mockRepository.payments, 1)
+}
+
+func TestCreatePaymentKo(t *testing.T) {
+
+	router, _ := setUp()
+
+	result := sendQuery(router, `mutation { createPayment(input: {accountOrigin: "GB29NWBK60161331926819", amount: 25}) { uid } }`, nil)
+
+	// verify
+
+	errorsFound := result["errors"].([]interface{})
+
+	assert.Len(t, errorsFound, 1)
+	assert.Equal(t, "account target is mandatory", errorsFound[0].(map[string]interface{})["message"])
+	assert.Equal(t, "BAD_USER_INPUT", errorsFound[0].(map[string]interface{})["extensions"].(map[string]interface{})["code"])
+}
+
+func TestDeletePaymentKoForbidden(t *testing.T) {
+
+	router, mockRepository := setUp()
+
+	mockRepository.Create(&model.Payment{Uid: "uid-1"})
+
+	result := sendQuery(router, `mutation { deletePayment(uid: "uid-1") }`, nil)
+
+	// verify
+
+	errorsFound := result["errors"].([]interface{})
+
+	assert.Equal(t, "FORBIDDEN", errorsFound[0].(map[string]interface{})["extensions"].(map[string]interface{})["code"])
+}
+
+func TestSubscribePaymentChanged(t *testing.T) {
+
+	router, mockRepository := setUp()
+
+	mockRepository.Create(&model.Payment{Uid: "uid-1", AccountOrigin: "GB29NWBK60161331926819", AccountTarget: "DE89370400440532013000"})
+
+	body, _ := json.Marshal(GraphRequest{
+		Query:     `subscription($since: Time) { paymentChanged(since: $since) { type payment { uid origin { totals { sentCount } } } } }`,
+		Variables: map[string]interface{}{"since": time.Now().Add(-time.Minute).Format(time.RFC3339)},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/graphql", strings.NewReader(string(body))).WithContext(ctx)
+	req.Header.Set("Accept", "text/event-stream")
+	w := httptest.NewRecorder()
+
+	done := make(chan bool)
+
+	go func() {
+		router.ServeHTTP(w, req)
+		done <- true
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	<-done
+
+	// verify
+
+	assert.Equal(t, "text/event-stream", w.Result().Header.Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `event: next`)
+	assert.Contains(t, w.Body.String(), `"type":"CREATED"`)
+	assert.Contains(t, w.Body.String(), `"uid":"uid-1"`)
+	assert.Contains(t, w.Body.String(), `"sentCount":1`)
+	assert.Contains(t, w.Body.String(), "event: complete")
+}
+
+func TestGetRunsOnlyQueries(t *testing.T) {
+
+	router, mockRepository := setUp()
+	mockRepository.Create(&model.Payment{Uid: "uid-1", AccountOrigin: "GB29NWBK60161331926819", AccountTarget: "DE89370400440532013000", Amount: 10, Currency: "EUR"})
+
+	for _, call := range []struct {
+		query         string
+		operationName string
+		status        int
+	}{
+		{`{ payment(uid: "uid-1") { uid } }`, "", http.StatusOK},
+		{`query Get { payment(uid: "uid-1") { uid } }`, "", http.StatusOK},
+		{`mutation { deletePayment(uid: "uid-1") }`, "", http.StatusMethodNotAllowed},
+		{`mutation Delete($uid: ID!) { deletePayment(uid: $uid) }`, "", http.StatusMethodNotAllowed},
+		{`# { payment(uid: "uid-1") { uid } }
+		mutation { deletePayment(uid: "uid-1") }`, "", http.StatusMethodNotAllowed},
+		{`query Get { payment(uid: "uid-1") { uid } } mutation Delete { deletePayment(uid: "uid-1") }`, "Delete", http.StatusMethodNotAllowed},
+		{`query Get { payment(uid: "uid-1") { uid } } mutation Delete { deletePayment(uid: "uid-1") }`, "Get", http.StatusOK},
+		{`subscription { paymentChanged { type } }`, "", http.StatusMethodNotAllowed},
+	} {
+
+		values := url.Values{"query": {call.query}, "operationName": {call.operationName}}
+
+		req := httptest.NewRequest("GET", "http://localhost:8080/graphql?"+values.Encode(), nil)
+		req.Header.Set(auth.HEADER_USER, "alice")
+		req.Header.Set(auth.HEADER_SCOPES, "payments:delete")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		// verify
+
+		assert.Equal(t, call.status, w.Result().StatusCode, call.query)
+	}
+
+	assert.Len(t, mockRepository.payments, 1)
+}
+
+//
+// private functions
+
+func setUp() (*mux.Router, *paymentRepositoryImplMock) {
+
+	var router = mux.NewRouter()
+
+	mockRepository := &paymentRepositoryImplMock{}
+
+	paymentHandler := handler.NewPaymentHandler(mockRepository)
+	paymentHandler.SetDeleteScope("payments:delete")
+
+	NewGraphHandler(paymentHandler, mockRepository, watch.NewWatcher(mockRepository, 10*time.Millisecond)).Register(router)
+
+	return router, mockRepository
+}
+
+func sendQuery(router *mux.Router, query string, variables map[string]interface{}) map[string]interface{} {
+
+	body, _ := json.Marshal(GraphRequest{Query: query, Variables: variables})
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/graphql", strings.NewReader(string(body)))
+	req.Header.Set(auth.HEADER_USER, "alice")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	responseBody, _ := ioutil.ReadAll(resp.Body)
+
+	var result map[string]interface{}
+
+	json.Unmarshal(responseBody, &result)
+
+	return result
+}
+
+func contains(values []string, value string) bool {
+
+	for _, item := range values {
+
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func changeOf(payment model.Payment) model.PaymentChange {
+
+	change := model.PaymentChange{ID: payment.ID, PaymentUid: payment.Uid, Type: model.CHANGE_UPDATED, CreatedAt: payment.ChangedAt(), Payment: &payment}
+
+	if payment.DeletedAt != nil {
+		change.Type = model.CHANGE_DELETED
+	} else if payment.CreatedAt.Equal(payment.UpdatedAt) {
+		change.Type = model.CHANGE_CREATED
+	}
+
+	return change
+}
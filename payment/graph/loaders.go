@@ -0,0 +1,126 @@
+package graph
+
+import (
+	"context"
+	"github.com/graph-gophers/dataloader"
+	"github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/payment/repository"
+)
+
+// loaders batch the lookups made while resolving one request, so that a page of payments costs one query per kind of
+// lookup instead of one per payment; each request gets its own, so nothing is cached across requests.
+type loaders struct {
+	accountTotals *dataloader.Loader
+	payments      *dataloader.Loader
+}
+
+func newLoaders(paymentRepository repository.PaymentRepository) *loaders {
+
+	return &loaders{
+		accountTotals: dataloader.NewBatchedLoader(accountTotalsBatch(paymentRepository), dataloader.WithClearCacheOnBatch()),
+		payments:      dataloader.NewBatchedLoader(paymentsBatch(paymentRepository), dataloader.WithClearCacheOnBatch()),
+	}
+}
+
+func (l *loaders) loadAccountTotals(ctx context.Context, account string) ([]repository.AccountTotal, error) {
+
+	result, errorLoad := l.accountTotals.Load(ctx, dataloader.StringKey(account))()
+
+	if errorLoad != nil {
+		return nil, errorLoad
+	}
+
+	return result.([]repository.AccountTotal), nil
+}
+
+// loadPayment returns nil, and no error, for an unknown uid.
+func (l *loaders) loadPayment(ctx context.Context, uid string) (*model.Payment, error) {
+
+	result, errorLoad := l.payments.Load(ctx, dataloader.StringKey(uid))()
+
+	if errorLoad != nil || result == nil {
+		return nil, errorLoad
+	}
+
+	return result.(*model.Payment), nil
+}
+
+//
+// private functions
+
+func accountTotalsBatch(paymentRepository repository.PaymentRepository) dataloader.BatchFunc {
+
+	return func(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+
+		totals, errorDB := paymentRepository.GetAccountTotals(keys.Keys())
+
+		if errorDB != nil {
+			return failedResults(keys, errorDB)
+		}
+
+		byAccount := map[string][]repository.AccountTotal{}
+
+		for _, total := range totals {
+			byAccount[total.Account] = append(byAccount[total.Account], total)
+		}
+
+		results := make([]*dataloader.Result, len(keys))
+
+		for index, key := range keys {
+
+			accountTotals := byAccount[key.String()]
+
+			if accountTotals == nil {
+				accountTotals = []repository.AccountTotal{}
+			}
+
+			results[index] = &dataloader.Result{Data: accountTotals}
+		}
+
+		return results
+	}
+}
+
+func paymentsBatch(paymentRepository repository.PaymentRepository) dataloader.BatchFunc {
+
+	return func(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+
+		conditions := []repository.Condition{{Query: "uid IN (?)", Args: []interface{}{keys.Keys()}}}
+
+		payments, _, errorDB := paymentRepository.Search(conditions, 0, len(keys))
+
+		if errorDB != nil {
+			return failedResults(keys, errorDB)
+		}
+
+		byUid := map[string]*model.Payment{}
+
+		for index := range payments {
+			byUid[payments[index].Uid] = &payments[index]
+		}
+
+		results := make([]*dataloader.Result, len(keys))
+
+		for index, key := range keys {
+
+			results[index] = &dataloader.Result{}
+
+			if payment, found := byUid[key.String()]; found {
+				results[index].Data = payment
+			}
+		}
+
+		return results
+	}
+}
+
+func failedResults(keys dataloader.Keys, err error) []*dataloader.Result {
+
+	results := make([]*dataloader.Result, len(keys))
+
+	for index := range keys {
+		results[index] = &dataloader.Result{Error: err}
+	}
+
+	return results
+}
@@ -0,0 +1,128 @@
+package graph
+
+import "strings"
+
+const (
+	OPERATION_QUERY        = "query"
+	OPERATION_MUTATION     = "mutation"
+	OPERATION_SUBSCRIPTION = "subscription"
+)
+
+type operation struct {
+	operationType string
+	name          string
+}
+
+//
+// private functions
+
+// operationType tells the type of the operation a document runs, the one of that name or else the only one; it is
+// empty when there is no such operation. Only the top level of the document is read, which is where the types are.
+func operationType(document string, operationName string) string {
+
+	var operations []operation
+
+	depth := 0
+	inDefinition := false
+	expectName := false
+
+	for index := 0; index < len(document); {
+
+		character := document[index]
+
+		switch {
+
+		case character == '#':
+			for index < len(document) && document[index] != '\n' {
+				index++
+			}
+			continue
+
+		case strings.HasPrefix(document[index:], `"""`):
+			end := strings.Index(document[index+3:], `"""`)
+
+			if end < 0 {
+				return ""
+			}
+
+			index += 3 + end + 3
+			continue
+
+		case character == '"':
+			for index++; index < len(document) && document[index] != '"'; index++ {
+				if document[index] == '\\' {
+					index++
+				}
+			}
+
+		case isNameStart(character):
+			start := index
+
+			for index < len(document) && (isNameStart(document[index]) || (document[index] >= '0' && document[index] <= '9')) {
+				index++
+			}
+
+			name := document[start:index]
+
+			if depth == 0 && expectName {
+				operations[len(operations)-1].name = name
+				expectName = false
+
+			} else if depth == 0 && !inDefinition {
+
+				switch name {
+
+				case OPERATION_QUERY, OPERATION_MUTATION, OPERATION_SUBSCRIPTION:
+					operations = append(operations, operation{operationType: name})
+					inDefinition = true
+					expectName = true
+
+				case "fragment":
+					inDefinition = true
+				}
+			}
+
+			continue
+
+		case character == '{' || character == '(' || character == '[':
+			// a selection set on its own is a query
+			if depth == 0 && character == '{' && !inDefinition {
+				operations = append(operations, operation{operationType: OPERATION_QUERY})
+				inDefinition = true
+			}
+
+			depth++
+			expectName = false
+
+		case character == '}' || character == ')' || character == ']':
+			depth--
+
+			if depth == 0 && character == '}' {
+				inDefinition = false
+			}
+
+		case character != ' ' && character != '\t' && character != '\n' && character != '\r' && character != ',':
+			expectName = false
+		}
+
+		index++
+	}
+
+	for _, operation := range operations {
+
+		if operationName != "" && operation.name == operationName {
+			return operation.operationType
+		}
+	}
+
+	if operationName == "" && len(operations) == 1 {
+		return operations[0].operationType
+	}
+
+	return ""
+}
+
+func isNameStart(character byte) bool {
+
+	return character == '_' || (character >= 'a' && character <= 'z') || (character >= 'A' && character <= 'Z')
+}
@@ -0,0 +1,299 @@
+package graph
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/payment/handler"
+	"github.com/javierjmgits/go-payment-api/payment/repository"
+	"github.com/javierjmgits/go-payment-api/payment/search"
+	"github.com/javierjmgits/go-payment-api/payment/watch"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type contextKey int
+
+const (
+	principalKey contextKey = iota
+	loadersKey
+)
+
+// resolver answers the root fields of the schema with the operations and the validation of the REST handler.
+type resolver struct {
+	paymentHandler    *handler.PaymentHandler
+	paymentRepository repository.PaymentRepository
+	watcher           *watch.Watcher
+}
+
+type paymentInput struct {
+	AccountOrigin  string
+	AccountTarget  *string
+	Amount         float64
+	Currency       *string
+	TargetCurrency *string
+	FxQuoteUid     *string
+	Date           *graphql.Time
+	Reference      *string
+	Description    *string
+	Metadata       *[]metadataEntry
+	FeeBearer      *string
+	BeneficiaryUid *graphql.ID
+	Authorize      *bool
+}
+
+type metadataEntry struct {
+	Key   string
+	Value string
+}
+
+func (r *resolver) Payment(ctx context.Context, args struct{ Uid graphql.ID }) (*paymentResolver, error) {
+
+	payment, errorGet := r.paymentHandler.Get(string(args.Uid))
+
+	if errorGet != nil {
+
+		if strings.Contains(errorGet.Error(), "not found") {
+			return nil, nil
+		}
+
+		return nil, graphErrorFor(errorGet)
+	}
+
+	return newPaymentResolver(payment), nil
+}
+
+func (r *resolver) Payments(ctx context.Context, args struct {
+	Query *string
+	First *int32
+	After *string
+}) (*connectionResolver, error) {
+
+	size := handler.DEFAULT_PAGE_SIZE
+
+	if args.First != nil {
+		size = int(*args.First)
+	}
+
+	if size < 1 || size > handler.MAX_PAGE_SIZE {
+		return nil, graphErrorFor(&util.InputError{Message: "first must be between 1 and " + strconv.Itoa(handler.MAX_PAGE_SIZE)})
+	}
+
+	offset := 0
+
+	if args.After != nil {
+
+		position, errorCursor := decodeCursor(*args.After)
+
+		if errorCursor != nil {
+			return nil, graphErrorFor(&util.InputError{Message: "invalid cursor"})
+		}
+
+		offset = position + 1
+	}
+
+	var conditions []repository.Condition
+
+	if args.Query != nil {
+
+		query, errorQuery := search.Parse(*args.Query)
+
+		if errorQuery != nil {
+			return nil, graphErrorFor(&util.InputError{Message: errorQuery.Error()})
+		}
+
+		if conditions, errorQuery = query.Conditions(); errorQuery != nil {
+			return nil, graphErrorFor(&util.InputError{Message: errorQuery.Error()})
+		}
+	}
+
+	payments, total, errorDB := r.paymentRepository.Search(conditions, offset, size)
+
+	if errorDB != nil {
+		return nil, graphErrorFor(errorDB)
+	}
+
+	connection := &connectionResolver{
+		edges:      []*edgeResolver{},
+		pageInfo:   &pageInfo{HasNextPage: offset+len(payments) < total},
+		totalCount: int32(total),
+	}
+
+	for index := range payments {
+
+		cursor := encodeCursor(offset + index)
+
+		connection.edges = append(connection.edges, &edgeResolver{cursor: cursor, node: newPaymentResolver(&payments[index])})
+		connection.pageInfo.EndCursor = &cursor
+	}
+
+	return connection, nil
+}
+
+func (r *resolver) CreatePayment(ctx context.Context, args struct{ Input paymentInput }) (*paymentResolver, error) {
+
+	input := args.Input
+
+	paymentCreate := &handler.PaymentCreate{
+		AccountOrigin:  input.AccountOrigin,
+		AccountTarget:  valueOf(input.AccountTarget),
+		Amount:         input.Amount,
+		Currency:       valueOf(input.Currency),
+		TargetCurrency: valueOf(input.TargetCurrency),
+		FxQuoteUid:     valueOf(input.FxQuoteUid),
+		Reference:      valueOf(input.Reference),
+		Description:    valueOf(input.Description),
+		FeeBearer:      valueOf(input.FeeBearer),
+	}
+
+	if input.Date != nil {
+		paymentCreate.Date = input.Date.Time
+	}
+
+	if input.BeneficiaryUid != nil {
+		paymentCreate.BeneficiaryUid = string(*input.BeneficiaryUid)
+	}
+
+	if input.Authorize != nil {
+		paymentCreate.Authorize = *input.Authorize
+	}
+
+	if input.Metadata != nil {
+
+		paymentCreate.Metadata = map[string]string{}
+
+		for _, entry := range *input.Metadata {
+			paymentCreate.Metadata[entry.Key] = entry.Value
+		}
+	}
+
+	payment, errorCreate := r.paymentHandler.Create(paymentCreate, principalFrom(ctx))
+
+	if errorCreate != nil {
+		return nil, graphErrorFor(errorCreate)
+	}
+
+	return newPaymentResolver(payment), nil
+}
+
+func (r *resolver) ProcessPayment(ctx context.Context, args struct{ Uid graphql.ID }) (*paymentResolver, error) {
+
+	payment, errorProcessed := r.paymentHandler.MarkProcessed(string(args.Uid))
+
+	if errorProcessed != nil {
+		return nil, graphErrorFor(errorProcessed)
+	}
+
+	return newPaymentResolver(payment), nil
+}
+
+func (r *resolver) DeletePayment(ctx context.Context, args struct {
+	Uid  graphql.ID
+	Hard *bool
+}) (bool, error) {
+
+	hard := args.Hard != nil && *args.Hard
+
+	if errorDelete := r.paymentHandler.Delete(string(args.Uid), principalFrom(ctx), hard); errorDelete != nil {
+		return false, graphErrorFor(errorDelete)
+	}
+
+	return true, nil
+}
+
+// PaymentChanged streams the changes seen by the watcher until the subscription ends.
+func (r *resolver) PaymentChanged(ctx context.Context, args struct{ Since *graphql.Time }) (<-chan *eventResolver, error) {
+
+	since := time.Now().UTC()
+
+	if args.Since != nil {
+		since = args.Since.Time
+	}
+
+	from, errorFrom := r.watcher.From(since)
+
+	if errorFrom != nil {
+		return nil, errorFrom
+	}
+
+	events := make(chan *eventResolver)
+
+	go func() {
+
+		defer close(events)
+
+		errorWatch := r.watcher.Watch(ctx, from, func(change *watch.Change) error {
+
+			select {
+
+			case events <- &eventResolver{change: change}:
+				return nil
+
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+
+		if errorWatch != nil && ctx.Err() == nil {
+			log.Printf("Error watching payments for a subscription: %v\n", errorWatch)
+		}
+	}()
+
+	return events, nil
+}
+
+//
+// private functions
+
+func principalFrom(ctx context.Context) *auth.Principal {
+
+	if principal, found := ctx.Value(principalKey).(*auth.Principal); found {
+		return principal
+	}
+
+	return &auth.Principal{}
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey).(*loaders)
+}
+
+// cursors are the position of the edge in the list, opaque to clients
+func encodeCursor(position int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("payment:%d", position)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+
+	decoded, errorDecode := base64.RawURLEncoding.DecodeString(cursor)
+
+	if errorDecode != nil {
+		return 0, errorDecode
+	}
+
+	var position int
+
+	if _, errorScan := fmt.Sscanf(string(decoded), "payment:%d", &position); errorScan != nil {
+		return 0, errorScan
+	}
+
+	if position < 0 {
+		return 0, strconv.ErrRange
+	}
+
+	return position, nil
+}
+
+func valueOf(value *string) string {
+
+	if value == nil {
+		return ""
+	}
+
+	return *value
+}
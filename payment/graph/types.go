@@ -0,0 +1,379 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/javierjmgits/go-payment-api/account/identifier"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/payment/handler"
+	"github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/payment/repository"
+	"github.com/javierjmgits/go-payment-api/payment/watch"
+	"sort"
+	"strings"
+	"time"
+)
+
+// paymentResolver shows a payment like the REST view does, with accounts formatted and the metadata decoded.
+type paymentResolver struct {
+	payment *model.Payment
+	view    *handler.PaymentView
+}
+
+type accountSummaryResolver struct {
+	account string
+}
+
+type accountTotal struct {
+	Currency       string
+	SentCount      int32
+	SentAmount     float64
+	ReceivedCount  int32
+	ReceivedAmount float64
+}
+
+type connectionResolver struct {
+	edges      []*edgeResolver
+	pageInfo   *pageInfo
+	totalCount int32
+}
+
+type edgeResolver struct {
+	cursor string
+	node   *paymentResolver
+}
+
+type pageInfo struct {
+	HasNextPage bool
+	EndCursor   *string
+}
+
+type eventResolver struct {
+	change *watch.Change
+}
+
+// graphError carries the kind of a handler error as the code extension, e.g. BAD_USER_INPUT for a 400 of the REST API.
+type graphError struct {
+	err        error
+	extensions map[string]interface{}
+}
+
+func newPaymentResolver(payment *model.Payment) *paymentResolver {
+
+	return &paymentResolver{
+		payment: payment,
+		view:    handler.NewPaymentView(payment),
+	}
+}
+
+func (pr *paymentResolver) Uid() graphql.ID {
+	return graphql.ID(pr.view.Uid)
+}
+
+func (pr *paymentResolver) AccountOrigin() string {
+	return pr.view.AccountOrigin
+}
+
+func (pr *paymentResolver) AccountTarget() string {
+	return pr.view.AccountTarget
+}
+
+func (pr *paymentResolver) Origin() *accountSummaryResolver {
+	return &accountSummaryResolver{account: pr.payment.AccountOrigin}
+}
+
+func (pr *paymentResolver) Target() *accountSummaryResolver {
+	return &accountSummaryResolver{account: pr.payment.AccountTarget}
+}
+
+func (pr *paymentResolver) Amount() float64 {
+	return pr.view.Amount
+}
+
+func (pr *paymentResolver) Currency() string {
+	return pr.view.Currency
+}
+
+func (pr *paymentResolver) TargetCurrency() string {
+	return pr.view.TargetCurrency
+}
+
+func (pr *paymentResolver) TargetAmount() float64 {
+	return pr.view.TargetAmount
+}
+
+func (pr *paymentResolver) FxRate() float64 {
+	return pr.view.FxRate
+}
+
+func (pr *paymentResolver) Date() graphql.Time {
+	return graphql.Time{Time: pr.view.Date}
+}
+
+func (pr *paymentResolver) Processed() bool {
+	return pr.view.Processed
+}
+
+func (pr *paymentResolver) ProcessedDate() *graphql.Time {
+	return optionalTime(pr.view.ProcessedDate)
+}
+
+func (pr *paymentResolver) RefundedAmount() float64 {
+	return pr.view.RefundedAmount
+}
+
+func (pr *paymentResolver) RefundableAmount() float64 {
+	return pr.view.RefundableAmount
+}
+
+func (pr *paymentResolver) RefundOf(ctx context.Context) (*paymentResolver, error) {
+
+	if pr.payment.RefundOfUid == nil {
+		return nil, nil
+	}
+
+	payment, errorLoad := loadersFrom(ctx).loadPayment(ctx, *pr.payment.RefundOfUid)
+
+	if errorLoad != nil {
+		return nil, graphErrorFor(errorLoad)
+	}
+
+	if payment == nil {
+		return nil, nil
+	}
+
+	return newPaymentResolver(payment), nil
+}
+
+func (pr *paymentResolver) RiskDecision() *string {
+	return optional(pr.view.RiskDecision)
+}
+
+func (pr *paymentResolver) ReviewStatus() *string {
+	return optional(pr.view.ReviewStatus)
+}
+
+func (pr *paymentResolver) ApprovalStatus() *string {
+	return optional(pr.view.ApprovalStatus)
+}
+
+func (pr *paymentResolver) CreatedBy() *string {
+	return optional(pr.view.CreatedBy)
+}
+
+func (pr *paymentResolver) Tenant() *string {
+	return optional(pr.view.Tenant)
+}
+
+func (pr *paymentResolver) Reference() *string {
+	return optional(pr.view.Reference)
+}
+
+func (pr *paymentResolver) Description() *string {
+	return optional(pr.view.Description)
+}
+
+// Metadata is a list of entries, as GraphQL has no maps, sorted by key.
+func (pr *paymentResolver) Metadata() []metadataEntry {
+
+	entries := []metadataEntry{}
+
+	for key, value := range pr.view.Metadata {
+		entries = append(entries, metadataEntry{Key: key, Value: value})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+
+	return entries
+}
+
+func (pr *paymentResolver) CancelledAt() *graphql.Time {
+	return optionalTime(pr.view.CancelledAt)
+}
+
+func (pr *paymentResolver) CancelReasonCode() *string {
+	return optional(pr.view.CancelReasonCode)
+}
+
+func (pr *paymentResolver) ProcessingStatus() *string {
+	return optional(pr.view.ProcessingStatus)
+}
+
+func (pr *paymentResolver) RailStatus() *string {
+	return optional(pr.view.RailStatus)
+}
+
+func (pr *paymentResolver) FeeAmount() float64 {
+	return pr.view.FeeAmount
+}
+
+func (pr *paymentResolver) FeeCurrency() *string {
+	return optional(pr.view.FeeCurrency)
+}
+
+func (pr *paymentResolver) FeeBearer() *string {
+	return optional(pr.view.FeeBearer)
+}
+
+func (pr *paymentResolver) AuthorizationStatus() *string {
+	return optional(pr.view.AuthorizationStatus)
+}
+
+func (pr *paymentResolver) BeneficiaryUid() *graphql.ID {
+
+	if pr.view.BeneficiaryUid == nil {
+		return nil
+	}
+
+	beneficiaryUid := graphql.ID(*pr.view.BeneficiaryUid)
+
+	return &beneficiaryUid
+}
+
+func (asr *accountSummaryResolver) Account() string {
+	return identifier.Format(asr.account)
+}
+
+// Totals are loaded in batches, for all the accounts shown in the response at once.
+func (asr *accountSummaryResolver) Totals(ctx context.Context) ([]accountTotal, error) {
+
+	totals, errorLoad := loadersFrom(ctx).loadAccountTotals(ctx, asr.account)
+
+	if errorLoad != nil {
+		return nil, graphErrorFor(errorLoad)
+	}
+
+	results := []accountTotal{}
+
+	for _, total := range totals {
+		results = append(results, newAccountTotal(total))
+	}
+
+	return results, nil
+}
+
+func (cr *connectionResolver) Edges() []*edgeResolver {
+	return cr.edges
+}
+
+func (cr *connectionResolver) PageInfo() *pageInfo {
+	return cr.pageInfo
+}
+
+func (cr *connectionResolver) TotalCount() int32 {
+	return cr.totalCount
+}
+
+func (er *edgeResolver) Cursor() string {
+	return er.cursor
+}
+
+func (er *edgeResolver) Node() *paymentResolver {
+	return er.node
+}
+
+func (er *eventResolver) Type() string {
+	return er.change.Type
+}
+
+func (er *eventResolver) Payment() *paymentResolver {
+	return newPaymentResolver(er.change.Payment)
+}
+
+func (er *eventResolver) Time() graphql.Time {
+	return graphql.Time{Time: er.change.ChangedAt}
+}
+
+func (ge *graphError) Error() string {
+	return ge.err.Error()
+}
+
+func (ge *graphError) Extensions() map[string]interface{} {
+	return ge.extensions
+}
+
+//
+// private functions
+
+// graphErrorFor classifies an error the way util.WriteErrorFor picks an HTTP status.
+func graphErrorFor(err error) error {
+
+	code := "INTERNAL"
+	extensions := map[string]interface{}{}
+
+	switch typed := err.(type) {
+
+	case *util.InputError:
+		code = "BAD_USER_INPUT"
+
+	case *util.ConflictError:
+		code = "CONFLICT"
+
+	case *util.RejectedError:
+		code = "REJECTED"
+
+		if typed.Details != nil {
+			extensions["details"] = jsonValue(typed.Details)
+		}
+
+	case *util.ForbiddenError:
+		code = "FORBIDDEN"
+
+	default:
+		if strings.Contains(err.Error(), "not found") {
+			code = "NOT_FOUND"
+		}
+	}
+
+	extensions["code"] = code
+
+	return &graphError{err: err, extensions: extensions}
+}
+
+// jsonValue turns the details of a rejection into what the REST API would write for them.
+func jsonValue(value interface{}) interface{} {
+
+	valueJson, errorJson := json.Marshal(value)
+
+	if errorJson != nil {
+		return nil
+	}
+
+	var result interface{}
+
+	json.Unmarshal(valueJson, &result)
+
+	return result
+}
+
+func newAccountTotal(total repository.AccountTotal) accountTotal {
+
+	return accountTotal{
+		Currency:       total.Currency,
+		SentCount:      int32(total.SentCount),
+		SentAmount:     total.SentAmount,
+		ReceivedCount:  int32(total.ReceivedCount),
+		ReceivedAmount: total.ReceivedAmount,
+	}
+}
+
+func optional(value string) *string {
+
+	if value == "" {
+		return nil
+	}
+
+	return &value
+}
+
+func optionalTime(value *time.Time) *graphql.Time {
+
+	if value == nil {
+		return nil
+	}
+
+	return &graphql.Time{Time: *value}
+}
@@ -0,0 +1,346 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/payment/model"
+	"math"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+const CONTENT_TYPE_MERGE_PATCH = "application/merge-patch+json"
+
+// fields of PaymentView that exist but can never be amended
+var immutableFields = map[string]bool{
+	"uid": true, "currency": true, "targetCurrency": true, "targetAmount": true, "fxRate": true, "fxQuoteUid": true,
+	"processed": true, "processedDate": true, "refundedAmount": true, "refundableAmount": true, "refundOfUid": true,
+	"riskDecision": true, "riskRules": true, "reviewStatus": true, "reviewedBy": true, "reviewedAt": true,
+	"createdBy": true, "tenant": true, "approvalStatus": true, "approvalLevels": true, "approvalExpiresAt": true,
+	"cancelledAt": true, "cancelledBy": true, "cancelReasonCode": true, "cancelNote": true,
+	"processingStatus": true, "processingAttempts": true, "processingError": true, "nextAttemptAt": true,
+	"rail": true, "railReference": true, "railStatus": true, "railReason": true,
+	"reconciledAt": true, "reconciliationUid": true, "feeAmount": true, "feeCurrency": true, "feeBearer": true,
+	"authorizationStatus": true, "authorizedAmount": true, "capturedAmount": true, "authorizationExpiresAt": true,
+	"beneficiaryUid": true,
+}
+
+type AmendmentView struct {
+	Revision  int       `json:"revision"`
+	Field     string    `json:"field"`
+	OldValue  string    `json:"oldValue"`
+	NewValue  string    `json:"newValue"`
+	AmendedBy string    `json:"amendedBy,omitempty"`
+	Date      time.Time `json:"date"`
+}
+
+// AmendPaymentByUid applies a JSON Merge Patch (RFC 7396) to an unprocessed payment.
+func (ph *PaymentHandler) AmendPaymentByUid(w http.ResponseWriter, r *http.Request) {
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	if mediaType != CONTENT_TYPE_MERGE_PATCH && mediaType != "application/json" {
+		util.WriteError(w, http.StatusUnsupportedMediaType, "content type must be "+CONTENT_TYPE_MERGE_PATCH)
+		return
+	}
+
+	var patch map[string]json.RawMessage
+
+	errorJson := json.NewDecoder(r.Body).Decode(&patch)
+
+	if errorJson != nil || patch == nil {
+		util.WriteError(w, http.StatusBadRequest, "patch must be a JSON object")
+		return
+	}
+
+	amendedBy := auth.FromRequest(r).User
+
+	payment, errorAmend := ph.amender.Amend(mux.Vars(r)["uid"], func(payment *model.Payment, revision int) ([]model.PaymentAmendment, error) {
+
+		accountOrigin, accountTarget, amount := payment.AccountOrigin, payment.AccountTarget, payment.Amount
+
+		amendments, paymentCreate, errorPatch := applyMergePatch(payment, patch, amendedBy, revision)
+
+		if errorPatch != nil {
+			return nil, errorPatch
+		}
+
+		if payment.AccountOrigin != accountOrigin || payment.AccountTarget != accountTarget || payment.Amount > amount {
+
+			for _, recheckHook := range ph.recheckHooks {
+
+				if errorHook := recheckHook.BeforeCreate(payment, paymentCreate); errorHook != nil {
+					return nil, errorHook
+				}
+			}
+		}
+
+		for _, amendHook := range ph.amendHooks {
+
+			if errorHook := amendHook.AfterAmend(payment); errorHook != nil {
+				return nil, errorHook
+			}
+		}
+
+		return amendments, nil
+	})
+
+	if errorAmend != nil {
+		util.WriteErrorFor(w, errorAmend)
+		return
+	}
+
+	util.WritePayload(w, http.StatusOK, NewPaymentView(payment))
+}
+
+func (ph *PaymentHandler) GetPaymentHistoryByUid(w http.ResponseWriter, r *http.Request) {
+
+	payment, responseGenerated := ph.getAndCheckPaymentByUid(w, r, false)
+
+	if responseGenerated {
+		return
+	}
+
+	amendments, errorDB := ph.paymentRepository.GetAmendments(payment.Uid)
+
+	if errorDB != nil {
+		util.WriteError(w, http.StatusInternalServerError, errorDB.Error())
+		return
+	}
+
+	results := []AmendmentView{}
+
+	for _, amendment := range amendments {
+
+		results = append(results, AmendmentView{
+			Revision:  amendment.Revision,
+			Field:     amendment.Field,
+			OldValue:  amendment.OldValue,
+			NewValue:  amendment.NewValue,
+			AmendedBy: amendment.AmendedBy,
+			Date:      amendment.CreatedAt,
+		})
+	}
+
+	util.WritePayload(w, http.StatusOK, results)
+}
+
+//
+// private functions
+
+func applyMergePatch(payment *model.Payment, patch map[string]json.RawMessage, amendedBy string, revision int) ([]model.PaymentAmendment, *PaymentCreate, error) {
+
+	if payment.Processed {
+		return nil, nil, &util.ConflictError{Message: "Payment already processed"}
+	}
+
+	if payment.IsCancelled() {
+		return nil, nil, &util.ConflictError{Message: "Payment cancelled"}
+	}
+
+	if payment.IsProcessing() {
+		return nil, nil, &util.ConflictError{Message: "Payment is being processed"}
+	}
+
+	// the risk rules and approvals run again on an amendment, a refusal must not turn back into a pending decision
+	if payment.ReviewStatus == model.REVIEW_STATUS_REJECTED || payment.ApprovalStatus == model.APPROVAL_STATUS_REJECTED || payment.ApprovalStatus == model.APPROVAL_STATUS_EXPIRED {
+		return nil, nil, &util.ConflictError{Message: "Payment rejected by review or approval"}
+	}
+
+	metadata := map[string]string{}
+
+	if payment.Metadata != "" {
+		json.Unmarshal([]byte(payment.Metadata), &metadata)
+	}
+
+	paymentCreate := &PaymentCreate{
+		AccountOrigin:  payment.AccountOrigin,
+		AccountTarget:  payment.AccountTarget,
+		Amount:         payment.Amount,
+		Currency:       payment.Currency,
+		TargetCurrency: payment.TargetCurrency,
+		Date:           payment.Date,
+		Reference:      payment.Reference,
+		Description:    payment.Description,
+		Metadata:       map[string]string{},
+	}
+
+	for key, value := range metadata {
+		paymentCreate.Metadata[key] = value
+	}
+
+	for field, value := range patch {
+
+		var errorField error
+
+		switch field {
+
+		case "accountOrigin":
+			errorField = decodeRequired(value, &paymentCreate.AccountOrigin)
+
+		case "accountTarget":
+			errorField = decodeRequired(value, &paymentCreate.AccountTarget)
+
+		case "amount":
+			errorField = decodeRequired(value, &paymentCreate.Amount)
+
+		case "date":
+			errorField = decodeRequired(value, &paymentCreate.Date)
+
+		case "reference":
+			errorField = decodeOptional(value, &paymentCreate.Reference)
+
+		case "description":
+			errorField = decodeOptional(value, &paymentCreate.Description)
+
+		case "metadata":
+			errorField = mergeMetadata(value, paymentCreate)
+
+		default:
+
+			if immutableFields[field] {
+				return nil, nil, &util.InputError{Message: fmt.Sprintf("field '%s' cannot be changed", field)}
+			}
+
+			return nil, nil, &util.InputError{Message: fmt.Sprintf("unknown field '%s'", field)}
+		}
+
+		if errorField != nil {
+			return nil, nil, &util.InputError{Message: fmt.Sprintf("invalid %s: %v", field, errorField)}
+		}
+	}
+
+	if errorValidation := ValidatePaymentCreate(paymentCreate); errorValidation != nil {
+		return nil, nil, &util.InputError{Message: errorValidation.Error()}
+	}
+
+	accountsChanged := paymentCreate.AccountOrigin != payment.AccountOrigin || paymentCreate.AccountTarget != payment.AccountTarget
+	amountChanged := paymentCreate.Amount != payment.Amount
+
+	if paymentCreate.AccountTarget != payment.AccountTarget && payment.BeneficiaryUid != nil {
+		return nil, nil, &util.ConflictError{Message: "account target is fixed by the beneficiary of the payment"}
+	}
+
+	if accountsChanged && (payment.ApprovalStatus == model.APPROVAL_STATUS_APPROVED || payment.ReviewStatus == model.REVIEW_STATUS_APPROVED) {
+		return nil, nil, &util.ConflictError{Message: "accounts cannot change once the payment is approved"}
+	}
+
+	// a larger amount would skip the approval given for the original one
+	if paymentCreate.Amount > payment.Amount && payment.ApprovalStatus != "" {
+		return nil, nil, &util.ConflictError{Message: "amount can only decrease once approval was requested"}
+	}
+
+	if amountChanged && payment.FxQuoteUid != nil {
+		return nil, nil, &util.ConflictError{Message: "amount is fixed by the FX quote of the payment"}
+	}
+
+	if amountChanged && payment.AuthorizationStatus != "" {
+		return nil, nil, &util.ConflictError{Message: "amount is fixed by the authorization of the payment"}
+	}
+
+	newMetadata, errorJson := marshalMetadata(paymentCreate.Metadata)
+
+	if errorJson != nil {
+		return nil, nil, errorJson
+	}
+
+	var amendments []model.PaymentAmendment
+
+	amend := func(field string, oldValue string, newValue string) {
+
+		if oldValue != newValue {
+			amendments = append(amendments, model.PaymentAmendment{
+				PaymentUid: payment.Uid,
+				Revision:   revision,
+				Field:      field,
+				OldValue:   oldValue,
+				NewValue:   newValue,
+				AmendedBy:  amendedBy,
+			})
+		}
+	}
+
+	amend("accountOrigin", payment.AccountOrigin, paymentCreate.AccountOrigin)
+	amend("accountTarget", payment.AccountTarget, paymentCreate.AccountTarget)
+	amend("amount", formatAmount(payment.Amount), formatAmount(paymentCreate.Amount))
+	amend("reference", payment.Reference, paymentCreate.Reference)
+	amend("description", payment.Description, paymentCreate.Description)
+
+	if !payment.Date.Equal(paymentCreate.Date) {
+		amend("date", payment.Date.Format(time.RFC3339), paymentCreate.Date.Format(time.RFC3339))
+	}
+
+	if !reflect.DeepEqual(metadata, paymentCreate.Metadata) {
+		amend("metadata", payment.Metadata, newMetadata)
+	}
+
+	payment.AccountOrigin = paymentCreate.AccountOrigin
+	payment.AccountTarget = paymentCreate.AccountTarget
+	payment.Date = paymentCreate.Date
+	payment.Reference = paymentCreate.Reference
+	payment.Description = paymentCreate.Description
+	payment.Metadata = newMetadata
+
+	if amountChanged {
+		payment.Amount = paymentCreate.Amount
+		payment.TargetAmount = math.Round(paymentCreate.Amount*payment.FxRate*100) / 100
+	}
+
+	return amendments, paymentCreate, nil
+}
+
+func decodeRequired(value json.RawMessage, target interface{}) error {
+
+	if string(value) == "null" {
+		return fmt.Errorf("it cannot be removed")
+	}
+
+	return json.Unmarshal(value, target)
+}
+
+func decodeOptional(value json.RawMessage, target *string) error {
+
+	if string(value) == "null" {
+		*target = ""
+		return nil
+	}
+
+	return json.Unmarshal(value, target)
+}
+
+// mergeMetadata merges the patch into the metadata: null removes a key, or all of them when it is the whole value.
+func mergeMetadata(value json.RawMessage, paymentCreate *PaymentCreate) error {
+
+	if string(value) == "null" {
+		paymentCreate.Metadata = map[string]string{}
+		return nil
+	}
+
+	var patch map[string]*string
+
+	if errorJson := json.Unmarshal(value, &patch); errorJson != nil {
+		return fmt.Errorf("it must be an object of strings")
+	}
+
+	for key, item := range patch {
+
+		if item == nil {
+			delete(paymentCreate.Metadata, key)
+			continue
+		}
+
+		paymentCreate.Metadata[key] = *item
+	}
+
+	return nil
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', -1, 64)
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/payment/model"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const MAX_CANCEL_NOTE_LENGTH = 500
+
+type PaymentCancel struct {
+	ReasonCode string `json:"reasonCode"`
+	Note       string `json:"note"`
+}
+
+// CancelPaymentByUid stops an unprocessed payment for good; unlike deleting it, the payment stays queryable.
+func (ph *PaymentHandler) CancelPaymentByUid(w http.ResponseWriter, r *http.Request) {
+
+	var paymentCancel PaymentCancel
+
+	errorJson := json.NewDecoder(r.Body).Decode(&paymentCancel)
+
+	if errorJson != nil {
+		util.WriteError(w, http.StatusBadRequest, errorJson.Error())
+		return
+	}
+
+	errorValidation := ph.validatePaymentCancel(&paymentCancel)
+
+	if errorValidation != nil {
+		util.WriteError(w, http.StatusBadRequest, errorValidation.Error())
+		return
+	}
+
+	cancelledBy := auth.FromRequest(r).User
+
+	payment, errorCancel := ph.paymentRepository.Amend(mux.Vars(r)["uid"], func(payment *model.Payment, revision int) ([]model.PaymentAmendment, error) {
+
+		if payment.Processed {
+			return nil, &util.ConflictError{Message: "Payment already processed"}
+		}
+
+		if payment.IsCancelled() {
+			return nil, &util.ConflictError{Message: "Payment already cancelled"}
+		}
+
+		// a queued job gives up once it sees the cancellation, a running one can not be stopped
+		if payment.ProcessingStatus == model.PROCESSING_STATUS_RUNNING {
+			return nil, &util.ConflictError{Message: "Payment is being processed"}
+		}
+
+		if payment.ProcessingStatus == model.PROCESSING_STATUS_SUBMITTED {
+			return nil, &util.ConflictError{Message: "Payment submitted to a rail, recall it there"}
+		}
+
+		payment.Cancel(paymentCancel.ReasonCode, paymentCancel.Note, cancelledBy, time.Now())
+
+		// the cancellation shows up in the history like any other change
+		return []model.PaymentAmendment{{
+			PaymentUid: payment.Uid,
+			Revision:   revision,
+			Field:      "cancelReasonCode",
+			NewValue:   paymentCancel.ReasonCode,
+			AmendedBy:  cancelledBy,
+		}}, nil
+	})
+
+	if errorCancel != nil {
+		util.WriteErrorFor(w, errorCancel)
+		return
+	}
+
+	util.WritePayload(w, http.StatusOK, NewPaymentView(payment))
+}
+
+//
+// private functions
+
+func (ph *PaymentHandler) validatePaymentCancel(paymentCancel *PaymentCancel) error {
+
+	paymentCancel.ReasonCode = strings.ToUpper(strings.TrimSpace(paymentCancel.ReasonCode))
+
+	if !ph.cancelReasons[paymentCancel.ReasonCode] {
+
+		var reasonCodes []string
+
+		for reasonCode := range ph.cancelReasons {
+			reasonCodes = append(reasonCodes, reasonCode)
+		}
+
+		sort.Strings(reasonCodes)
+
+		return fmt.Errorf("reasonCode must be one of [%s]", strings.Join(reasonCodes, ", "))
+	}
+
+	if utf8.RuneCountInString(paymentCancel.Note) > MAX_CANCEL_NOTE_LENGTH {
+		return fmt.Errorf("note can not be longer than %d characters", MAX_CANCEL_NOTE_LENGTH)
+	}
+
+	return nil
+}
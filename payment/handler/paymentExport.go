@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/payment/repository"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	CONTENT_TYPE_CSV    = "text/csv"
+	CONTENT_TYPE_NDJSON = "application/x-ndjson"
+	CONTENT_TYPE_XML    = "application/xml"
+
+	EXPORT_FLUSH_EVERY = 100
+)
+
+type paymentExporter interface {
+	Begin() error
+	Write(view *PaymentView) error
+	End() error
+}
+
+//
+// private functions
+
+func negotiateExportContentType(accept string) string {
+
+	for _, mediaRange := range strings.Split(accept, ",") {
+
+		mediaType := strings.TrimSpace(strings.Split(mediaRange, ";")[0])
+
+		switch mediaType {
+
+		case CONTENT_TYPE_CSV, CONTENT_TYPE_NDJSON, CONTENT_TYPE_XML:
+			return mediaType
+
+		case "application/json", "*/*":
+			return ""
+		}
+	}
+
+	return ""
+}
+
+func (ph *PaymentHandler) exportPayments(w http.ResponseWriter, contentType string, filter repository.PaymentFilter) {
+
+	exporter := newPaymentExporter(contentType, w)
+	flusher, canFlush := w.(http.Flusher)
+	started := false
+	count := 0
+
+	errorStream := ph.paymentRepository.Stream(filter, func(payment *model.Payment) error {
+
+		if !started {
+			started = true
+			w.Header().Set("Content-Type", contentType)
+			w.WriteHeader(http.StatusOK)
+
+			if errorBegin := exporter.Begin(); errorBegin != nil {
+				return errorBegin
+			}
+		}
+
+		if errorWrite := exporter.Write(NewPaymentView(payment)); errorWrite != nil {
+			return errorWrite
+		}
+
+		count++
+
+		if canFlush && count%EXPORT_FLUSH_EVERY == 0 {
+			flusher.Flush()
+		}
+
+		return nil
+	})
+
+	if errorStream != nil && !started {
+		util.WriteError(w, http.StatusInternalServerError, errorStream.Error())
+		return
+	}
+
+	if errorStream != nil {
+		// headers are already sent, so the truncated body is all we can give the client
+		log.Printf("Error exporting payments: %v\n", errorStream)
+		return
+	}
+
+	if !started {
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+		exporter.Begin()
+	}
+
+	exporter.End()
+}
+
+func newPaymentExporter(contentType string, w io.Writer) paymentExporter {
+
+	switch contentType {
+
+	case CONTENT_TYPE_CSV:
+		return &csvPaymentExporter{writer: csv.NewWriter(w)}
+
+	case CONTENT_TYPE_NDJSON:
+		return &ndjsonPaymentExporter{encoder: json.NewEncoder(w)}
+
+	default:
+		return &xmlPaymentExporter{encoder: xml.NewEncoder(w)}
+	}
+}
+
+//
+// csv
+
+type csvPaymentExporter struct {
+	writer *csv.Writer
+}
+
+func (cpe *csvPaymentExporter) Begin() error {
+	return cpe.writer.Write([]string{"uid", "accountOrigin", "accountTarget", "amount", "currency", "date", "processed", "processedDate", "reference"})
+}
+
+func (cpe *csvPaymentExporter) Write(view *PaymentView) error {
+
+	processedDate := ""
+
+	if view.ProcessedDate != nil {
+		processedDate = view.ProcessedDate.Format(time.RFC3339)
+	}
+
+	errorWrite := cpe.writer.Write([]string{
+		view.Uid,
+		view.AccountOrigin,
+		view.AccountTarget,
+		strconv.FormatFloat(view.Amount, 'f', -1, 64),
+		view.Currency,
+		view.Date.Format(time.RFC3339),
+		strconv.FormatBool(view.Processed),
+		processedDate,
+		view.Reference,
+	})
+
+	if errorWrite != nil {
+		return errorWrite
+	}
+
+	cpe.writer.Flush()
+
+	return cpe.writer.Error()
+}
+
+func (cpe *csvPaymentExporter) End() error {
+
+	cpe.writer.Flush()
+
+	return cpe.writer.Error()
+}
+
+//
+// ndjson
+
+type ndjsonPaymentExporter struct {
+	encoder *json.Encoder
+}
+
+func (npe *ndjsonPaymentExporter) Begin() error {
+	return nil
+}
+
+func (npe *ndjsonPaymentExporter) Write(view *PaymentView) error {
+	return npe.encoder.Encode(view)
+}
+
+func (npe *ndjsonPaymentExporter) End() error {
+	return nil
+}
+
+//
+// xml
+
+type xmlPaymentExporter struct {
+	encoder *xml.Encoder
+}
+
+var xmlPaymentsElement = xml.StartElement{Name: xml.Name{Local: "payments"}}
+
+func (xpe *xmlPaymentExporter) Begin() error {
+	return xpe.encoder.EncodeToken(xmlPaymentsElement)
+}
+
+func (xpe *xmlPaymentExporter) Write(view *PaymentView) error {
+	return xpe.encoder.EncodeElement(view, xml.StartElement{Name: xml.Name{Local: "payment"}})
+}
+
+func (xpe *xmlPaymentExporter) End() error {
+
+	errorEnd := xpe.encoder.EncodeToken(xmlPaymentsElement.End())
+
+	if errorEnd != nil {
+		return errorEnd
+	}
+
+	return xpe.encoder.Flush()
+}
@@ -2,54 +2,270 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/account/identifier"
+	"github.com/javierjmgits/go-payment-api/base/auth"
 	"github.com/javierjmgits/go-payment-api/base/util"
 	"github.com/javierjmgits/go-payment-api/payment/model"
 	"github.com/javierjmgits/go-payment-api/payment/repository"
 	"github.com/satori/go.uuid"
 	"net/http"
+	"regexp"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	MAX_REFERENCE_LENGTH      = 35
+	MAX_DESCRIPTION_LENGTH    = 140
+	MAX_METADATA_ENTRIES      = 20
+	MAX_METADATA_VALUE_LENGTH = 500
+
+	HEADER_IDEMPOTENCY_KEY     = "Idempotency-Key"
+	MAX_IDEMPOTENCY_KEY_LENGTH = 64
+)
+
+var (
+	// the SEPA character set, so references survive any payment rail
+	referencePattern   = regexp.MustCompile(`^[A-Za-z0-9/?:().,'+ -]*$`)
+	metadataKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,40}$`)
 )
 
 type PaymentHandler struct {
 	paymentRepository repository.PaymentRepository
+	creator           Creator
+	amender           Amender
+	enqueuer          Enqueuer
+	createHooks       []CreateHook
+	recheckHooks      []CreateHook
+	amendHooks        []AmendHook
+	targetResolver    TargetResolver
+	cancelReasons     map[string]bool
+	deleteScope       string
+}
+
+// CreateHook lets other subsystems complete or reject a payment before it is persisted.
+type CreateHook interface {
+	BeforeCreate(payment *model.Payment, paymentCreate *PaymentCreate) error
+}
+
+// CreateAborter is a create hook that reserves something for the payment, e.g. a quote, and gives it back when the
+// payment is not created after all because a later hook or the creator refused it.
+type CreateAborter interface {
+	AbortCreate(payment *model.Payment)
+}
+
+// AmendHook lets other subsystems update the values they derive from an amended payment before it is persisted.
+type AmendHook interface {
+	AfterAmend(payment *model.Payment) error
+}
+
+// TargetResolver fills the target account of a payment that is sent to a saved beneficiary.
+type TargetResolver interface {
+	ResolveTarget(paymentCreate *PaymentCreate, principal *auth.Principal) error
+}
+
+// Creator persists new payments; by default the payment repository, but it can be replaced to create them under extra checks.
+type Creator interface {
+	Create(payment *model.Payment) (*model.Payment, error)
+}
+
+// Amender persists amendments of payments; by default the payment repository, but it can be replaced to amend them under extra checks.
+type Amender interface {
+	Amend(uid string, amend func(*model.Payment, int) ([]model.PaymentAmendment, error)) (*model.Payment, error)
+}
+
+// Enqueuer queues a payment for the processing workers, e.g. the processing service.
+type Enqueuer interface {
+	Enqueue(paymentUid string) (*model.Payment, error)
 }
 
 type PaymentView struct {
-	Uid           string     `json:"uid"`
-	AccountOrigin string     `json:"accountOrigin"`
-	AccountTarget string     `json:"accountTarget"`
-	Amount        float64    `json:"amount"`
-	Date          time.Time  `json:"date"`
-	Processed     bool       `json:"processed"`
-	ProcessedDate *time.Time `json:"processedDate"`
+	Uid                    string            `json:"uid" xml:"uid"`
+	AccountOrigin          string            `json:"accountOrigin" xml:"accountOrigin"`
+	AccountTarget          string            `json:"accountTarget" xml:"accountTarget"`
+	Amount                 float64           `json:"amount" xml:"amount"`
+	Currency               string            `json:"currency" xml:"currency"`
+	TargetCurrency         string            `json:"targetCurrency" xml:"targetCurrency"`
+	TargetAmount           float64           `json:"targetAmount" xml:"targetAmount"`
+	FxRate                 float64           `json:"fxRate" xml:"fxRate"`
+	FxQuoteUid             *string           `json:"fxQuoteUid,omitempty" xml:"fxQuoteUid,omitempty"`
+	Date                   time.Time         `json:"date" xml:"date"`
+	Processed              bool              `json:"processed" xml:"processed"`
+	ProcessedDate          *time.Time        `json:"processedDate" xml:"processedDate,omitempty"`
+	RefundedAmount         float64           `json:"refundedAmount" xml:"refundedAmount"`
+	RefundableAmount       float64           `json:"refundableAmount" xml:"refundableAmount"`
+	RefundOfUid            *string           `json:"refundOfUid,omitempty" xml:"refundOfUid,omitempty"`
+	RiskDecision           string            `json:"riskDecision,omitempty" xml:"riskDecision,omitempty"`
+	RiskRules              []RiskRule        `json:"riskRules,omitempty" xml:"riskRules>riskRule,omitempty"`
+	ReviewStatus           string            `json:"reviewStatus,omitempty" xml:"reviewStatus,omitempty"`
+	ReviewedBy             *string           `json:"reviewedBy,omitempty" xml:"reviewedBy,omitempty"`
+	ReviewedAt             *time.Time        `json:"reviewedAt,omitempty" xml:"reviewedAt,omitempty"`
+	CreatedBy              string            `json:"createdBy,omitempty" xml:"createdBy,omitempty"`
+	Tenant                 string            `json:"tenant,omitempty" xml:"tenant,omitempty"`
+	ApprovalStatus         string            `json:"approvalStatus,omitempty" xml:"approvalStatus,omitempty"`
+	ApprovalLevels         int               `json:"approvalLevels,omitempty" xml:"approvalLevels,omitempty"`
+	ApprovalExpiresAt      *time.Time        `json:"approvalExpiresAt,omitempty" xml:"approvalExpiresAt,omitempty"`
+	Reference              string            `json:"reference,omitempty" xml:"reference,omitempty"`
+	Description            string            `json:"description,omitempty" xml:"description,omitempty"`
+	Metadata               map[string]string `json:"metadata,omitempty" xml:"-"`
+	CancelledAt            *time.Time        `json:"cancelledAt,omitempty" xml:"cancelledAt,omitempty"`
+	CancelledBy            string            `json:"cancelledBy,omitempty" xml:"cancelledBy,omitempty"`
+	CancelReasonCode       string            `json:"cancelReasonCode,omitempty" xml:"cancelReasonCode,omitempty"`
+	CancelNote             string            `json:"cancelNote,omitempty" xml:"cancelNote,omitempty"`
+	ProcessingStatus       string            `json:"processingStatus,omitempty" xml:"processingStatus,omitempty"`
+	ProcessingAttempts     int               `json:"processingAttempts,omitempty" xml:"processingAttempts,omitempty"`
+	ProcessingError        string            `json:"processingError,omitempty" xml:"processingError,omitempty"`
+	NextAttemptAt          *time.Time        `json:"nextAttemptAt,omitempty" xml:"nextAttemptAt,omitempty"`
+	Rail                   string            `json:"rail,omitempty" xml:"rail,omitempty"`
+	RailReference          *string           `json:"railReference,omitempty" xml:"railReference,omitempty"`
+	RailStatus             string            `json:"railStatus,omitempty" xml:"railStatus,omitempty"`
+	RailReason             string            `json:"railReason,omitempty" xml:"railReason,omitempty"`
+	ReconciledAt           *time.Time        `json:"reconciledAt,omitempty" xml:"reconciledAt,omitempty"`
+	ReconciliationUid      *string           `json:"reconciliationUid,omitempty" xml:"reconciliationUid,omitempty"`
+	FeeAmount              float64           `json:"feeAmount" xml:"feeAmount"`
+	FeeCurrency            string            `json:"feeCurrency,omitempty" xml:"feeCurrency,omitempty"`
+	FeeBearer              string            `json:"feeBearer,omitempty" xml:"feeBearer,omitempty"`
+	AuthorizationStatus    string            `json:"authorizationStatus,omitempty" xml:"authorizationStatus,omitempty"`
+	AuthorizedAmount       float64           `json:"authorizedAmount,omitempty" xml:"authorizedAmount,omitempty"`
+	CapturedAmount         float64           `json:"capturedAmount,omitempty" xml:"capturedAmount,omitempty"`
+	AuthorizationExpiresAt *time.Time        `json:"authorizationExpiresAt,omitempty" xml:"authorizationExpiresAt,omitempty"`
+	BeneficiaryUid         *string           `json:"beneficiaryUid,omitempty" xml:"beneficiaryUid,omitempty"`
+}
+
+type RiskRule struct {
+	Rule     string `json:"rule" xml:"rule"`
+	Decision string `json:"decision" xml:"decision"`
+	Reason   string `json:"reason" xml:"reason"`
 }
 
 type PaymentCreate struct {
-	AccountOrigin string    `json:"accountOrigin"`
-	AccountTarget string    `json:"accountTarget"`
-	Amount        float64   `json:"amount"`
-	Date          time.Time `json:"date"`
+	AccountOrigin  string            `json:"accountOrigin"`
+	AccountTarget  string            `json:"accountTarget"`
+	Amount         float64           `json:"amount"`
+	Currency       string            `json:"currency"`
+	TargetCurrency string            `json:"targetCurrency"`
+	FxQuoteUid     string            `json:"fxQuoteUid"`
+	Date           time.Time         `json:"date"`
+	Reference      string            `json:"reference"`
+	Description    string            `json:"description"`
+	Metadata       map[string]string `json:"metadata"`
+	FeeBearer      string            `json:"feeBearer"`
+	BeneficiaryUid string            `json:"beneficiaryUid"`
+	Authorize      bool              `json:"authorize"`
+	IdempotencyKey string            `json:"-"`
+
+	// set by the batch importer and the standing orders for the payments they create
+	BatchUid         string `json:"-"`
+	StandingOrderUid string `json:"-"`
 }
 
 func NewPaymentHandler(paymentRepository repository.PaymentRepository) *PaymentHandler {
 
 	return &PaymentHandler{
 		paymentRepository: paymentRepository,
+		creator:           paymentRepository,
+		amender:           paymentRepository,
 	}
 }
 
+func (ph *PaymentHandler) SetCreator(creator Creator) {
+
+	ph.creator = creator
+}
+
+func (ph *PaymentHandler) AddCreateHook(createHook CreateHook) {
+
+	ph.createHooks = append(ph.createHooks, createHook)
+}
+
+func (ph *PaymentHandler) SetAmender(amender Amender) {
+
+	ph.amender = amender
+}
+
+// SetEnqueuer makes flagging a payment as processed queue it for the processing workers instead, so that it goes
+// through the processor like any other payment.
+func (ph *PaymentHandler) SetEnqueuer(enqueuer Enqueuer) {
+
+	ph.enqueuer = enqueuer
+}
+
+// AddRecheckHook registers a create hook that is run again when an amendment changes the accounts of a payment or
+// raises its amount, e.g. the risk rules or the approvals.
+func (ph *PaymentHandler) AddRecheckHook(createHook CreateHook) {
+
+	ph.recheckHooks = append(ph.recheckHooks, createHook)
+}
+
+// SetTargetResolver lets payments reference a saved beneficiary instead of a target account.
+func (ph *PaymentHandler) SetTargetResolver(targetResolver TargetResolver) {
+
+	ph.targetResolver = targetResolver
+}
+
+func (ph *PaymentHandler) AddAmendHook(amendHook AmendHook) {
+
+	ph.amendHooks = append(ph.amendHooks, amendHook)
+}
+
+// SetCancelReasons sets the catalogue of reason codes a payment can be cancelled with.
+func (ph *PaymentHandler) SetCancelReasons(reasonCodes []string) {
+
+	ph.cancelReasons = map[string]bool{}
+
+	for _, reasonCode := range reasonCodes {
+
+		if reasonCode = strings.TrimSpace(reasonCode); reasonCode != "" {
+			ph.cancelReasons[reasonCode] = true
+		}
+	}
+}
+
+// SetDeleteScope restricts deleting payments to callers with the given scope; empty lets anyone delete them.
+func (ph *PaymentHandler) SetDeleteScope(scope string) {
+
+	ph.deleteScope = scope
+}
+
 func (ph *PaymentHandler) Register(router *mux.Router) {
 	router.HandleFunc("/api/v1/payments", ph.GetPayments).Methods("GET")
+	router.HandleFunc("/api/v1/payments/statement", ph.GetStatement).Methods("GET")
+	router.HandleFunc("/api/v1/payments/search", ph.SearchPayments).Methods("GET")
 	router.HandleFunc("/api/v1/payments/uid/{uid}", ph.GetPaymentByUid).Methods("GET")
 	router.HandleFunc("/api/v1/payments", ph.CreatePayment).Methods("POST")
+	router.HandleFunc("/api/v1/payments/uid/{uid}", ph.AmendPaymentByUid).Methods("PATCH")
+	router.HandleFunc("/api/v1/payments/uid/{uid}/history", ph.GetPaymentHistoryByUid).Methods("GET")
 	router.HandleFunc("/api/v1/payments/uid/{uid}/processed", ph.FlagPaymentAsProcessedByUid).Methods("PATCH")
+	router.HandleFunc("/api/v1/payments/uid/{uid}/cancel", ph.CancelPaymentByUid).Methods("POST")
 	router.HandleFunc("/api/v1/payments/uid/{uid}", ph.DeletePaymentByUid).Methods("DELETE")
 }
 
 func (ph *PaymentHandler) GetPayments(w http.ResponseWriter, r *http.Request) {
 
+	filter, errorFilter := newPaymentFilter(r)
+
+	if errorFilter != nil {
+		util.WriteError(w, http.StatusBadRequest, errorFilter.Error())
+		return
+	}
+
+	exportContentType := negotiateExportContentType(r.Header.Get("Accept"))
+
+	if exportContentType != "" {
+		ph.exportPayments(w, exportContentType, *filter)
+		return
+	}
+
+	if !filter.IsEmpty() {
+		ph.getFilteredPayments(w, *filter)
+		return
+	}
+
 	payments, errorDB := ph.paymentRepository.GetAll()
 
 	if errorDB != nil {
@@ -68,135 +284,266 @@ func (ph *PaymentHandler) GetPaymentByUid(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	util.WritePayload(w, http.StatusOK, newPaymentView(payment))
+	util.WritePayload(w, http.StatusOK, NewPaymentView(payment))
 
 }
 
 func (ph *PaymentHandler) CreatePayment(w http.ResponseWriter, r *http.Request) {
 
-	paymentCreate, responseGenerated := decodeAndValidatePaymentCreate(w, r)
+	var paymentCreate PaymentCreate
 
-	if responseGenerated {
+	errorJson := json.NewDecoder(r.Body).Decode(&paymentCreate)
+
+	if errorJson != nil {
+		util.WriteError(w, http.StatusBadRequest, errorJson.Error())
 		return
 	}
 
-	paymentToSave, errorUuid := newPayment(paymentCreate)
+	paymentCreate.IdempotencyKey = r.Header.Get(HEADER_IDEMPOTENCY_KEY)
 
-	if errorUuid != nil {
-		util.WriteError(w, http.StatusInternalServerError, errorUuid.Error())
+	paymentSaved, errorCreate := ph.Create(&paymentCreate, auth.FromRequest(r))
+
+	if errorCreate != nil {
+		util.WriteErrorFor(w, errorCreate)
 		return
 	}
 
-	paymentSaved, errorDB := ph.paymentRepository.Create(paymentToSave)
+	util.WritePayload(w, http.StatusCreated, NewPaymentView(paymentSaved))
+}
 
-	if errorDB != nil {
-		util.WriteError(w, http.StatusInternalServerError, errorDB.Error())
+func (ph *PaymentHandler) FlagPaymentAsProcessedByUid(w http.ResponseWriter, r *http.Request) {
+
+	payment, errorProcessed := ph.MarkProcessed(mux.Vars(r)["uid"])
+
+	if errorProcessed != nil {
+		util.WriteErrorFor(w, errorProcessed)
+		return
+	}
+
+	// a queued payment is only accepted, the processing workers process it later
+	if ph.enqueuer != nil {
+		util.WritePayload(w, http.StatusAccepted, NewPaymentView(payment))
 		return
 	}
 
-	util.WritePayload(w, http.StatusCreated, newPaymentView(paymentSaved))
+	util.WritePayload(w, http.StatusOK, NewPaymentView(payment))
 }
 
-func (ph *PaymentHandler) FlagPaymentAsProcessedByUid(w http.ResponseWriter, r *http.Request) {
+// DeletePaymentByUid hides an unprocessed payment, or removes it for good with ?hard=true; prefer cancelling it.
+func (ph *PaymentHandler) DeletePaymentByUid(w http.ResponseWriter, r *http.Request) {
 
-	payment, responseGenerated := ph.getAndCheckPaymentByUid(w, r, true)
+	errorDelete := ph.Delete(mux.Vars(r)["uid"], auth.FromRequest(r), r.URL.Query().Get("hard") == "true")
 
-	if responseGenerated {
+	if errorDelete != nil {
+		util.WriteErrorFor(w, errorDelete)
 		return
 	}
 
-	now := time.Now().UTC().Truncate(time.Second)
+	util.WritePayload(w, http.StatusNoContent, map[string]string{})
+}
 
-	payment.Processed = true
-	payment.ProcessedDate = &now
+//
+// operations shared by the REST handlers and the gRPC service
 
-	payment, errorDB := ph.paymentRepository.Update(payment)
+// Create resolves, validates and saves a new payment through the create hooks and the creator. A create with the
+// idempotency key of an earlier one of the tenant returns the payment it made instead of making another.
+func (ph *PaymentHandler) Create(paymentCreate *PaymentCreate, principal *auth.Principal) (*model.Payment, error) {
 
-	if errorDB != nil {
-		util.WriteError(w, http.StatusInternalServerError, errorDB.Error())
-		return
+	if paymentCreate.IdempotencyKey != "" {
+
+		if len(paymentCreate.IdempotencyKey) > MAX_IDEMPOTENCY_KEY_LENGTH {
+			return nil, &util.InputError{Message: fmt.Sprintf("idempotency key must be at most %d characters", MAX_IDEMPOTENCY_KEY_LENGTH)}
+		}
+
+		paymentCreated, errorDB := ph.paymentRepository.GetByIdempotencyKey(principal.Tenant, paymentCreate.IdempotencyKey)
+
+		if errorDB != nil || paymentCreated != nil {
+			return paymentCreated, errorDB
+		}
 	}
 
-	util.WritePayload(w, http.StatusOK, newPaymentView(payment))
+	if paymentCreate.BeneficiaryUid != "" {
 
-}
+		if ph.targetResolver == nil || paymentCreate.AccountTarget != "" {
+			return nil, &util.InputError{Message: "give either an account target or a beneficiary"}
+		}
 
-func (ph *PaymentHandler) DeletePaymentByUid(w http.ResponseWriter, r *http.Request) {
+		if errorResolve := ph.targetResolver.ResolveTarget(paymentCreate, principal); errorResolve != nil {
+			return nil, errorResolve
+		}
+	}
 
-	payment, responseGenerated := ph.getAndCheckPaymentByUid(w, r, true)
+	if errorValidation := ValidatePaymentCreate(paymentCreate); errorValidation != nil {
+		return nil, &util.InputError{Message: errorValidation.Error()}
+	}
 
-	if responseGenerated {
-		return
+	paymentToSave, errorUuid := NewPayment(paymentCreate)
+
+	if errorUuid != nil {
+		return nil, errorUuid
 	}
 
-	errorDB := ph.paymentRepository.Delete(payment)
+	paymentToSave.CreatedBy = principal.User
+	paymentToSave.Tenant = principal.Tenant
 
-	if errorDB != nil {
-		util.WriteError(w, http.StatusInternalServerError, errorDB.Error())
-		return
+	if paymentCreate.IdempotencyKey != "" {
+		idempotencyKey := paymentCreate.IdempotencyKey
+		paymentToSave.IdempotencyKey = &idempotencyKey
 	}
 
-	util.WritePayload(w, http.StatusNoContent, map[string]string{})
-}
+	if paymentCreate.BeneficiaryUid != "" {
+		beneficiaryUid := paymentCreate.BeneficiaryUid
+		paymentToSave.BeneficiaryUid = &beneficiaryUid
+	}
 
-//
-// private functions
+	if paymentCreate.BatchUid != "" {
+		batchUid := paymentCreate.BatchUid
+		paymentToSave.BatchUid = &batchUid
+	}
 
-func (ph *PaymentHandler) getAndCheckPaymentByUid(w http.ResponseWriter, r *http.Request, ensureNotProcessed bool) (payment *model.Payment, responseGenerated bool) {
+	if paymentCreate.StandingOrderUid != "" {
+		standingOrderUid := paymentCreate.StandingOrderUid
+		paymentToSave.StandingOrderUid = &standingOrderUid
+	}
 
-	uid := mux.Vars(r)["uid"]
+	for index, createHook := range ph.createHooks {
 
-	payment, errorDB := ph.paymentRepository.GetByUid(uid)
+		if errorHook := createHook.BeforeCreate(paymentToSave, paymentCreate); errorHook != nil {
+			abortCreate(ph.createHooks[:index], paymentToSave)
+			return nil, errorHook
+		}
+	}
 
-	if errorDB != nil {
+	paymentSaved, errorCreate := ph.creator.Create(paymentToSave)
+
+	if errorCreate != nil {
+		abortCreate(ph.createHooks, paymentToSave)
+	}
 
-		if strings.Contains(errorDB.Error(), "not found") {
-			util.WriteError(w, http.StatusNotFound, errorDB.Error())
+	// a concurrent retry may have won the unique index on the key
+	if errorCreate != nil && paymentCreate.IdempotencyKey != "" {
 
-		} else {
-			util.WriteError(w, http.StatusInternalServerError, errorDB.Error())
+		if paymentCreated, _ := ph.paymentRepository.GetByIdempotencyKey(principal.Tenant, paymentCreate.IdempotencyKey); paymentCreated != nil {
+			return paymentCreated, nil
 		}
+	}
 
-		return nil, true
+	return paymentSaved, errorCreate
+}
 
+func (ph *PaymentHandler) Get(uid string) (*model.Payment, error) {
+
+	return ph.getAndCheck(uid, false)
+}
+
+// MarkProcessed flags a payment as processed by hand, or queues it when there is an enqueuer; cancelled, held and
+// in-flight payments are refused.
+func (ph *PaymentHandler) MarkProcessed(uid string) (*model.Payment, error) {
+
+	if ph.enqueuer != nil {
+		return ph.enqueuer.Enqueue(uid)
 	}
 
-	if ensureNotProcessed && payment.Processed {
-		util.WriteError(w, http.StatusConflict, "Payment already processed")
-		return nil, true
+	payment, errorGet := ph.getAndCheck(uid, true)
+
+	if errorGet != nil {
+		return nil, errorGet
 	}
 
-	return payment, false
+	if payment.IsCancelled() {
+		return nil, &util.ConflictError{Message: "Payment cancelled"}
+	}
+
+	if payment.IsHeld() {
+		return nil, &util.ConflictError{Message: "Payment held for review or approval"}
+	}
+
+	if payment.IsProcessing() {
+		return nil, &util.ConflictError{Message: "Payment is being processed"}
+	}
+
+	payment.MarkAsProcessed(time.Now())
+
+	return ph.paymentRepository.Update(payment)
 }
 
-func decodeAndValidatePaymentCreate(w http.ResponseWriter, r *http.Request) (paymentCreate *PaymentCreate, responseGenerated bool) {
+// Delete hides an unprocessed payment, or purges it when hard is set, if the principal has the delete scope; a payment
+// being processed is refused, its job would be left without a payment.
+func (ph *PaymentHandler) Delete(uid string, principal *auth.Principal, hard bool) error {
 
-	errorJson := json.NewDecoder(r.Body).Decode(&paymentCreate)
+	if ph.deleteScope != "" && !principal.HasScope(ph.deleteScope) {
+		return &util.ForbiddenError{Message: fmt.Sprintf("deleting payments requires the '%s' scope", ph.deleteScope)}
+	}
 
-	if errorJson != nil {
-		util.WriteError(w, http.StatusBadRequest, errorJson.Error())
-		return nil, true
+	payment, errorGet := ph.getAndCheck(uid, true)
+
+	if errorGet != nil {
+		return errorGet
+	}
+
+	if payment.IsProcessing() {
+		return &util.ConflictError{Message: "Payment is being processed"}
 	}
 
+	if hard {
+		return ph.paymentRepository.Purge(payment)
+	}
+
+	return ph.paymentRepository.Delete(payment)
+}
+
+//
+// shared functions
+
+func ValidatePaymentCreate(paymentCreate *PaymentCreate) error {
+
 	if paymentCreate.AccountOrigin == "" {
-		util.WriteError(w, http.StatusBadRequest, "account origin is mandatory")
-		return nil, true
+		return errors.New("account origin is mandatory")
 	}
 
 	if paymentCreate.AccountTarget == "" {
-		util.WriteError(w, http.StatusBadRequest, "account target is mandatory")
-		return nil, true
+		return errors.New("account target is mandatory")
 	}
 
+	accountOrigin, errorOrigin := identifier.Normalize(paymentCreate.AccountOrigin)
+
+	if errorOrigin != nil {
+		return errors.New("account origin " + errorOrigin.Error())
+	}
+
+	accountTarget, errorTarget := identifier.Normalize(paymentCreate.AccountTarget)
+
+	if errorTarget != nil {
+		return errors.New("account target " + errorTarget.Error())
+	}
+
+	if accountOrigin == accountTarget {
+		return errors.New("account origin and target must be different")
+	}
+
+	// accounts are stored in their normalized form so that equal identifiers always compare equal
+	paymentCreate.AccountOrigin = accountOrigin
+	paymentCreate.AccountTarget = accountTarget
+
 	if paymentCreate.Amount <= 0 {
-		util.WriteError(w, http.StatusBadRequest, "amount must be a positive number")
-		return nil, true
+		return errors.New("amount must be a positive number")
+	}
+
+	paymentCreate.Currency = strings.ToUpper(paymentCreate.Currency)
+	paymentCreate.TargetCurrency = strings.ToUpper(paymentCreate.TargetCurrency)
+
+	if paymentCreate.Currency != "" && !util.IsCurrencyCode(paymentCreate.Currency) {
+		return errors.New("currency must be an ISO 4217 code")
 	}
 
-	return paymentCreate, false
+	if paymentCreate.TargetCurrency != "" && !util.IsCurrencyCode(paymentCreate.TargetCurrency) {
+		return errors.New("target currency must be an ISO 4217 code")
+	}
+
+	return validateRemittance(paymentCreate)
 }
 
-func newPayment(paymentCreate *PaymentCreate) (*model.Payment, error) {
+func NewPayment(paymentCreate *PaymentCreate) (*model.Payment, error) {
 
 	uuidResult, errorUuid := uuid.NewV4()
 
@@ -204,28 +551,261 @@ func newPayment(paymentCreate *PaymentCreate) (*model.Payment, error) {
 		return nil, errorUuid
 	}
 
+	metadata, errorJson := marshalMetadata(paymentCreate.Metadata)
+
+	if errorJson != nil {
+		return nil, errorJson
+	}
+
+	currency := paymentCreate.Currency
+
+	if currency == "" {
+		currency = model.DEFAULT_CURRENCY
+	}
+
+	targetCurrency := paymentCreate.TargetCurrency
+
+	if targetCurrency == "" {
+		targetCurrency = currency
+	}
+
+	// same-currency payments; conversions are applied by the FX create hook
 	return &model.Payment{
-		Uid:           uuidResult.String(),
-		AccountOrigin: paymentCreate.AccountOrigin,
-		AccountTarget: paymentCreate.AccountTarget,
-		Amount:        paymentCreate.Amount,
-		Date:          paymentCreate.Date,
-		Processed:     false,
+		Uid:            uuidResult.String(),
+		AccountOrigin:  paymentCreate.AccountOrigin,
+		AccountTarget:  paymentCreate.AccountTarget,
+		Amount:         paymentCreate.Amount,
+		Currency:       currency,
+		TargetCurrency: targetCurrency,
+		TargetAmount:   paymentCreate.Amount,
+		FxRate:         1,
+		Date:           paymentCreate.Date,
+		Processed:      false,
+		Reference:      paymentCreate.Reference,
+		Description:    paymentCreate.Description,
+		Metadata:       metadata,
 	}, nil
 
 }
 
-func newPaymentView(payment *model.Payment) *PaymentView {
+func NewPaymentView(payment *model.Payment) *PaymentView {
+
+	var riskRules []RiskRule
+
+	if payment.RiskHits != "" {
+		// hits are written by the risk engine, an unreadable value is shown as no rules
+		json.Unmarshal([]byte(payment.RiskHits), &riskRules)
+	}
+
+	var metadata map[string]string
+
+	if payment.Metadata != "" {
+		json.Unmarshal([]byte(payment.Metadata), &metadata)
+	}
 
 	return &PaymentView{
-		Uid:           payment.Uid,
-		AccountOrigin: payment.AccountOrigin,
-		AccountTarget: payment.AccountTarget,
-		Amount:        payment.Amount,
-		Date:          payment.Date,
-		Processed:     payment.Processed,
-		ProcessedDate: payment.ProcessedDate,
+		Uid:                    payment.Uid,
+		AccountOrigin:          identifier.Format(payment.AccountOrigin),
+		AccountTarget:          identifier.Format(payment.AccountTarget),
+		Amount:                 payment.Amount,
+		Currency:               payment.Currency,
+		TargetCurrency:         payment.TargetCurrency,
+		TargetAmount:           payment.TargetAmount,
+		FxRate:                 payment.FxRate,
+		FxQuoteUid:             payment.FxQuoteUid,
+		Date:                   payment.Date,
+		Processed:              payment.Processed,
+		ProcessedDate:          payment.ProcessedDate,
+		RefundedAmount:         payment.RefundedAmount,
+		RefundableAmount:       payment.RefundableAmount(),
+		RefundOfUid:            payment.RefundOfUid,
+		RiskDecision:           payment.RiskDecision,
+		RiskRules:              riskRules,
+		ReviewStatus:           payment.ReviewStatus,
+		ReviewedBy:             payment.ReviewedBy,
+		ReviewedAt:             payment.ReviewedAt,
+		CreatedBy:              payment.CreatedBy,
+		Tenant:                 payment.Tenant,
+		ApprovalStatus:         payment.ApprovalStatus,
+		ApprovalLevels:         payment.ApprovalLevels,
+		ApprovalExpiresAt:      payment.ApprovalExpiresAt,
+		Reference:              payment.Reference,
+		Description:            payment.Description,
+		Metadata:               metadata,
+		CancelledAt:            payment.CancelledAt,
+		CancelledBy:            payment.CancelledBy,
+		CancelReasonCode:       payment.CancelReasonCode,
+		CancelNote:             payment.CancelNote,
+		ProcessingStatus:       payment.ProcessingStatus,
+		ProcessingAttempts:     payment.ProcessingAttempts,
+		ProcessingError:        payment.ProcessingError,
+		NextAttemptAt:          payment.NextAttemptAt,
+		Rail:                   payment.Rail,
+		RailReference:          payment.RailReference,
+		RailStatus:             payment.RailStatus,
+		RailReason:             payment.RailReason,
+		ReconciledAt:           payment.ReconciledAt,
+		ReconciliationUid:      payment.ReconciliationUid,
+		FeeAmount:              payment.FeeAmount,
+		FeeCurrency:            payment.FeeCurrency,
+		FeeBearer:              payment.FeeBearer,
+		AuthorizationStatus:    payment.AuthorizationStatus,
+		AuthorizedAmount:       payment.AuthorizedAmount,
+		CapturedAmount:         payment.CapturedAmount,
+		AuthorizationExpiresAt: payment.AuthorizationExpiresAt,
+		BeneficiaryUid:         payment.BeneficiaryUid,
+	}
+}
+
+//
+// private functions
+
+func (ph *PaymentHandler) getAndCheckPaymentByUid(w http.ResponseWriter, r *http.Request, ensureNotProcessed bool) (payment *model.Payment, responseGenerated bool) {
+
+	payment, errorGet := ph.getAndCheck(mux.Vars(r)["uid"], ensureNotProcessed)
+
+	if errorGet != nil {
+		util.WriteErrorFor(w, errorGet)
+		return nil, true
+	}
+
+	return payment, false
+}
+
+// abortCreate gives back what the hooks that already ran reserved for a payment that is not created.
+func abortCreate(createHooks []CreateHook, payment *model.Payment) {
+
+	for _, createHook := range createHooks {
+
+		if createAborter, ok := createHook.(CreateAborter); ok {
+			createAborter.AbortCreate(payment)
+		}
+	}
+}
+
+func (ph *PaymentHandler) getAndCheck(uid string, ensureNotProcessed bool) (*model.Payment, error) {
+
+	payment, errorDB := ph.paymentRepository.GetByUid(uid)
+
+	if errorDB != nil {
+		return nil, errorDB
 	}
+
+	if ensureNotProcessed && payment.Processed {
+		return nil, &util.ConflictError{Message: "Payment already processed"}
+	}
+
+	return payment, nil
+}
+
+func (ph *PaymentHandler) getFilteredPayments(w http.ResponseWriter, filter repository.PaymentFilter) {
+
+	results := []PaymentView{}
+
+	errorDB := ph.paymentRepository.Stream(filter, func(payment *model.Payment) error {
+
+		results = append(results, *NewPaymentView(payment))
+
+		return nil
+	})
+
+	if errorDB != nil {
+		util.WriteError(w, http.StatusInternalServerError, errorDB.Error())
+		return
+	}
+
+	util.WritePayload(w, http.StatusOK, results)
+}
+
+// newPaymentFilter reads the list filters, e.g. ?reference=INV-42&metadata=orderId:1234
+func newPaymentFilter(r *http.Request) (*repository.PaymentFilter, error) {
+
+	filter := &repository.PaymentFilter{
+		Reference: strings.TrimSpace(r.URL.Query().Get("reference")),
+	}
+
+	for _, item := range r.URL.Query()["metadata"] {
+
+		pair := strings.SplitN(item, ":", 2)
+
+		if len(pair) != 2 || !metadataKeyPattern.MatchString(pair[0]) {
+			return nil, fmt.Errorf("metadata filter '%s' must be key:value", item)
+		}
+
+		if filter.Metadata == nil {
+			filter.Metadata = map[string]string{}
+		}
+
+		filter.Metadata[pair[0]] = pair[1]
+	}
+
+	return filter, nil
+}
+
+func validateRemittance(paymentCreate *PaymentCreate) error {
+
+	paymentCreate.Reference = strings.TrimSpace(paymentCreate.Reference)
+	paymentCreate.Description = strings.TrimSpace(paymentCreate.Description)
+
+	if len(paymentCreate.Reference) > MAX_REFERENCE_LENGTH {
+		return fmt.Errorf("reference must be at most %d characters", MAX_REFERENCE_LENGTH)
+	}
+
+	if !referencePattern.MatchString(paymentCreate.Reference) {
+		return errors.New("reference may only contain letters, digits, spaces and /-?:().,'+")
+	}
+
+	if utf8.RuneCountInString(paymentCreate.Description) > MAX_DESCRIPTION_LENGTH {
+		return fmt.Errorf("description must be at most %d characters", MAX_DESCRIPTION_LENGTH)
+	}
+
+	if !isPrintable(paymentCreate.Description) {
+		return errors.New("description must not contain control characters")
+	}
+
+	if len(paymentCreate.Metadata) > MAX_METADATA_ENTRIES {
+		return fmt.Errorf("metadata must have at most %d entries", MAX_METADATA_ENTRIES)
+	}
+
+	for key, value := range paymentCreate.Metadata {
+
+		if !metadataKeyPattern.MatchString(key) {
+			return fmt.Errorf("metadata key '%s' must be 1 to 40 letters, digits or _.-", key)
+		}
+
+		if utf8.RuneCountInString(value) > MAX_METADATA_VALUE_LENGTH || !isPrintable(value) {
+			return fmt.Errorf("metadata value for '%s' must be at most %d printable characters", key, MAX_METADATA_VALUE_LENGTH)
+		}
+	}
+
+	return nil
+}
+
+func isPrintable(value string) bool {
+
+	if !utf8.ValidString(value) {
+		return false
+	}
+
+	for _, character := range value {
+
+		if unicode.IsControl(character) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func marshalMetadata(metadata map[string]string) (string, error) {
+
+	if len(metadata) == 0 {
+		return "", nil
+	}
+
+	metadataJson, errorJson := json.Marshal(metadata)
+
+	return string(metadataJson), errorJson
 }
 
 func newPaymentViews(payments []model.Payment) []PaymentView {
@@ -234,7 +814,7 @@ func newPaymentViews(payments []model.Payment) []PaymentView {
 
 	for _, item := range payments {
 
-		payment := newPaymentView(&item)
+		payment := NewPaymentView(&item)
 
 		results = append(results, *payment)
 	}
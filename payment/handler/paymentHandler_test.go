@@ -3,14 +3,19 @@ package handler
 import (
 	"bytes"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	"github.com/javierjmgits/go-payment-api/base/util"
 	"github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/payment/repository"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -25,6 +30,7 @@ var now = time.Now()
 
 type paymentRepositoryImplMock struct {
 	mock.Mock
+	amendments []model.PaymentAmendment
 }
 
 func (mock *paymentRepositoryImplMock) GetAll() ([]model.Payment, error) {
@@ -39,6 +45,39 @@ func (mock *paymentRepositoryImplMock) GetAll() ([]model.Payment, error) {
 	return nil, args.Get(1).(error)
 }
 
+func (mock *paymentRepositoryImplMock) Stream(filter repository.PaymentFilter, consumer func(*model.Payment) error) error {
+
+	args := mock.Mock.Called(filter)
+
+	results := args.Get(0)
+
+	if results == nil {
+		return args.Get(1).(error)
+	}
+
+	for _, payment := range results.([]model.Payment) {
+
+		if errorConsumer := consumer(&payment); errorConsumer != nil {
+			return errorConsumer
+		}
+	}
+
+	return nil
+}
+
+func (mock *paymentRepositoryImplMock) Search(conditions []repository.Condition, offset int, limit int) ([]model.Payment, int, error) {
+
+	args := mock.Mock.Called(len(conditions), offset, limit)
+
+	results := args.Get(0)
+
+	if results == nil {
+		return nil, 0, args.Get(1).(error)
+	}
+
+	return results.([]model.Payment), args.Int(1), nil
+}
+
 func (mock *paymentRepositoryImplMock) GetByUid(uid string) (*model.Payment, error) {
 
 	args := mock.Mock.Called(uid)
@@ -52,6 +91,19 @@ func (mock *paymentRepositoryImplMock) GetByUid(uid string) (*model.Payment, err
 	return nil, args.Get(1).(error)
 }
 
+func (mock *paymentRepositoryImplMock) GetByIdempotencyKey(tenant string, key string) (*model.Payment, error) {
+
+	args := mock.Mock.Called(tenant, key)
+
+	result := args.Get(0)
+
+	if result != nil {
+		return result.(*model.Payment), nil
+	}
+
+	return nil, nil
+}
+
 func (mock *paymentRepositoryImplMock) Create(payment *model.Payment) (*model.Payment, error) {
 
 	args := mock.Mock.Called(payment)
@@ -86,6 +138,117 @@ func (mock *paymentRepositoryImplMock) Delete(payment *model.Payment) error {
 	return nil
 }
 
+func (mock *paymentRepositoryImplMock) Purge(payment *model.Payment) error {
+
+	mock.Mock.Called(payment)
+
+	return nil
+}
+
+func (mock *paymentRepositoryImplMock) Amend(uid string, amend func(*model.Payment, int) ([]model.PaymentAmendment, error)) (*model.Payment, error) {
+
+	args := mock.Mock.Called(uid)
+
+	result := args.Get(0)
+
+	if result == nil {
+		return nil, args.Get(1).(error)
+	}
+
+	payment := result.(*model.Payment)
+
+	amendments, errorAmend := amend(payment, 2)
+
+	if errorAmend != nil {
+		return nil, errorAmend
+	}
+
+	mock.amendments = amendments
+
+	return payment, nil
+}
+
+func (mock *paymentRepositoryImplMock) GetAmendments(uid string) ([]model.PaymentAmendment, error) {
+
+	args := mock.Mock.Called(uid)
+
+	return args.Get(0).([]model.PaymentAmendment), nil
+}
+
+func (mock *paymentRepositoryImplMock) GetChangedSince(afterId uint, limit int) ([]model.PaymentChange, error) {
+
+	args := mock.Mock.Called(afterId, limit)
+
+	return args.Get(0).([]model.PaymentChange), nil
+}
+
+func (mock *paymentRepositoryImplMock) GetLastChangeIdBefore(at time.Time) (uint, error) {
+
+	args := mock.Mock.Called(at)
+
+	return args.Get(0).(uint), nil
+}
+
+func (mock *paymentRepositoryImplMock) GetAccountTotals(accounts []string) ([]repository.AccountTotal, error) {
+
+	args := mock.Mock.Called(accounts)
+
+	return args.Get(0).([]repository.AccountTotal), nil
+}
+
+type reservingHookMock struct {
+	err     error
+	aborted []string
+}
+
+func (mock *reservingHookMock) BeforeCreate(payment *model.Payment, paymentCreate *PaymentCreate) error {
+	return mock.err
+}
+
+func (mock *reservingHookMock) AbortCreate(payment *model.Payment) {
+	mock.aborted = append(mock.aborted, payment.Uid)
+}
+
+type recheckHookMock struct {
+	err   error
+	calls int
+}
+
+func (mock *recheckHookMock) BeforeCreate(payment *model.Payment, paymentCreate *PaymentCreate) error {
+
+	mock.calls++
+
+	return mock.err
+}
+
+type enqueuerMock struct {
+	queued []string
+}
+
+func (mock *enqueuerMock) Enqueue(paymentUid string) (*model.Payment, error) {
+
+	mock.queued = append(mock.queued, paymentUid)
+
+	return &model.Payment{Uid: paymentUid, ProcessingStatus: model.PROCESSING_STATUS_QUEUED}, nil
+}
+
+type targetResolverMock struct {
+	accounts map[string]string
+}
+
+func (mock *targetResolverMock) ResolveTarget(paymentCreate *PaymentCreate, principal *auth.Principal) error {
+
+	account, found := mock.accounts[paymentCreate.BeneficiaryUid]
+
+	if !found {
+		return &util.InputError{Message: "unknown beneficiary"}
+	}
+
+	paymentCreate.AccountTarget = account
+
+	return nil
+}
+
 //
 // tests
 
@@ -132,12 +295,13 @@ func TestGetPayments(t *testing.T) {
 	assert.Len(t, payments, 1)
 }
 
-func TestGetPaymentByUidKoNotFound(t *testing.T) {
+func TestGetPaymentsByReferenceAndMetadata(t *testing.T) {
 
 	router, mockRepository := setUp()
-	mockRepository.On("GetByUid", "unknown").Return(nil, errors.New("record not found"))
+	expectedPayment1 := expectedPayment("myUid", false)
+	mockRepository.On("Stream", repository.PaymentFilter{Reference: "INV-42", Metadata: map[string]string{"orderId": "12:34"}}).Return([]model.Payment{*expectedPayment1}, nil)
 
-	req := httptest.NewRequest("GET", "http://localhost:8080/api/v1/payments/uid/unknown", nil)
+	req := httptest.NewRequest("GET", "http://localhost:8080/api/v1/payments?reference=INV-42&metadata=orderId:12:34", nil)
 	w := httptest.NewRecorder()
 
 	router.ServeHTTP(w, req)
@@ -145,15 +309,30 @@ func TestGetPaymentByUidKoNotFound(t *testing.T) {
 	resp := w.Result()
 
 	mockRepository.AssertExpectations(t)
-	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	var payments []PaymentView
+
+	json.Unmarshal(body, &payments)
+
+	// verify
+
+	assert.Len(t, payments, 1)
 }
 
-func TestGetPaymentByUidKoError(t *testing.T) {
+func TestGetPaymentsAsCsv(t *testing.T) {
 
 	router, mockRepository := setUp()
-	mockRepository.On("GetByUid", "myUid").Return(nil, errors.New("DB error"))
+	expectedPayment1 := expectedPayment("myUid1", false)
+	expectedPayment1.Currency = "USD"
+	expectedPayment1.Reference = "INV-1"
+	expectedPayment2 := expectedPayment("myUid2", true)
+	mockRepository.On("Stream", repository.PaymentFilter{}).Return([]model.Payment{*expectedPayment1, *expectedPayment2}, nil)
 
-	req := httptest.NewRequest("GET", "http://localhost:8080/api/v1/payments/uid/myUid", nil)
+	req := httptest.NewRequest("GET", "http://localhost:8080/api/v1/payments", nil)
+	req.Header.Set("Accept", "text/csv")
 	w := httptest.NewRecorder()
 
 	router.ServeHTTP(w, req)
@@ -161,16 +340,31 @@ func TestGetPaymentByUidKoError(t *testing.T) {
 	resp := w.Result()
 
 	mockRepository.AssertExpectations(t)
-	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/csv", resp.Header.Get("Content-Type"))
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+
+	// verify
+
+	assert.Len(t, lines, 3)
+	assert.Equal(t, "uid,accountOrigin,accountTarget,amount,currency,date,processed,processedDate,reference", lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "myUid1,60-16-13 31926819,20-00-00 55779911,25,USD,"))
+	assert.True(t, strings.HasSuffix(lines[1], ",false,,INV-1"))
+	assert.True(t, strings.HasPrefix(lines[2], "myUid2,"))
 }
 
-func TestGetPaymentByUid(t *testing.T) {
+func TestGetPaymentsAsNdjson(t *testing.T) {
 
 	router, mockRepository := setUp()
-	expectedPayment := expectedPayment("myUid", false)
-	mockRepository.On("GetByUid", "myUid").Return(expectedPayment, nil)
+	expectedPayment1 := expectedPayment("myUid1", false)
+	expectedPayment2 := expectedPayment("myUid2", false)
+	mockRepository.On("Stream", repository.PaymentFilter{}).Return([]model.Payment{*expectedPayment1, *expectedPayment2}, nil)
 
-	req := httptest.NewRequest("GET", "http://localhost:8080/api/v1/payments/uid/myUid", nil)
+	req := httptest.NewRequest("GET", "http://localhost:8080/api/v1/payments", nil)
+	req.Header.Set("Accept", "application/x-ndjson")
 	w := httptest.NewRecorder()
 
 	router.ServeHTTP(w, req)
@@ -180,30 +374,29 @@ func TestGetPaymentByUid(t *testing.T) {
 	mockRepository.AssertExpectations(t)
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 
-	body, _ := ioutil.ReadAll(resp.Body)
+	decoder := json.NewDecoder(resp.Body)
 
-	var payment PaymentView
+	var uids []string
 
-	json.Unmarshal(body, &payment)
+	for decoder.More() {
+
+		var payment PaymentView
+
+		decoder.Decode(&payment)
+
+		uids = append(uids, payment.Uid)
+	}
 
 	// verify
 
-	assert.Equal(t, expectedPayment.Uid, payment.Uid)
-	assert.Equal(t, expectedPayment.AccountOrigin, payment.AccountOrigin)
-	assert.Equal(t, expectedPayment.AccountTarget, payment.AccountTarget)
-	assert.Equal(t, expectedPayment.Amount, payment.Amount)
-	assert.Equal(t, expectedPayment.Date, payment.Date)
-	assert.Equal(t, expectedPayment.Processed, payment.Processed)
-	assert.Equal(t, expectedPayment.ProcessedDate, payment.ProcessedDate)
+	assert.Equal(t, []string{"myUid1", "myUid2"}, uids)
 }
 
-func TestCreatePaymentKoMissingMandatoryFields(t *testing.T) {
+func TestGetStatementKoMissingAccount(t *testing.T) {
 
 	router, mockRepository := setUp()
-	var paymentCreate PaymentCreate
-	paymentCreateAsBytes, _ := json.Marshal(paymentCreate)
 
-	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/payments", bytes.NewReader(paymentCreateAsBytes))
+	req := httptest.NewRequest("GET", "http://localhost:8080/api/v1/payments/statement?from=2026-01-01&to=2026-01-31", nil)
 	w := httptest.NewRecorder()
 
 	router.ServeHTTP(w, req)
@@ -214,44 +407,17 @@ func TestCreatePaymentKoMissingMandatoryFields(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 }
 
-func TestCreatePayment(t *testing.T) {
+func TestGetStatement(t *testing.T) {
 
 	router, mockRepository := setUp()
-	expectedPayment := expectedPayment("myUid", false)
-	paymentCreate := PaymentCreate{
-		AccountOrigin: expectedPayment.AccountOrigin,
-		AccountTarget: expectedPayment.AccountTarget,
-		Amount:        expectedPayment.Amount,
-		Date:          expectedPayment.Date,
-	}
-
-	mockRepository.On("Create", mock.MatchedBy(func(passed *model.Payment) bool {
-
-		// uid is generated
-		if passed.Uid == "" || passed.Uid == expectedPayment.Uid {
-			return false
-		}
-
-		if passed.AccountOrigin != expectedPayment.AccountOrigin {
-			return false
-		}
-
-		if passed.AccountTarget != expectedPayment.AccountTarget {
-			return false
-		}
-
-		if passed.Date != expectedPayment.Date {
-			return false
-		}
-
-		return true
-	})).Return(expectedPayment, nil)
-
-	mockRepository.On("Create", mock.Anything).Return(expectedPayment, nil)
-
-	paymentCreateAsBytes, _ := json.Marshal(paymentCreate)
+	cancelledPayment := expectedPayment("cancelledUid", false)
+	expectedPayment := expectedPayment("myUid", true)
+	cancelledPayment.CancelledAt = &now
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	mockRepository.On("Stream", repository.PaymentFilter{Account: "60-16-13 31926819", From: &from, To: &to}).Return([]model.Payment{*expectedPayment, *cancelledPayment}, nil)
 
-	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/payments", bytes.NewReader(paymentCreateAsBytes))
+	req := httptest.NewRequest("GET", "http://localhost:8080/api/v1/payments/statement?account=601613%2031926819&from=2026-01-01&to=2026-01-31", nil)
 	w := httptest.NewRecorder()
 
 	router.ServeHTTP(w, req)
@@ -259,31 +425,38 @@ func TestCreatePayment(t *testing.T) {
 	resp := w.Result()
 
 	mockRepository.AssertExpectations(t)
-	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
 
-	body, _ := ioutil.ReadAll(resp.Body)
+	var statement struct {
+		Account string `xml:"BkToCstmrStmt>Stmt>Acct>Id>Othr>Id"`
+		Entries []struct {
+			Reference   string `xml:"NtryRef"`
+			Amount      string `xml:"Amt"`
+			CreditDebit string `xml:"CdtDbtInd"`
+			Status      string `xml:"Sts"`
+		} `xml:"BkToCstmrStmt>Stmt>Ntry"`
+	}
 
-	var payment PaymentView
+	body, _ := ioutil.ReadAll(resp.Body)
 
-	json.Unmarshal(body, &payment)
+	errorXml := xml.Unmarshal(body, &statement)
 
 	// verify
 
-	assert.NotEmpty(t, payment.Uid)
-	assert.Equal(t, paymentCreate.AccountOrigin, payment.AccountOrigin)
-	assert.Equal(t, paymentCreate.AccountTarget, payment.AccountTarget)
-	assert.Equal(t, paymentCreate.Amount, payment.Amount)
-	assert.Equal(t, paymentCreate.Date, payment.Date)
-	assert.False(t, payment.Processed)
-	assert.Empty(t, payment.ProcessedDate)
+	assert.Nil(t, errorXml)
+	assert.Equal(t, "60-16-13 31926819", statement.Account)
+	assert.Len(t, statement.Entries, 1)
+	assert.Equal(t, "myUid", statement.Entries[0].Reference)
+	assert.Equal(t, "25.00", statement.Entries[0].Amount)
+	assert.Equal(t, "DBIT", statement.Entries[0].CreditDebit)
+	assert.Equal(t, "BOOK", statement.Entries[0].Status)
 }
 
-func TestFlagPaymentAsProcessedByUidKoNotFound(t *testing.T) {
+func TestSearchPaymentsKoInvalidQuery(t *testing.T) {
 
 	router, mockRepository := setUp()
-	mockRepository.On("GetByUid", "unknown").Return(nil, errors.New("not found"))
 
-	req := httptest.NewRequest("PATCH", "http://localhost:8080/api/v1/payments/uid/unknown/processed", nil)
+	req := httptest.NewRequest("GET", "http://localhost:8080/api/v1/payments/search?q=colour:red", nil)
 	w := httptest.NewRecorder()
 
 	router.ServeHTTP(w, req)
@@ -291,17 +464,15 @@ func TestFlagPaymentAsProcessedByUidKoNotFound(t *testing.T) {
 	resp := w.Result()
 
 	mockRepository.AssertExpectations(t)
-	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 }
 
-func TestFlagPaymentAsProcessedByUidKoAlreadyProcessed(t *testing.T) {
+func TestSearchPaymentsEmptyQuery(t *testing.T) {
 
 	router, mockRepository := setUp()
-	expectedPayment := expectedPayment("myUid", true)
+	mockRepository.On("Search", 0, 0, DEFAULT_PAGE_SIZE).Return([]model.Payment{*expectedPayment("myUid", false)}, 1)
 
-	mockRepository.On("GetByUid", "myUid").Return(expectedPayment, nil)
-
-	req := httptest.NewRequest("PATCH", "http://localhost:8080/api/v1/payments/uid/myUid/processed", nil)
+	req := httptest.NewRequest("GET", "http://localhost:8080/api/v1/payments/search?q=", nil)
 	w := httptest.NewRecorder()
 
 	router.ServeHTTP(w, req)
@@ -309,31 +480,28 @@ func TestFlagPaymentAsProcessedByUidKoAlreadyProcessed(t *testing.T) {
 	resp := w.Result()
 
 	mockRepository.AssertExpectations(t)
-	assert.Equal(t, http.StatusConflict, resp.StatusCode)
-}
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
 
-func TestFlagPaymentAsProcessedByUid(t *testing.T) {
+	body, _ := ioutil.ReadAll(resp.Body)
 
-	router, mockRepository := setUp()
+	var result SearchResultView
 
-	expectedPayment := expectedPayment("myUid", false)
+	json.Unmarshal(body, &result)
 
-	mockRepository.On("GetByUid", "myUid").Return(expectedPayment, nil)
-	mockRepository.On("Update", mock.MatchedBy(func(passed *model.Payment) bool {
+	// verify
 
-		// uid is generated
-		if passed.Processed == false {
-			return false
-		}
+	assert.Equal(t, 1, result.Total)
+	assert.Len(t, result.Items, 1)
+	assert.Empty(t, result.Items[0].MatchedFields)
+}
 
-		if passed.ProcessedDate == nil {
-			return false
-		}
+func TestSearchPayments(t *testing.T) {
 
-		return true
-	})).Return(expectedPayment, nil)
+	router, mockRepository := setUp()
+	expectedPayment := expectedPayment("myUid", false)
+	mockRepository.On("Search", 3, 10, 10).Return([]model.Payment{*expectedPayment}, 11)
 
-	req := httptest.NewRequest("PATCH", "http://localhost:8080/api/v1/payments/uid/myUid/processed", nil)
+	req := httptest.NewRequest("GET", "http://localhost:8080/api/v1/payments/search?q=account:6016*+amount%3E10+processed:false&page=2&size=10", nil)
 	w := httptest.NewRecorder()
 
 	router.ServeHTTP(w, req)
@@ -345,22 +513,25 @@ func TestFlagPaymentAsProcessedByUid(t *testing.T) {
 
 	body, _ := ioutil.ReadAll(resp.Body)
 
-	var payment PaymentView
+	var result SearchResultView
 
-	json.Unmarshal(body, &payment)
+	json.Unmarshal(body, &result)
 
 	// verify
 
-	assert.True(t, payment.Processed)
-	assert.Equal(t, expectedPayment.ProcessedDate, payment.ProcessedDate)
+	assert.Equal(t, 11, result.Total)
+	assert.Equal(t, 2, result.Page)
+	assert.Len(t, result.Items, 1)
+	assert.Equal(t, "myUid", result.Items[0].Payment.Uid)
+	assert.Equal(t, []string{"accountOrigin", "amount", "processed"}, result.Items[0].MatchedFields)
 }
 
-func TestDeletePaymentByUidKoNotFound(t *testing.T) {
+func TestGetPaymentByUidKoNotFound(t *testing.T) {
 
 	router, mockRepository := setUp()
-	mockRepository.On("GetByUid", "unknown").Return(nil, errors.New("not found"))
+	mockRepository.On("GetByUid", "unknown").Return(nil, errors.New("record not found"))
 
-	req := httptest.NewRequest("DELETE", "http://localhost:8080/api/v1/payments/uid/unknown", nil)
+	req := httptest.NewRequest("GET", "http://localhost:8080/api/v1/payments/uid/unknown", nil)
 	w := httptest.NewRecorder()
 
 	router.ServeHTTP(w, req)
@@ -371,14 +542,12 @@ func TestDeletePaymentByUidKoNotFound(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
 }
 
-func TestDeletePaymentByUidKoAlreadyProcessed(t *testing.T) {
+func TestGetPaymentByUidKoError(t *testing.T) {
 
 	router, mockRepository := setUp()
-	expectedPayment := expectedPayment("myUid", true)
-
-	mockRepository.On("GetByUid", "myUid").Return(expectedPayment, nil)
+	mockRepository.On("GetByUid", "myUid").Return(nil, errors.New("DB error"))
 
-	req := httptest.NewRequest("DELETE", "http://localhost:8080/api/v1/payments/uid/myUid", nil)
+	req := httptest.NewRequest("GET", "http://localhost:8080/api/v1/payments/uid/myUid", nil)
 	w := httptest.NewRecorder()
 
 	router.ServeHTTP(w, req)
@@ -386,18 +555,16 @@ func TestDeletePaymentByUidKoAlreadyProcessed(t *testing.T) {
 	resp := w.Result()
 
 	mockRepository.AssertExpectations(t)
-	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
 }
 
-func TestDeletePaymentByUid(t *testing.T) {
+func TestGetPaymentByUid(t *testing.T) {
 
 	router, mockRepository := setUp()
 	expectedPayment := expectedPayment("myUid", false)
-
 	mockRepository.On("GetByUid", "myUid").Return(expectedPayment, nil)
-	mockRepository.On("Delete", expectedPayment).Return(nil)
 
-	req := httptest.NewRequest("DELETE", "http://localhost:8080/api/v1/payments/uid/myUid", nil)
+	req := httptest.NewRequest("GET", "http://localhost:8080/api/v1/payments/uid/myUid", nil)
 	w := httptest.NewRecorder()
 
 	router.ServeHTTP(w, req)
@@ -405,9 +572,800 @@ func TestDeletePaymentByUid(t *testing.T) {
 	resp := w.Result()
 
 	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	var payment PaymentView
+
+	json.Unmarshal(body, &payment)
+
+	// verify
+
+	assert.Equal(t, expectedPayment.Uid, payment.Uid)
+	assert.Equal(t, expectedPayment.AccountOrigin, payment.AccountOrigin)
+	assert.Equal(t, expectedPayment.AccountTarget, payment.AccountTarget)
+	assert.Equal(t, expectedPayment.Amount, payment.Amount)
+	assert.Equal(t, expectedPayment.Date, payment.Date)
+	assert.Equal(t, expectedPayment.Processed, payment.Processed)
+	assert.Equal(t, expectedPayment.ProcessedDate, payment.ProcessedDate)
+}
+
+func TestCreatePaymentKoMissingMandatoryFields(t *testing.T) {
+
+	router, mockRepository := setUp()
+	var paymentCreate PaymentCreate
+	paymentCreateAsBytes, _ := json.Marshal(paymentCreate)
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/payments", bytes.NewReader(paymentCreateAsBytes))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestCreatePaymentKoInvalidAccount(t *testing.T) {
+
+	router, mockRepository := setUp()
+	paymentCreate := PaymentCreate{AccountOrigin: "GB29NWBK60161331926818", AccountTarget: "DE89370400440532013000", Amount: 25}
+	paymentCreateAsBytes, _ := json.Marshal(paymentCreate)
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/payments", bytes.NewReader(paymentCreateAsBytes))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestCreatePaymentKoSameAccounts(t *testing.T) {
+
+	router, mockRepository := setUp()
+	paymentCreate := PaymentCreate{AccountOrigin: "GB29NWBK60161331926819", AccountTarget: "gb29 nwbk 6016 1331 9268 19", Amount: 25}
+	paymentCreateAsBytes, _ := json.Marshal(paymentCreate)
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/payments", bytes.NewReader(paymentCreateAsBytes))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestCreatePaymentKoInvalidRemittance(t *testing.T) {
+
+	for _, paymentCreate := range []PaymentCreate{
+		{Reference: "INV#42"},
+		{Reference: strings.Repeat("R", 36)},
+		{Description: "line\nbreak"},
+		{Metadata: map[string]string{"order id": "1234"}},
+	} {
+
+		router, mockRepository := setUp()
+
+		paymentCreate.AccountOrigin = "60-16-13 31926819"
+		paymentCreate.AccountTarget = "20-00-00 55779911"
+		paymentCreate.Amount = 25
+
+		paymentCreateAsBytes, _ := json.Marshal(paymentCreate)
+
+		req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/payments", bytes.NewReader(paymentCreateAsBytes))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		resp := w.Result()
+
+		mockRepository.AssertExpectations(t)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	}
+}
+
+func TestCreatePaymentWithRemittance(t *testing.T) {
+
+	router, mockRepository := setUp()
+	paymentCreate := PaymentCreate{
+		AccountOrigin: "60-16-13 31926819",
+		AccountTarget: "20-00-00 55779911",
+		Amount:        25,
+		Date:          time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		Reference:     " INV-2026/042 ",
+		Description:   "Invoice 42, March",
+		Metadata:      map[string]string{"orderId": "1234", "channel": "web"},
+	}
+
+	expectedPayment := expectedPayment("myUid", false)
+	expectedPayment.Reference = "INV-2026/042"
+	expectedPayment.Description = "Invoice 42, March"
+	expectedPayment.Metadata = `{"channel":"web","orderId":"1234"}`
+
+	mockRepository.On("Create", mock.MatchedBy(func(passed *model.Payment) bool {
+		return passed.Reference == expectedPayment.Reference && passed.Description == expectedPayment.Description && passed.Metadata == expectedPayment.Metadata
+	})).Return(expectedPayment, nil)
+
+	paymentCreateAsBytes, _ := json.Marshal(paymentCreate)
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/payments", bytes.NewReader(paymentCreateAsBytes))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	var payment PaymentView
+
+	json.Unmarshal(body, &payment)
+
+	// verify
+
+	assert.Equal(t, "INV-2026/042", payment.Reference)
+	assert.Equal(t, "Invoice 42, March", payment.Description)
+	assert.Equal(t, map[string]string{"orderId": "1234", "channel": "web"}, payment.Metadata)
+}
+
+func TestCreatePaymentToBeneficiary(t *testing.T) {
+
+	router, mockRepository := setUpWithResolver(&targetResolverMock{accounts: map[string]string{"payee-1": "20-00-00 55779911"}})
+	expectedPayment := expectedPayment("myUid", false)
+
+	mockRepository.On("Create", mock.MatchedBy(func(passed *model.Payment) bool {
+		return passed.AccountTarget == "20-00-00 55779911" && passed.BeneficiaryUid != nil && *passed.BeneficiaryUid == "payee-1"
+	})).Return(expectedPayment, nil)
+
+	resp := postPayment(router, `{"accountOrigin": "60-16-13 31926819", "beneficiaryUid": "payee-1", "amount": 25}`)
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+}
+
+func TestCreatePaymentToBeneficiaryKo(t *testing.T) {
+
+	for _, body := range []string{
+		`{"accountOrigin": "60-16-13 31926819", "beneficiaryUid": "unknown", "amount": 25}`,
+		`{"accountOrigin": "60-16-13 31926819", "accountTarget": "20-00-00 55779911", "beneficiaryUid": "payee-1", "amount": 25}`,
+	} {
+
+		router, mockRepository := setUpWithResolver(&targetResolverMock{accounts: map[string]string{"payee-1": "20-00-00 55779911"}})
+
+		resp := postPayment(router, body)
+
+		mockRepository.AssertExpectations(t)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+	}
+}
+
+func TestCreatePayment(t *testing.T) {
+
+	router, mockRepository := setUp()
+	expectedPayment := expectedPayment("myUid", false)
+	paymentCreate := PaymentCreate{
+		AccountOrigin: expectedPayment.AccountOrigin,
+		AccountTarget: expectedPayment.AccountTarget,
+		Amount:        expectedPayment.Amount,
+		Date:          expectedPayment.Date,
+	}
+
+	mockRepository.On("Create", mock.MatchedBy(func(passed *model.Payment) bool {
+
+		// uid is generated
+		if passed.Uid == "" || passed.Uid == expectedPayment.Uid {
+			return false
+		}
+
+		if passed.AccountOrigin != expectedPayment.AccountOrigin {
+			return false
+		}
+
+		if passed.AccountTarget != expectedPayment.AccountTarget {
+			return false
+		}
+
+		if passed.Date != expectedPayment.Date {
+			return false
+		}
+
+		return true
+	})).Return(expectedPayment, nil)
+
+	mockRepository.On("Create", mock.Anything).Return(expectedPayment, nil)
+
+	paymentCreateAsBytes, _ := json.Marshal(paymentCreate)
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/payments", bytes.NewReader(paymentCreateAsBytes))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	var payment PaymentView
+
+	json.Unmarshal(body, &payment)
+
+	// verify
+
+	assert.NotEmpty(t, payment.Uid)
+	assert.Equal(t, paymentCreate.AccountOrigin, payment.AccountOrigin)
+	assert.Equal(t, paymentCreate.AccountTarget, payment.AccountTarget)
+	assert.Equal(t, paymentCreate.Amount, payment.Amount)
+	assert.Equal(t, paymentCreate.Date, payment.Date)
+	assert.False(t, payment.Processed)
+	assert.Empty(t, payment.ProcessedDate)
+}
+
+func TestCreatePaymentKoAbortsHooksThatRan(t *testing.T) {
+
+	router, paymentHandler, mockRepository := setUpWithHandler()
+	reservingHook := &reservingHookMock{}
+	refusingHook := &reservingHookMock{err: &util.RejectedError{Message: "Payment denied"}}
+	paymentHandler.AddCreateHook(reservingHook)
+	paymentHandler.AddCreateHook(refusingHook)
+
+	resp := postPayment(router, `{"accountOrigin": "60-16-13 31926819", "accountTarget": "20-00-00 55779911", "amount": 25}`)
+
+	// verify
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Len(t, reservingHook.aborted, 1)
+	assert.Empty(t, refusingHook.aborted)
+}
+
+func TestCreatePaymentKoCreatorAbortsHooks(t *testing.T) {
+
+	router, paymentHandler, mockRepository := setUpWithHandler()
+	reservingHook := &reservingHookMock{}
+	paymentHandler.AddCreateHook(reservingHook)
+	mockRepository.On("Create", mock.Anything).Return(nil, &util.RejectedError{Message: "payment exceeds the daily limit(s)"})
+
+	resp := postPayment(router, `{"accountOrigin": "60-16-13 31926819", "accountTarget": "20-00-00 55779911", "amount": 25}`)
+
+	// verify
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Len(t, reservingHook.aborted, 1)
+}
+
+func TestCreatePaymentWithIdempotencyKey(t *testing.T) {
+
+	router, mockRepository := setUp()
+	expectedPayment := expectedPayment("myUid", false)
+
+	mockRepository.On("GetByIdempotencyKey", "", "key-1").Return(nil).Once()
+	mockRepository.On("Create", mock.MatchedBy(func(passed *model.Payment) bool {
+		return passed.IdempotencyKey != nil && *passed.IdempotencyKey == "key-1"
+	})).Return(expectedPayment, nil).Once()
+
+	resp := postPaymentWithIdempotencyKey(router, "key-1", `{"accountOrigin": "60-16-13 31926819", "accountTarget": "20-00-00 55779911", "amount": 25}`)
+
+	// a retry gets the payment of the first create back
+	mockRepository.On("GetByIdempotencyKey", "", "key-1").Return(expectedPayment).Once()
+
+	respRetry := postPaymentWithIdempotencyKey(router, "key-1", `{"accountOrigin": "60-16-13 31926819", "accountTarget": "20-00-00 55779911", "amount": 25}`)
+
+	// verify
+
+	mockRepository.AssertExpectations(t)
+	mockRepository.AssertNumberOfCalls(t, "Create", 1)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, http.StatusCreated, respRetry.StatusCode)
+
+	var payment PaymentView
+	json.NewDecoder(respRetry.Body).Decode(&payment)
+
+	assert.Equal(t, "myUid", payment.Uid)
+}
+
+func TestCreatePaymentWithIdempotencyKeyConcurrentRetry(t *testing.T) {
+
+	router, mockRepository := setUp()
+	expectedPayment := expectedPayment("myUid", false)
+
+	mockRepository.On("GetByIdempotencyKey", "", "key-1").Return(nil).Once()
+	mockRepository.On("Create", mock.Anything).Return(nil, errors.New("Error 1062: Duplicate entry")).Once()
+	mockRepository.On("GetByIdempotencyKey", "", "key-1").Return(expectedPayment).Once()
+
+	resp := postPaymentWithIdempotencyKey(router, "key-1", `{"accountOrigin": "60-16-13 31926819", "accountTarget": "20-00-00 55779911", "amount": 25}`)
+
+	// verify
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+}
+
+func TestCreatePaymentKoIdempotencyKeyTooLong(t *testing.T) {
+
+	router, mockRepository := setUp()
+
+	resp := postPaymentWithIdempotencyKey(router, strings.Repeat("k", MAX_IDEMPOTENCY_KEY_LENGTH+1), `{"accountOrigin": "60-16-13 31926819", "accountTarget": "20-00-00 55779911", "amount": 25}`)
+
+	// verify
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestFlagPaymentAsProcessedByUidKoNotFound(t *testing.T) {
+
+	router, mockRepository := setUp()
+	mockRepository.On("GetByUid", "unknown").Return(nil, errors.New("not found"))
+
+	req := httptest.NewRequest("PATCH", "http://localhost:8080/api/v1/payments/uid/unknown/processed", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestFlagPaymentAsProcessedByUidKoAlreadyProcessed(t *testing.T) {
+
+	router, mockRepository := setUp()
+	expectedPayment := expectedPayment("myUid", true)
+
+	mockRepository.On("GetByUid", "myUid").Return(expectedPayment, nil)
+
+	req := httptest.NewRequest("PATCH", "http://localhost:8080/api/v1/payments/uid/myUid/processed", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestFlagPaymentAsProcessedByUidKoHeldForReview(t *testing.T) {
+
+	router, mockRepository := setUp()
+	expectedPayment := expectedPayment("myUid", false)
+	expectedPayment.ReviewStatus = model.REVIEW_STATUS_PENDING
+
+	mockRepository.On("GetByUid", "myUid").Return(expectedPayment, nil)
+
+	req := httptest.NewRequest("PATCH", "http://localhost:8080/api/v1/payments/uid/myUid/processed", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestFlagPaymentAsProcessedByUid(t *testing.T) {
+
+	router, mockRepository := setUp()
+
+	expectedPayment := expectedPayment("myUid", false)
+
+	mockRepository.On("GetByUid", "myUid").Return(expectedPayment, nil)
+	mockRepository.On("Update", mock.MatchedBy(func(passed *model.Payment) bool {
+
+		// uid is generated
+		if passed.Processed == false {
+			return false
+		}
+
+		if passed.ProcessedDate == nil {
+			return false
+		}
+
+		return true
+	})).Return(expectedPayment, nil)
+
+	req := httptest.NewRequest("PATCH", "http://localhost:8080/api/v1/payments/uid/myUid/processed", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	var payment PaymentView
+
+	json.Unmarshal(body, &payment)
+
+	// verify
+
+	assert.True(t, payment.Processed)
+	assert.Equal(t, expectedPayment.ProcessedDate, payment.ProcessedDate)
+}
+
+func TestAmendPayment(t *testing.T) {
+
+	router, mockRepository := setUp()
+	expectedPayment := expectedPayment("myUid", false)
+	expectedPayment.FxRate = 1.1
+	expectedPayment.Metadata = `{"channel":"web","orderId":"1234"}`
+	mockRepository.On("Amend", "myUid").Return(expectedPayment, nil)
+
+	resp := patchPayment(router, "myUid", `{"amount": 30, "reference": "INV-42", "metadata": {"channel": null, "batch": "7"}}`)
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	var payment PaymentView
+
+	json.Unmarshal(body, &payment)
+
+	// verify
+
+	assert.Equal(t, 30.0, payment.Amount)
+	assert.Equal(t, 33.0, payment.TargetAmount)
+	assert.Equal(t, "INV-42", payment.Reference)
+	assert.Equal(t, map[string]string{"orderId": "1234", "batch": "7"}, payment.Metadata)
+
+	fields := map[string]model.PaymentAmendment{}
+
+	for _, amendment := range mockRepository.amendments {
+		fields[amendment.Field] = amendment
+	}
+
+	assert.Len(t, fields, 3)
+	assert.Equal(t, "25", fields["amount"].OldValue)
+	assert.Equal(t, "30", fields["amount"].NewValue)
+	assert.Equal(t, 2, fields["reference"].Revision)
+	assert.Equal(t, `{"batch":"7","orderId":"1234"}`, fields["metadata"].NewValue)
+}
+
+func TestAmendPaymentKoNotAmendable(t *testing.T) {
+
+	for _, patch := range []string{`{"currency": "USD"}`, `{"colour": "red"}`, `{"amount": null}`, `{"amount": -5}`, `[1]`} {
+
+		router, mockRepository := setUp()
+		mockRepository.On("Amend", "myUid").Return(expectedPayment("myUid", false), nil).Maybe()
+
+		resp := patchPayment(router, "myUid", patch)
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, patch)
+	}
+}
+
+func TestAmendPaymentKoAccountsAfterApproval(t *testing.T) {
+
+	router, mockRepository := setUp()
+	expectedPayment := expectedPayment("myUid", false)
+	expectedPayment.ApprovalStatus = model.APPROVAL_STATUS_APPROVED
+	mockRepository.On("Amend", "myUid").Return(expectedPayment, nil)
+
+	resp := patchPayment(router, "myUid", `{"accountTarget": "GB29 NWBK 6016 1331 9268 19"}`)
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestAmendPaymentKoAlreadyProcessed(t *testing.T) {
+
+	router, mockRepository := setUp()
+	mockRepository.On("Amend", "myUid").Return(expectedPayment("myUid", true), nil)
+
+	resp := patchPayment(router, "myUid", `{"amount": 30}`)
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestAmendPaymentRechecksNewAccount(t *testing.T) {
+
+	router, paymentHandler, mockRepository := setUpWithHandler()
+	recheckHook := &recheckHookMock{}
+	paymentHandler.AddRecheckHook(recheckHook)
+	mockRepository.On("Amend", "myUid").Return(expectedPayment("myUid", false), nil)
+
+	resp := patchPayment(router, "myUid", `{"accountTarget": "GB29 NWBK 6016 1331 9268 19"}`)
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// verify
+
+	assert.Equal(t, 1, recheckHook.calls)
+}
+
+func TestAmendPaymentKoRecheckRefused(t *testing.T) {
+
+	router, paymentHandler, mockRepository := setUpWithHandler()
+	recheckHook := &recheckHookMock{err: &util.RejectedError{Message: "payment denied by risk rules: blockedAccount"}}
+	paymentHandler.AddRecheckHook(recheckHook)
+	mockRepository.On("Amend", "myUid").Return(expectedPayment("myUid", false), nil)
+
+	resp := patchPayment(router, "myUid", `{"amount": 30}`)
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	// verify
+
+	assert.Equal(t, 1, recheckHook.calls)
+	assert.Empty(t, mockRepository.amendments)
+}
+
+func TestAmendPaymentNoRecheckForLowerAmount(t *testing.T) {
+
+	router, paymentHandler, mockRepository := setUpWithHandler()
+	recheckHook := &recheckHookMock{}
+	paymentHandler.AddRecheckHook(recheckHook)
+	mockRepository.On("Amend", "myUid").Return(expectedPayment("myUid", false), nil)
+
+	resp := patchPayment(router, "myUid", `{"amount": 20, "reference": "INV-42"}`)
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// verify
+
+	assert.Equal(t, 0, recheckHook.calls)
+}
+
+func TestAmendPaymentKoRejectedByReview(t *testing.T) {
+
+	router, mockRepository := setUp()
+	expectedPayment := expectedPayment("myUid", false)
+	expectedPayment.ReviewStatus = model.REVIEW_STATUS_REJECTED
+	mockRepository.On("Amend", "myUid").Return(expectedPayment, nil)
+
+	resp := patchPayment(router, "myUid", `{"accountTarget": "GB29 NWBK 6016 1331 9268 19"}`)
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestDeletePaymentByUidKoNotFound(t *testing.T) {
+
+	router, mockRepository := setUp()
+	mockRepository.On("GetByUid", "unknown").Return(nil, errors.New("not found"))
+
+	req := httptest.NewRequest("DELETE", "http://localhost:8080/api/v1/payments/uid/unknown", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestDeletePaymentByUidKoAlreadyProcessed(t *testing.T) {
+
+	router, mockRepository := setUp()
+	expectedPayment := expectedPayment("myUid", true)
+
+	mockRepository.On("GetByUid", "myUid").Return(expectedPayment, nil)
+
+	req := httptest.NewRequest("DELETE", "http://localhost:8080/api/v1/payments/uid/myUid", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestDeletePaymentByUidKoProcessing(t *testing.T) {
+
+	router, mockRepository := setUp()
+	expectedPayment := expectedPayment("myUid", false)
+	expectedPayment.ProcessingStatus = model.PROCESSING_STATUS_QUEUED
+
+	mockRepository.On("GetByUid", "myUid").Return(expectedPayment, nil)
+
+	req := httptest.NewRequest("DELETE", "http://localhost:8080/api/v1/payments/uid/myUid", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	mockRepository.AssertNotCalled(t, "Delete", expectedPayment)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestDeletePaymentByUid(t *testing.T) {
+
+	router, mockRepository := setUp()
+	expectedPayment := expectedPayment("myUid", false)
+
+	mockRepository.On("GetByUid", "myUid").Return(expectedPayment, nil)
+	mockRepository.On("Delete", expectedPayment).Return(nil)
+
+	req := httptest.NewRequest("DELETE", "http://localhost:8080/api/v1/payments/uid/myUid", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
+
+func TestDeletePaymentByUidHard(t *testing.T) {
+
+	router, mockRepository := setUp()
+	expectedPayment := expectedPayment("myUid", false)
+
+	mockRepository.On("GetByUid", "myUid").Return(expectedPayment, nil)
+	mockRepository.On("Purge", expectedPayment).Return(nil)
+
+	req := httptest.NewRequest("DELETE", "http://localhost:8080/api/v1/payments/uid/myUid?hard=true", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	mockRepository.AssertNotCalled(t, "Delete", expectedPayment)
 	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
 }
 
+func TestDeletePaymentByUidRestrictedToScope(t *testing.T) {
+
+	var router = mux.NewRouter()
+	var mockRepository paymentRepositoryImplMock
+
+	paymentHandler := NewPaymentHandler(&mockRepository)
+	paymentHandler.SetDeleteScope("payments:admin")
+	paymentHandler.Register(router)
+
+	req := httptest.NewRequest("DELETE", "http://localhost:8080/api/v1/payments/uid/myUid", nil)
+	req.Header.Set("X-Scopes", "payments:read, payments:write")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Result().StatusCode)
+
+	expectedPayment := expectedPayment("myUid", false)
+
+	mockRepository.On("GetByUid", "myUid").Return(expectedPayment, nil)
+	mockRepository.On("Delete", expectedPayment).Return(nil)
+
+	req = httptest.NewRequest("DELETE", "http://localhost:8080/api/v1/payments/uid/myUid", nil)
+	req.Header.Set("X-Scopes", "payments:read,payments:admin")
+	w = httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+}
+
+func TestCancelPaymentByUid(t *testing.T) {
+
+	router, mockRepository := setUp()
+	mockRepository.On("Amend", "myUid").Return(expectedPayment("myUid", false), nil)
+
+	resp := cancelPayment(router, "myUid", `{"reasonCode": "dupl", "note": "sent twice by the ERP"}`)
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var paymentView PaymentView
+	json.NewDecoder(resp.Body).Decode(&paymentView)
+
+	assert.NotNil(t, paymentView.CancelledAt)
+	assert.Equal(t, "alice", paymentView.CancelledBy)
+	assert.Equal(t, "DUPL", paymentView.CancelReasonCode)
+	assert.Equal(t, "sent twice by the ERP", paymentView.CancelNote)
+	assert.Equal(t, []model.PaymentAmendment{{PaymentUid: "myUid", Revision: 2, Field: "cancelReasonCode", NewValue: "DUPL", AmendedBy: "alice"}}, mockRepository.amendments)
+}
+
+func TestCancelPaymentByUidKoUnknownReason(t *testing.T) {
+
+	router, mockRepository := setUp()
+
+	resp := cancelPayment(router, "myUid", `{"reasonCode": "NOPE"}`)
+
+	mockRepository.AssertNotCalled(t, "Amend", "myUid")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestCancelPaymentByUidKoAlreadyCancelled(t *testing.T) {
+
+	router, mockRepository := setUp()
+	payment := expectedPayment("myUid", false)
+	payment.Cancel("CUST", "", "bob", now)
+
+	mockRepository.On("Amend", "myUid").Return(payment, nil)
+
+	resp := cancelPayment(router, "myUid", `{"reasonCode": "DUPL"}`)
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "CUST", payment.CancelReasonCode)
+}
+
+func TestCancelPaymentByUidKoAlreadyProcessed(t *testing.T) {
+
+	router, mockRepository := setUp()
+	mockRepository.On("Amend", "myUid").Return(expectedPayment("myUid", true), nil)
+
+	resp := cancelPayment(router, "myUid", `{"reasonCode": "DUPL"}`)
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestFlagPaymentAsProcessedByUidKoCancelled(t *testing.T) {
+
+	router, mockRepository := setUp()
+	payment := expectedPayment("myUid", false)
+	payment.Cancel("DUPL", "", "alice", now)
+
+	mockRepository.On("GetByUid", "myUid").Return(payment, nil)
+
+	req := httptest.NewRequest("PATCH", "http://localhost:8080/api/v1/payments/uid/myUid/processed", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusConflict, w.Result().StatusCode)
+	assert.False(t, payment.Processed)
+}
+
+func TestFlagPaymentAsProcessedByUidQueued(t *testing.T) {
+
+	router, paymentHandler, mockRepository := setUpWithHandler()
+	enqueuer := &enqueuerMock{}
+	paymentHandler.SetEnqueuer(enqueuer)
+
+	req := httptest.NewRequest("PATCH", "http://localhost:8080/api/v1/payments/uid/myUid/processed", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	var view PaymentView
+
+	json.Unmarshal(w.Body.Bytes(), &view)
+
+	// verify
+
+	mockRepository.AssertNotCalled(t, "Update", mock.Anything)
+	assert.Equal(t, http.StatusAccepted, w.Result().StatusCode)
+	assert.Equal(t, []string{"myUid"}, enqueuer.queued)
+	assert.False(t, view.Processed)
+	assert.Equal(t, model.PROCESSING_STATUS_QUEUED, view.ProcessingStatus)
+}
+
 //
 // private functions
 
@@ -416,18 +1374,86 @@ func setUp() (*mux.Router, *paymentRepositoryImplMock) {
 	var router = mux.NewRouter()
 	var mockRepository paymentRepositoryImplMock
 
-	NewPaymentHandler(&mockRepository).Register(router)
+	paymentHandler := NewPaymentHandler(&mockRepository)
+	paymentHandler.SetCancelReasons([]string{"DUPL", "CUST", "FRAD"})
+	paymentHandler.Register(router)
+
+	return router, &mockRepository
+
+}
+
+func setUpWithHandler() (*mux.Router, *PaymentHandler, *paymentRepositoryImplMock) {
+
+	var router = mux.NewRouter()
+	var mockRepository paymentRepositoryImplMock
+
+	paymentHandler := NewPaymentHandler(&mockRepository)
+	paymentHandler.Register(router)
+
+	return router, paymentHandler, &mockRepository
+}
+
+func setUpWithResolver(targetResolver TargetResolver) (*mux.Router, *paymentRepositoryImplMock) {
+
+	var router = mux.NewRouter()
+	var mockRepository paymentRepositoryImplMock
+
+	paymentHandler := NewPaymentHandler(&mockRepository)
+	paymentHandler.SetTargetResolver(targetResolver)
+	paymentHandler.Register(router)
 
 	return router, &mockRepository
+}
+
+func postPayment(router *mux.Router, body string) *http.Response {
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/payments", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	return w.Result()
+}
+
+func postPaymentWithIdempotencyKey(router *mux.Router, key string, body string) *http.Response {
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/payments", strings.NewReader(body))
+	req.Header.Set(HEADER_IDEMPOTENCY_KEY, key)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	return w.Result()
+}
+
+func cancelPayment(router *mux.Router, uid string, body string) *http.Response {
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/payments/uid/"+uid+"/cancel", strings.NewReader(body))
+	req.Header.Set("X-User", "alice")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	return w.Result()
+}
+
+func patchPayment(router *mux.Router, uid string, patch string) *http.Response {
+
+	req := httptest.NewRequest("PATCH", "http://localhost:8080/api/v1/payments/uid/"+uid, strings.NewReader(patch))
+	req.Header.Set("Content-Type", CONTENT_TYPE_MERGE_PATCH)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
 
+	return w.Result()
 }
 
 func expectedPayment(uid string, processed bool) *model.Payment {
 
 	payment := model.Payment{
 		Uid:           uid,
-		AccountOrigin: "myAccountOrigin",
-		AccountTarget: "myAccountTarget",
+		AccountOrigin: "60-16-13 31926819",
+		AccountTarget: "20-00-00 55779911",
 		Amount:        25,
 		Date:          now,
 		Processed:     processed,
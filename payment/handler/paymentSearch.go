@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/payment/search"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	DEFAULT_PAGE_SIZE = 20
+	MAX_PAGE_SIZE     = 100
+)
+
+type SearchResultView struct {
+	Query string          `json:"query"`
+	Page  int             `json:"page"`
+	Size  int             `json:"size"`
+	Total int             `json:"total"`
+	Items []SearchHitView `json:"items"`
+}
+
+type SearchHitView struct {
+	Payment       *PaymentView `json:"payment"`
+	MatchedFields []string     `json:"matchedFields"`
+}
+
+// SearchPayments finds payments with a small query language, e.g. `account:GB29* amount>100 processed:false date:2026-01..2026-03`;
+// an empty query finds them all.
+func (ph *PaymentHandler) SearchPayments(w http.ResponseWriter, r *http.Request) {
+
+	queryText := r.URL.Query().Get("q")
+
+	page, errorPage := queryParamAsInt(r, "page", 1)
+
+	if errorPage != nil || page < 1 {
+		util.WriteError(w, http.StatusBadRequest, "page must be a positive number")
+		return
+	}
+
+	size, errorSize := queryParamAsInt(r, "size", DEFAULT_PAGE_SIZE)
+
+	if errorSize != nil || size < 1 || size > MAX_PAGE_SIZE {
+		util.WriteError(w, http.StatusBadRequest, "size must be between 1 and "+strconv.Itoa(MAX_PAGE_SIZE))
+		return
+	}
+
+	query := &search.Query{}
+
+	if strings.TrimSpace(queryText) != "" {
+
+		parsed, errorQuery := search.Parse(queryText)
+
+		if errorQuery != nil {
+			util.WriteError(w, http.StatusBadRequest, errorQuery.Error())
+			return
+		}
+
+		query = parsed
+	}
+
+	conditions, errorQuery := query.Conditions()
+
+	if errorQuery != nil {
+		util.WriteError(w, http.StatusBadRequest, errorQuery.Error())
+		return
+	}
+
+	payments, total, errorDB := ph.paymentRepository.Search(conditions, (page-1)*size, size)
+
+	if errorDB != nil {
+		util.WriteError(w, http.StatusInternalServerError, errorDB.Error())
+		return
+	}
+
+	result := &SearchResultView{
+		Query: queryText,
+		Page:  page,
+		Size:  size,
+		Total: total,
+		Items: []SearchHitView{},
+	}
+
+	for index := range payments {
+
+		result.Items = append(result.Items, SearchHitView{
+			Payment:       NewPaymentView(&payments[index]),
+			MatchedFields: query.Matches(&payments[index]),
+		})
+	}
+
+	util.WritePayload(w, http.StatusOK, result)
+}
+
+//
+// private functions
+
+func queryParamAsInt(r *http.Request, name string, defaultValue int) (int, error) {
+
+	value := r.URL.Query().Get(name)
+
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	return strconv.Atoi(value)
+}
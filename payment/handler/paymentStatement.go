@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/javierjmgits/go-payment-api/account/identifier"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/payment/repository"
+	"log"
+	"net/http"
+	"time"
+)
+
+const CAMT053_NAMESPACE = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+type camt053Amount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camt053Account struct {
+	Id string `xml:"Id>Othr>Id"`
+}
+
+type camt053Entry struct {
+	XMLName         xml.Name       `xml:"Ntry"`
+	Reference       string         `xml:"NtryRef"`
+	Amount          camt053Amount  `xml:"Amt"`
+	CreditDebit     string         `xml:"CdtDbtInd"`
+	Status          string         `xml:"Sts"`
+	BookingDate     string         `xml:"BookgDt>Dt,omitempty"`
+	ValueDate       string         `xml:"ValDt>Dt"`
+	EndToEndId      string         `xml:"NtryDtls>TxDtls>Refs>EndToEndId"`
+	DebtorAccount   camt053Account `xml:"NtryDtls>TxDtls>RltdPties>DbtrAcct"`
+	CreditorAccount camt053Account `xml:"NtryDtls>TxDtls>RltdPties>CdtrAcct"`
+	Remittance      string         `xml:"NtryDtls>TxDtls>RmtInf>Ustrd,omitempty"`
+}
+
+func (ph *PaymentHandler) GetStatement(w http.ResponseWriter, r *http.Request) {
+
+	filter, errorFilter := newStatementFilter(r)
+
+	if errorFilter != nil {
+		util.WriteError(w, http.StatusBadRequest, errorFilter.Error())
+		return
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	statementId := fmt.Sprintf("%s-%s-%s", filter.Account, filter.From.Format("20060102"), filter.To.AddDate(0, 0, -1).Format("20060102"))
+
+	encoder := xml.NewEncoder(w)
+	started := false
+
+	errorStream := ph.paymentRepository.Stream(filter, func(payment *model.Payment) error {
+
+		// a cancelled payment never moves money, it is neither booked nor pending
+		if payment.IsCancelled() {
+			return nil
+		}
+
+		if !started {
+			started = true
+
+			if errorBegin := beginStatement(w, encoder, statementId, filter, now); errorBegin != nil {
+				return errorBegin
+			}
+		}
+
+		return encoder.EncodeElement(newCamt053Entry(filter.Account, payment), xml.StartElement{Name: xml.Name{Local: "Ntry"}})
+	})
+
+	if errorStream != nil && !started {
+		util.WriteError(w, http.StatusInternalServerError, errorStream.Error())
+		return
+	}
+
+	if errorStream != nil {
+		log.Printf("Error exporting statement: %v\n", errorStream)
+		return
+	}
+
+	if !started {
+		beginStatement(w, encoder, statementId, filter, now)
+	}
+
+	encoder.EncodeToken(xml.EndElement{Name: xml.Name{Local: "Stmt"}})
+	encoder.EncodeToken(xml.EndElement{Name: xml.Name{Local: "BkToCstmrStmt"}})
+	encoder.EncodeToken(xml.EndElement{Name: xml.Name{Local: "Document"}})
+	encoder.Flush()
+}
+
+//
+// private functions
+
+func newStatementFilter(r *http.Request) (repository.PaymentFilter, error) {
+
+	query := r.URL.Query()
+
+	if query.Get("account") == "" {
+		return repository.PaymentFilter{}, fmt.Errorf("account is mandatory")
+	}
+
+	account, errorAccount := identifier.Normalize(query.Get("account"))
+
+	if errorAccount != nil {
+		return repository.PaymentFilter{}, fmt.Errorf("account %v", errorAccount)
+	}
+
+	from, errorFrom := time.Parse("2006-01-02", query.Get("from"))
+
+	if errorFrom != nil {
+		return repository.PaymentFilter{}, fmt.Errorf("from must be a date formatted as YYYY-MM-DD")
+	}
+
+	to, errorTo := time.Parse("2006-01-02", query.Get("to"))
+
+	if errorTo != nil {
+		return repository.PaymentFilter{}, fmt.Errorf("to must be a date formatted as YYYY-MM-DD")
+	}
+
+	if to.Before(from) {
+		return repository.PaymentFilter{}, fmt.Errorf("to must not be before from")
+	}
+
+	// the range is inclusive of the whole "to" day
+	to = to.AddDate(0, 0, 1)
+
+	return repository.PaymentFilter{
+		Account: account,
+		From:    &from,
+		To:      &to,
+	}, nil
+}
+
+func beginStatement(w http.ResponseWriter, encoder *xml.Encoder, statementId string, filter repository.PaymentFilter, now time.Time) error {
+
+	w.Header().Set("Content-Type", CONTENT_TYPE_XML)
+	w.WriteHeader(http.StatusOK)
+
+	tokens := []xml.Token{
+		xml.ProcInst{Target: "xml", Inst: []byte(`version="1.0" encoding="UTF-8"`)},
+		xml.StartElement{Name: xml.Name{Local: "Document"}, Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: CAMT053_NAMESPACE}}},
+		xml.StartElement{Name: xml.Name{Local: "BkToCstmrStmt"}},
+	}
+
+	for _, token := range tokens {
+
+		if errorToken := encoder.EncodeToken(token); errorToken != nil {
+			return errorToken
+		}
+	}
+
+	header := struct {
+		MessageId string `xml:"MsgId"`
+		Created   string `xml:"CreDtTm"`
+	}{statementId, now.Format(time.RFC3339)}
+
+	if errorHeader := encoder.EncodeElement(header, xml.StartElement{Name: xml.Name{Local: "GrpHdr"}}); errorHeader != nil {
+		return errorHeader
+	}
+
+	if errorStatement := encoder.EncodeToken(xml.StartElement{Name: xml.Name{Local: "Stmt"}}); errorStatement != nil {
+		return errorStatement
+	}
+
+	period := struct {
+		From string `xml:"FrDtTm"`
+		To   string `xml:"ToDtTm"`
+	}{filter.From.Format(time.RFC3339), filter.To.Add(-time.Second).Format(time.RFC3339)}
+
+	elements := []struct {
+		name  string
+		value interface{}
+	}{
+		{"Id", statementId},
+		{"CreDtTm", now.Format(time.RFC3339)},
+		{"FrToDt", period},
+		{"Acct", camt053Account{Id: identifier.Format(filter.Account)}},
+	}
+
+	for _, element := range elements {
+
+		if errorElement := encoder.EncodeElement(element.value, xml.StartElement{Name: xml.Name{Local: element.name}}); errorElement != nil {
+			return errorElement
+		}
+	}
+
+	return nil
+}
+
+func newCamt053Entry(account string, payment *model.Payment) *camt053Entry {
+
+	entry := &camt053Entry{
+		Reference:       payment.Uid,
+		Amount:          camt053Amount{Currency: payment.TargetCurrency, Value: fmt.Sprintf("%.2f", payment.TargetAmount)},
+		CreditDebit:     "CRDT",
+		Status:          "PDNG",
+		ValueDate:       payment.Date.Format("2006-01-02"),
+		EndToEndId:      payment.Uid,
+		DebtorAccount:   camt053Account{Id: identifier.Format(payment.AccountOrigin)},
+		CreditorAccount: camt053Account{Id: identifier.Format(payment.AccountTarget)},
+		Remittance:      payment.Description,
+	}
+
+	if payment.Reference != "" {
+		entry.EndToEndId = payment.Reference
+	}
+
+	// the origin account is debited the source amount, the target is credited the converted one
+	if payment.AccountOrigin == account {
+		entry.CreditDebit = "DBIT"
+		entry.Amount = camt053Amount{Currency: payment.Currency, Value: fmt.Sprintf("%.2f", payment.Amount)}
+	}
+
+	if payment.Processed && payment.ProcessedDate != nil {
+		entry.Status = "BOOK"
+		entry.BookingDate = payment.ProcessedDate.Format("2006-01-02")
+	}
+
+	return entry
+}
@@ -1,25 +1,246 @@
 package model
 
 import (
+	"encoding/json"
+	"errors"
 	"github.com/jinzhu/gorm"
+	"reflect"
 	"time"
 )
 
+const (
+	DEFAULT_CURRENCY = "EUR"
+
+	REVIEW_STATUS_PENDING  = "PENDING"
+	REVIEW_STATUS_APPROVED = "APPROVED"
+	REVIEW_STATUS_REJECTED = "REJECTED"
+
+	APPROVAL_STATUS_PENDING  = "PENDING"
+	APPROVAL_STATUS_APPROVED = "APPROVED"
+	APPROVAL_STATUS_REJECTED = "REJECTED"
+	APPROVAL_STATUS_EXPIRED  = "EXPIRED"
+
+	PROCESSING_STATUS_QUEUED    = "QUEUED"
+	PROCESSING_STATUS_RUNNING   = "RUNNING"
+	PROCESSING_STATUS_SUBMITTED = "SUBMITTED"
+	PROCESSING_STATUS_SUCCEEDED = "SUCCEEDED"
+	PROCESSING_STATUS_FAILED    = "FAILED"
+
+	FEE_BEARER_SENDER   = "SENDER"
+	FEE_BEARER_RECEIVER = "RECEIVER"
+	FEE_BEARER_SHARED   = "SHARED"
+
+	AUTHORIZATION_STATUS_AUTHORIZED = "AUTHORIZED"
+	AUTHORIZATION_STATUS_CAPTURED   = "CAPTURED"
+	AUTHORIZATION_STATUS_VOIDED     = "VOIDED"
+	AUTHORIZATION_STATUS_EXPIRED    = "EXPIRED"
+
+	CHANGE_CREATED = "CREATED"
+	CHANGE_UPDATED = "UPDATED"
+	CHANGE_DELETED = "DELETED"
+)
+
+var paymentType = reflect.TypeOf(Payment{})
+
 type Payment struct {
 	gorm.Model
-	Uid           string     `gorm:"unique;not null"`
-	AccountOrigin string     `gorm:"not null"`
-	AccountTarget string     `gorm:"not null"`
-	Amount        float64    `gorm:"not null"`
-	Date          time.Time  `gorm:"not null"`
-	Processed     bool       `gorm:"not null"`
-	ProcessedDate *time.Time `gorm:"null"`
+	Uid                    string     `gorm:"unique;not null"`
+	AccountOrigin          string     `gorm:"not null"`
+	AccountTarget          string     `gorm:"not null"`
+	Amount                 float64    `gorm:"not null"`
+	Currency               string     `gorm:"not null;default:'EUR'"`
+	TargetCurrency         string     `gorm:"not null;default:'EUR'"`
+	TargetAmount           float64    `gorm:"not null;default:0"`
+	FxRate                 float64    `gorm:"not null;default:1"`
+	FxQuoteUid             *string    `gorm:"null"`
+	Date                   time.Time  `gorm:"not null"`
+	Processed              bool       `gorm:"not null"`
+	ProcessedDate          *time.Time `gorm:"null"`
+	BatchUid               *string    `gorm:"null;index"`
+	StandingOrderUid       *string    `gorm:"null;index"`
+	RefundedAmount         float64    `gorm:"not null;default:0"`
+	RefundOfUid            *string    `gorm:"null;index"`
+	RiskDecision           string     `gorm:"not null;default:''"`
+	RiskHits               string     `gorm:"type:text"`
+	ReviewStatus           string     `gorm:"not null;default:'';index"`
+	ReviewedBy             *string    `gorm:"null"`
+	ReviewedAt             *time.Time `gorm:"null"`
+	CreatedBy              string     `gorm:"not null;default:''"`
+	Tenant                 string     `gorm:"not null;default:'';index;unique_index:idx_payment_idempotency"`
+	ApprovalStatus         string     `gorm:"not null;default:'';index"`
+	ApprovalLevels         int        `gorm:"not null;default:0"`
+	ApprovalExpiresAt      *time.Time `gorm:"null"`
+	Reference              string     `gorm:"size:35;not null;default:'';index"`
+	Description            string     `gorm:"size:140;not null;default:''"`
+	Metadata               string     `gorm:"type:text"`
+	CancelledAt            *time.Time `gorm:"null;index"`
+	CancelledBy            string     `gorm:"not null;default:''"`
+	CancelReasonCode       string     `gorm:"not null;default:''"`
+	CancelNote             string     `gorm:"type:text"`
+	ProcessingStatus       string     `gorm:"not null;default:'';index"`
+	ProcessingAttempts     int        `gorm:"not null;default:0"`
+	ProcessingError        string     `gorm:"type:text"`
+	NextAttemptAt          *time.Time `gorm:"null"`
+	Rail                   string     `gorm:"not null;default:''"`
+	RailReference          *string    `gorm:"null;index"`
+	RailStatus             string     `gorm:"not null;default:''"`
+	RailReason             string     `gorm:"type:text"`
+	ReconciledAt           *time.Time `gorm:"null"`
+	ReconciliationUid      *string    `gorm:"null;index"`
+	FeeAmount              float64    `gorm:"not null;default:0"`
+	FeeCurrency            string     `gorm:"not null;default:''"`
+	FeeBearer              string     `gorm:"not null;default:''"`
+	AuthorizationStatus    string     `gorm:"not null;default:'';index"`
+	AuthorizedAmount       float64    `gorm:"not null;default:0"`
+	CapturedAmount         float64    `gorm:"not null;default:0"`
+	AuthorizationExpiresAt *time.Time `gorm:"null"`
+	BeneficiaryUid         *string    `gorm:"null;index"`
+	IdempotencyKey         *string    `gorm:"size:64;null;unique_index:idx_payment_idempotency"`
+}
+
+// PaymentAmendment records the change of one field of an unprocessed payment; a PATCH gets one revision.
+type PaymentAmendment struct {
+	gorm.Model
+	PaymentUid string `gorm:"not null;index"`
+	Revision   int    `gorm:"not null"`
+	Field      string `gorm:"not null"`
+	OldValue   string `gorm:"type:text"`
+	NewValue   string `gorm:"type:text"`
+	AmendedBy  string `gorm:"not null;default:''"`
+}
+
+// ChangedAt is the time of the last change of a payment; deleting it only sets DeletedAt.
+func (payment *Payment) ChangedAt() time.Time {
+
+	if payment.DeletedAt != nil {
+		return *payment.DeletedAt
+	}
+
+	return payment.UpdatedAt
+}
+
+func (payment *Payment) MarkAsProcessed(now time.Time) {
+
+	processedDate := now.UTC().Truncate(time.Second)
+
+	payment.Processed = true
+	payment.ProcessedDate = &processedDate
+}
+
+// IsHeld tells whether a payment waits for, or failed, a risk review, an approval or a capture and so must not be processed.
+func (payment *Payment) IsHeld() bool {
+
+	if payment.ReviewStatus == REVIEW_STATUS_PENDING || payment.ReviewStatus == REVIEW_STATUS_REJECTED {
+		return true
+	}
+
+	if payment.AuthorizationStatus != "" && payment.AuthorizationStatus != AUTHORIZATION_STATUS_CAPTURED {
+		return true
+	}
+
+	return payment.ApprovalStatus != "" && payment.ApprovalStatus != APPROVAL_STATUS_APPROVED
+}
+
+func (payment *Payment) Cancel(reasonCode string, note string, cancelledBy string, now time.Time) {
+
+	cancelledAt := now.UTC().Truncate(time.Second)
+
+	payment.CancelledAt = &cancelledAt
+	payment.CancelledBy = cancelledBy
+	payment.CancelReasonCode = reasonCode
+	payment.CancelNote = note
+}
+
+func (payment *Payment) IsCancelled() bool {
+	return payment.CancelledAt != nil
+}
+
+// IsProcessing tells whether the payment is queued, running or submitted to a rail that has not settled it yet.
+func (payment *Payment) IsProcessing() bool {
+
+	switch payment.ProcessingStatus {
+
+	case PROCESSING_STATUS_QUEUED, PROCESSING_STATUS_RUNNING, PROCESSING_STATUS_SUBMITTED:
+		return true
+	}
+
+	return false
+}
+
+// PaymentChange is an entry of the change log of the payments, an append-only log of events. It is written in the
+// transaction of the change, so its ids follow the order the changes were made in, and a change rolled back leaves a
+// gap instead of an entry. Snapshot is the payment as JSON right after the change, Payment once decoded.
+type PaymentChange struct {
+	ID         uint      `gorm:"primary_key"`
+	PaymentUid string    `gorm:"not null;index"`
+	Type       string    `gorm:"not null"`
+	Snapshot   string    `gorm:"type:text"`
+	CreatedAt  time.Time `gorm:"not null;index"`
+	Payment    *Payment  `gorm:"-"`
+}
+
+func (payment *Payment) RefundableAmount() float64 {
+
+	if !payment.Processed || payment.RefundOfUid != nil {
+		return 0
+	}
+
+	return payment.Amount - payment.RefundedAmount
 }
 
 func SetUp(db *gorm.DB) *gorm.DB {
 
 	db.SingularTable(true)
-	db.AutoMigrate(&Payment{})
+	db.AutoMigrate(&Payment{}, &PaymentAmendment{}, &PaymentChange{})
+
+	// whichever subsystem writes a payment, the change is logged
+	db.Callback().Create().After("gorm:create").Register("payment:log_created", logChange(CHANGE_CREATED))
+	db.Callback().Update().After("gorm:update").Register("payment:log_updated", logChange(CHANGE_UPDATED))
+	db.Callback().Delete().After("gorm:delete").Register("payment:log_deleted", logChange(CHANGE_DELETED))
 
 	return db
 }
+
+//
+// private functions
+
+// logChange appends the write of a payment to the change log, in the transaction of the write. Writes must go through a
+// payment with its uid, e.g. Model(&Payment{Uid: uid}), or they fail, rather than go unnoticed by the watchers.
+func logChange(changeType string) func(*gorm.Scope) {
+
+	return func(scope *gorm.Scope) {
+
+		if scope.HasError() || scope.GetModelStruct().ModelType != paymentType || scope.DB().RowsAffected == 0 {
+			return
+		}
+
+		uid, found := scope.FieldByName("Uid")
+
+		if !found || !uid.Field.IsValid() || uid.Field.String() == "" {
+			scope.Err(errors.New("payment written without its uid, the change can not be logged"))
+			return
+		}
+
+		// read back, as an update may only hold the columns it set; a purged payment is only left in the value
+		var payment Payment
+		errorDB := scope.NewDB().Unscoped().Where("uid = ?", uid.Field.String()).First(&payment).Error
+
+		if gorm.IsRecordNotFoundError(errorDB) {
+			payment, errorDB = scope.IndirectValue().Interface().(Payment), nil
+		}
+
+		if errorDB != nil {
+			scope.Err(errorDB)
+			return
+		}
+
+		snapshot, errorJson := json.Marshal(&payment)
+
+		if errorJson != nil {
+			scope.Err(errorJson)
+			return
+		}
+
+		scope.Err(scope.NewDB().Create(&PaymentChange{PaymentUid: payment.Uid, Type: changeType, Snapshot: string(snapshot)}).Error)
+	}
+}
@@ -0,0 +1,42 @@
+package openapi
+
+import (
+	_ "embed"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"net/http"
+)
+
+// the page renders the document itself, so the docs work without reaching any other site
+//
+//go:embed docs.html
+var docsHtml []byte
+
+type DocsHandler struct {
+	document *openapi3.T
+}
+
+func NewDocsHandler(document *openapi3.T) *DocsHandler {
+
+	return &DocsHandler{
+		document: document,
+	}
+}
+
+func (dh *DocsHandler) Register(router *mux.Router) {
+	router.HandleFunc("/openapi.json", dh.GetSpec).Methods("GET")
+	router.HandleFunc("/docs", dh.GetDocs).Methods("GET")
+}
+
+func (dh *DocsHandler) GetSpec(w http.ResponseWriter, r *http.Request) {
+
+	util.WritePayload(w, http.StatusOK, dh.document)
+}
+
+func (dh *DocsHandler) GetDocs(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(docsHtml)
+}
@@ -0,0 +1,308 @@
+package openapi
+
+import (
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3gen"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	"github.com/javierjmgits/go-payment-api/payment/handler"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	OPENAPI_VERSION = "3.0.3"
+	API_VERSION     = "1.0.0"
+
+	CONTENT_TYPE_JSON = "application/json"
+)
+
+// spec builds the document piece by piece, keeping the schemas shared by the operations
+type spec struct {
+	document *openapi3.T
+}
+
+// NewSpec describes the routes of PaymentHandler.Register; the schemas of the bodies are generated from the types the
+// handler reads and writes, so they follow them when fields are added.
+func NewSpec() *openapi3.T {
+
+	s := &spec{
+		document: &openapi3.T{
+			OpenAPI: OPENAPI_VERSION,
+			Info: &openapi3.Info{
+				Title:       "Payments API",
+				Description: "Creates, searches, amends, processes and cancels payments.",
+				Version:     API_VERSION,
+			},
+			Paths: openapi3.NewPaths(),
+			Components: &openapi3.Components{
+				Schemas:    openapi3.Schemas{},
+				Parameters: openapi3.ParametersMap{},
+			},
+		},
+	}
+
+	s.addSchemas()
+	s.addParameters()
+	s.addPaths()
+
+	return s.document
+}
+
+//
+// private functions
+
+func (s *spec) addSchemas() {
+
+	schemas := s.document.Components.Schemas
+
+	schemas["PaymentView"] = generate(handler.PaymentView{}, true)
+	schemas["PaymentCreate"] = generate(handler.PaymentCreate{}, false)
+	schemas["PaymentCancel"] = generate(handler.PaymentCancel{}, false)
+	schemas["AmendmentView"] = generate(handler.AmendmentView{}, true)
+
+	// hits hold the same payments as every other response
+	searchResult := generate(handler.SearchResultView{}, true)
+	searchResult.Value.Properties["items"].Value.Items.Value.Properties["payment"] = s.schemaRef("PaymentView")
+	schemas["SearchResultView"] = searchResult
+
+	schemas["PaymentPatch"] = openapi3.NewSchemaRef("", &openapi3.Schema{
+		Type:        &openapi3.Types{openapi3.TypeObject},
+		Description: "A JSON Merge Patch (RFC 7396) of the fields that can be amended; null removes an optional field or a metadata key.",
+		Properties: openapi3.Schemas{
+			"accountOrigin": openapi3.NewStringSchema().NewRef(),
+			"accountTarget": openapi3.NewStringSchema().NewRef(),
+			"amount":        openapi3.NewFloat64Schema().NewRef(),
+			"date":          openapi3.NewDateTimeSchema().NewRef(),
+			"reference":     openapi3.NewStringSchema().WithNullable().NewRef(),
+			"description":   openapi3.NewStringSchema().WithNullable().NewRef(),
+			"metadata":      openapi3.NewObjectSchema().WithNullable().WithAdditionalProperties(openapi3.NewStringSchema().WithNullable()).NewRef(),
+		},
+	})
+
+	// what util.WriteError and util.WriteErrorWithDetails write
+	schemas["Error"] = openapi3.NewSchemaRef("", &openapi3.Schema{
+		Type:     &openapi3.Types{openapi3.TypeObject},
+		Required: []string{"code", "error"},
+		Properties: openapi3.Schemas{
+			"code":    (&openapi3.Schema{Type: &openapi3.Types{openapi3.TypeInteger}, Description: "The HTTP status."}).NewRef(),
+			"error":   openapi3.NewStringSchema().NewRef(),
+			"details": (&openapi3.Schema{Description: "Why a valid request was rejected, on 422 responses."}).NewRef(),
+		},
+	})
+}
+
+func (s *spec) addParameters() {
+
+	parameters := s.document.Components.Parameters
+
+	parameters["User"] = &openapi3.ParameterRef{Value: openapi3.NewHeaderParameter(auth.HEADER_USER).
+		WithDescription("The caller, as asserted by the gateway.").WithSchema(openapi3.NewStringSchema())}
+
+	parameters["Tenant"] = &openapi3.ParameterRef{Value: openapi3.NewHeaderParameter(auth.HEADER_TENANT).
+		WithDescription("The tenant of the caller, as asserted by the gateway.").WithSchema(openapi3.NewStringSchema())}
+
+	parameters["Scopes"] = &openapi3.ParameterRef{Value: openapi3.NewHeaderParameter(auth.HEADER_SCOPES).
+		WithDescription("The comma-separated scopes of the caller, as asserted by the gateway.").WithSchema(openapi3.NewStringSchema())}
+
+	parameters["Uid"] = &openapi3.ParameterRef{Value: openapi3.NewPathParameter("uid").
+		WithDescription("The uid of the payment.").WithSchema(openapi3.NewStringSchema())}
+}
+
+func (s *spec) addPaths() {
+
+	paymentList := openapi3.NewArraySchema()
+	paymentList.Items = s.schemaRef("PaymentView")
+
+	exportContent := openapi3.NewContentWithJSONSchema(paymentList)
+
+	for _, contentType := range []string{handler.CONTENT_TYPE_CSV, handler.CONTENT_TYPE_NDJSON, handler.CONTENT_TYPE_XML} {
+		exportContent[contentType] = openapi3.NewMediaType().WithSchema(openapi3.NewStringSchema())
+	}
+
+	s.path("/api/v1/payments", http.MethodGet, &openapi3.Operation{
+		OperationID: "listPayments",
+		Summary:     "Lists the payments, or exports them as CSV, NDJSON or XML when asked for in the Accept header.",
+		Parameters: openapi3.Parameters{
+			{Value: openapi3.NewQueryParameter("reference").WithDescription("Keeps the payments with this reference.").WithSchema(openapi3.NewStringSchema())},
+			{Value: openapi3.NewQueryParameter("metadata").WithDescription("Keeps the payments with this key:value metadata; can be repeated.").
+				WithSchema(openapi3.NewArraySchema().WithItems(openapi3.NewStringSchema()))},
+		},
+		Responses: s.responses(http.StatusOK, openapi3.NewResponse().WithDescription("The payments.").WithContent(exportContent), http.StatusBadRequest),
+	})
+
+	s.path("/api/v1/payments/statement", http.MethodGet, &openapi3.Operation{
+		OperationID: "getStatement",
+		Summary:     "Writes the payments of an account between two days as a camt.053 statement.",
+		Parameters: openapi3.Parameters{
+			{Value: openapi3.NewQueryParameter("account").WithRequired(true).WithSchema(openapi3.NewStringSchema())},
+			{Value: openapi3.NewQueryParameter("from").WithRequired(true).WithSchema(openapi3.NewStringSchema().WithFormat("date"))},
+			{Value: openapi3.NewQueryParameter("to").WithRequired(true).WithDescription("Inclusive.").WithSchema(openapi3.NewStringSchema().WithFormat("date"))},
+		},
+		Responses: s.responses(http.StatusOK, openapi3.NewResponse().WithDescription("The statement.").
+			WithContent(openapi3.NewContentWithSchema(openapi3.NewStringSchema(), []string{handler.CONTENT_TYPE_XML})), http.StatusBadRequest),
+	})
+
+	s.path("/api/v1/payments/search", http.MethodGet, &openapi3.Operation{
+		OperationID: "searchPayments",
+		Summary:     "Finds payments with the search language, e.g. `account:GB29* amount>100 processed:false`; all of them without one.",
+		Parameters: openapi3.Parameters{
+			{Value: &openapi3.Parameter{Name: "q", In: openapi3.ParameterInQuery, AllowEmptyValue: true, Schema: openapi3.NewStringSchema().NewRef()}},
+			{Value: openapi3.NewQueryParameter("page").WithSchema(openapi3.NewIntegerSchema().WithMin(1).WithDefault(1))},
+			{Value: openapi3.NewQueryParameter("size").WithSchema(openapi3.NewIntegerSchema().WithMin(1).WithMax(handler.MAX_PAGE_SIZE).WithDefault(handler.DEFAULT_PAGE_SIZE))},
+		},
+		Responses: s.responses(http.StatusOK, s.jsonResponse("A page of payments.", s.schemaRef("SearchResultView")), http.StatusBadRequest),
+	})
+
+	s.path("/api/v1/payments", http.MethodPost, &openapi3.Operation{
+		OperationID: "createPayment",
+		Summary:     "Creates a payment; a retry with the idempotency key of an earlier create returns the payment it made.",
+		Parameters: append(openapi3.Parameters{
+			{Value: openapi3.NewHeaderParameter(handler.HEADER_IDEMPOTENCY_KEY).WithDescription("A key of the caller identifying the create.").
+				WithSchema(openapi3.NewStringSchema().WithMaxLength(handler.MAX_IDEMPOTENCY_KEY_LENGTH))},
+		}, s.principalParameters()...),
+		RequestBody: &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().WithRequired(true).WithJSONSchemaRef(s.schemaRef("PaymentCreate"))},
+		Responses: s.responses(http.StatusCreated, s.jsonResponse("The payment created.", s.schemaRef("PaymentView")),
+			http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity),
+	})
+
+	s.path("/api/v1/payments/uid/{uid}", http.MethodGet, &openapi3.Operation{
+		OperationID: "getPayment",
+		Summary:     "Gets a payment.",
+		Parameters:  openapi3.Parameters{s.parameterRef("Uid")},
+		Responses:   s.responses(http.StatusOK, s.jsonResponse("The payment.", s.schemaRef("PaymentView")), http.StatusNotFound),
+	})
+
+	s.path("/api/v1/payments/uid/{uid}", http.MethodPatch, &openapi3.Operation{
+		OperationID: "amendPayment",
+		Summary:     "Amends an unprocessed payment.",
+		Parameters:  append(openapi3.Parameters{s.parameterRef("Uid")}, s.principalParameters()...),
+		RequestBody: &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().WithRequired(true).
+			WithSchemaRef(s.schemaRef("PaymentPatch"), []string{handler.CONTENT_TYPE_MERGE_PATCH})},
+		Responses: s.responses(http.StatusOK, s.jsonResponse("The payment amended.", s.schemaRef("PaymentView")),
+			http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity),
+	})
+
+	s.path("/api/v1/payments/uid/{uid}", http.MethodDelete, &openapi3.Operation{
+		OperationID: "deletePayment",
+		Summary:     "Deletes an unprocessed payment; with hard, for good.",
+		Parameters: append(openapi3.Parameters{
+			s.parameterRef("Uid"),
+			{Value: openapi3.NewQueryParameter("hard").WithSchema(openapi3.NewBoolSchema().WithDefault(false))},
+		}, s.principalParameters()...),
+		Responses: s.responses(http.StatusNoContent, openapi3.NewResponse().WithDescription("The payment was deleted."),
+			http.StatusForbidden, http.StatusNotFound, http.StatusConflict),
+	})
+
+	amendmentList := openapi3.NewArraySchema()
+	amendmentList.Items = s.schemaRef("AmendmentView")
+
+	s.path("/api/v1/payments/uid/{uid}/history", http.MethodGet, &openapi3.Operation{
+		OperationID: "getPaymentHistory",
+		Summary:     "Lists the amendments of a payment, oldest first.",
+		Parameters:  openapi3.Parameters{s.parameterRef("Uid")},
+		Responses:   s.responses(http.StatusOK, s.jsonResponse("The amendments.", amendmentList.NewRef()), http.StatusNotFound),
+	})
+
+	processedResponses := s.responses(http.StatusAccepted, s.jsonResponse("The payment queued.", s.schemaRef("PaymentView")),
+		http.StatusNotFound, http.StatusConflict)
+	processedResponses.Set(strconv.Itoa(http.StatusOK), &openapi3.ResponseRef{Value: s.jsonResponse("The payment flagged as processed, without processing workers.", s.schemaRef("PaymentView"))})
+
+	s.path("/api/v1/payments/uid/{uid}/processed", http.MethodPatch, &openapi3.Operation{
+		OperationID: "processPayment",
+		Summary:     "Queues an unprocessed payment for the processing workers, or flags it as processed without them.",
+		Parameters:  openapi3.Parameters{s.parameterRef("Uid")},
+		Responses:   processedResponses,
+	})
+
+	s.path("/api/v1/payments/uid/{uid}/cancel", http.MethodPost, &openapi3.Operation{
+		OperationID: "cancelPayment",
+		Summary:     "Cancels an unprocessed payment, which stays queryable.",
+		Parameters:  append(openapi3.Parameters{s.parameterRef("Uid")}, s.principalParameters()...),
+		RequestBody: &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().WithRequired(true).WithJSONSchemaRef(s.schemaRef("PaymentCancel"))},
+		Responses: s.responses(http.StatusOK, s.jsonResponse("The payment cancelled.", s.schemaRef("PaymentView")),
+			http.StatusBadRequest, http.StatusNotFound, http.StatusConflict),
+	})
+}
+
+func (s *spec) path(path string, method string, operation *openapi3.Operation) {
+
+	pathItem := s.document.Paths.Value(path)
+
+	if pathItem == nil {
+		pathItem = &openapi3.PathItem{}
+		s.document.Paths.Set(path, pathItem)
+	}
+
+	operation.Tags = []string{"payments"}
+
+	pathItem.SetOperation(method, operation)
+}
+
+// responses adds the errors to the successful response; any operation may fail with a 500.
+func (s *spec) responses(status int, response *openapi3.Response, errorStatuses ...int) *openapi3.Responses {
+
+	responses := openapi3.NewResponses(openapi3.WithStatus(status, &openapi3.ResponseRef{Value: response}))
+
+	for _, errorStatus := range append(errorStatuses, http.StatusInternalServerError) {
+		responses.Set(strconv.Itoa(errorStatus), &openapi3.ResponseRef{Value: s.jsonResponse(http.StatusText(errorStatus), s.schemaRef("Error"))})
+	}
+
+	return responses
+}
+
+func (s *spec) jsonResponse(description string, schema *openapi3.SchemaRef) *openapi3.Response {
+	return openapi3.NewResponse().WithDescription(description).WithJSONSchemaRef(schema)
+}
+
+// refs carry their value, so that the document validates requests without being loaded
+func (s *spec) schemaRef(name string) *openapi3.SchemaRef {
+	return openapi3.NewSchemaRef("#/components/schemas/"+name, s.document.Components.Schemas[name].Value)
+}
+
+func (s *spec) parameterRef(name string) *openapi3.ParameterRef {
+	return &openapi3.ParameterRef{Ref: "#/components/parameters/" + name, Value: s.document.Components.Parameters[name].Value}
+}
+
+func (s *spec) principalParameters() openapi3.Parameters {
+	return openapi3.Parameters{s.parameterRef("User"), s.parameterRef("Tenant"), s.parameterRef("Scopes")}
+}
+
+// generate reflects the schema of a body; for what the API writes, the fields without omitempty are always there, for
+// what it reads, maps and lists may be null.
+func generate(value interface{}, written bool) *openapi3.SchemaRef {
+
+	schemaRef, errorGenerate := openapi3gen.NewSchemaRefForValue(value, nil)
+
+	// the types are fixed at build time, and covered by the tests
+	if errorGenerate != nil {
+		panic(errorGenerate)
+	}
+
+	if written {
+
+		valueType := reflect.TypeOf(value)
+
+		for index := 0; index < valueType.NumField(); index++ {
+
+			tag := valueType.Field(index).Tag.Get("json")
+			name := strings.Split(tag, ",")[0]
+
+			if name != "" && name != "-" && !strings.Contains(tag, ",omitempty") {
+				schemaRef.Value.Required = append(schemaRef.Value.Required, name)
+			}
+		}
+	} else {
+
+		// the handlers read a null map or list as an empty one, and Go clients send nil ones as null
+		for _, property := range schemaRef.Value.Properties {
+
+			if property.Value.Type.Is(openapi3.TypeObject) || property.Value.Type.Is(openapi3.TypeArray) {
+				property.Value.Nullable = true
+			}
+		}
+	}
+
+	return openapi3.NewSchemaRef("", schemaRef.Value)
+}
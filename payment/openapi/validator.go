@@ -0,0 +1,199 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/payment/handler"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Validator checks the requests to the routes of the document against it, before they reach the handlers, and, when
+// asked to, the responses of the handlers too; routes the document does not describe go through untouched.
+type Validator struct {
+	router            routers.Router
+	validateRequests  bool
+	validateResponses bool
+}
+
+// bufferedResponse holds what a handler writes until it has been validated
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func init() {
+	openapi3filter.RegisterBodyDecoder(handler.CONTENT_TYPE_MERGE_PATCH, openapi3filter.JSONBodyDecoder)
+}
+
+// NewValidator validates requests, responses, or both; validating responses holds every response in memory until it is
+// complete, so it is meant for tests and test environments.
+func NewValidator(document *openapi3.T, validateRequests bool, validateResponses bool) (*Validator, error) {
+
+	router, errorRouter := gorillamux.NewRouter(document)
+
+	if errorRouter != nil {
+		return nil, errorRouter
+	}
+
+	return &Validator{
+		router:            router,
+		validateRequests:  validateRequests,
+		validateResponses: validateResponses,
+	}, nil
+}
+
+func (v *Validator) Middleware(next http.Handler) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		route, pathParams, errorRoute := v.router.FindRoute(r)
+
+		if errorRoute != nil || (!v.validateRequests && !v.validateResponses) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    newOptions(),
+		}
+
+		if v.validateRequests && !validateRequest(w, input) {
+			return
+		}
+
+		if !v.validateResponses {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		response := &bufferedResponse{header: http.Header{}, status: http.StatusOK}
+
+		next.ServeHTTP(response, r)
+
+		if errorResponse := validateResponse(input, response); errorResponse != nil {
+			log.Printf("Response of %s %s does not match the specification: %v\n", r.Method, r.URL.Path, errorResponse)
+			util.WriteError(w, http.StatusInternalServerError, "response does not match the specification: "+errorResponse.Error())
+			return
+		}
+
+		for key, values := range response.header {
+			w.Header()[key] = values
+		}
+
+		w.WriteHeader(response.status)
+		w.Write(response.body.Bytes())
+	})
+}
+
+func (br *bufferedResponse) Header() http.Header {
+	return br.header
+}
+
+func (br *bufferedResponse) WriteHeader(status int) {
+	br.status = status
+}
+
+func (br *bufferedResponse) Write(data []byte) (int, error) {
+	return br.body.Write(data)
+}
+
+// Flush does nothing, exports flush as they go but are only sent once validated
+func (br *bufferedResponse) Flush() {
+}
+
+//
+// private functions
+
+// validateRequest answers the request itself when it is invalid, as the handlers do: a 415 for a body of another
+// content type, a 400 for anything else.
+func validateRequest(w http.ResponseWriter, input *openapi3filter.RequestValidationInput) bool {
+
+	r := input.Request
+
+	if requestBody := input.Route.Operation.RequestBody; requestBody != nil && requestBody.Value != nil {
+
+		// the handlers read bodies without a content type as JSON
+		if r.Header.Get("Content-Type") == "" {
+			r.Header.Set("Content-Type", CONTENT_TYPE_JSON)
+		}
+
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+		if requestBody.Value.Content.Get(mediaType) == nil {
+			util.WriteError(w, http.StatusUnsupportedMediaType, "content type must be "+strings.Join(contentTypes(requestBody.Value.Content), " or "))
+			return false
+		}
+	}
+
+	if errorRequest := openapi3filter.ValidateRequest(r.Context(), input); errorRequest != nil {
+		util.WriteError(w, http.StatusBadRequest, errorRequest.Error())
+		return false
+	}
+
+	return true
+}
+
+// validateResponse checks the status and the headers of every response, and the body of the JSON ones.
+func validateResponse(input *openapi3filter.RequestValidationInput, response *bufferedResponse) error {
+
+	mediaType, _, _ := mime.ParseMediaType(response.header.Get("Content-Type"))
+
+	options := newOptions()
+	options.IncludeResponseStatus = true
+	options.ExcludeResponseBody = mediaType != CONTENT_TYPE_JSON && !strings.HasSuffix(mediaType, "+json")
+
+	return openapi3filter.ValidateResponse(input.Request.Context(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 response.status,
+		Header:                 response.header,
+		Body:                   io.NopCloser(bytes.NewReader(response.body.Bytes())),
+		Options:                options,
+	})
+}
+
+func newOptions() *openapi3filter.Options {
+
+	options := &openapi3filter.Options{
+		AuthenticationFunc:  openapi3filter.NoopAuthenticationFunc,
+		SkipSettingDefaults: true,
+	}
+
+	// "amount: value must be a number" rather than the schema and the value in full
+	options.WithCustomSchemaErrorFunc(func(schemaError *openapi3.SchemaError) string {
+
+		if pointer := schemaError.JSONPointer(); len(pointer) > 0 {
+			return fmt.Sprintf("%s: %s", strings.Join(pointer, "."), schemaError.Reason)
+		}
+
+		return schemaError.Reason
+	})
+
+	return options
+}
+
+func contentTypes(content openapi3.Content) []string {
+
+	var results []string
+
+	for contentType := range content {
+		results = append(results, contentType)
+	}
+
+	sort.Strings(results)
+
+	return results
+}
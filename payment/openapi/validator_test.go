@@ -0,0 +1,366 @@
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/payment/handler"
+	"github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/payment/repository"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+//
+// mocks
+
+type paymentRepositoryImplMock struct {
+	repository.PaymentRepository
+	payments []*model.Payment
+}
+
+func (mock *paymentRepositoryImplMock) GetAll() ([]model.Payment, error) {
+
+	var results []model.Payment
+
+	for _, payment := range mock.payments {
+		results = append(results, *payment)
+	}
+
+	return results, nil
+}
+
+func (mock *paymentRepositoryImplMock) Stream(filter repository.PaymentFilter, consumer func(*model.Payment) error) error {
+
+	for _, payment := range mock.payments {
+
+		if errorConsumer := consumer(payment); errorConsumer != nil {
+			return errorConsumer
+		}
+	}
+
+	return nil
+}
+
+func (mock *paymentRepositoryImplMock) Search(conditions []repository.Condition, offset int, limit int) ([]model.Payment, int, error) {
+
+	payments, _ := mock.GetAll()
+
+	return payments, len(payments), nil
+}
+
+func (mock *paymentRepositoryImplMock) GetByUid(uid string) (*model.Payment, error) {
+
+	for _, payment := range mock.payments {
+
+		if payment.Uid == uid {
+			return payment, nil
+		}
+	}
+
+	return nil, errors.New("record not found")
+}
+
+func (mock *paymentRepositoryImplMock) Create(payment *model.Payment) (*model.Payment, error) {
+
+	payment.ID = uint(len(mock.payments) + 1)
+	payment.CreatedAt = time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	payment.UpdatedAt = payment.CreatedAt
+
+	mock.payments = append(mock.payments, payment)
+
+	return payment, nil
+}
+
+func (mock *paymentRepositoryImplMock) Update(payment *model.Payment) (*model.Payment, error) {
+	return payment, nil
+}
+
+func (mock *paymentRepositoryImplMock) Delete(payment *model.Payment) error {
+	return nil
+}
+
+func (mock *paymentRepositoryImplMock) Amend(uid string, amend func(*model.Payment, int) ([]model.PaymentAmendment, error)) (*model.Payment, error) {
+
+	payment, errorGet := mock.GetByUid(uid)
+
+	if errorGet != nil {
+		return nil, errorGet
+	}
+
+	if _, errorAmend := amend(payment, 1); errorAmend != nil {
+		return nil, errorAmend
+	}
+
+	return payment, nil
+}
+
+func (mock *paymentRepositoryImplMock) GetAmendments(uid string) ([]model.PaymentAmendment, error) {
+	return []model.PaymentAmendment{{Revision: 1, Field: "amount", OldValue: "10", NewValue: "12"}}, nil
+}
+
+//
+// tests
+
+func TestSpecIsValid(t *testing.T) {
+
+	document := NewSpec()
+
+	// verify
+
+	assert.Nil(t, document.Validate(context.Background()))
+}
+
+func TestSpecDescribesEveryRoute(t *testing.T) {
+
+	document := NewSpec()
+
+	router := mux.NewRouter()
+	handler.NewPaymentHandler(&paymentRepositoryImplMock{}).Register(router)
+
+	// verify
+
+	router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+
+		path, _ := route.GetPathTemplate()
+		methods, _ := route.GetMethods()
+
+		for _, method := range methods {
+
+			pathItem := document.Paths.Value(path)
+
+			if assert.NotNil(t, pathItem, path) {
+				assert.NotNil(t, pathItem.GetOperation(method), method+" "+path)
+			}
+		}
+
+		return nil
+	})
+}
+
+func TestValidatedRoutes(t *testing.T) {
+
+	router, mockRepository := setUp()
+
+	mockRepository.payments = append(mockRepository.payments, newPayment("uid-1"), newPayment("uid-2"))
+
+	for _, call := range []struct {
+		method      string
+		url         string
+		contentType string
+		body        string
+		status      int
+	}{
+		{"GET", "/api/v1/payments", "", "", http.StatusOK},
+		{"GET", "/api/v1/payments/search?q=processed:false&size=10", "", "", http.StatusOK},
+		{"GET", "/api/v1/payments/search?q=", "", "", http.StatusOK},
+		{"GET", "/api/v1/payments/uid/uid-1", "", "", http.StatusOK},
+		{"GET", "/api/v1/payments/uid/missing", "", "", http.StatusNotFound},
+		{"POST", "/api/v1/payments", "application/json", `{"accountOrigin": "GB29NWBK60161331926819", "accountTarget": "DE89370400440532013000", "amount": 25, "metadata": {"orderId": "1234"}}`, http.StatusCreated},
+		{"POST", "/api/v1/payments", "", `{"accountOrigin": "GB29NWBK60161331926819", "accountTarget": "DE89370400440532013000", "amount": 25}`, http.StatusCreated},
+		{"POST", "/api/v1/payments", "application/json", `{"accountOrigin": "GB29NWBK60161331926819", "amount": 25}`, http.StatusBadRequest},
+		{"PATCH", "/api/v1/payments/uid/uid-1", handler.CONTENT_TYPE_MERGE_PATCH, `{"amount": 12, "reference": null}`, http.StatusOK},
+		{"GET", "/api/v1/payments/uid/uid-1/history", "", "", http.StatusOK},
+		{"POST", "/api/v1/payments/uid/uid-2/cancel", "application/json", `{"reasonCode": "DUPL"}`, http.StatusOK},
+		{"PATCH", "/api/v1/payments/uid/uid-1/processed", "", "", http.StatusOK},
+		{"PATCH", "/api/v1/payments/uid/uid-1/processed", "", "", http.StatusConflict},
+		{"DELETE", "/api/v1/payments/uid/uid-1?hard=false", "", "", http.StatusForbidden},
+	} {
+
+		resp := send(router, call.method, call.url, call.contentType, call.body)
+
+		// verify
+
+		body, _ := ioutil.ReadAll(resp.Body)
+
+		assert.Equal(t, call.status, resp.StatusCode, call.method+" "+call.url+": "+string(body))
+	}
+}
+
+func TestValidatedExportAndDelete(t *testing.T) {
+
+	router, mockRepository := setUp()
+
+	mockRepository.payments = append(mockRepository.payments, newPayment("uid-1"))
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/api/v1/payments", nil)
+	req.Header.Set("Accept", handler.CONTENT_TYPE_CSV)
+	export := httptest.NewRecorder()
+
+	router.ServeHTTP(export, req)
+
+	req = httptest.NewRequest("DELETE", "http://localhost:8080/api/v1/payments/uid/uid-1", nil)
+	req.Header.Set(auth.HEADER_SCOPES, "payments:delete")
+	deleted := httptest.NewRecorder()
+
+	router.ServeHTTP(deleted, req)
+
+	// verify
+
+	assert.Equal(t, http.StatusOK, export.Code)
+	assert.Equal(t, handler.CONTENT_TYPE_CSV, export.Header().Get("Content-Type"))
+	assert.Contains(t, export.Body.String(), "uid-1")
+
+	assert.Equal(t, http.StatusNoContent, deleted.Code)
+}
+
+func TestValidateRequestKo(t *testing.T) {
+
+	router, mockRepository := setUp()
+
+	mockRepository.payments = append(mockRepository.payments, newPayment("uid-1"))
+
+	for _, call := range []struct {
+		method      string
+		url         string
+		contentType string
+		body        string
+		status      int
+		message     string
+	}{
+		{"POST", "/api/v1/payments", "application/json", `{"accountOrigin": "GB29NWBK60161331926819", "amount": "25"}`, http.StatusBadRequest, "amount: value must be a number"},
+		{"POST", "/api/v1/payments", "text/plain", `amount=25`, http.StatusUnsupportedMediaType, "content type must be application/json"},
+		{"GET", "/api/v1/payments/search?size=500", "", "", http.StatusBadRequest, `parameter "size" in query has an error`},
+		{"GET", "/api/v1/payments/statement?account=GB29NWBK60161331926819&from=2026-01-01", "", "", http.StatusBadRequest, `parameter "to" in query has an error`},
+		{"PATCH", "/api/v1/payments/uid/uid-1", "application/json", `{"amount": 12}`, http.StatusUnsupportedMediaType, "content type must be " + handler.CONTENT_TYPE_MERGE_PATCH},
+		{"DELETE", "/api/v1/payments/uid/uid-1?hard=maybe", "", "", http.StatusBadRequest, `parameter "hard" in query has an error`},
+	} {
+
+		resp := send(router, call.method, call.url, call.contentType, call.body)
+
+		// verify
+
+		var errorBody map[string]interface{}
+
+		json.NewDecoder(resp.Body).Decode(&errorBody)
+
+		assert.Equal(t, call.status, resp.StatusCode, call.method+" "+call.url)
+		assert.Contains(t, errorBody["error"], call.message)
+	}
+}
+
+func TestValidateResponseKo(t *testing.T) {
+
+	router := mux.NewRouter()
+
+	// a handler that breaks the contract: PaymentView requires the uid
+	router.HandleFunc("/api/v1/payments/uid/{uid}", func(w http.ResponseWriter, r *http.Request) {
+		util.WritePayload(w, http.StatusOK, map[string]interface{}{"amount": 10})
+	}).Methods("GET")
+
+	validator, _ := NewValidator(NewSpec(), true, true)
+	router.Use(validator.Middleware)
+
+	resp := send(router, "GET", "/api/v1/payments/uid/uid-1", "", "")
+
+	// verify
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Contains(t, string(body), "response does not match the specification")
+}
+
+func TestNotDescribedRoutesAreNotValidated(t *testing.T) {
+
+	router := mux.NewRouter()
+
+	router.HandleFunc("/api/v1/limits", func(w http.ResponseWriter, r *http.Request) {
+		util.WritePayload(w, http.StatusOK, map[string]interface{}{"anything": true})
+	}).Methods("POST")
+
+	validator, _ := NewValidator(NewSpec(), true, true)
+	router.Use(validator.Middleware)
+
+	resp := send(router, "POST", "/api/v1/limits", "text/plain", "anything")
+
+	// verify
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestGetSpecAndDocs(t *testing.T) {
+
+	router := mux.NewRouter()
+
+	NewDocsHandler(NewSpec()).Register(router)
+
+	resp := send(router, "GET", "/openapi.json", "", "")
+
+	var document map[string]interface{}
+
+	json.NewDecoder(resp.Body).Decode(&document)
+
+	docs := send(router, "GET", "/docs", "", "")
+
+	// verify
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, OPENAPI_VERSION, document["openapi"])
+	assert.Contains(t, document["paths"], "/api/v1/payments/uid/{uid}")
+	assert.Contains(t, document["components"].(map[string]interface{})["schemas"], "PaymentView")
+
+	assert.Equal(t, http.StatusOK, docs.StatusCode)
+	assert.Equal(t, "text/html; charset=utf-8", docs.Header.Get("Content-Type"))
+}
+
+//
+// private functions
+
+func setUp() (*mux.Router, *paymentRepositoryImplMock) {
+
+	router := mux.NewRouter()
+
+	mockRepository := &paymentRepositoryImplMock{}
+
+	paymentHandler := handler.NewPaymentHandler(mockRepository)
+	paymentHandler.SetDeleteScope("payments:delete")
+	paymentHandler.SetCancelReasons([]string{"DUPL"})
+	paymentHandler.Register(router)
+
+	validator, _ := NewValidator(NewSpec(), true, true)
+	router.Use(validator.Middleware)
+
+	return router, mockRepository
+}
+
+func newPayment(uid string) *model.Payment {
+
+	payment := &model.Payment{
+		Uid:           uid,
+		AccountOrigin: "GB29NWBK60161331926819",
+		AccountTarget: "DE89370400440532013000",
+		Amount:        10,
+		Currency:      model.DEFAULT_CURRENCY,
+		Date:          time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	payment.CreatedAt = time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	payment.UpdatedAt = payment.CreatedAt
+
+	return payment
+}
+
+func send(router *mux.Router, method string, url string, contentType string, body string) *http.Response {
+
+	req := httptest.NewRequest(method, "http://localhost:8080"+url, strings.NewReader(body))
+	req.Header.Set(auth.HEADER_USER, "alice")
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	return w.Result()
+}
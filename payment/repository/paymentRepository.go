@@ -1,16 +1,56 @@
 package repository
 
 import (
+	"encoding/json"
+	"fmt"
 	"github.com/javierjmgits/go-payment-api/payment/model"
 	"github.com/jinzhu/gorm"
+	"time"
 )
 
+type PaymentFilter struct {
+	Account   string
+	From      *time.Time
+	To        *time.Time
+	Reference string
+	Metadata  map[string]string
+}
+
+func (filter *PaymentFilter) IsEmpty() bool {
+	return filter.Account == "" && filter.From == nil && filter.To == nil && filter.Reference == "" && len(filter.Metadata) == 0
+}
+
+// Condition is a SQL fragment with its arguments, e.g. built from a search query.
+type Condition struct {
+	Query string
+	Args  []interface{}
+}
+
+// AccountTotal is what an account sent and received in one currency; cancelled payments are left out.
+type AccountTotal struct {
+	Account        string
+	Currency       string
+	SentCount      int
+	SentAmount     float64
+	ReceivedCount  int
+	ReceivedAmount float64
+}
+
 type PaymentRepository interface {
 	GetAll() ([]model.Payment, error)
+	Stream(filter PaymentFilter, consumer func(*model.Payment) error) error
+	Search(conditions []Condition, offset int, limit int) ([]model.Payment, int, error)
 	GetByUid(uid string) (*model.Payment, error)
+	GetByIdempotencyKey(tenant string, key string) (*model.Payment, error)
 	Create(*model.Payment) (*model.Payment, error)
 	Update(*model.Payment) (*model.Payment, error)
 	Delete(*model.Payment) error
+	Purge(*model.Payment) error
+	Amend(uid string, amend func(*model.Payment, int) ([]model.PaymentAmendment, error)) (*model.Payment, error)
+	GetAmendments(uid string) ([]model.PaymentAmendment, error)
+	GetChangedSince(afterId uint, limit int) ([]model.PaymentChange, error)
+	GetLastChangeIdBefore(at time.Time) (uint, error)
+	GetAccountTotals(accounts []string) ([]AccountTotal, error)
 }
 
 type paymentRepositoryImpl struct {
@@ -35,6 +75,79 @@ func (pri *paymentRepositoryImpl) GetAll() ([]model.Payment, error) {
 	return payments, nil
 }
 
+func (pri *paymentRepositoryImpl) Stream(filter PaymentFilter, consumer func(*model.Payment) error) error {
+
+	query := pri.db.Model(&model.Payment{}).Order("date, id")
+
+	if filter.Account != "" {
+		query = query.Where("account_origin = ? OR account_target = ?", filter.Account, filter.Account)
+	}
+
+	if filter.From != nil {
+		query = query.Where("date >= ?", *filter.From)
+	}
+
+	if filter.To != nil {
+		query = query.Where("date < ?", *filter.To)
+	}
+
+	if filter.Reference != "" {
+		query = query.Where("reference = ?", filter.Reference)
+	}
+
+	// metadata keys are validated against a strict pattern, so they are safe to use in a JSON path
+	for key, value := range filter.Metadata {
+		query = query.Where("JSON_UNQUOTE(JSON_EXTRACT(metadata, ?)) = ?", `$."`+key+`"`, value)
+	}
+
+	rows, errorDB := query.Rows()
+
+	if errorDB != nil {
+		return errorDB
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var payment model.Payment
+
+		if errorScan := pri.db.ScanRows(rows, &payment); errorScan != nil {
+			return errorScan
+		}
+
+		if errorConsumer := consumer(&payment); errorConsumer != nil {
+			return errorConsumer
+		}
+	}
+
+	return rows.Err()
+}
+
+func (pri *paymentRepositoryImpl) Search(conditions []Condition, offset int, limit int) ([]model.Payment, int, error) {
+
+	query := pri.db.Model(&model.Payment{})
+
+	for _, condition := range conditions {
+		query = query.Where(condition.Query, condition.Args...)
+	}
+
+	var total int
+
+	if errorDB := query.Count(&total).Error; errorDB != nil {
+		return nil, 0, errorDB
+	}
+
+	var payments []model.Payment
+	errorDB := query.Order("date DESC, id DESC").Offset(offset).Limit(limit).Find(&payments).Error
+
+	if errorDB != nil {
+		return nil, 0, errorDB
+	}
+
+	return payments, total, nil
+}
+
 func (pri *paymentRepositoryImpl) GetByUid(uid string) (*model.Payment, error) {
 
 	var payment model.Payment
@@ -47,6 +160,24 @@ func (pri *paymentRepositoryImpl) GetByUid(uid string) (*model.Payment, error) {
 	return &payment, nil
 }
 
+// GetByIdempotencyKey returns nil when no payment was created with the key; deleted payments are found too, a retried
+// create must not make a payment again once it was deleted.
+func (pri *paymentRepositoryImpl) GetByIdempotencyKey(tenant string, key string) (*model.Payment, error) {
+
+	var payment model.Payment
+	errorFind := pri.db.Unscoped().Where("tenant = ? AND idempotency_key = ?", tenant, key).First(&payment).Error
+
+	if gorm.IsRecordNotFoundError(errorFind) {
+		return nil, nil
+	}
+
+	if errorFind != nil {
+		return nil, errorFind
+	}
+
+	return &payment, nil
+}
+
 func (pri *paymentRepositoryImpl) Create(payment *model.Payment) (*model.Payment, error) {
 
 	errorDB := pri.db.Create(&payment).Error
@@ -80,3 +211,223 @@ func (pri *paymentRepositoryImpl) Delete(payment *model.Payment) error {
 
 	return nil
 }
+
+// Purge removes a payment and its amendment history for good, where Delete only hides it.
+func (pri *paymentRepositoryImpl) Purge(payment *model.Payment) error {
+
+	tx := pri.db.Begin()
+
+	errorDB := tx.Unscoped().Where("payment_uid = ?", payment.Uid).Delete(&model.PaymentAmendment{}).Error
+
+	if errorDB != nil {
+		tx.Rollback()
+		return errorDB
+	}
+
+	errorDB = tx.Unscoped().Delete(payment).Error
+
+	if errorDB != nil {
+		tx.Rollback()
+		return errorDB
+	}
+
+	return tx.Commit().Error
+}
+
+func (pri *paymentRepositoryImpl) Amend(uid string, amend func(*model.Payment, int) ([]model.PaymentAmendment, error)) (*model.Payment, error) {
+
+	tx := pri.db.Begin()
+
+	// the payment stays locked so that it cannot be processed or amended by someone else meanwhile
+	var payment model.Payment
+	errorDB := tx.Set("gorm:query_option", "FOR UPDATE").Where("uid = ?", uid).First(&payment).Error
+
+	if errorDB != nil {
+		tx.Rollback()
+		return nil, errorDB
+	}
+
+	var revision struct{ Last int }
+	errorDB = tx.Model(&model.PaymentAmendment{}).Select("COALESCE(MAX(revision), 0) AS last").Where("payment_uid = ?", uid).Scan(&revision).Error
+
+	if errorDB != nil {
+		tx.Rollback()
+		return nil, errorDB
+	}
+
+	amendments, errorAmend := amend(&payment, revision.Last+1)
+
+	if errorAmend != nil {
+		tx.Rollback()
+		return nil, errorAmend
+	}
+
+	if len(amendments) == 0 {
+		tx.Rollback()
+		return &payment, nil
+	}
+
+	if errorDB := tx.Save(&payment).Error; errorDB != nil {
+		tx.Rollback()
+		return nil, errorDB
+	}
+
+	for index := range amendments {
+
+		if errorDB := tx.Create(&amendments[index]).Error; errorDB != nil {
+			tx.Rollback()
+			return nil, errorDB
+		}
+	}
+
+	errorDB = tx.Commit().Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return &payment, nil
+}
+
+func (pri *paymentRepositoryImpl) GetAmendments(uid string) ([]model.PaymentAmendment, error) {
+
+	var amendments []model.PaymentAmendment
+	errorDB := pri.db.Where("payment_uid = ?", uid).Order("revision, id").Find(&amendments).Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return amendments, nil
+}
+
+// GetChangedSince returns the changes logged after the given one, in order, each with the payment as it was right after
+// it, purged payments included.
+func (pri *paymentRepositoryImpl) GetChangedSince(afterId uint, limit int) ([]model.PaymentChange, error) {
+
+	var changes []model.PaymentChange
+	errorDB := pri.db.Where("id > ?", afterId).Order("id").Limit(limit).Find(&changes).Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	for index := range changes {
+
+		var payment model.Payment
+
+		if errorJson := json.Unmarshal([]byte(changes[index].Snapshot), &payment); errorJson != nil {
+			return nil, fmt.Errorf("invalid snapshot of change %d: %v", changes[index].ID, errorJson)
+		}
+
+		changes[index].Payment = &payment
+	}
+
+	return changes, nil
+}
+
+// GetLastChangeIdBefore returns the id of the last change logged before the given time, 0 when there is none, to watch
+// the changes from that time on.
+func (pri *paymentRepositoryImpl) GetLastChangeIdBefore(at time.Time) (uint, error) {
+
+	var ids []uint
+	errorDB := pri.db.Model(&model.PaymentChange{}).Where("created_at < ?", at).Order("id DESC").Limit(1).Pluck("id", &ids).Error
+
+	if errorDB != nil || len(ids) == 0 {
+		return 0, errorDB
+	}
+
+	return ids[0], nil
+}
+
+// UpdateMatching sets a column of the payments matching a condition one payment at a time, so that every change is
+// logged; a payment that no longer matches by its turn is left alone. It returns how many payments were changed.
+func UpdateMatching(db *gorm.DB, column string, value interface{}, query string, args ...interface{}) (int, error) {
+
+	var uids []string
+	errorDB := db.Model(&model.Payment{}).Where(query, args...).Pluck("uid", &uids).Error
+
+	if errorDB != nil {
+		return 0, errorDB
+	}
+
+	updated := 0
+
+	for _, uid := range uids {
+
+		result := db.Model(&model.Payment{Uid: uid}).
+			Where("uid = ?", uid).
+			Where(query, args...).
+			Update(column, value)
+
+		if result.Error != nil {
+			return updated, result.Error
+		}
+
+		updated += int(result.RowsAffected)
+	}
+
+	return updated, nil
+}
+
+// GetAccountTotals adds up the payments of several accounts at once, sent amounts in their currency and received ones in the target currency.
+func (pri *paymentRepositoryImpl) GetAccountTotals(accounts []string) ([]AccountTotal, error) {
+
+	type row struct {
+		Account  string
+		Currency string
+		Count    int
+		Amount   float64
+	}
+
+	var sent []row
+	errorDB := pri.db.Model(&model.Payment{}).
+		Select("account_origin AS account, currency, COUNT(*) AS count, SUM(amount) AS amount").
+		Where("account_origin IN (?) AND cancelled_at IS NULL", accounts).
+		Group("account_origin, currency").
+		Scan(&sent).Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	var received []row
+	errorDB = pri.db.Model(&model.Payment{}).
+		Select("account_target AS account, target_currency AS currency, COUNT(*) AS count, SUM(target_amount) AS amount").
+		Where("account_target IN (?) AND cancelled_at IS NULL", accounts).
+		Group("account_target, target_currency").
+		Scan(&received).Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	var totals []AccountTotal
+	indexes := map[[2]string]int{}
+
+	total := func(account string, currency string) *AccountTotal {
+
+		key := [2]string{account, currency}
+
+		if _, found := indexes[key]; !found {
+			indexes[key] = len(totals)
+			totals = append(totals, AccountTotal{Account: account, Currency: currency})
+		}
+
+		return &totals[indexes[key]]
+	}
+
+	for _, item := range sent {
+		accountTotal := total(item.Account, item.Currency)
+		accountTotal.SentCount = item.Count
+		accountTotal.SentAmount = item.Amount
+	}
+
+	for _, item := range received {
+		accountTotal := total(item.Account, item.Currency)
+		accountTotal.ReceivedCount = item.Count
+		accountTotal.ReceivedAmount = item.Amount
+	}
+
+	return totals, nil
+}
@@ -0,0 +1,340 @@
+package rpc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/payment/handler"
+	"github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/payment/repository"
+	"github.com/javierjmgits/go-payment-api/payment/rpc/paymentpb"
+	"github.com/javierjmgits/go-payment-api/payment/watch"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PaymentServer serves the payment endpoints over gRPC with the validation, hooks and errors of the REST handler.
+type PaymentServer struct {
+	paymentpb.UnimplementedPaymentServiceServer
+	paymentHandler    *handler.PaymentHandler
+	paymentRepository repository.PaymentRepository
+	watcher           *watch.Watcher
+}
+
+func NewPaymentServer(paymentHandler *handler.PaymentHandler, paymentRepository repository.PaymentRepository, watcher *watch.Watcher) *PaymentServer {
+
+	return &PaymentServer{
+		paymentHandler:    paymentHandler,
+		paymentRepository: paymentRepository,
+		watcher:           watcher,
+	}
+}
+
+func (ps *PaymentServer) Register(server *grpc.Server) {
+	paymentpb.RegisterPaymentServiceServer(server, ps)
+}
+
+func (ps *PaymentServer) CreatePayment(ctx context.Context, request *paymentpb.CreatePaymentRequest) (*paymentpb.Payment, error) {
+
+	paymentCreate := &handler.PaymentCreate{
+		AccountOrigin:  request.AccountOrigin,
+		AccountTarget:  request.AccountTarget,
+		Amount:         request.Amount,
+		Currency:       request.Currency,
+		TargetCurrency: request.TargetCurrency,
+		FxQuoteUid:     request.FxQuoteUid,
+		Reference:      request.Reference,
+		Description:    request.Description,
+		Metadata:       request.Metadata,
+		FeeBearer:      request.FeeBearer,
+		BeneficiaryUid: request.BeneficiaryUid,
+	}
+
+	if request.Date != nil {
+		paymentCreate.Date = request.Date.AsTime()
+	}
+
+	payment, errorCreate := ps.paymentHandler.Create(paymentCreate, principalFrom(ctx))
+
+	if errorCreate != nil {
+		return nil, statusFor(errorCreate)
+	}
+
+	return newPayment(payment), nil
+}
+
+func (ps *PaymentServer) GetPayment(ctx context.Context, request *paymentpb.GetPaymentRequest) (*paymentpb.Payment, error) {
+
+	payment, errorGet := ps.paymentHandler.Get(request.Uid)
+
+	if errorGet != nil {
+		return nil, statusFor(errorGet)
+	}
+
+	return newPayment(payment), nil
+}
+
+// ListPayments pages through the payments like the search endpoint without a query, newest first.
+func (ps *PaymentServer) ListPayments(ctx context.Context, request *paymentpb.ListPaymentsRequest) (*paymentpb.ListPaymentsResponse, error) {
+
+	size := int(request.PageSize)
+
+	if size == 0 {
+		size = handler.DEFAULT_PAGE_SIZE
+	}
+
+	if size < 1 || size > handler.MAX_PAGE_SIZE {
+		return nil, status.Errorf(codes.InvalidArgument, "page size must be between 1 and %d", handler.MAX_PAGE_SIZE)
+	}
+
+	offset, errorToken := decodePageToken(request.PageToken)
+
+	if errorToken != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid page token")
+	}
+
+	payments, total, errorDB := ps.paymentRepository.Search(nil, offset, size)
+
+	if errorDB != nil {
+		return nil, statusFor(errorDB)
+	}
+
+	response := &paymentpb.ListPaymentsResponse{
+		Total: int32(total),
+	}
+
+	for index := range payments {
+		response.Payments = append(response.Payments, newPayment(&payments[index]))
+	}
+
+	if offset+len(payments) < total {
+		response.NextPageToken = encodePageToken(offset + len(payments))
+	}
+
+	return response, nil
+}
+
+func (ps *PaymentServer) MarkProcessed(ctx context.Context, request *paymentpb.MarkProcessedRequest) (*paymentpb.Payment, error) {
+
+	payment, errorProcessed := ps.paymentHandler.MarkProcessed(request.Uid)
+
+	if errorProcessed != nil {
+		return nil, statusFor(errorProcessed)
+	}
+
+	return newPayment(payment), nil
+}
+
+func (ps *PaymentServer) DeletePayment(ctx context.Context, request *paymentpb.DeletePaymentRequest) (*paymentpb.DeletePaymentResponse, error) {
+
+	if errorDelete := ps.paymentHandler.Delete(request.Uid, principalFrom(ctx), request.Hard); errorDelete != nil {
+		return nil, statusFor(errorDelete)
+	}
+
+	return &paymentpb.DeletePaymentResponse{}, nil
+}
+
+// WatchPayments streams the changes of the payments from the given time on, each with the payment as it was then.
+func (ps *PaymentServer) WatchPayments(request *paymentpb.WatchPaymentsRequest, stream paymentpb.PaymentService_WatchPaymentsServer) error {
+
+	since := time.Now().UTC()
+
+	if request.Since != nil {
+		since = request.Since.AsTime()
+	}
+
+	from, errorFrom := ps.watcher.From(since)
+
+	if errorFrom != nil {
+		return statusFor(errorFrom)
+	}
+
+	errorWatch := ps.watcher.Watch(stream.Context(), from, func(change *watch.Change) error {
+		return stream.Send(newPaymentEvent(change))
+	})
+
+	if errorWatch != nil && stream.Context().Err() == nil {
+		return statusFor(errorWatch)
+	}
+
+	return nil
+}
+
+//
+// private functions
+
+// principalFrom reads the caller from the metadata of the call, named like the headers of the REST API.
+func principalFrom(ctx context.Context) *auth.Principal {
+
+	header := http.Header{}
+
+	incoming, _ := metadata.FromIncomingContext(ctx)
+
+	for key, values := range incoming {
+
+		for _, value := range values {
+			header.Add(key, value)
+		}
+	}
+
+	return auth.FromHeader(header)
+}
+
+// statusFor maps the errors of the handler to codes the way util.WriteErrorFor maps them to HTTP statuses.
+func statusFor(err error) error {
+
+	switch typed := err.(type) {
+
+	case *util.InputError:
+		return status.Error(codes.InvalidArgument, err.Error())
+
+	case *util.ConflictError:
+		return status.Error(codes.Aborted, err.Error())
+
+	case *util.RejectedError:
+		return rejectedStatus(typed).Err()
+
+	case *util.ForbiddenError:
+		return status.Error(codes.PermissionDenied, err.Error())
+
+	default:
+		if strings.Contains(err.Error(), "not found") {
+			return status.Error(codes.NotFound, err.Error())
+		}
+
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// rejectedStatus attaches the details of a rejection, as they would be written in JSON, to a failed precondition.
+func rejectedStatus(rejectedError *util.RejectedError) *status.Status {
+
+	rejected := status.New(codes.FailedPrecondition, rejectedError.Error())
+
+	if rejectedError.Details == nil {
+		return rejected
+	}
+
+	detailsJson, errorJson := json.Marshal(rejectedError.Details)
+
+	if errorJson != nil {
+		return rejected
+	}
+
+	var details interface{}
+
+	if errorJson = json.Unmarshal(detailsJson, &details); errorJson != nil {
+		return rejected
+	}
+
+	value, errorValue := structpb.NewValue(details)
+
+	if errorValue != nil {
+		return rejected
+	}
+
+	withDetails, errorDetails := rejected.WithDetails(value)
+
+	if errorDetails != nil {
+		return rejected
+	}
+
+	return withDetails
+}
+
+func encodePageToken(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func decodePageToken(pageToken string) (int, error) {
+
+	if pageToken == "" {
+		return 0, nil
+	}
+
+	decoded, errorDecode := base64.RawURLEncoding.DecodeString(pageToken)
+
+	if errorDecode != nil {
+		return 0, errorDecode
+	}
+
+	offset, errorOffset := strconv.Atoi(string(decoded))
+
+	if errorOffset == nil && offset < 0 {
+		return 0, strconv.ErrRange
+	}
+
+	return offset, errorOffset
+}
+
+func newPaymentEvent(change *watch.Change) *paymentpb.PaymentEvent {
+
+	return &paymentpb.PaymentEvent{
+		Type:    paymentpb.PaymentEvent_Type(paymentpb.PaymentEvent_Type_value[change.Type]),
+		Payment: newPayment(change.Payment),
+		Time:    timestamppb.New(change.ChangedAt),
+	}
+}
+
+// newPayment builds the message from the REST view, so both APIs show accounts and metadata alike.
+func newPayment(payment *model.Payment) *paymentpb.Payment {
+
+	view := handler.NewPaymentView(payment)
+
+	message := &paymentpb.Payment{
+		Uid:                 view.Uid,
+		AccountOrigin:       view.AccountOrigin,
+		AccountTarget:       view.AccountTarget,
+		Amount:              view.Amount,
+		Currency:            view.Currency,
+		TargetCurrency:      view.TargetCurrency,
+		TargetAmount:        view.TargetAmount,
+		FxRate:              view.FxRate,
+		Date:                timestamppb.New(view.Date),
+		Processed:           view.Processed,
+		ProcessedDate:       newTimestamp(view.ProcessedDate),
+		RefundedAmount:      view.RefundedAmount,
+		RefundableAmount:    view.RefundableAmount,
+		RiskDecision:        view.RiskDecision,
+		ReviewStatus:        view.ReviewStatus,
+		ApprovalStatus:      view.ApprovalStatus,
+		CreatedBy:           view.CreatedBy,
+		Tenant:              view.Tenant,
+		Reference:           view.Reference,
+		Description:         view.Description,
+		Metadata:            view.Metadata,
+		CancelledAt:         newTimestamp(view.CancelledAt),
+		CancelReasonCode:    view.CancelReasonCode,
+		ProcessingStatus:    view.ProcessingStatus,
+		RailStatus:          view.RailStatus,
+		FeeAmount:           view.FeeAmount,
+		FeeCurrency:         view.FeeCurrency,
+		FeeBearer:           view.FeeBearer,
+		AuthorizationStatus: view.AuthorizationStatus,
+	}
+
+	if view.BeneficiaryUid != nil {
+		message.BeneficiaryUid = *view.BeneficiaryUid
+	}
+
+	return message
+}
+
+func newTimestamp(value *time.Time) *timestamppb.Timestamp {
+
+	if value == nil {
+		return nil
+	}
+
+	return timestamppb.New(*value)
+}
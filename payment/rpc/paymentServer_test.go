@@ -0,0 +1,352 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"github.com/javierjmgits/go-payment-api/payment/handler"
+	"github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/payment/repository"
+	"github.com/javierjmgits/go-payment-api/payment/rpc/paymentpb"
+	"github.com/javierjmgits/go-payment-api/payment/watch"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+//
+// mocks
+
+// the watch stream reads the payments while other calls change them, so they are copied under a lock
+type paymentRepositoryImplMock struct {
+	repository.PaymentRepository
+	lock     sync.Mutex
+	payments []*model.Payment
+	changes  []model.PaymentChange
+}
+
+func (mock *paymentRepositoryImplMock) Search(conditions []repository.Condition, offset int, limit int) ([]model.Payment, int, error) {
+
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+
+	var results []model.Payment
+
+	for index := offset; index < len(mock.payments) && index < offset+limit; index++ {
+		results = append(results, *mock.payments[index])
+	}
+
+	return results, len(mock.payments), nil
+}
+
+func (mock *paymentRepositoryImplMock) GetByUid(uid string) (*model.Payment, error) {
+
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+
+	for _, payment := range mock.payments {
+
+		if payment.Uid == uid && payment.DeletedAt == nil {
+			copied := *payment
+			return &copied, nil
+		}
+	}
+
+	return nil, errors.New("record not found")
+}
+
+func (mock *paymentRepositoryImplMock) Create(payment *model.Payment) (*model.Payment, error) {
+
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+
+	payment.ID = uint(len(mock.payments) + 1)
+	payment.CreatedAt = time.Now().UTC()
+	payment.UpdatedAt = payment.CreatedAt
+
+	mock.payments = append(mock.payments, payment)
+	mock.changes = append(mock.changes, newChange(len(mock.changes), payment, model.CHANGE_CREATED))
+
+	return payment, nil
+}
+
+func (mock *paymentRepositoryImplMock) Update(payment *model.Payment) (*model.Payment, error) {
+
+	payment.UpdatedAt = time.Now().UTC()
+
+	mock.replace(payment, model.CHANGE_UPDATED)
+
+	return payment, nil
+}
+
+func (mock *paymentRepositoryImplMock) Delete(payment *model.Payment) error {
+
+	deletedAt := time.Now().UTC()
+	payment.DeletedAt = &deletedAt
+
+	mock.replace(payment, model.CHANGE_DELETED)
+
+	return nil
+}
+
+func (mock *paymentRepositoryImplMock) GetChangedSince(afterId uint, limit int) ([]model.PaymentChange, error) {
+
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+
+	var results []model.PaymentChange
+
+	for _, change := range mock.changes {
+
+		if change.ID > afterId && len(results) < limit {
+			results = append(results, change)
+		}
+	}
+
+	return results, nil
+}
+
+func (mock *paymentRepositoryImplMock) GetLastChangeIdBefore(at time.Time) (uint, error) {
+
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+
+	var id uint
+
+	for _, change := range mock.changes {
+
+		if change.CreatedAt.Before(at) {
+			id = change.ID
+		}
+	}
+
+	return id, nil
+}
+
+func (mock *paymentRepositoryImplMock) replace(payment *model.Payment, changeType string) {
+
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+
+	for index := range mock.payments {
+
+		if mock.payments[index].Uid == payment.Uid {
+			copied := *payment
+			mock.payments[index] = &copied
+		}
+	}
+
+	mock.changes = append(mock.changes, newChange(len(mock.changes), payment, changeType))
+}
+
+//
+// tests
+
+func TestCreatePayment(t *testing.T) {
+
+	client, mockRepository := setUp(t)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-user", "alice", "x-tenant", "acme")
+
+	payment, errorCreate := client.CreatePayment(ctx, &paymentpb.CreatePaymentRequest{
+		AccountOrigin: "GB29NWBK60161331926819",
+		AccountTarget: "DE89370400440532013000",
+		Amount:        120.5,
+		Reference:     "INV-42",
+		Metadata:      map[string]string{"orderId": "1234"},
+	})
+
+	// verify
+
+	assert.Nil(t, errorCreate)
+	assert.NotEmpty(t, payment.Uid)
+	assert.Equal(t, "GB29 NWBK 6016 1331 9268 19", payment.AccountOrigin)
+	assert.Equal(t, model.DEFAULT_CURRENCY, payment.Currency)
+	assert.Equal(t, "alice", payment.CreatedBy)
+	assert.Equal(t, "acme", payment.Tenant)
+	assert.Equal(t, "1234", payment.Metadata["orderId"])
+	assert.Len(t, mockRepository.payments, 1)
+}
+
+func TestCreatePaymentKoValidation(t *testing.T) {
+
+	client, _ := setUp(t)
+
+	_, errorCreate := client.CreatePayment(context.Background(), &paymentpb.CreatePaymentRequest{
+		AccountOrigin: "GB29NWBK60161331926819",
+		AccountTarget: "GB29NWBK60161331926819",
+		Amount:        10,
+	})
+
+	// verify
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(errorCreate))
+	assert.Equal(t, "account origin and target must be different", status.Convert(errorCreate).Message())
+}
+
+func TestGetPaymentKoNotFound(t *testing.T) {
+
+	client, _ := setUp(t)
+
+	_, errorGet := client.GetPayment(context.Background(), &paymentpb.GetPaymentRequest{Uid: "missing"})
+
+	assert.Equal(t, codes.NotFound, status.Code(errorGet))
+}
+
+func TestListPayments(t *testing.T) {
+
+	client, mockRepository := setUp(t)
+
+	for _, uid := range []string{"uid-1", "uid-2", "uid-3"} {
+		mockRepository.Create(&model.Payment{Uid: uid, Date: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)})
+	}
+
+	page, errorList := client.ListPayments(context.Background(), &paymentpb.ListPaymentsRequest{PageSize: 2})
+
+	assert.Nil(t, errorList)
+	assert.Len(t, page.Payments, 2)
+	assert.Equal(t, int32(3), page.Total)
+	assert.NotEmpty(t, page.NextPageToken)
+
+	page, errorList = client.ListPayments(context.Background(), &paymentpb.ListPaymentsRequest{PageSize: 2, PageToken: page.NextPageToken})
+
+	// verify
+
+	assert.Nil(t, errorList)
+	assert.Len(t, page.Payments, 1)
+	assert.Equal(t, "uid-3", page.Payments[0].Uid)
+	assert.Empty(t, page.NextPageToken)
+
+	_, errorList = client.ListPayments(context.Background(), &paymentpb.ListPaymentsRequest{PageToken: "not a token"})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(errorList))
+}
+
+func TestMarkProcessed(t *testing.T) {
+
+	client, mockRepository := setUp(t)
+
+	mockRepository.Create(&model.Payment{Uid: "uid-1"})
+
+	payment, errorProcessed := client.MarkProcessed(context.Background(), &paymentpb.MarkProcessedRequest{Uid: "uid-1"})
+
+	assert.Nil(t, errorProcessed)
+	assert.True(t, payment.Processed)
+	assert.NotNil(t, payment.ProcessedDate)
+
+	// verify
+
+	_, errorProcessed = client.MarkProcessed(context.Background(), &paymentpb.MarkProcessedRequest{Uid: "uid-1"})
+
+	assert.Equal(t, codes.Aborted, status.Code(errorProcessed))
+	assert.Equal(t, "Payment already processed", status.Convert(errorProcessed).Message())
+}
+
+func TestDeletePaymentKoScope(t *testing.T) {
+
+	client, mockRepository := setUp(t)
+
+	mockRepository.Create(&model.Payment{Uid: "uid-1"})
+
+	_, errorDelete := client.DeletePayment(context.Background(), &paymentpb.DeletePaymentRequest{Uid: "uid-1"})
+
+	assert.Equal(t, codes.PermissionDenied, status.Code(errorDelete))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-scopes", "payments:read, payments:delete")
+
+	_, errorDelete = client.DeletePayment(ctx, &paymentpb.DeletePaymentRequest{Uid: "uid-1"})
+
+	// verify
+
+	assert.Nil(t, errorDelete)
+	assert.NotNil(t, mockRepository.payments[0].DeletedAt)
+}
+
+func TestWatchPayments(t *testing.T) {
+
+	client, mockRepository := setUp(t)
+
+	since := time.Now().UTC().Add(-time.Minute)
+
+	mockRepository.Create(&model.Payment{Uid: "uid-1"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	defer cancel()
+
+	stream, errorWatch := client.WatchPayments(ctx, &paymentpb.WatchPaymentsRequest{Since: timestamppb.New(since)})
+
+	assert.Nil(t, errorWatch)
+
+	event, errorRecv := stream.Recv()
+
+	assert.Nil(t, errorRecv)
+	assert.Equal(t, paymentpb.PaymentEvent_CREATED, event.Type)
+	assert.Equal(t, "uid-1", event.Payment.Uid)
+
+	// a change after the stream started is sent on the next poll
+	time.Sleep(5 * time.Millisecond)
+
+	_, errorProcessed := client.MarkProcessed(context.Background(), &paymentpb.MarkProcessedRequest{Uid: "uid-1"})
+
+	assert.Nil(t, errorProcessed)
+
+	event, errorRecv = stream.Recv()
+
+	// verify
+
+	assert.Nil(t, errorRecv)
+	assert.Equal(t, paymentpb.PaymentEvent_UPDATED, event.Type)
+	assert.True(t, event.Payment.Processed)
+}
+
+//
+// private functions
+
+func setUp(t *testing.T) (paymentpb.PaymentServiceClient, *paymentRepositoryImplMock) {
+
+	mockRepository := &paymentRepositoryImplMock{}
+
+	paymentHandler := handler.NewPaymentHandler(mockRepository)
+	paymentHandler.SetDeleteScope("payments:delete")
+
+	listener := bufconn.Listen(1024 * 1024)
+
+	server := grpc.NewServer()
+
+	NewPaymentServer(paymentHandler, mockRepository, watch.NewWatcher(mockRepository, 10*time.Millisecond)).Register(server)
+
+	go server.Serve(listener)
+
+	connection, errorDial := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, address string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+
+	if errorDial != nil {
+		t.Fatal(errorDial)
+	}
+
+	t.Cleanup(func() {
+		connection.Close()
+		server.Stop()
+	})
+
+	return paymentpb.NewPaymentServiceClient(connection), mockRepository
+}
+
+func newChange(logged int, payment *model.Payment, changeType string) model.PaymentChange {
+
+	snapshot := *payment
+
+	return model.PaymentChange{ID: uint(logged + 1), PaymentUid: payment.Uid, Type: changeType, CreatedAt: time.Now().UTC(), Payment: &snapshot}
+}
@@ -0,0 +1,4 @@
+// Package paymentpb holds the protobuf messages and the gRPC stubs of the payment service.
+package paymentpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative payment.proto
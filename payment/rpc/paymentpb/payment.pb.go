@@ -0,0 +1,1064 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v4.25.3
+// source: payment.proto
+
+package paymentpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type PaymentEvent_Type int32
+
+const (
+	PaymentEvent_TYPE_UNSPECIFIED PaymentEvent_Type = 0
+	PaymentEvent_CREATED          PaymentEvent_Type = 1
+	PaymentEvent_UPDATED          PaymentEvent_Type = 2
+	PaymentEvent_DELETED          PaymentEvent_Type = 3
+)
+
+// Enum value maps for PaymentEvent_Type.
+var (
+	PaymentEvent_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "CREATED",
+		2: "UPDATED",
+		3: "DELETED",
+	}
+	PaymentEvent_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED": 0,
+		"CREATED":          1,
+		"UPDATED":          2,
+		"DELETED":          3,
+	}
+)
+
+func (x PaymentEvent_Type) Enum() *PaymentEvent_Type {
+	p := new(PaymentEvent_Type)
+	*p = x
+	return p
+}
+
+func (x PaymentEvent_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PaymentEvent_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_payment_proto_enumTypes[0].Descriptor()
+}
+
+func (PaymentEvent_Type) Type() protoreflect.EnumType {
+	return &file_payment_proto_enumTypes[0]
+}
+
+func (x PaymentEvent_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PaymentEvent_Type.Descriptor instead.
+func (PaymentEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{9, 0}
+}
+
+type Payment struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Uid                 string                 `protobuf:"bytes,1,opt,name=uid,proto3" json:"uid,omitempty"`
+	AccountOrigin       string                 `protobuf:"bytes,2,opt,name=account_origin,json=accountOrigin,proto3" json:"account_origin,omitempty"`
+	AccountTarget       string                 `protobuf:"bytes,3,opt,name=account_target,json=accountTarget,proto3" json:"account_target,omitempty"`
+	Amount              float64                `protobuf:"fixed64,4,opt,name=amount,proto3" json:"amount,omitempty"`
+	Currency            string                 `protobuf:"bytes,5,opt,name=currency,proto3" json:"currency,omitempty"`
+	TargetCurrency      string                 `protobuf:"bytes,6,opt,name=target_currency,json=targetCurrency,proto3" json:"target_currency,omitempty"`
+	TargetAmount        float64                `protobuf:"fixed64,7,opt,name=target_amount,json=targetAmount,proto3" json:"target_amount,omitempty"`
+	FxRate              float64                `protobuf:"fixed64,8,opt,name=fx_rate,json=fxRate,proto3" json:"fx_rate,omitempty"`
+	Date                *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=date,proto3" json:"date,omitempty"`
+	Processed           bool                   `protobuf:"varint,10,opt,name=processed,proto3" json:"processed,omitempty"`
+	ProcessedDate       *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=processed_date,json=processedDate,proto3" json:"processed_date,omitempty"`
+	RefundedAmount      float64                `protobuf:"fixed64,12,opt,name=refunded_amount,json=refundedAmount,proto3" json:"refunded_amount,omitempty"`
+	RefundableAmount    float64                `protobuf:"fixed64,13,opt,name=refundable_amount,json=refundableAmount,proto3" json:"refundable_amount,omitempty"`
+	RiskDecision        string                 `protobuf:"bytes,14,opt,name=risk_decision,json=riskDecision,proto3" json:"risk_decision,omitempty"`
+	ReviewStatus        string                 `protobuf:"bytes,15,opt,name=review_status,json=reviewStatus,proto3" json:"review_status,omitempty"`
+	ApprovalStatus      string                 `protobuf:"bytes,16,opt,name=approval_status,json=approvalStatus,proto3" json:"approval_status,omitempty"`
+	CreatedBy           string                 `protobuf:"bytes,17,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	Tenant              string                 `protobuf:"bytes,18,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	Reference           string                 `protobuf:"bytes,19,opt,name=reference,proto3" json:"reference,omitempty"`
+	Description         string                 `protobuf:"bytes,20,opt,name=description,proto3" json:"description,omitempty"`
+	Metadata            map[string]string      `protobuf:"bytes,21,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	CancelledAt         *timestamppb.Timestamp `protobuf:"bytes,22,opt,name=cancelled_at,json=cancelledAt,proto3" json:"cancelled_at,omitempty"`
+	CancelReasonCode    string                 `protobuf:"bytes,23,opt,name=cancel_reason_code,json=cancelReasonCode,proto3" json:"cancel_reason_code,omitempty"`
+	ProcessingStatus    string                 `protobuf:"bytes,24,opt,name=processing_status,json=processingStatus,proto3" json:"processing_status,omitempty"`
+	RailStatus          string                 `protobuf:"bytes,25,opt,name=rail_status,json=railStatus,proto3" json:"rail_status,omitempty"`
+	FeeAmount           float64                `protobuf:"fixed64,26,opt,name=fee_amount,json=feeAmount,proto3" json:"fee_amount,omitempty"`
+	FeeCurrency         string                 `protobuf:"bytes,27,opt,name=fee_currency,json=feeCurrency,proto3" json:"fee_currency,omitempty"`
+	FeeBearer           string                 `protobuf:"bytes,28,opt,name=fee_bearer,json=feeBearer,proto3" json:"fee_bearer,omitempty"`
+	AuthorizationStatus string                 `protobuf:"bytes,29,opt,name=authorization_status,json=authorizationStatus,proto3" json:"authorization_status,omitempty"`
+	BeneficiaryUid      string                 `protobuf:"bytes,30,opt,name=beneficiary_uid,json=beneficiaryUid,proto3" json:"beneficiary_uid,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *Payment) Reset() {
+	*x = Payment{}
+	mi := &file_payment_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Payment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Payment) ProtoMessage() {}
+
+func (x *Payment) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Payment.ProtoReflect.Descriptor instead.
+func (*Payment) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Payment) GetUid() string {
+	if x != nil {
+		return x.Uid
+	}
+	return ""
+}
+
+func (x *Payment) GetAccountOrigin() string {
+	if x != nil {
+		return x.AccountOrigin
+	}
+	return ""
+}
+
+func (x *Payment) GetAccountTarget() string {
+	if x != nil {
+		return x.AccountTarget
+	}
+	return ""
+}
+
+func (x *Payment) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *Payment) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *Payment) GetTargetCurrency() string {
+	if x != nil {
+		return x.TargetCurrency
+	}
+	return ""
+}
+
+func (x *Payment) GetTargetAmount() float64 {
+	if x != nil {
+		return x.TargetAmount
+	}
+	return 0
+}
+
+func (x *Payment) GetFxRate() float64 {
+	if x != nil {
+		return x.FxRate
+	}
+	return 0
+}
+
+func (x *Payment) GetDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Date
+	}
+	return nil
+}
+
+func (x *Payment) GetProcessed() bool {
+	if x != nil {
+		return x.Processed
+	}
+	return false
+}
+
+func (x *Payment) GetProcessedDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ProcessedDate
+	}
+	return nil
+}
+
+func (x *Payment) GetRefundedAmount() float64 {
+	if x != nil {
+		return x.RefundedAmount
+	}
+	return 0
+}
+
+func (x *Payment) GetRefundableAmount() float64 {
+	if x != nil {
+		return x.RefundableAmount
+	}
+	return 0
+}
+
+func (x *Payment) GetRiskDecision() string {
+	if x != nil {
+		return x.RiskDecision
+	}
+	return ""
+}
+
+func (x *Payment) GetReviewStatus() string {
+	if x != nil {
+		return x.ReviewStatus
+	}
+	return ""
+}
+
+func (x *Payment) GetApprovalStatus() string {
+	if x != nil {
+		return x.ApprovalStatus
+	}
+	return ""
+}
+
+func (x *Payment) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+func (x *Payment) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+func (x *Payment) GetReference() string {
+	if x != nil {
+		return x.Reference
+	}
+	return ""
+}
+
+func (x *Payment) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Payment) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *Payment) GetCancelledAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CancelledAt
+	}
+	return nil
+}
+
+func (x *Payment) GetCancelReasonCode() string {
+	if x != nil {
+		return x.CancelReasonCode
+	}
+	return ""
+}
+
+func (x *Payment) GetProcessingStatus() string {
+	if x != nil {
+		return x.ProcessingStatus
+	}
+	return ""
+}
+
+func (x *Payment) GetRailStatus() string {
+	if x != nil {
+		return x.RailStatus
+	}
+	return ""
+}
+
+func (x *Payment) GetFeeAmount() float64 {
+	if x != nil {
+		return x.FeeAmount
+	}
+	return 0
+}
+
+func (x *Payment) GetFeeCurrency() string {
+	if x != nil {
+		return x.FeeCurrency
+	}
+	return ""
+}
+
+func (x *Payment) GetFeeBearer() string {
+	if x != nil {
+		return x.FeeBearer
+	}
+	return ""
+}
+
+func (x *Payment) GetAuthorizationStatus() string {
+	if x != nil {
+		return x.AuthorizationStatus
+	}
+	return ""
+}
+
+func (x *Payment) GetBeneficiaryUid() string {
+	if x != nil {
+		return x.BeneficiaryUid
+	}
+	return ""
+}
+
+type CreatePaymentRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	AccountOrigin  string                 `protobuf:"bytes,1,opt,name=account_origin,json=accountOrigin,proto3" json:"account_origin,omitempty"`
+	AccountTarget  string                 `protobuf:"bytes,2,opt,name=account_target,json=accountTarget,proto3" json:"account_target,omitempty"`
+	Amount         float64                `protobuf:"fixed64,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	Currency       string                 `protobuf:"bytes,4,opt,name=currency,proto3" json:"currency,omitempty"`
+	TargetCurrency string                 `protobuf:"bytes,5,opt,name=target_currency,json=targetCurrency,proto3" json:"target_currency,omitempty"`
+	FxQuoteUid     string                 `protobuf:"bytes,6,opt,name=fx_quote_uid,json=fxQuoteUid,proto3" json:"fx_quote_uid,omitempty"`
+	Date           *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=date,proto3" json:"date,omitempty"`
+	Reference      string                 `protobuf:"bytes,8,opt,name=reference,proto3" json:"reference,omitempty"`
+	Description    string                 `protobuf:"bytes,9,opt,name=description,proto3" json:"description,omitempty"`
+	Metadata       map[string]string      `protobuf:"bytes,10,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	FeeBearer      string                 `protobuf:"bytes,11,opt,name=fee_bearer,json=feeBearer,proto3" json:"fee_bearer,omitempty"`
+	BeneficiaryUid string                 `protobuf:"bytes,12,opt,name=beneficiary_uid,json=beneficiaryUid,proto3" json:"beneficiary_uid,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CreatePaymentRequest) Reset() {
+	*x = CreatePaymentRequest{}
+	mi := &file_payment_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreatePaymentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreatePaymentRequest) ProtoMessage() {}
+
+func (x *CreatePaymentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreatePaymentRequest.ProtoReflect.Descriptor instead.
+func (*CreatePaymentRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CreatePaymentRequest) GetAccountOrigin() string {
+	if x != nil {
+		return x.AccountOrigin
+	}
+	return ""
+}
+
+func (x *CreatePaymentRequest) GetAccountTarget() string {
+	if x != nil {
+		return x.AccountTarget
+	}
+	return ""
+}
+
+func (x *CreatePaymentRequest) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *CreatePaymentRequest) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *CreatePaymentRequest) GetTargetCurrency() string {
+	if x != nil {
+		return x.TargetCurrency
+	}
+	return ""
+}
+
+func (x *CreatePaymentRequest) GetFxQuoteUid() string {
+	if x != nil {
+		return x.FxQuoteUid
+	}
+	return ""
+}
+
+func (x *CreatePaymentRequest) GetDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Date
+	}
+	return nil
+}
+
+func (x *CreatePaymentRequest) GetReference() string {
+	if x != nil {
+		return x.Reference
+	}
+	return ""
+}
+
+func (x *CreatePaymentRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *CreatePaymentRequest) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *CreatePaymentRequest) GetFeeBearer() string {
+	if x != nil {
+		return x.FeeBearer
+	}
+	return ""
+}
+
+func (x *CreatePaymentRequest) GetBeneficiaryUid() string {
+	if x != nil {
+		return x.BeneficiaryUid
+	}
+	return ""
+}
+
+type GetPaymentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Uid           string                 `protobuf:"bytes,1,opt,name=uid,proto3" json:"uid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPaymentRequest) Reset() {
+	*x = GetPaymentRequest{}
+	mi := &file_payment_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPaymentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPaymentRequest) ProtoMessage() {}
+
+func (x *GetPaymentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPaymentRequest.ProtoReflect.Descriptor instead.
+func (*GetPaymentRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetPaymentRequest) GetUid() string {
+	if x != nil {
+		return x.Uid
+	}
+	return ""
+}
+
+// ListPaymentsRequest pages through the payments, newest first; page_token is the next_page_token of the previous page.
+type ListPaymentsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PageSize      int32                  `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	PageToken     string                 `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPaymentsRequest) Reset() {
+	*x = ListPaymentsRequest{}
+	mi := &file_payment_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPaymentsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPaymentsRequest) ProtoMessage() {}
+
+func (x *ListPaymentsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPaymentsRequest.ProtoReflect.Descriptor instead.
+func (*ListPaymentsRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListPaymentsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListPaymentsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListPaymentsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Payments      []*Payment             `protobuf:"bytes,1,rep,name=payments,proto3" json:"payments,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	Total         int32                  `protobuf:"varint,3,opt,name=total,proto3" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPaymentsResponse) Reset() {
+	*x = ListPaymentsResponse{}
+	mi := &file_payment_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPaymentsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPaymentsResponse) ProtoMessage() {}
+
+func (x *ListPaymentsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPaymentsResponse.ProtoReflect.Descriptor instead.
+func (*ListPaymentsResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListPaymentsResponse) GetPayments() []*Payment {
+	if x != nil {
+		return x.Payments
+	}
+	return nil
+}
+
+func (x *ListPaymentsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+func (x *ListPaymentsResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type MarkProcessedRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Uid           string                 `protobuf:"bytes,1,opt,name=uid,proto3" json:"uid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MarkProcessedRequest) Reset() {
+	*x = MarkProcessedRequest{}
+	mi := &file_payment_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MarkProcessedRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MarkProcessedRequest) ProtoMessage() {}
+
+func (x *MarkProcessedRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MarkProcessedRequest.ProtoReflect.Descriptor instead.
+func (*MarkProcessedRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *MarkProcessedRequest) GetUid() string {
+	if x != nil {
+		return x.Uid
+	}
+	return ""
+}
+
+// DeletePaymentRequest hides an unprocessed payment, or removes it for good when hard is set.
+type DeletePaymentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Uid           string                 `protobuf:"bytes,1,opt,name=uid,proto3" json:"uid,omitempty"`
+	Hard          bool                   `protobuf:"varint,2,opt,name=hard,proto3" json:"hard,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeletePaymentRequest) Reset() {
+	*x = DeletePaymentRequest{}
+	mi := &file_payment_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeletePaymentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeletePaymentRequest) ProtoMessage() {}
+
+func (x *DeletePaymentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeletePaymentRequest.ProtoReflect.Descriptor instead.
+func (*DeletePaymentRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DeletePaymentRequest) GetUid() string {
+	if x != nil {
+		return x.Uid
+	}
+	return ""
+}
+
+func (x *DeletePaymentRequest) GetHard() bool {
+	if x != nil {
+		return x.Hard
+	}
+	return false
+}
+
+type DeletePaymentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeletePaymentResponse) Reset() {
+	*x = DeletePaymentResponse{}
+	mi := &file_payment_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeletePaymentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeletePaymentResponse) ProtoMessage() {}
+
+func (x *DeletePaymentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeletePaymentResponse.ProtoReflect.Descriptor instead.
+func (*DeletePaymentResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{7}
+}
+
+// WatchPaymentsRequest starts the stream at since, or at the time of the call when it is not given.
+type WatchPaymentsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Since         *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=since,proto3" json:"since,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchPaymentsRequest) Reset() {
+	*x = WatchPaymentsRequest{}
+	mi := &file_payment_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchPaymentsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchPaymentsRequest) ProtoMessage() {}
+
+func (x *WatchPaymentsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchPaymentsRequest.ProtoReflect.Descriptor instead.
+func (*WatchPaymentsRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *WatchPaymentsRequest) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+type PaymentEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          PaymentEvent_Type      `protobuf:"varint,1,opt,name=type,proto3,enum=payment.v1.PaymentEvent_Type" json:"type,omitempty"`
+	Payment       *Payment               `protobuf:"bytes,2,opt,name=payment,proto3" json:"payment,omitempty"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=time,proto3" json:"time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PaymentEvent) Reset() {
+	*x = PaymentEvent{}
+	mi := &file_payment_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PaymentEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PaymentEvent) ProtoMessage() {}
+
+func (x *PaymentEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PaymentEvent.ProtoReflect.Descriptor instead.
+func (*PaymentEvent) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *PaymentEvent) GetType() PaymentEvent_Type {
+	if x != nil {
+		return x.Type
+	}
+	return PaymentEvent_TYPE_UNSPECIFIED
+}
+
+func (x *PaymentEvent) GetPayment() *Payment {
+	if x != nil {
+		return x.Payment
+	}
+	return nil
+}
+
+func (x *PaymentEvent) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+var File_payment_proto protoreflect.FileDescriptor
+
+const file_payment_proto_rawDesc = "" +
+	"\n" +
+	"\rpayment.proto\x12\n" +
+	"payment.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xc9\t\n" +
+	"\aPayment\x12\x10\n" +
+	"\x03uid\x18\x01 \x01(\tR\x03uid\x12%\n" +
+	"\x0eaccount_origin\x18\x02 \x01(\tR\raccountOrigin\x12%\n" +
+	"\x0eaccount_target\x18\x03 \x01(\tR\raccountTarget\x12\x16\n" +
+	"\x06amount\x18\x04 \x01(\x01R\x06amount\x12\x1a\n" +
+	"\bcurrency\x18\x05 \x01(\tR\bcurrency\x12'\n" +
+	"\x0ftarget_currency\x18\x06 \x01(\tR\x0etargetCurrency\x12#\n" +
+	"\rtarget_amount\x18\a \x01(\x01R\ftargetAmount\x12\x17\n" +
+	"\afx_rate\x18\b \x01(\x01R\x06fxRate\x12.\n" +
+	"\x04date\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\x04date\x12\x1c\n" +
+	"\tprocessed\x18\n" +
+	" \x01(\bR\tprocessed\x12A\n" +
+	"\x0eprocessed_date\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\rprocessedDate\x12'\n" +
+	"\x0frefunded_amount\x18\f \x01(\x01R\x0erefundedAmount\x12+\n" +
+	"\x11refundable_amount\x18\r \x01(\x01R\x10refundableAmount\x12#\n" +
+	"\rrisk_decision\x18\x0e \x01(\tR\friskDecision\x12#\n" +
+	"\rreview_status\x18\x0f \x01(\tR\freviewStatus\x12'\n" +
+	"\x0fapproval_status\x18\x10 \x01(\tR\x0eapprovalStatus\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\x11 \x01(\tR\tcreatedBy\x12\x16\n" +
+	"\x06tenant\x18\x12 \x01(\tR\x06tenant\x12\x1c\n" +
+	"\treference\x18\x13 \x01(\tR\treference\x12 \n" +
+	"\vdescription\x18\x14 \x01(\tR\vdescription\x12=\n" +
+	"\bmetadata\x18\x15 \x03(\v2!.payment.v1.Payment.MetadataEntryR\bmetadata\x12=\n" +
+	"\fcancelled_at\x18\x16 \x01(\v2\x1a.google.protobuf.TimestampR\vcancelledAt\x12,\n" +
+	"\x12cancel_reason_code\x18\x17 \x01(\tR\x10cancelReasonCode\x12+\n" +
+	"\x11processing_status\x18\x18 \x01(\tR\x10processingStatus\x12\x1f\n" +
+	"\vrail_status\x18\x19 \x01(\tR\n" +
+	"railStatus\x12\x1d\n" +
+	"\n" +
+	"fee_amount\x18\x1a \x01(\x01R\tfeeAmount\x12!\n" +
+	"\ffee_currency\x18\x1b \x01(\tR\vfeeCurrency\x12\x1d\n" +
+	"\n" +
+	"fee_bearer\x18\x1c \x01(\tR\tfeeBearer\x121\n" +
+	"\x14authorization_status\x18\x1d \x01(\tR\x13authorizationStatus\x12'\n" +
+	"\x0fbeneficiary_uid\x18\x1e \x01(\tR\x0ebeneficiaryUid\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xa4\x04\n" +
+	"\x14CreatePaymentRequest\x12%\n" +
+	"\x0eaccount_origin\x18\x01 \x01(\tR\raccountOrigin\x12%\n" +
+	"\x0eaccount_target\x18\x02 \x01(\tR\raccountTarget\x12\x16\n" +
+	"\x06amount\x18\x03 \x01(\x01R\x06amount\x12\x1a\n" +
+	"\bcurrency\x18\x04 \x01(\tR\bcurrency\x12'\n" +
+	"\x0ftarget_currency\x18\x05 \x01(\tR\x0etargetCurrency\x12 \n" +
+	"\ffx_quote_uid\x18\x06 \x01(\tR\n" +
+	"fxQuoteUid\x12.\n" +
+	"\x04date\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\x04date\x12\x1c\n" +
+	"\treference\x18\b \x01(\tR\treference\x12 \n" +
+	"\vdescription\x18\t \x01(\tR\vdescription\x12J\n" +
+	"\bmetadata\x18\n" +
+	" \x03(\v2..payment.v1.CreatePaymentRequest.MetadataEntryR\bmetadata\x12\x1d\n" +
+	"\n" +
+	"fee_bearer\x18\v \x01(\tR\tfeeBearer\x12'\n" +
+	"\x0fbeneficiary_uid\x18\f \x01(\tR\x0ebeneficiaryUid\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"%\n" +
+	"\x11GetPaymentRequest\x12\x10\n" +
+	"\x03uid\x18\x01 \x01(\tR\x03uid\"Q\n" +
+	"\x13ListPaymentsRequest\x12\x1b\n" +
+	"\tpage_size\x18\x01 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x02 \x01(\tR\tpageToken\"\x85\x01\n" +
+	"\x14ListPaymentsResponse\x12/\n" +
+	"\bpayments\x18\x01 \x03(\v2\x13.payment.v1.PaymentR\bpayments\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\x12\x14\n" +
+	"\x05total\x18\x03 \x01(\x05R\x05total\"(\n" +
+	"\x14MarkProcessedRequest\x12\x10\n" +
+	"\x03uid\x18\x01 \x01(\tR\x03uid\"<\n" +
+	"\x14DeletePaymentRequest\x12\x10\n" +
+	"\x03uid\x18\x01 \x01(\tR\x03uid\x12\x12\n" +
+	"\x04hard\x18\x02 \x01(\bR\x04hard\"\x17\n" +
+	"\x15DeletePaymentResponse\"H\n" +
+	"\x14WatchPaymentsRequest\x120\n" +
+	"\x05since\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\"\xe5\x01\n" +
+	"\fPaymentEvent\x121\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x1d.payment.v1.PaymentEvent.TypeR\x04type\x12-\n" +
+	"\apayment\x18\x02 \x01(\v2\x13.payment.v1.PaymentR\apayment\x12.\n" +
+	"\x04time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\"C\n" +
+	"\x04Type\x12\x14\n" +
+	"\x10TYPE_UNSPECIFIED\x10\x00\x12\v\n" +
+	"\aCREATED\x10\x01\x12\v\n" +
+	"\aUPDATED\x10\x02\x12\v\n" +
+	"\aDELETED\x10\x032\xda\x03\n" +
+	"\x0ePaymentService\x12F\n" +
+	"\rCreatePayment\x12 .payment.v1.CreatePaymentRequest\x1a\x13.payment.v1.Payment\x12@\n" +
+	"\n" +
+	"GetPayment\x12\x1d.payment.v1.GetPaymentRequest\x1a\x13.payment.v1.Payment\x12Q\n" +
+	"\fListPayments\x12\x1f.payment.v1.ListPaymentsRequest\x1a .payment.v1.ListPaymentsResponse\x12F\n" +
+	"\rMarkProcessed\x12 .payment.v1.MarkProcessedRequest\x1a\x13.payment.v1.Payment\x12T\n" +
+	"\rDeletePayment\x12 .payment.v1.DeletePaymentRequest\x1a!.payment.v1.DeletePaymentResponse\x12M\n" +
+	"\rWatchPayments\x12 .payment.v1.WatchPaymentsRequest\x1a\x18.payment.v1.PaymentEvent0\x01B>Z<github.com/javierjmgits/go-payment-api/payment/rpc/paymentpbb\x06proto3"
+
+var (
+	file_payment_proto_rawDescOnce sync.Once
+	file_payment_proto_rawDescData []byte
+)
+
+func file_payment_proto_rawDescGZIP() []byte {
+	file_payment_proto_rawDescOnce.Do(func() {
+		file_payment_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_payment_proto_rawDesc), len(file_payment_proto_rawDesc)))
+	})
+	return file_payment_proto_rawDescData
+}
+
+var file_payment_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_payment_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_payment_proto_goTypes = []any{
+	(PaymentEvent_Type)(0),        // 0: payment.v1.PaymentEvent.Type
+	(*Payment)(nil),               // 1: payment.v1.Payment
+	(*CreatePaymentRequest)(nil),  // 2: payment.v1.CreatePaymentRequest
+	(*GetPaymentRequest)(nil),     // 3: payment.v1.GetPaymentRequest
+	(*ListPaymentsRequest)(nil),   // 4: payment.v1.ListPaymentsRequest
+	(*ListPaymentsResponse)(nil),  // 5: payment.v1.ListPaymentsResponse
+	(*MarkProcessedRequest)(nil),  // 6: payment.v1.MarkProcessedRequest
+	(*DeletePaymentRequest)(nil),  // 7: payment.v1.DeletePaymentRequest
+	(*DeletePaymentResponse)(nil), // 8: payment.v1.DeletePaymentResponse
+	(*WatchPaymentsRequest)(nil),  // 9: payment.v1.WatchPaymentsRequest
+	(*PaymentEvent)(nil),          // 10: payment.v1.PaymentEvent
+	nil,                           // 11: payment.v1.Payment.MetadataEntry
+	nil,                           // 12: payment.v1.CreatePaymentRequest.MetadataEntry
+	(*timestamppb.Timestamp)(nil), // 13: google.protobuf.Timestamp
+}
+var file_payment_proto_depIdxs = []int32{
+	13, // 0: payment.v1.Payment.date:type_name -> google.protobuf.Timestamp
+	13, // 1: payment.v1.Payment.processed_date:type_name -> google.protobuf.Timestamp
+	11, // 2: payment.v1.Payment.metadata:type_name -> payment.v1.Payment.MetadataEntry
+	13, // 3: payment.v1.Payment.cancelled_at:type_name -> google.protobuf.Timestamp
+	13, // 4: payment.v1.CreatePaymentRequest.date:type_name -> google.protobuf.Timestamp
+	12, // 5: payment.v1.CreatePaymentRequest.metadata:type_name -> payment.v1.CreatePaymentRequest.MetadataEntry
+	1,  // 6: payment.v1.ListPaymentsResponse.payments:type_name -> payment.v1.Payment
+	13, // 7: payment.v1.WatchPaymentsRequest.since:type_name -> google.protobuf.Timestamp
+	0,  // 8: payment.v1.PaymentEvent.type:type_name -> payment.v1.PaymentEvent.Type
+	1,  // 9: payment.v1.PaymentEvent.payment:type_name -> payment.v1.Payment
+	13, // 10: payment.v1.PaymentEvent.time:type_name -> google.protobuf.Timestamp
+	2,  // 11: payment.v1.PaymentService.CreatePayment:input_type -> payment.v1.CreatePaymentRequest
+	3,  // 12: payment.v1.PaymentService.GetPayment:input_type -> payment.v1.GetPaymentRequest
+	4,  // 13: payment.v1.PaymentService.ListPayments:input_type -> payment.v1.ListPaymentsRequest
+	6,  // 14: payment.v1.PaymentService.MarkProcessed:input_type -> payment.v1.MarkProcessedRequest
+	7,  // 15: payment.v1.PaymentService.DeletePayment:input_type -> payment.v1.DeletePaymentRequest
+	9,  // 16: payment.v1.PaymentService.WatchPayments:input_type -> payment.v1.WatchPaymentsRequest
+	1,  // 17: payment.v1.PaymentService.CreatePayment:output_type -> payment.v1.Payment
+	1,  // 18: payment.v1.PaymentService.GetPayment:output_type -> payment.v1.Payment
+	5,  // 19: payment.v1.PaymentService.ListPayments:output_type -> payment.v1.ListPaymentsResponse
+	1,  // 20: payment.v1.PaymentService.MarkProcessed:output_type -> payment.v1.Payment
+	8,  // 21: payment.v1.PaymentService.DeletePayment:output_type -> payment.v1.DeletePaymentResponse
+	10, // 22: payment.v1.PaymentService.WatchPayments:output_type -> payment.v1.PaymentEvent
+	17, // [17:23] is the sub-list for method output_type
+	11, // [11:17] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_payment_proto_init() }
+func file_payment_proto_init() {
+	if File_payment_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_payment_proto_rawDesc), len(file_payment_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_payment_proto_goTypes,
+		DependencyIndexes: file_payment_proto_depIdxs,
+		EnumInfos:         file_payment_proto_enumTypes,
+		MessageInfos:      file_payment_proto_msgTypes,
+	}.Build()
+	File_payment_proto = out.File
+	file_payment_proto_goTypes = nil
+	file_payment_proto_depIdxs = nil
+}
@@ -0,0 +1,290 @@
+package search
+
+import (
+	"fmt"
+	"github.com/javierjmgits/go-payment-api/account/identifier"
+	"github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/payment/repository"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+	// sort codes are stored as 12-34-56 12345678, partial accounts are compared without separators
+	accountCompactor = strings.NewReplacer(" ", "", "-", "")
+)
+
+// Conditions translates the query into repository conditions, all of which must hold.
+func (query *Query) Conditions() ([]repository.Condition, error) {
+
+	var conditions []repository.Condition
+
+	for _, term := range query.Terms {
+
+		condition, errorTerm := term.condition()
+
+		if errorTerm != nil {
+			return nil, fmt.Errorf("invalid term '%s': %v", term.Raw, errorTerm)
+		}
+
+		conditions = append(conditions, *condition)
+	}
+
+	return conditions, nil
+}
+
+// Matches returns the payment fields, as named in the views, that matched a term of the query.
+func (query *Query) Matches(payment *model.Payment) []string {
+
+	matches := []string{}
+
+	seen := map[string]bool{}
+
+	for _, term := range query.Terms {
+
+		for _, viewField := range term.matches(payment) {
+
+			if !seen[viewField] {
+				seen[viewField] = true
+				matches = append(matches, viewField)
+			}
+		}
+	}
+
+	return matches
+}
+
+//
+// private functions
+
+func (term *Term) condition() (*repository.Condition, error) {
+
+	if term.Operator == OPERATOR_TEXT {
+		return anyColumn(textColumns, false, "LIKE", "%"+likeEscaper.Replace(term.Value)+"%"), nil
+	}
+
+	field := fields[term.Field]
+
+	switch field.kind {
+
+	case kindText, kindAccount:
+
+		if term.Operator != OPERATOR_EQUAL {
+			return nil, fmt.Errorf("only ':' can be used with %s", term.Field)
+		}
+
+		value := term.textValue(field.kind)
+
+		if strings.Contains(value, "*") {
+			return anyColumn(field.columns, field.kind == kindAccount, "LIKE", strings.Replace(likeEscaper.Replace(value), "*", "%", -1)), nil
+		}
+
+		return anyColumn(field.columns, false, "=", value), nil
+
+	case kindBool:
+
+		if term.Operator != OPERATOR_EQUAL {
+			return nil, fmt.Errorf("only ':' can be used with %s", term.Field)
+		}
+
+		value, errorBool := strconv.ParseBool(term.Value)
+
+		if errorBool != nil {
+			return nil, fmt.Errorf("%s must be true or false", term.Field)
+		}
+
+		return anyColumn(field.columns, false, "=", value), nil
+
+	case kindNumber:
+		return term.rangeCondition(field.columns[0].name, func(value string) (interface{}, interface{}, error) {
+
+			number, errorNumber := strconv.ParseFloat(value, 64)
+
+			return number, number, errorNumber
+		})
+
+	case kindDate:
+		return term.rangeCondition(field.columns[0].name, func(value string) (interface{}, interface{}, error) {
+
+			start, end, errorDate := parsePeriod(value)
+
+			return start, end, errorDate
+		})
+	}
+
+	return nil, fmt.Errorf("unsupported field %s", term.Field)
+}
+
+// rangeCondition builds comparisons for values that are a period: a number is the period [n, n], a month is
+// [first day, first day of the next month). bounds returns the start and the end of the period.
+func (term *Term) rangeCondition(name string, bounds func(value string) (interface{}, interface{}, error)) (*repository.Condition, error) {
+
+	isDate := fields[term.Field].kind == kindDate
+
+	// numbers have inclusive ends, dates exclusive ones
+	upper := "<="
+
+	if isDate {
+		upper = "<"
+	}
+
+	if term.Operator == OPERATOR_RANGE {
+
+		var parts []string
+		var args []interface{}
+
+		if term.Value != "" {
+
+			start, _, errorBound := bounds(term.Value)
+
+			if errorBound != nil {
+				return nil, errorBound
+			}
+
+			parts = append(parts, name+" >= ?")
+			args = append(args, start)
+		}
+
+		if term.High != "" {
+
+			_, end, errorBound := bounds(term.High)
+
+			if errorBound != nil {
+				return nil, errorBound
+			}
+
+			parts = append(parts, name+" "+upper+" ?")
+			args = append(args, end)
+		}
+
+		return &repository.Condition{Query: strings.Join(parts, " AND "), Args: args}, nil
+	}
+
+	start, end, errorBound := bounds(term.Value)
+
+	if errorBound != nil {
+		return nil, errorBound
+	}
+
+	switch term.Operator {
+
+	case OPERATOR_EQUAL:
+		return &repository.Condition{Query: name + " >= ? AND " + name + " " + upper + " ?", Args: []interface{}{start, end}}, nil
+
+	case OPERATOR_GREATER:
+
+		if isDate {
+			return &repository.Condition{Query: name + " >= ?", Args: []interface{}{end}}, nil
+		}
+
+		return &repository.Condition{Query: name + " > ?", Args: []interface{}{end}}, nil
+
+	case OPERATOR_GREATER_OR_EQUAL:
+		return &repository.Condition{Query: name + " >= ?", Args: []interface{}{start}}, nil
+
+	case OPERATOR_LESS:
+		return &repository.Condition{Query: name + " < ?", Args: []interface{}{start}}, nil
+
+	case OPERATOR_LESS_OR_EQUAL:
+		return &repository.Condition{Query: name + " " + upper + " ?", Args: []interface{}{end}}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported operator %s", term.Operator)
+}
+
+func (term *Term) textValue(kind fieldKind) string {
+
+	if kind != kindAccount {
+		return strings.ToUpper(term.Value)
+	}
+
+	if !strings.Contains(term.Value, "*") {
+
+		if normalized, errorAccount := identifier.Normalize(term.Value); errorAccount == nil {
+			return normalized
+		}
+	}
+
+	return strings.ToUpper(accountCompactor.Replace(term.Value))
+}
+
+func (term *Term) matches(payment *model.Payment) []string {
+
+	if term.Operator == OPERATOR_TEXT {
+		return matchingColumns(textColumns, false, payment, "*"+term.Value+"*")
+	}
+
+	field := fields[term.Field]
+
+	if field.kind == kindText || field.kind == kindAccount {
+		return matchingColumns(field.columns, field.kind == kindAccount, payment, term.textValue(field.kind))
+	}
+
+	// every other field has a single column, which matched since the payment was found
+	return []string{field.columns[0].viewField}
+}
+
+func matchingColumns(columns []column, compact bool, payment *model.Payment, pattern string) []string {
+
+	expression := regexp.MustCompile("(?i)^" + strings.Replace(regexp.QuoteMeta(pattern), `\*`, ".*", -1) + "$")
+
+	var matches []string
+
+	for _, column := range columns {
+
+		value := column.value(payment)
+
+		if compact && strings.Contains(pattern, "*") {
+			value = accountCompactor.Replace(value)
+		}
+
+		if expression.MatchString(value) {
+			matches = append(matches, column.viewField)
+		}
+	}
+
+	return matches
+}
+
+func anyColumn(columns []column, compact bool, operator string, value interface{}) *repository.Condition {
+
+	var parts []string
+	var args []interface{}
+
+	for _, column := range columns {
+
+		expression := column.name
+
+		if compact {
+			expression = "REPLACE(REPLACE(" + column.name + ", '-', ''), ' ', '')"
+		}
+
+		parts = append(parts, expression+" "+operator+" ?")
+		args = append(args, value)
+	}
+
+	return &repository.Condition{Query: "(" + strings.Join(parts, " OR ") + ")", Args: args}
+}
+
+func parsePeriod(value string) (time.Time, time.Time, error) {
+
+	for _, layout := range []struct {
+		format string
+		next   func(time.Time) time.Time
+	}{
+		{"2006-01-02", func(start time.Time) time.Time { return start.AddDate(0, 0, 1) }},
+		{"2006-01", func(start time.Time) time.Time { return start.AddDate(0, 1, 0) }},
+		{"2006", func(start time.Time) time.Time { return start.AddDate(1, 0, 0) }},
+	} {
+
+		if start, errorParse := time.Parse(layout.format, value); errorParse == nil {
+			return start, layout.next(start), nil
+		}
+	}
+
+	return time.Time{}, time.Time{}, fmt.Errorf("'%s' is not a date, use YYYY, YYYY-MM or YYYY-MM-DD", value)
+}
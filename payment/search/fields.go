@@ -0,0 +1,49 @@
+package search
+
+import (
+	"github.com/javierjmgits/go-payment-api/payment/model"
+)
+
+type fieldKind int
+
+const (
+	kindText fieldKind = iota
+	kindAccount
+	kindNumber
+	kindDate
+	kindBool
+)
+
+type column struct {
+	name      string
+	viewField string
+	value     func(payment *model.Payment) string
+}
+
+type field struct {
+	kind    fieldKind
+	columns []column
+}
+
+var (
+	uidColumn           = column{"uid", "uid", func(payment *model.Payment) string { return payment.Uid }}
+	accountOriginColumn = column{"account_origin", "accountOrigin", func(payment *model.Payment) string { return payment.AccountOrigin }}
+	accountTargetColumn = column{"account_target", "accountTarget", func(payment *model.Payment) string { return payment.AccountTarget }}
+	currencyColumn      = column{"currency", "currency", func(payment *model.Payment) string { return payment.Currency }}
+	referenceColumn     = column{"reference", "reference", func(payment *model.Payment) string { return payment.Reference }}
+)
+
+var fields = map[string]field{
+	"uid":       {kindText, []column{uidColumn}},
+	"account":   {kindAccount, []column{accountOriginColumn, accountTargetColumn}},
+	"origin":    {kindAccount, []column{accountOriginColumn}},
+	"target":    {kindAccount, []column{accountTargetColumn}},
+	"currency":  {kindText, []column{currencyColumn}},
+	"reference": {kindText, []column{referenceColumn}},
+	"amount":    {kindNumber, []column{{name: "amount", viewField: "amount"}}},
+	"date":      {kindDate, []column{{name: "date", viewField: "date"}}},
+	"processed": {kindBool, []column{{name: "processed", viewField: "processed"}}},
+}
+
+// free text looks for the word anywhere in these columns
+var textColumns = []column{uidColumn, accountOriginColumn, accountTargetColumn, referenceColumn}
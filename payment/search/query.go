@@ -0,0 +1,153 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	OPERATOR_EQUAL            = ":"
+	OPERATOR_GREATER          = ">"
+	OPERATOR_GREATER_OR_EQUAL = ">="
+	OPERATOR_LESS             = "<"
+	OPERATOR_LESS_OR_EQUAL    = "<="
+	OPERATOR_RANGE            = ".."
+	OPERATOR_TEXT             = ""
+)
+
+// a term is either free text or a field, an operator and a value, e.g. amount>=100 or date:2026-01..2026-03
+var termPattern = regexp.MustCompile(`^([a-zA-Z]+)(:|>=|<=|>|<)(.*)$`)
+
+type Term struct {
+	Field    string
+	Operator string
+	Value    string
+	High     string
+	Raw      string
+}
+
+type Query struct {
+	Terms []Term
+}
+
+// Parse reads a query made of whitespace separated terms, all of which must match. Values with spaces can be quoted.
+func Parse(query string) (*Query, error) {
+
+	tokens, errorTokens := tokenize(query)
+
+	if errorTokens != nil {
+		return nil, errorTokens
+	}
+
+	result := &Query{}
+
+	for _, token := range tokens {
+
+		term, errorTerm := parseTerm(token)
+
+		if errorTerm != nil {
+			return nil, errorTerm
+		}
+
+		result.Terms = append(result.Terms, *term)
+	}
+
+	return result, nil
+}
+
+//
+// private functions
+
+func parseTerm(token string) (*Term, error) {
+
+	groups := termPattern.FindStringSubmatch(token)
+
+	if groups == nil {
+		return &Term{Operator: OPERATOR_TEXT, Value: token, Raw: token}, nil
+	}
+
+	term := &Term{
+		Field:    strings.ToLower(groups[1]),
+		Operator: groups[2],
+		Value:    unquote(groups[3]),
+		Raw:      token,
+	}
+
+	if _, found := fields[term.Field]; !found {
+		return nil, fmt.Errorf("unknown field '%s' in '%s'", term.Field, token)
+	}
+
+	if term.Operator == OPERATOR_EQUAL && strings.Contains(term.Value, OPERATOR_RANGE) {
+
+		bounds := strings.SplitN(term.Value, OPERATOR_RANGE, 2)
+
+		term.Operator = OPERATOR_RANGE
+		term.Value = bounds[0]
+		term.High = bounds[1]
+
+		if term.Value == "" && term.High == "" {
+			return nil, fmt.Errorf("range '%s' needs at least one bound", token)
+		}
+
+		return term, nil
+	}
+
+	if term.Value == "" {
+		return nil, fmt.Errorf("missing value in '%s'", token)
+	}
+
+	return term, nil
+}
+
+func tokenize(query string) ([]string, error) {
+
+	var tokens []string
+	var current strings.Builder
+
+	quoted := false
+
+	for _, character := range query {
+
+		switch {
+
+		case character == '"':
+			quoted = !quoted
+			current.WriteRune(character)
+
+		case unicode.IsSpace(character) && !quoted:
+
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+
+		default:
+			current.WriteRune(character)
+		}
+	}
+
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote in query")
+	}
+
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("query is empty")
+	}
+
+	return tokens, nil
+}
+
+func unquote(value string) string {
+
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		return value[1 : len(value)-1]
+	}
+
+	return value
+}
@@ -0,0 +1,70 @@
+package search
+
+import (
+	"github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+
+	query, errorQuery := Parse(`account:GB29*  amount>100 processed:false date:2026-01..2026-03 "acme ltd"`)
+
+	assert.Nil(t, errorQuery)
+	assert.Equal(t, []Term{
+		{Field: "account", Operator: OPERATOR_EQUAL, Value: "GB29*", Raw: "account:GB29*"},
+		{Field: "amount", Operator: OPERATOR_GREATER, Value: "100", Raw: "amount>100"},
+		{Field: "processed", Operator: OPERATOR_EQUAL, Value: "false", Raw: "processed:false"},
+		{Field: "date", Operator: OPERATOR_RANGE, Value: "2026-01", High: "2026-03", Raw: "date:2026-01..2026-03"},
+		{Operator: OPERATOR_TEXT, Value: `"acme ltd"`, Raw: `"acme ltd"`},
+	}, query.Terms)
+}
+
+func TestParseKo(t *testing.T) {
+
+	for _, query := range []string{"", "colour:red", "amount:", "date:..", `"open`} {
+
+		_, errorQuery := Parse(query)
+
+		assert.NotNil(t, errorQuery, query)
+	}
+}
+
+func TestConditions(t *testing.T) {
+
+	query, _ := Parse("account:GB29* amount:10..20 date:2026-01..2026-03 date>2026-02-14")
+
+	conditions, errorConditions := query.Conditions()
+
+	assert.Nil(t, errorConditions)
+	assert.Equal(t, "(REPLACE(REPLACE(account_origin, '-', ''), ' ', '') LIKE ? OR REPLACE(REPLACE(account_target, '-', ''), ' ', '') LIKE ?)", conditions[0].Query)
+	assert.Equal(t, []interface{}{"GB29%", "GB29%"}, conditions[0].Args)
+	assert.Equal(t, "amount >= ? AND amount <= ?", conditions[1].Query)
+	assert.Equal(t, []interface{}{10.0, 20.0}, conditions[1].Args)
+	assert.Equal(t, "date >= ? AND date < ?", conditions[2].Query)
+	assert.Equal(t, []interface{}{time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)}, conditions[2].Args)
+	assert.Equal(t, "date >= ?", conditions[3].Query)
+	assert.Equal(t, []interface{}{time.Date(2026, 2, 15, 0, 0, 0, 0, time.UTC)}, conditions[3].Args)
+}
+
+func TestConditionsKo(t *testing.T) {
+
+	for _, text := range []string{"amount:abc", "processed:maybe", "account>GB29", "date:2026-13"} {
+
+		query, _ := Parse(text)
+
+		_, errorConditions := query.Conditions()
+
+		assert.NotNil(t, errorConditions, text)
+	}
+}
+
+func TestMatches(t *testing.T) {
+
+	payment := &model.Payment{Uid: "a1b2", AccountOrigin: "60-16-13 31926819", AccountTarget: "GB29NWBK60161331926819", Amount: 150}
+
+	query, _ := Parse("account:*6016* amount>100 a1b")
+
+	assert.Equal(t, []string{"accountOrigin", "accountTarget", "amount", "uid"}, query.Matches(payment))
+}
@@ -0,0 +1,392 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/javierjmgits/go-payment-api/account/identifier"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/payment/handler"
+	"github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/payment/watch"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	STATUS_PENDING    = "PENDING"
+	STATUS_HELD       = "HELD"
+	STATUS_PROCESSING = "PROCESSING"
+	STATUS_PROCESSED  = "PROCESSED"
+	STATUS_CANCELLED  = "CANCELLED"
+
+	// events read from the log and not yet written to a client; once full, the log is not read further for it
+	BUFFER_SIZE = 64
+
+	// a client that cannot take an event or a heartbeat in this time is disconnected, and resumes from its last event
+	WRITE_TIMEOUT = 10 * time.Second
+
+	// how long an SSE client waits before reconnecting
+	RETRY_INTERVAL = 3 * time.Second
+)
+
+var statuses = []string{STATUS_PENDING, STATUS_HELD, STATUS_PROCESSING, STATUS_PROCESSED, STATUS_CANCELLED}
+
+// StreamHandler sends the changes of the payments as they happen, over SSE or WebSocket. The change log of the payments
+// is the log of events: every client reads it with its own watcher, from where it stopped, at the pace it can take.
+type StreamHandler struct {
+	watcher   *watch.Watcher
+	heartbeat time.Duration
+	upgrader  websocket.Upgrader
+}
+
+// PaymentEvent is a change of a payment, with the payment as it was right after the change; its id, the id of the change
+// in the log, is where to resume from.
+type PaymentEvent struct {
+	Id      string               `json:"id"`
+	Type    string               `json:"type"`
+	Status  string               `json:"status"`
+	Payment *handler.PaymentView `json:"payment"`
+}
+
+type streamFilter struct {
+	accounts map[string]bool
+	statuses map[string]bool
+}
+
+func NewStreamHandler(watcher *watch.Watcher, heartbeat time.Duration) *StreamHandler {
+
+	return &StreamHandler{
+		watcher:   watcher,
+		heartbeat: heartbeat,
+	}
+}
+
+func (sh *StreamHandler) Register(router *mux.Router) {
+	router.HandleFunc("/api/v1/payments/stream", sh.StreamPayments).Methods("GET")
+}
+
+// StreamPayments sends the changes from the Last-Event-ID header, or the lastEventId parameter, on; or from now on
+// without them. A WebSocket upgrade gets the events as JSON messages, anything else gets an event stream.
+func (sh *StreamHandler) StreamPayments(w http.ResponseWriter, r *http.Request) {
+
+	filter, errorFilter := newStreamFilter(r)
+
+	if errorFilter != nil {
+		util.WriteError(w, http.StatusBadRequest, errorFilter.Error())
+		return
+	}
+
+	from, errorCursor := sh.resumeCursor(r)
+
+	if errorCursor != nil {
+		util.WriteErrorFor(w, errorCursor)
+		return
+	}
+
+	if websocket.IsWebSocketUpgrade(r) {
+		sh.streamWebSocket(w, r, filter, from)
+		return
+	}
+
+	sh.streamEvents(w, r, filter, from)
+}
+
+//
+// private functions
+
+func (sh *StreamHandler) streamEvents(w http.ResponseWriter, r *http.Request, filter *streamFilter, from watch.Cursor) {
+
+	if _, canFlush := w.(http.Flusher); !canFlush {
+		util.WriteError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+
+	defer cancel()
+
+	events := sh.watch(ctx, filter, from)
+
+	heartbeat := time.NewTicker(sh.heartbeat)
+
+	defer heartbeat.Stop()
+
+	controller := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	if writeEvent(controller, w, "retry: %d\n\n", RETRY_INTERVAL.Milliseconds()) != nil {
+		return
+	}
+
+	for {
+
+		select {
+
+		case event, open := <-events:
+
+			if !open {
+				return
+			}
+
+			eventJson, errorJson := json.Marshal(event)
+
+			if errorJson != nil {
+				log.Printf("Error encoding payment event %s: %v\n", event.Id, errorJson)
+				return
+			}
+
+			if writeEvent(controller, w, "id: %s\nevent: %s\ndata: %s\n\n", event.Id, strings.ToLower(event.Type), eventJson) != nil {
+				return
+			}
+
+		case <-heartbeat.C:
+
+			if writeEvent(controller, w, ": heartbeat\n\n") != nil {
+				return
+			}
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (sh *StreamHandler) streamWebSocket(w http.ResponseWriter, r *http.Request, filter *streamFilter, from watch.Cursor) {
+
+	// the upgrader has answered the client already when it fails
+	conn, errorUpgrade := sh.upgrader.Upgrade(w, r, nil)
+
+	if errorUpgrade != nil {
+		return
+	}
+
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+
+	defer cancel()
+
+	// clients are not expected to send anything, reading is only to see them leave and to answer their pings
+	go func() {
+
+		defer cancel()
+
+		for {
+
+			if _, _, errorRead := conn.NextReader(); errorRead != nil {
+				return
+			}
+		}
+	}()
+
+	events := sh.watch(ctx, filter, from)
+
+	heartbeat := time.NewTicker(sh.heartbeat)
+
+	defer heartbeat.Stop()
+
+	for {
+
+		select {
+
+		case event, open := <-events:
+
+			if !open {
+				conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, ""), time.Now().Add(WRITE_TIMEOUT))
+				return
+			}
+
+			conn.SetWriteDeadline(time.Now().Add(WRITE_TIMEOUT))
+
+			if conn.WriteJSON(event) != nil {
+				return
+			}
+
+		case <-heartbeat.C:
+
+			if conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(WRITE_TIMEOUT)) != nil {
+				return
+			}
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// watch reads the changes that pass the filter into a buffer, until the context is done; the buffer is closed when the
+// watcher stops, so the client reconnects and resumes.
+func (sh *StreamHandler) watch(ctx context.Context, filter *streamFilter, from watch.Cursor) <-chan *PaymentEvent {
+
+	events := make(chan *PaymentEvent, BUFFER_SIZE)
+
+	go func() {
+
+		defer close(events)
+
+		errorWatch := sh.watcher.Watch(ctx, from, func(change *watch.Change) error {
+
+			if !filter.matches(change.Payment) {
+				return nil
+			}
+
+			select {
+
+			case events <- newPaymentEvent(change):
+				return nil
+
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+
+		if errorWatch != nil && ctx.Err() == nil {
+			log.Printf("Error watching payments for a stream: %v\n", errorWatch)
+		}
+	}()
+
+	return events
+}
+
+// writeEvent sends a piece of the stream right away, giving up on clients that do not take it in time.
+func writeEvent(controller *http.ResponseController, w http.ResponseWriter, format string, args ...interface{}) error {
+
+	if errorDeadline := controller.SetWriteDeadline(time.Now().Add(WRITE_TIMEOUT)); errorDeadline != nil && !errors.Is(errorDeadline, http.ErrNotSupported) {
+		return errorDeadline
+	}
+
+	if _, errorWrite := fmt.Fprintf(w, format, args...); errorWrite != nil {
+		return errorWrite
+	}
+
+	return controller.Flush()
+}
+
+func newPaymentEvent(change *watch.Change) *PaymentEvent {
+
+	return &PaymentEvent{
+		Id:      encodeEventId(change.Cursor),
+		Type:    change.Type,
+		Status:  statusOf(change.Payment),
+		Payment: handler.NewPaymentView(change.Payment),
+	}
+}
+
+func statusOf(payment *model.Payment) string {
+
+	switch {
+
+	case payment.IsCancelled():
+		return STATUS_CANCELLED
+
+	case payment.Processed:
+		return STATUS_PROCESSED
+
+	case payment.IsProcessing():
+		return STATUS_PROCESSING
+
+	case payment.IsHeld():
+		return STATUS_HELD
+
+	default:
+		return STATUS_PENDING
+	}
+}
+
+// newStreamFilter takes any number of account and status parameters, a status parameter may also list several of them.
+func newStreamFilter(r *http.Request) (*streamFilter, error) {
+
+	filter := &streamFilter{}
+
+	for _, account := range r.URL.Query()["account"] {
+
+		normalized, errorAccount := identifier.Normalize(account)
+
+		if errorAccount != nil {
+			return nil, errorAccount
+		}
+
+		if filter.accounts == nil {
+			filter.accounts = map[string]bool{}
+		}
+
+		filter.accounts[normalized] = true
+	}
+
+	for _, parameter := range r.URL.Query()["status"] {
+
+		for _, status := range strings.Split(parameter, ",") {
+
+			status = strings.ToUpper(strings.TrimSpace(status))
+
+			if !isStatus(status) {
+				return nil, fmt.Errorf("status must be one of %s", strings.Join(statuses, ", "))
+			}
+
+			if filter.statuses == nil {
+				filter.statuses = map[string]bool{}
+			}
+
+			filter.statuses[status] = true
+		}
+	}
+
+	return filter, nil
+}
+
+func (filter *streamFilter) matches(payment *model.Payment) bool {
+
+	if filter.accounts != nil && !filter.accounts[payment.AccountOrigin] && !filter.accounts[payment.AccountTarget] {
+		return false
+	}
+
+	return filter.statuses == nil || filter.statuses[statusOf(payment)]
+}
+
+func isStatus(value string) bool {
+
+	for _, status := range statuses {
+
+		if status == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (sh *StreamHandler) resumeCursor(r *http.Request) (watch.Cursor, error) {
+
+	eventId := r.Header.Get("Last-Event-ID")
+
+	if eventId == "" {
+		eventId = r.URL.Query().Get("lastEventId")
+	}
+
+	if eventId == "" {
+		return sh.watcher.From(time.Now().UTC())
+	}
+
+	id, errorDecode := strconv.ParseUint(eventId, 10, 64)
+
+	if errorDecode != nil {
+		return watch.Cursor{}, &util.InputError{Message: fmt.Sprintf("invalid last event id '%s'", eventId)}
+	}
+
+	return watch.Cursor{Id: uint(id)}, nil
+}
+
+// event ids are the ids of the changes in the change log
+func encodeEventId(cursor watch.Cursor) string {
+	return strconv.FormatUint(uint64(cursor.Id), 10)
+}
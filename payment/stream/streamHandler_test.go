@@ -0,0 +1,277 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/payment/repository"
+	"github.com/javierjmgits/go-payment-api/payment/watch"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+//
+// mocks
+
+type paymentRepositoryImplMock struct {
+	repository.PaymentRepository
+	lock     sync.Mutex
+	payments []model.Payment
+}
+
+func (mock *paymentRepositoryImplMock) GetChangedSince(afterId uint, limit int) ([]model.PaymentChange, error) {
+
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+
+	var results []model.PaymentChange
+
+	for index := range mock.payments {
+
+		// one change per payment, its last one, with the id of the payment
+		if change := changeOf(mock.payments[index]); change.ID > afterId {
+			results = append(results, change)
+		}
+	}
+
+	return results, nil
+}
+
+func (mock *paymentRepositoryImplMock) GetLastChangeIdBefore(at time.Time) (uint, error) {
+
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+
+	var id uint
+
+	for index := range mock.payments {
+
+		if change := changeOf(mock.payments[index]); change.CreatedAt.Before(at) && change.ID > id {
+			id = change.ID
+		}
+	}
+
+	return id, nil
+}
+
+//
+// tests
+
+func TestStreamPayments(t *testing.T) {
+
+	router := setUp(time.Minute,
+		newPayment(1, "GB29NWBK60161331926819", "DE89370400440532013000", false),
+		newPayment(2, "FR1420041010050500013M02606", "DE89370400440532013000", true),
+		newPayment(3, "GB29NWBK60161331926819", "FR1420041010050500013M02606", true))
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/api/v1/payments/stream?account=GB29+NWBK+6016+1331+9268+19&status=processed", nil)
+	req.Header.Set("Last-Event-ID", "0")
+
+	w := stream(router, req)
+
+	// verify
+
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	assert.Equal(t, "text/event-stream", w.Result().Header.Get("Content-Type"))
+
+	events := readEvents(w.Body.String())
+
+	assert.Len(t, events, 1)
+	assert.Equal(t, "uid-3", events[0].Payment.Uid)
+	assert.Equal(t, STATUS_PROCESSED, events[0].Status)
+	assert.Equal(t, watch.CHANGE_UPDATED, events[0].Type)
+	assert.Contains(t, w.Body.String(), "id: "+events[0].Id+"\nevent: updated\n")
+}
+
+func TestStreamPaymentsResume(t *testing.T) {
+
+	router := setUp(time.Minute,
+		newPayment(1, "GB29NWBK60161331926819", "DE89370400440532013000", false),
+		newPayment(2, "GB29NWBK60161331926819", "DE89370400440532013000", false))
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/api/v1/payments/stream", nil)
+	req.Header.Set("Last-Event-ID", "0")
+
+	first := readEvents(stream(router, req).Body.String())
+
+	assert.Len(t, first, 2)
+
+	req = httptest.NewRequest("GET", "http://localhost:8080/api/v1/payments/stream?lastEventId="+first[0].Id, nil)
+
+	// verify
+
+	resumed := readEvents(stream(router, req).Body.String())
+
+	assert.Len(t, resumed, 1)
+	assert.Equal(t, "uid-2", resumed[0].Payment.Uid)
+	assert.Equal(t, first[1].Id, resumed[0].Id)
+}
+
+func TestStreamPaymentsFromNow(t *testing.T) {
+
+	router := setUp(time.Minute, newPayment(1, "GB29NWBK60161331926819", "DE89370400440532013000", false))
+
+	w := stream(router, httptest.NewRequest("GET", "http://localhost:8080/api/v1/payments/stream", nil))
+
+	// verify
+
+	assert.Empty(t, readEvents(w.Body.String()))
+}
+
+func TestStreamPaymentsHeartbeat(t *testing.T) {
+
+	router := setUp(10 * time.Millisecond)
+
+	w := stream(router, httptest.NewRequest("GET", "http://localhost:8080/api/v1/payments/stream", nil))
+
+	// verify
+
+	assert.Contains(t, w.Body.String(), ": heartbeat\n\n")
+}
+
+func TestStreamPaymentsKo(t *testing.T) {
+
+	router := setUp(time.Minute)
+
+	for _, url := range []string{
+		"http://localhost:8080/api/v1/payments/stream?status=sent",
+		"http://localhost:8080/api/v1/payments/stream?account=123",
+		"http://localhost:8080/api/v1/payments/stream?lastEventId=yesterday",
+	} {
+
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+
+		// verify
+
+		assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode, url)
+	}
+}
+
+func TestStreamPaymentsWebSocket(t *testing.T) {
+
+	router := setUp(time.Minute,
+		newPayment(1, "GB29NWBK60161331926819", "DE89370400440532013000", false),
+		newPayment(2, "GB29NWBK60161331926819", "DE89370400440532013000", true))
+
+	server := httptest.NewServer(router)
+
+	defer server.Close()
+
+	conn, _, errorDial := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/v1/payments/stream?status=pending&lastEventId=0", nil)
+
+	assert.Nil(t, errorDial)
+
+	defer conn.Close()
+
+	var event PaymentEvent
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	errorRead := conn.ReadJSON(&event)
+
+	// verify
+
+	assert.Nil(t, errorRead)
+	assert.Equal(t, "uid-1", event.Payment.Uid)
+	assert.Equal(t, STATUS_PENDING, event.Status)
+	assert.Equal(t, watch.CHANGE_CREATED, event.Type)
+}
+
+//
+// private functions
+
+func setUp(heartbeat time.Duration, payments ...model.Payment) *mux.Router {
+
+	var router = mux.NewRouter()
+
+	mockRepository := &paymentRepositoryImplMock{payments: payments}
+
+	NewStreamHandler(watch.NewWatcher(mockRepository, 10*time.Millisecond), heartbeat).Register(router)
+
+	return router
+}
+
+func newPayment(id uint, accountOrigin string, accountTarget string, processed bool) model.Payment {
+
+	createdAt := time.Date(2026, 3, 2, 10, 0, int(id), 0, time.UTC)
+
+	payment := model.Payment{
+		Uid:           "uid-" + string(rune('0'+id)),
+		AccountOrigin: accountOrigin,
+		AccountTarget: accountTarget,
+		Amount:        10,
+	}
+
+	payment.ID = id
+	payment.CreatedAt = createdAt
+	payment.UpdatedAt = createdAt
+
+	if processed {
+		payment.MarkAsProcessed(createdAt.Add(time.Minute))
+		payment.UpdatedAt = createdAt.Add(time.Minute)
+	}
+
+	return payment
+}
+
+// stream runs the request for a while and then disconnects, as a client would
+func stream(router *mux.Router, req *http.Request) *httptest.ResponseRecorder {
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	w := httptest.NewRecorder()
+
+	done := make(chan bool)
+
+	go func() {
+		router.ServeHTTP(w, req.WithContext(ctx))
+		done <- true
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	<-done
+
+	return w
+}
+
+func readEvents(body string) []PaymentEvent {
+
+	var events []PaymentEvent
+
+	for _, line := range strings.Split(body, "\n") {
+
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+
+		var event PaymentEvent
+
+		json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event)
+
+		events = append(events, event)
+	}
+
+	return events
+}
+
+func changeOf(payment model.Payment) model.PaymentChange {
+
+	change := model.PaymentChange{ID: payment.ID, PaymentUid: payment.Uid, Type: model.CHANGE_UPDATED, CreatedAt: payment.ChangedAt(), Payment: &payment}
+
+	if payment.DeletedAt != nil {
+		change.Type = model.CHANGE_DELETED
+	} else if payment.CreatedAt.Equal(payment.UpdatedAt) {
+		change.Type = model.CHANGE_CREATED
+	}
+
+	return change
+}
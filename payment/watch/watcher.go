@@ -0,0 +1,113 @@
+package watch
+
+import (
+	"context"
+	"github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/payment/repository"
+	"time"
+)
+
+const (
+	CHANGE_CREATED = model.CHANGE_CREATED
+	CHANGE_UPDATED = model.CHANGE_UPDATED
+	CHANGE_DELETED = model.CHANGE_DELETED
+
+	BATCH_SIZE = 100
+
+	// a change logged after a gap in the ids waits this long for the changes of the gap, which may still be committing;
+	// after that the gap is taken for a rolled back change and skipped
+	GAP_TIMEOUT = 10 * time.Second
+)
+
+// Cursor is the position of a change in the feed: the id of the change in the change log.
+type Cursor struct {
+	Id uint
+}
+
+type Change struct {
+	Type      string
+	Payment   *model.Payment
+	ChangedAt time.Time
+	Cursor    Cursor
+}
+
+// Watcher polls the change log of the payments, so it sees the changes whichever instance or subsystem made them, each
+// with the payment as it was right after it.
+type Watcher struct {
+	paymentRepository repository.PaymentRepository
+	interval          time.Duration
+	now               func() time.Time
+}
+
+func NewWatcher(paymentRepository repository.PaymentRepository, interval time.Duration) *Watcher {
+
+	return &Watcher{
+		paymentRepository: paymentRepository,
+		interval:          interval,
+		now:               time.Now,
+	}
+}
+
+// From is the cursor to watch the changes made from the given time on.
+func (w *Watcher) From(since time.Time) (Cursor, error) {
+
+	id, errorDB := w.paymentRepository.GetLastChangeIdBefore(since)
+
+	return Cursor{Id: id}, errorDB
+}
+
+// Watch hands every change after the cursor to the consumer, in order, until the context is done or the consumer fails.
+func (w *Watcher) Watch(ctx context.Context, from Cursor, consumer func(*Change) error) error {
+
+	cursor := from
+
+	ticker := time.NewTicker(w.interval)
+
+	defer ticker.Stop()
+
+	for {
+
+		changes, errorDB := w.paymentRepository.GetChangedSince(cursor.Id, BATCH_SIZE)
+
+		if errorDB != nil {
+			return errorDB
+		}
+
+		consumed := 0
+
+		for index := range changes {
+
+			// ids are taken when a change is written but seen when it commits, so an earlier id may still show up
+			if changes[index].ID != cursor.Id+1 && w.now().Sub(changes[index].CreatedAt) < GAP_TIMEOUT {
+				break
+			}
+
+			cursor = Cursor{Id: changes[index].ID}
+			consumed++
+
+			change := &Change{
+				Type:      changes[index].Type,
+				Payment:   changes[index].Payment,
+				ChangedAt: changes[index].CreatedAt,
+				Cursor:    cursor,
+			}
+
+			if errorConsumer := consumer(change); errorConsumer != nil {
+				return errorConsumer
+			}
+		}
+
+		// a full batch means there may be more changes waiting
+		if consumed == BATCH_SIZE && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+
+		case <-ctx.Done():
+			return nil
+
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package watch
+
+import (
+	"context"
+	"github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/payment/repository"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+//
+// mocks
+
+type paymentRepositoryImplMock struct {
+	repository.PaymentRepository
+	changes []model.PaymentChange
+}
+
+func (mock *paymentRepositoryImplMock) GetChangedSince(afterId uint, limit int) ([]model.PaymentChange, error) {
+
+	var results []model.PaymentChange
+
+	for _, change := range mock.changes {
+
+		if change.ID > afterId && len(results) < limit {
+			results = append(results, change)
+		}
+	}
+
+	return results, nil
+}
+
+//
+// tests
+
+func TestWatchWaitsForGap(t *testing.T) {
+
+	// change 2 is still committing while 3, just logged, is visible
+	watcher := setUp(newChange(1, now.Add(-time.Minute)), newChange(3, now))
+
+	seen := watchOnce(watcher, Cursor{})
+
+	// verify
+
+	assert.Equal(t, []uint{1}, seen)
+}
+
+func TestWatchSkipsStaleGap(t *testing.T) {
+
+	// change 2 was rolled back long ago
+	watcher := setUp(newChange(1, now.Add(-time.Minute)), newChange(3, now.Add(-GAP_TIMEOUT)))
+
+	seen := watchOnce(watcher, Cursor{})
+
+	// verify
+
+	assert.Equal(t, []uint{1, 3}, seen)
+}
+
+func TestWatchHandsOverSnapshots(t *testing.T) {
+
+	created := newChange(1, now.Add(-time.Minute))
+	created.Payment = &model.Payment{Uid: "uid", Amount: 10}
+
+	updated := newChange(2, now)
+	updated.Payment = &model.Payment{Uid: "uid", Amount: 20}
+
+	watcher := setUp(created, updated)
+
+	var amounts []float64
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	watcher.Watch(ctx, Cursor{}, func(change *Change) error {
+
+		amounts = append(amounts, change.Payment.Amount)
+
+		return nil
+	})
+
+	// verify
+
+	assert.Equal(t, []float64{10, 20}, amounts)
+}
+
+//
+// private functions
+
+func setUp(changes ...model.PaymentChange) *Watcher {
+
+	watcher := NewWatcher(&paymentRepositoryImplMock{changes: changes}, time.Hour)
+	watcher.now = func() time.Time { return now }
+
+	return watcher
+}
+
+func newChange(id uint, createdAt time.Time) model.PaymentChange {
+
+	return model.PaymentChange{ID: id, PaymentUid: "uid", Type: model.CHANGE_UPDATED, CreatedAt: createdAt, Payment: &model.Payment{Uid: "uid"}}
+}
+
+// watchOnce returns the ids of the changes handed over by the first poll
+func watchOnce(watcher *Watcher, from Cursor) []uint {
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var seen []uint
+
+	cancel()
+
+	watcher.Watch(ctx, from, func(change *Change) error {
+
+		seen = append(seen, change.Cursor.Id)
+
+		return nil
+	})
+
+	return seen
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	paymentHandler "github.com/javierjmgits/go-payment-api/payment/handler"
+	"github.com/javierjmgits/go-payment-api/processing/service"
+	"net/http"
+)
+
+type ProcessingHandler struct {
+	processingService *service.ProcessingService
+}
+
+func NewProcessingHandler(processingService *service.ProcessingService) *ProcessingHandler {
+
+	return &ProcessingHandler{
+		processingService: processingService,
+	}
+}
+
+func (ph *ProcessingHandler) Register(router *mux.Router) {
+	router.HandleFunc("/api/v1/payments/uid/{uid}/process", ph.ProcessPayment).Methods("POST")
+}
+
+// ProcessPayment queues the payment for the workers; its progress is shown in the payment's processing fields.
+func (ph *ProcessingHandler) ProcessPayment(w http.ResponseWriter, r *http.Request) {
+
+	payment, errorEnqueue := ph.processingService.Enqueue(mux.Vars(r)["uid"])
+
+	if errorEnqueue != nil {
+		util.WriteErrorFor(w, errorEnqueue)
+		return
+	}
+
+	util.WritePayload(w, http.StatusAccepted, paymentHandler.NewPaymentView(payment))
+}
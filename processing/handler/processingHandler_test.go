@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/base/config"
+	paymentHandler "github.com/javierjmgits/go-payment-api/payment/handler"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/processing/model"
+	"github.com/javierjmgits/go-payment-api/processing/processor"
+	"github.com/javierjmgits/go-payment-api/processing/service"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+//
+// mocks
+
+type jobRepositoryImplMock struct {
+	payment *paymentModel.Payment
+}
+
+func (mock *jobRepositoryImplMock) Enqueue(paymentUid string, check func(*paymentModel.Payment) error, now time.Time) (*paymentModel.Payment, error) {
+
+	if errorCheck := check(mock.payment); errorCheck != nil {
+		return nil, errorCheck
+	}
+
+	mock.payment.ProcessingStatus = paymentModel.PROCESSING_STATUS_QUEUED
+	mock.payment.NextAttemptAt = &now
+
+	return mock.payment, nil
+}
+
+func (mock *jobRepositoryImplMock) Claim(worker string, now time.Time, lease time.Duration) (*model.Job, *paymentModel.Payment, error) {
+	return nil, nil, nil
+}
+
+func (mock *jobRepositoryImplMock) Release(job *model.Job, now time.Time) (bool, error) {
+	return true, nil
+}
+
+//
+// tests
+
+func TestProcessPayment(t *testing.T) {
+
+	router, _ := setUp(false)
+
+	resp := postProcess(router)
+
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	var paymentView paymentHandler.PaymentView
+	json.NewDecoder(resp.Body).Decode(&paymentView)
+
+	assert.Equal(t, paymentModel.PROCESSING_STATUS_QUEUED, paymentView.ProcessingStatus)
+	assert.NotNil(t, paymentView.NextAttemptAt)
+	assert.False(t, paymentView.Processed)
+}
+
+func TestProcessPaymentKoAlreadyProcessed(t *testing.T) {
+
+	router, _ := setUp(true)
+
+	resp := postProcess(router)
+
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestProcessPaymentKoAlreadyQueued(t *testing.T) {
+
+	router, _ := setUp(false)
+
+	postProcess(router)
+	resp := postProcess(router)
+
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+//
+// private functions
+
+func setUp(processed bool) (*mux.Router, *jobRepositoryImplMock) {
+
+	var router = mux.NewRouter()
+	mockRepository := &jobRepositoryImplMock{
+		payment: &paymentModel.Payment{Uid: "myUid", Amount: 25, Processed: processed},
+	}
+
+	processingService := service.NewProcessingService(mockRepository, processor.NewInstantProcessor(), &config.ProcessingConfig{})
+
+	NewProcessingHandler(processingService).Register(router)
+
+	return router, mockRepository
+}
+
+func postProcess(router *mux.Router) *http.Response {
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/payments/uid/myUid/process", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	return w.Result()
+}
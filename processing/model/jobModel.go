@@ -0,0 +1,25 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+// Job queues the processing of one payment; its status uses the payment's PROCESSING_STATUS_* values.
+type Job struct {
+	gorm.Model
+	PaymentUid    string     `gorm:"unique;not null"`
+	Status        string     `gorm:"not null;index"`
+	Attempts      int        `gorm:"not null;default:0"`
+	NextAttemptAt time.Time  `gorm:"not null;index"`
+	LockedBy      string     `gorm:"not null;default:''"`
+	LockedUntil   *time.Time `gorm:"null"`
+	LastError     string     `gorm:"type:text"`
+}
+
+func SetUp(db *gorm.DB) *gorm.DB {
+
+	db.AutoMigrate(&Job{})
+
+	return db
+}
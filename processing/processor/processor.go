@@ -0,0 +1,52 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+)
+
+// PaymentProcessor settles a payment, e.g. by sending it through a payment rail.
+// Errors are final unless wrapped with Transient; the payment is then tried again later.
+// ErrSubmitted tells that the payment was handed over and settles later; it stays SUBMITTED until then.
+type PaymentProcessor interface {
+	Process(ctx context.Context, payment *paymentModel.Payment) error
+}
+
+var ErrSubmitted = errors.New("submitted, awaiting settlement")
+
+type TransientError struct {
+	Err error
+}
+
+func (te *TransientError) Error() string {
+	return te.Err.Error()
+}
+
+func (te *TransientError) Unwrap() error {
+	return te.Err
+}
+
+func Transient(err error) error {
+	return &TransientError{Err: err}
+}
+
+// IsTransient tells whether an error is worth a retry; running out of time is.
+func IsTransient(err error) bool {
+
+	var transientError *TransientError
+
+	return errors.As(err, &transientError) || errors.Is(err, context.DeadlineExceeded)
+}
+
+type instantProcessor struct {
+}
+
+// NewInstantProcessor settles every payment at once, as flagging it as processed does.
+func NewInstantProcessor() PaymentProcessor {
+	return &instantProcessor{}
+}
+
+func (ip *instantProcessor) Process(ctx context.Context, payment *paymentModel.Payment) error {
+	return nil
+}
@@ -0,0 +1,252 @@
+package repository
+
+import (
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/processing/model"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+type JobRepository interface {
+	Enqueue(paymentUid string, check func(*paymentModel.Payment) error, now time.Time) (*paymentModel.Payment, error)
+	Claim(worker string, now time.Time, lease time.Duration) (*model.Job, *paymentModel.Payment, error)
+	Release(job *model.Job, now time.Time) (bool, error)
+}
+
+type jobRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewJobRepositoryImpl(db *gorm.DB) JobRepository {
+	return &jobRepositoryImpl{
+		db: db,
+	}
+}
+
+// Enqueue queues a payment that passes the check, or queues it again after its job failed.
+func (jri *jobRepositoryImpl) Enqueue(paymentUid string, check func(*paymentModel.Payment) error, now time.Time) (*paymentModel.Payment, error) {
+
+	tx := jri.db.Begin()
+
+	var payment paymentModel.Payment
+	errorDB := tx.Set("gorm:query_option", "FOR UPDATE").Where("uid = ?", paymentUid).First(&payment).Error
+
+	if errorDB != nil {
+		tx.Rollback()
+		return nil, errorDB
+	}
+
+	if errorCheck := check(&payment); errorCheck != nil {
+		tx.Rollback()
+		return nil, errorCheck
+	}
+
+	var job model.Job
+	errorDB = tx.Where("payment_uid = ?", paymentUid).FirstOrInit(&job, model.Job{PaymentUid: paymentUid}).Error
+
+	if errorDB != nil {
+		tx.Rollback()
+		return nil, errorDB
+	}
+
+	job.Status = paymentModel.PROCESSING_STATUS_QUEUED
+	job.Attempts = 0
+	job.NextAttemptAt = now
+	job.LockedBy = ""
+	job.LockedUntil = nil
+	job.LastError = ""
+
+	payment.ProcessingStatus = job.Status
+	payment.ProcessingAttempts = 0
+	payment.ProcessingError = ""
+	payment.NextAttemptAt = &now
+
+	for _, value := range []interface{}{&job, &payment} {
+
+		if errorDB := tx.Save(value).Error; errorDB != nil {
+			tx.Rollback()
+			return nil, errorDB
+		}
+	}
+
+	errorDB = tx.Commit().Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return &payment, nil
+}
+
+// Claim leases the next due job to a worker, or returns nil when there is none. Jobs whose lease
+// ran out are claimed again, as their worker died. A job whose payment is gone fails, and the next
+// one is claimed instead.
+func (jri *jobRepositoryImpl) Claim(worker string, now time.Time, lease time.Duration) (*model.Job, *paymentModel.Payment, error) {
+
+	for {
+
+		job, payment, errorClaim := jri.claim(worker, now, lease)
+
+		if errorClaim != nil || job == nil || payment != nil {
+			return job, payment, errorClaim
+		}
+	}
+}
+
+// Release stores the outcome the worker set on the job and mirrors it on the payment. It returns
+// false when the worker lost its lease, in which case nothing is changed. A job whose payment is
+// gone fails.
+func (jri *jobRepositoryImpl) Release(job *model.Job, now time.Time) (bool, error) {
+
+	tx := jri.db.Begin()
+
+	var nextAttemptAt *time.Time
+
+	if job.Status == paymentModel.PROCESSING_STATUS_QUEUED {
+		nextAttemptAt = &job.NextAttemptAt
+	}
+
+	result := tx.Model(&model.Job{}).
+		Where("id = ? AND status = ? AND locked_by = ?", job.ID, paymentModel.PROCESSING_STATUS_RUNNING, job.LockedBy).
+		Updates(map[string]interface{}{
+			"status":          job.Status,
+			"next_attempt_at": job.NextAttemptAt,
+			"last_error":      job.LastError,
+			"locked_by":       "",
+			"locked_until":    nil,
+		})
+
+	if result.Error != nil {
+		tx.Rollback()
+		return false, result.Error
+	}
+
+	if result.RowsAffected != 1 {
+		tx.Rollback()
+		return false, nil
+	}
+
+	var payment paymentModel.Payment
+	errorDB := tx.Set("gorm:query_option", "FOR UPDATE").Where("uid = ?", job.PaymentUid).First(&payment).Error
+
+	if gorm.IsRecordNotFoundError(errorDB) {
+
+		if errorDB := failJob(tx, job.ID); errorDB != nil {
+			tx.Rollback()
+			return false, errorDB
+		}
+
+		return true, tx.Commit().Error
+	}
+
+	if errorDB != nil {
+		tx.Rollback()
+		return false, errorDB
+	}
+
+	payment.ProcessingStatus = job.Status
+	payment.ProcessingError = job.LastError
+	payment.NextAttemptAt = nextAttemptAt
+
+	if job.Status == paymentModel.PROCESSING_STATUS_SUCCEEDED && !payment.Processed {
+		payment.MarkAsProcessed(now)
+	}
+
+	if errorDB := tx.Save(&payment).Error; errorDB != nil {
+		tx.Rollback()
+		return false, errorDB
+	}
+
+	errorDB = tx.Commit().Error
+
+	if errorDB != nil {
+		return false, errorDB
+	}
+
+	return true, nil
+}
+
+//
+// private functions
+
+// claim leases the next due job, failing it and returning no payment when its payment is gone
+func (jri *jobRepositoryImpl) claim(worker string, now time.Time, lease time.Duration) (*model.Job, *paymentModel.Payment, error) {
+
+	tx := jri.db.Begin()
+
+	// rows locked by other workers are skipped, not waited for, so workers never block each other
+	var job model.Job
+	errorDB := tx.Set("gorm:query_option", "FOR UPDATE SKIP LOCKED").
+		Where("(status = ? AND next_attempt_at <= ?) OR (status = ? AND locked_until <= ?)",
+			paymentModel.PROCESSING_STATUS_QUEUED, now, paymentModel.PROCESSING_STATUS_RUNNING, now).
+		Order("next_attempt_at, id").
+		First(&job).Error
+
+	if gorm.IsRecordNotFoundError(errorDB) {
+		tx.Rollback()
+		return nil, nil, nil
+	}
+
+	if errorDB != nil {
+		tx.Rollback()
+		return nil, nil, errorDB
+	}
+
+	lockedUntil := now.Add(lease)
+
+	job.Status = paymentModel.PROCESSING_STATUS_RUNNING
+	job.Attempts++
+	job.LockedBy = worker
+	job.LockedUntil = &lockedUntil
+
+	var payment paymentModel.Payment
+	errorDB = tx.Set("gorm:query_option", "FOR UPDATE").Where("uid = ?", job.PaymentUid).First(&payment).Error
+
+	// a payment deleted while queued would otherwise keep its job at the head of the queue for good
+	if gorm.IsRecordNotFoundError(errorDB) {
+
+		if errorDB := failJob(tx, job.ID); errorDB != nil {
+			tx.Rollback()
+			return nil, nil, errorDB
+		}
+
+		return &job, nil, tx.Commit().Error
+	}
+
+	if errorDB != nil {
+		tx.Rollback()
+		return nil, nil, errorDB
+	}
+
+	payment.ProcessingStatus = job.Status
+	payment.ProcessingAttempts = job.Attempts
+	payment.NextAttemptAt = nil
+
+	for _, value := range []interface{}{&job, &payment} {
+
+		if errorDB := tx.Save(value).Error; errorDB != nil {
+			tx.Rollback()
+			return nil, nil, errorDB
+		}
+	}
+
+	errorDB = tx.Commit().Error
+
+	if errorDB != nil {
+		return nil, nil, errorDB
+	}
+
+	return &job, &payment, nil
+}
+
+func failJob(tx *gorm.DB, jobID uint) error {
+
+	return tx.Model(&model.Job{}).
+		Where("id = ?", jobID).
+		Updates(map[string]interface{}{
+			"status":       paymentModel.PROCESSING_STATUS_FAILED,
+			"last_error":   "payment not found",
+			"locked_by":    "",
+			"locked_until": nil,
+		}).Error
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/javierjmgits/go-payment-api/base/config"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/processing/model"
+	"github.com/javierjmgits/go-payment-api/processing/processor"
+	"github.com/javierjmgits/go-payment-api/processing/repository"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+type ProcessingService struct {
+	jobRepository repository.JobRepository
+	processor     processor.PaymentProcessor
+	config        *config.ProcessingConfig
+	now           func() time.Time
+
+	mutex   sync.Mutex
+	stop    chan struct{}
+	workers sync.WaitGroup
+}
+
+func NewProcessingService(jobRepository repository.JobRepository, paymentProcessor processor.PaymentProcessor, config *config.ProcessingConfig) *ProcessingService {
+
+	return &ProcessingService{
+		jobRepository: jobRepository,
+		processor:     paymentProcessor,
+		config:        config,
+		now:           time.Now,
+	}
+}
+
+// Enqueue queues a payment for the workers; a payment whose processing failed can be queued again.
+func (ps *ProcessingService) Enqueue(paymentUid string) (*paymentModel.Payment, error) {
+
+	return ps.jobRepository.Enqueue(paymentUid, func(payment *paymentModel.Payment) error {
+
+		switch {
+
+		case payment.Processed:
+			return &util.ConflictError{Message: "Payment already processed"}
+
+		case payment.IsCancelled():
+			return &util.ConflictError{Message: "Payment cancelled"}
+
+		case payment.IsHeld():
+			return &util.ConflictError{Message: "Payment held for review or approval"}
+
+		case payment.IsProcessing():
+			return &util.ConflictError{Message: "Payment is already being processed"}
+		}
+
+		return nil
+	}, ps.now())
+}
+
+func (ps *ProcessingService) Start() {
+
+	if ps.config.Workers <= 0 {
+		log.Println("Processing workers disabled")
+		return
+	}
+
+	ps.mutex.Lock()
+	ps.stop = make(chan struct{})
+	stop := ps.stop
+	ps.mutex.Unlock()
+
+	hostname, _ := os.Hostname()
+
+	log.Printf("Starting %d processing worker(s)\n", ps.config.Workers)
+
+	for index := 1; index <= ps.config.Workers; index++ {
+
+		worker := fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), index)
+
+		ps.workers.Add(1)
+
+		go func() {
+
+			defer ps.workers.Done()
+
+			ps.run(worker, stop)
+		}()
+	}
+}
+
+// Stop lets the workers finish the job at hand and waits for them.
+func (ps *ProcessingService) Stop() {
+
+	ps.mutex.Lock()
+
+	if ps.stop != nil {
+		close(ps.stop)
+		ps.stop = nil
+	}
+
+	ps.mutex.Unlock()
+
+	ps.workers.Wait()
+}
+
+// Work claims and processes at most one due job, and tells whether there was one.
+func (ps *ProcessingService) Work(worker string) (bool, error) {
+
+	job, payment, errorDB := ps.jobRepository.Claim(worker, ps.now(), ps.lease())
+
+	if errorDB != nil || job == nil {
+		return false, errorDB
+	}
+
+	errorProcess := ps.process(job, payment)
+
+	now := ps.now()
+
+	switch {
+
+	case errorProcess == nil:
+		job.Status = paymentModel.PROCESSING_STATUS_SUCCEEDED
+		job.LastError = ""
+
+	// the rail reports the outcome later, the payment is not processed until it settles
+	case errors.Is(errorProcess, processor.ErrSubmitted):
+		job.Status = paymentModel.PROCESSING_STATUS_SUBMITTED
+		job.LastError = ""
+
+	case processor.IsTransient(errorProcess) && job.Attempts < ps.config.MaxAttempts:
+		job.Status = paymentModel.PROCESSING_STATUS_QUEUED
+		job.NextAttemptAt = now.Add(Backoff(job.Attempts, ps.config.Backoff, ps.config.MaxBackoff))
+		job.LastError = errorProcess.Error()
+
+	default:
+		job.Status = paymentModel.PROCESSING_STATUS_FAILED
+		job.LastError = errorProcess.Error()
+	}
+
+	released, errorDB := ps.jobRepository.Release(job, now)
+
+	if errorDB != nil {
+		return true, errorDB
+	}
+
+	if !released {
+		log.Printf("Processing worker %s lost its lease on payment %s\n", worker, job.PaymentUid)
+	}
+
+	return true, nil
+}
+
+// Backoff doubles the wait after every failed attempt, up to a maximum.
+func Backoff(attempts int, backoff time.Duration, maxBackoff time.Duration) time.Duration {
+
+	wait := backoff
+
+	for attempt := 1; attempt < attempts && wait < maxBackoff; attempt++ {
+		wait *= 2
+	}
+
+	if wait > maxBackoff {
+		return maxBackoff
+	}
+
+	return wait
+}
+
+//
+// private functions
+
+func (ps *ProcessingService) run(worker string, stop chan struct{}) {
+
+	for {
+
+		claimed, errorWork := ps.Work(worker)
+
+		if errorWork != nil {
+			log.Printf("Processing worker %s failed: %v\n", worker, errorWork)
+		}
+
+		// keep draining the queue while there is work, otherwise wait for the next poll
+		wait := ps.config.PollInterval
+
+		if claimed && errorWork == nil {
+			wait = 0
+		}
+
+		select {
+
+		case <-time.After(wait):
+
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (ps *ProcessingService) process(job *model.Job, payment *paymentModel.Payment) error {
+
+	if payment.IsCancelled() {
+		return errors.New("payment cancelled")
+	}
+
+	// processed meanwhile, e.g. flagged by hand
+	if payment.Processed {
+		return nil
+	}
+
+	// a job claimed again after its worker died may already have used up its attempts
+	if job.Attempts > ps.config.MaxAttempts {
+		return fmt.Errorf("gave up after %d attempts", ps.config.MaxAttempts)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ps.config.Timeout)
+	defer cancel()
+
+	return ps.processor.Process(ctx, payment)
+}
+
+// the lease outlives the processing timeout, so only jobs of dead workers are claimed again
+func (ps *ProcessingService) lease() time.Duration {
+	return 2 * ps.config.Timeout
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/javierjmgits/go-payment-api/base/config"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/processing/model"
+	"github.com/javierjmgits/go-payment-api/processing/processor"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+//
+// mock data
+
+var now = time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+//
+// mocks
+
+type jobRepositoryImplMock struct {
+	payment  *paymentModel.Payment
+	job      *model.Job
+	released *model.Job
+}
+
+func (mock *jobRepositoryImplMock) Enqueue(paymentUid string, check func(*paymentModel.Payment) error, now time.Time) (*paymentModel.Payment, error) {
+
+	if errorCheck := check(mock.payment); errorCheck != nil {
+		return nil, errorCheck
+	}
+
+	mock.payment.ProcessingStatus = paymentModel.PROCESSING_STATUS_QUEUED
+
+	return mock.payment, nil
+}
+
+func (mock *jobRepositoryImplMock) Claim(worker string, now time.Time, lease time.Duration) (*model.Job, *paymentModel.Payment, error) {
+
+	if mock.job == nil {
+		return nil, nil, nil
+	}
+
+	job := *mock.job
+	job.Status = paymentModel.PROCESSING_STATUS_RUNNING
+	job.Attempts++
+	job.LockedBy = worker
+
+	return &job, mock.payment, nil
+}
+
+func (mock *jobRepositoryImplMock) Release(job *model.Job, now time.Time) (bool, error) {
+
+	mock.released = job
+
+	return true, nil
+}
+
+type paymentProcessorMock struct {
+	err error
+}
+
+func (mock *paymentProcessorMock) Process(ctx context.Context, payment *paymentModel.Payment) error {
+	return mock.err
+}
+
+//
+// tests
+
+func TestEnqueueKoHeld(t *testing.T) {
+
+	processingService, mockRepository := setUp(nil)
+	mockRepository.payment.ApprovalStatus = paymentModel.APPROVAL_STATUS_PENDING
+
+	_, errorEnqueue := processingService.Enqueue("myUid")
+
+	assert.IsType(t, &util.ConflictError{}, errorEnqueue)
+}
+
+func TestEnqueueKoAlreadyQueued(t *testing.T) {
+
+	processingService, mockRepository := setUp(nil)
+	mockRepository.payment.ProcessingStatus = paymentModel.PROCESSING_STATUS_QUEUED
+
+	_, errorEnqueue := processingService.Enqueue("myUid")
+
+	assert.IsType(t, &util.ConflictError{}, errorEnqueue)
+}
+
+func TestWorkNothingDue(t *testing.T) {
+
+	processingService, mockRepository := setUp(nil)
+	mockRepository.job = nil
+
+	claimed, errorWork := processingService.Work("worker-1")
+
+	assert.Nil(t, errorWork)
+	assert.False(t, claimed)
+	assert.Nil(t, mockRepository.released)
+}
+
+func TestWorkSucceeded(t *testing.T) {
+
+	processingService, mockRepository := setUp(nil)
+
+	claimed, errorWork := processingService.Work("worker-1")
+
+	assert.Nil(t, errorWork)
+	assert.True(t, claimed)
+	assert.Equal(t, paymentModel.PROCESSING_STATUS_SUCCEEDED, mockRepository.released.Status)
+	assert.Equal(t, "worker-1", mockRepository.released.LockedBy)
+}
+
+func TestWorkSubmittedIsNotSucceeded(t *testing.T) {
+
+	processingService, mockRepository := setUp(processor.ErrSubmitted)
+
+	processingService.Work("worker-1")
+
+	assert.Equal(t, paymentModel.PROCESSING_STATUS_SUBMITTED, mockRepository.released.Status)
+	assert.Equal(t, "", mockRepository.released.LastError)
+}
+
+func TestWorkTransientErrorIsRetried(t *testing.T) {
+
+	processingService, mockRepository := setUp(processor.Transient(errors.New("rail unavailable")))
+	mockRepository.job.Attempts = 2
+
+	processingService.Work("worker-1")
+
+	assert.Equal(t, paymentModel.PROCESSING_STATUS_QUEUED, mockRepository.released.Status)
+	assert.Equal(t, now.Add(4*time.Second), mockRepository.released.NextAttemptAt)
+	assert.Equal(t, "rail unavailable", mockRepository.released.LastError)
+}
+
+func TestWorkTimeoutIsRetried(t *testing.T) {
+
+	processingService, mockRepository := setUp(context.DeadlineExceeded)
+
+	processingService.Work("worker-1")
+
+	assert.Equal(t, paymentModel.PROCESSING_STATUS_QUEUED, mockRepository.released.Status)
+}
+
+func TestWorkTransientErrorFailsOnLastAttempt(t *testing.T) {
+
+	processingService, mockRepository := setUp(processor.Transient(errors.New("rail unavailable")))
+	mockRepository.job.Attempts = 2
+	processingService.config.MaxAttempts = 3
+
+	processingService.Work("worker-1")
+
+	assert.Equal(t, paymentModel.PROCESSING_STATUS_FAILED, mockRepository.released.Status)
+	assert.Equal(t, "rail unavailable", mockRepository.released.LastError)
+}
+
+func TestWorkPermanentErrorFails(t *testing.T) {
+
+	processingService, mockRepository := setUp(errors.New("account closed"))
+
+	processingService.Work("worker-1")
+
+	assert.Equal(t, paymentModel.PROCESSING_STATUS_FAILED, mockRepository.released.Status)
+	assert.Equal(t, "account closed", mockRepository.released.LastError)
+}
+
+func TestWorkCancelledPaymentFails(t *testing.T) {
+
+	processingService, mockRepository := setUp(nil)
+	mockRepository.payment.Cancel("DUPL", "", "alice", now)
+
+	processingService.Work("worker-1")
+
+	assert.Equal(t, paymentModel.PROCESSING_STATUS_FAILED, mockRepository.released.Status)
+	assert.Equal(t, "payment cancelled", mockRepository.released.LastError)
+}
+
+func TestBackoff(t *testing.T) {
+
+	assert.Equal(t, time.Second, Backoff(1, time.Second, time.Minute))
+	assert.Equal(t, 2*time.Second, Backoff(2, time.Second, time.Minute))
+	assert.Equal(t, 32*time.Second, Backoff(6, time.Second, time.Minute))
+	assert.Equal(t, time.Minute, Backoff(7, time.Second, time.Minute))
+	assert.Equal(t, time.Minute, Backoff(100, time.Second, time.Minute))
+}
+
+//
+// private functions
+
+func setUp(processError error) (*ProcessingService, *jobRepositoryImplMock) {
+
+	mockRepository := &jobRepositoryImplMock{
+		payment: &paymentModel.Payment{Uid: "myUid", Amount: 25},
+		job:     &model.Job{PaymentUid: "myUid", Status: paymentModel.PROCESSING_STATUS_QUEUED, NextAttemptAt: now},
+	}
+
+	processingService := NewProcessingService(mockRepository, &paymentProcessorMock{err: processError}, &config.ProcessingConfig{
+		Timeout:     time.Second,
+		MaxAttempts: 5,
+		Backoff:     time.Second,
+		MaxBackoff:  time.Minute,
+	})
+
+	processingService.now = func() time.Time { return now }
+
+	return processingService, mockRepository
+}
@@ -0,0 +1,33 @@
+package connector
+
+import (
+	"context"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+)
+
+const (
+	STATUS_PENDING   = "PENDING"
+	STATUS_SETTLED   = "SETTLED"
+	STATUS_REJECTED  = "REJECTED"
+	STATUS_CANCELLED = "CANCELLED"
+)
+
+// Submission is a payment as known by a rail, identified by the rail's own reference.
+type Submission struct {
+	Reference string
+	Status    string
+	Reason    string
+}
+
+// Connector settles payments through a payment rail (SEPA, ACH, Faster Payments...).
+// Submit must be idempotent on the payment uid, as a payment is submitted again when the
+// previous attempt timed out; errors worth a retry are wrapped with processor.Transient.
+type Connector interface {
+	Name() string
+	Submit(ctx context.Context, payment *paymentModel.Payment) (*Submission, error)
+	Status(ctx context.Context, reference string) (*Submission, error)
+	Cancel(ctx context.Context, reference string) (*Submission, error)
+}
+
+// Callback receives the status changes a rail reports on its own, e.g. a settlement.
+type Callback func(rail string, submission *Submission)
@@ -0,0 +1,181 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/javierjmgits/go-payment-api/base/config"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/processing/processor"
+	"github.com/satori/go.uuid"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const SIMULATOR_NAME = "SIMULATOR"
+
+// Simulator is an in-memory rail for local end-to-end tests: every call takes some latency and may
+// fail, and submissions settle or get rejected after a delay, which is reported to the callback.
+type Simulator struct {
+	config   *config.RailConfig
+	callback Callback
+
+	mutex        sync.Mutex
+	random       *rand.Rand
+	byPaymentUid map[string]*Submission
+	byReference  map[string]*Submission
+}
+
+func NewSimulator(config *config.RailConfig) *Simulator {
+
+	return &Simulator{
+		config:       config,
+		random:       rand.New(rand.NewSource(time.Now().UnixNano())),
+		byPaymentUid: map[string]*Submission{},
+		byReference:  map[string]*Submission{},
+	}
+}
+
+func (s *Simulator) SetCallback(callback Callback) {
+
+	s.callback = callback
+}
+
+func (s *Simulator) Name() string {
+	return SIMULATOR_NAME
+}
+
+func (s *Simulator) Submit(ctx context.Context, payment *paymentModel.Payment) (*Submission, error) {
+
+	if errorCall := s.call(ctx); errorCall != nil {
+		return nil, errorCall
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if submission, found := s.byPaymentUid[payment.Uid]; found {
+		return s.copy(submission), nil
+	}
+
+	uuidResult, errorUuid := uuid.NewV4()
+
+	if errorUuid != nil {
+		return nil, processor.Transient(errorUuid)
+	}
+
+	submission := &Submission{
+		Reference: "SIM-" + uuidResult.String(),
+		Status:    STATUS_PENDING,
+	}
+
+	s.byPaymentUid[payment.Uid] = submission
+	s.byReference[submission.Reference] = submission
+
+	time.AfterFunc(s.config.SettlementDelay, func() { s.settle(submission) })
+
+	return s.copy(submission), nil
+}
+
+func (s *Simulator) Status(ctx context.Context, reference string) (*Submission, error) {
+
+	if errorCall := s.call(ctx); errorCall != nil {
+		return nil, errorCall
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	submission, found := s.byReference[reference]
+
+	if !found {
+		return nil, fmt.Errorf("submission %s not found", reference)
+	}
+
+	return s.copy(submission), nil
+}
+
+func (s *Simulator) Cancel(ctx context.Context, reference string) (*Submission, error) {
+
+	if errorCall := s.call(ctx); errorCall != nil {
+		return nil, errorCall
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	submission, found := s.byReference[reference]
+
+	if !found {
+		return nil, fmt.Errorf("submission %s not found", reference)
+	}
+
+	if submission.Status != STATUS_PENDING {
+		return nil, &util.ConflictError{Message: fmt.Sprintf("submission is already %s", submission.Status)}
+	}
+
+	submission.Status = STATUS_CANCELLED
+
+	return s.copy(submission), nil
+}
+
+//
+// private functions
+
+// call waits the latency and then fails as often as configured, like a remote call would
+func (s *Simulator) call(ctx context.Context) error {
+
+	select {
+
+	case <-time.After(s.config.Latency):
+
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	s.mutex.Lock()
+	failed := s.random.Float64() < s.config.FailureRate
+	s.mutex.Unlock()
+
+	if failed {
+		return processor.Transient(errors.New("simulated rail unavailable"))
+	}
+
+	return nil
+}
+
+func (s *Simulator) settle(submission *Submission) {
+
+	s.mutex.Lock()
+
+	// cancelled in the meantime
+	if submission.Status != STATUS_PENDING {
+		s.mutex.Unlock()
+		return
+	}
+
+	if s.random.Float64() < s.config.RejectionRate {
+		submission.Status = STATUS_REJECTED
+		submission.Reason = "simulated rejection"
+
+	} else {
+		submission.Status = STATUS_SETTLED
+	}
+
+	settled := s.copy(submission)
+
+	s.mutex.Unlock()
+
+	if s.callback != nil {
+		s.callback(SIMULATOR_NAME, settled)
+	}
+}
+
+func (s *Simulator) copy(submission *Submission) *Submission {
+
+	result := *submission
+
+	return &result
+}
@@ -0,0 +1,101 @@
+package connector
+
+import (
+	"context"
+	"github.com/javierjmgits/go-payment-api/base/config"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/processing/processor"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+//
+// tests
+
+func TestSubmitIsIdempotent(t *testing.T) {
+
+	simulator := NewSimulator(&config.RailConfig{SettlementDelay: time.Hour})
+	payment := &paymentModel.Payment{Uid: "myUid", Amount: 25}
+
+	first, errorFirst := simulator.Submit(context.Background(), payment)
+	second, errorSecond := simulator.Submit(context.Background(), payment)
+
+	assert.Nil(t, errorFirst)
+	assert.Nil(t, errorSecond)
+	assert.Equal(t, STATUS_PENDING, first.Status)
+	assert.Equal(t, first.Reference, second.Reference)
+}
+
+func TestSubmitSettlesAndCallsBack(t *testing.T) {
+
+	simulator := NewSimulator(&config.RailConfig{SettlementDelay: time.Millisecond})
+	callbacks := make(chan *Submission, 1)
+	simulator.SetCallback(func(rail string, submission *Submission) { callbacks <- submission })
+
+	submission, _ := simulator.Submit(context.Background(), &paymentModel.Payment{Uid: "myUid"})
+
+	select {
+
+	case settled := <-callbacks:
+		assert.Equal(t, submission.Reference, settled.Reference)
+		assert.Equal(t, STATUS_SETTLED, settled.Status)
+
+	case <-time.After(time.Second):
+		t.Fatal("no settlement reported")
+	}
+
+	status, _ := simulator.Status(context.Background(), submission.Reference)
+
+	assert.Equal(t, STATUS_SETTLED, status.Status)
+}
+
+func TestSubmitRejected(t *testing.T) {
+
+	simulator := NewSimulator(&config.RailConfig{SettlementDelay: time.Millisecond, RejectionRate: 1})
+	callbacks := make(chan *Submission, 1)
+	simulator.SetCallback(func(rail string, submission *Submission) { callbacks <- submission })
+
+	simulator.Submit(context.Background(), &paymentModel.Payment{Uid: "myUid"})
+
+	settled := <-callbacks
+
+	assert.Equal(t, STATUS_REJECTED, settled.Status)
+	assert.NotEmpty(t, settled.Reason)
+}
+
+func TestSubmitKoUnavailable(t *testing.T) {
+
+	simulator := NewSimulator(&config.RailConfig{FailureRate: 1})
+
+	_, errorSubmit := simulator.Submit(context.Background(), &paymentModel.Payment{Uid: "myUid"})
+
+	assert.True(t, processor.IsTransient(errorSubmit))
+}
+
+func TestSubmitKoTimeout(t *testing.T) {
+
+	simulator := NewSimulator(&config.RailConfig{Latency: time.Hour})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	_, errorSubmit := simulator.Submit(ctx, &paymentModel.Payment{Uid: "myUid"})
+
+	assert.Equal(t, context.DeadlineExceeded, errorSubmit)
+}
+
+func TestCancel(t *testing.T) {
+
+	simulator := NewSimulator(&config.RailConfig{SettlementDelay: time.Hour})
+	submission, _ := simulator.Submit(context.Background(), &paymentModel.Payment{Uid: "myUid"})
+
+	cancelled, errorCancel := simulator.Cancel(context.Background(), submission.Reference)
+
+	assert.Nil(t, errorCancel)
+	assert.Equal(t, STATUS_CANCELLED, cancelled.Status)
+
+	_, errorCancel = simulator.Cancel(context.Background(), submission.Reference)
+
+	assert.IsType(t, &util.ConflictError{}, errorCancel)
+}
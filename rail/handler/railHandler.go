@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	paymentHandler "github.com/javierjmgits/go-payment-api/payment/handler"
+	"github.com/javierjmgits/go-payment-api/rail/service"
+	"net/http"
+)
+
+type RailHandler struct {
+	railService *service.RailService
+}
+
+func NewRailHandler(railService *service.RailService) *RailHandler {
+
+	return &RailHandler{
+		railService: railService,
+	}
+}
+
+func (rh *RailHandler) Register(router *mux.Router) {
+	router.HandleFunc("/api/v1/payments/uid/{uid}/rail", rh.RefreshRailStatus).Methods("GET")
+	router.HandleFunc("/api/v1/payments/uid/{uid}/rail/cancel", rh.RecallPayment).Methods("POST")
+}
+
+func (rh *RailHandler) RefreshRailStatus(w http.ResponseWriter, r *http.Request) {
+
+	payment, errorRail := rh.railService.Refresh(r.Context(), mux.Vars(r)["uid"])
+
+	if errorRail != nil {
+		util.WriteErrorFor(w, errorRail)
+		return
+	}
+
+	util.WritePayload(w, http.StatusOK, paymentHandler.NewPaymentView(payment))
+}
+
+func (rh *RailHandler) RecallPayment(w http.ResponseWriter, r *http.Request) {
+
+	payment, errorRail := rh.railService.Recall(r.Context(), mux.Vars(r)["uid"])
+
+	if errorRail != nil {
+		util.WriteErrorFor(w, errorRail)
+		return
+	}
+
+	util.WritePayload(w, http.StatusOK, paymentHandler.NewPaymentView(payment))
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/base/config"
+	paymentHandler "github.com/javierjmgits/go-payment-api/payment/handler"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/processing/processor"
+	"github.com/javierjmgits/go-payment-api/rail/connector"
+	"github.com/javierjmgits/go-payment-api/rail/service"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+//
+// mocks
+
+type railRepositoryImplMock struct {
+	payment *paymentModel.Payment
+}
+
+func (mock *railRepositoryImplMock) GetByUid(uid string) (*paymentModel.Payment, error) {
+
+	payment := *mock.payment
+
+	return &payment, nil
+}
+
+func (mock *railRepositoryImplMock) Record(paymentUid string, rail string, submission *connector.Submission) error {
+
+	mock.payment.Rail = rail
+	mock.payment.RailReference = &submission.Reference
+	mock.payment.RailStatus = submission.Status
+
+	return nil
+}
+
+func (mock *railRepositoryImplMock) UpdateStatus(rail string, submission *connector.Submission, settle func(*paymentModel.Payment)) (bool, error) {
+
+	if mock.payment.RailStatus != connector.STATUS_PENDING {
+		return false, nil
+	}
+
+	mock.payment.RailStatus = submission.Status
+	mock.payment.RailReason = submission.Reason
+
+	settle(mock.payment)
+
+	return true, nil
+}
+
+//
+// tests
+
+func TestRefreshRailStatusKoNotSubmitted(t *testing.T) {
+
+	router, _, _ := setUp()
+
+	resp := call(router, "GET", "/rail")
+
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestRefreshRailStatus(t *testing.T) {
+
+	router, railService, _ := setUp()
+	errorProcess := railService.Process(context.Background(), &paymentModel.Payment{Uid: "myUid"})
+
+	assert.Equal(t, processor.ErrSubmitted, errorProcess)
+
+	resp := call(router, "GET", "/rail")
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var paymentView paymentHandler.PaymentView
+	json.NewDecoder(resp.Body).Decode(&paymentView)
+
+	assert.Equal(t, connector.SIMULATOR_NAME, paymentView.Rail)
+	assert.Equal(t, connector.STATUS_PENDING, paymentView.RailStatus)
+	assert.NotNil(t, paymentView.RailReference)
+}
+
+func TestRecallPayment(t *testing.T) {
+
+	router, railService, mockRepository := setUp()
+	railService.Process(context.Background(), &paymentModel.Payment{Uid: "myUid"})
+
+	resp := call(router, "POST", "/rail/cancel")
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, connector.STATUS_CANCELLED, mockRepository.payment.RailStatus)
+	assert.Equal(t, paymentModel.PROCESSING_STATUS_FAILED, mockRepository.payment.ProcessingStatus)
+	assert.False(t, mockRepository.payment.Processed)
+
+	resp = call(router, "POST", "/rail/cancel")
+
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestRailStatusSettled(t *testing.T) {
+
+	_, railService, mockRepository := setUp()
+	railService.Process(context.Background(), &paymentModel.Payment{Uid: "myUid"})
+
+	railService.OnStatus(connector.SIMULATOR_NAME, &connector.Submission{Reference: *mockRepository.payment.RailReference, Status: connector.STATUS_SETTLED})
+
+	// verify
+
+	assert.Equal(t, connector.STATUS_SETTLED, mockRepository.payment.RailStatus)
+	assert.Equal(t, paymentModel.PROCESSING_STATUS_SUCCEEDED, mockRepository.payment.ProcessingStatus)
+	assert.True(t, mockRepository.payment.Processed)
+	assert.NotNil(t, mockRepository.payment.ProcessedDate)
+}
+
+func TestRailStatusRejectedAfterAccept(t *testing.T) {
+
+	_, railService, mockRepository := setUp()
+	errorProcess := railService.Process(context.Background(), &paymentModel.Payment{Uid: "myUid"})
+
+	assert.Equal(t, processor.ErrSubmitted, errorProcess)
+	assert.False(t, mockRepository.payment.Processed)
+
+	railService.OnStatus(connector.SIMULATOR_NAME, &connector.Submission{Reference: *mockRepository.payment.RailReference, Status: connector.STATUS_REJECTED, Reason: "account closed"})
+
+	// verify
+
+	assert.Equal(t, connector.STATUS_REJECTED, mockRepository.payment.RailStatus)
+	assert.Equal(t, paymentModel.PROCESSING_STATUS_FAILED, mockRepository.payment.ProcessingStatus)
+	assert.Equal(t, "rejected by "+connector.SIMULATOR_NAME+": account closed", mockRepository.payment.ProcessingError)
+	assert.False(t, mockRepository.payment.Processed)
+
+	// a late settlement can not undo the rejection
+	railService.OnStatus(connector.SIMULATOR_NAME, &connector.Submission{Reference: *mockRepository.payment.RailReference, Status: connector.STATUS_SETTLED})
+
+	assert.False(t, mockRepository.payment.Processed)
+}
+
+//
+// private functions
+
+func setUp() (*mux.Router, *service.RailService, *railRepositoryImplMock) {
+
+	var router = mux.NewRouter()
+	mockRepository := &railRepositoryImplMock{payment: &paymentModel.Payment{Uid: "myUid", Amount: 25}}
+
+	railService := service.NewRailService(mockRepository, connector.NewSimulator(&config.RailConfig{SettlementDelay: time.Hour}))
+
+	NewRailHandler(railService).Register(router)
+
+	return router, railService, mockRepository
+}
+
+func call(router *mux.Router, method string, path string) *http.Response {
+
+	req := httptest.NewRequest(method, "http://localhost:8080/api/v1/payments/uid/myUid"+path, nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	return w.Result()
+}
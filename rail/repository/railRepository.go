@@ -0,0 +1,99 @@
+package repository
+
+import (
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	processingModel "github.com/javierjmgits/go-payment-api/processing/model"
+	"github.com/javierjmgits/go-payment-api/rail/connector"
+	"github.com/jinzhu/gorm"
+)
+
+type RailRepository interface {
+	GetByUid(uid string) (*paymentModel.Payment, error)
+	Record(paymentUid string, rail string, submission *connector.Submission) error
+	UpdateStatus(rail string, submission *connector.Submission, settle func(*paymentModel.Payment)) (bool, error)
+}
+
+type railRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewRailRepositoryImpl(db *gorm.DB) RailRepository {
+	return &railRepositoryImpl{
+		db: db,
+	}
+}
+
+func (rri *railRepositoryImpl) GetByUid(uid string) (*paymentModel.Payment, error) {
+
+	var payment paymentModel.Payment
+	errorFind := rri.db.Where("uid = ?", uid).First(&payment).Error
+
+	if errorFind != nil {
+		return nil, errorFind
+	}
+
+	return &payment, nil
+}
+
+// Record links a payment to its submission on a rail.
+func (rri *railRepositoryImpl) Record(paymentUid string, rail string, submission *connector.Submission) error {
+
+	return rri.db.Model(&paymentModel.Payment{Uid: paymentUid}).
+		Where("uid = ?", paymentUid).
+		Updates(map[string]interface{}{
+			"rail":           rail,
+			"rail_reference": submission.Reference,
+			"rail_status":    submission.Status,
+			"rail_reason":    submission.Reason,
+		}).Error
+}
+
+// UpdateStatus moves a pending submission to its new status, letting settle bring the payment in line, e.g. flag it as
+// processed, and mirrors its processing status on its job; it returns false for unknown or already final submissions.
+func (rri *railRepositoryImpl) UpdateStatus(rail string, submission *connector.Submission, settle func(*paymentModel.Payment)) (bool, error) {
+
+	tx := rri.db.Begin()
+
+	// only pending submissions are locked, so late or repeated callbacks can not undo a final status
+	var payment paymentModel.Payment
+	errorDB := tx.Set("gorm:query_option", "FOR UPDATE").
+		Where("rail = ? AND rail_reference = ? AND rail_status = ?", rail, submission.Reference, connector.STATUS_PENDING).
+		First(&payment).Error
+
+	if gorm.IsRecordNotFoundError(errorDB) {
+		tx.Rollback()
+		return false, nil
+	}
+
+	if errorDB != nil {
+		tx.Rollback()
+		return false, errorDB
+	}
+
+	payment.RailStatus = submission.Status
+	payment.RailReason = submission.Reason
+
+	settle(&payment)
+
+	if errorDB := tx.Save(&payment).Error; errorDB != nil {
+		tx.Rollback()
+		return false, errorDB
+	}
+
+	errorDB = tx.Model(&processingModel.Job{}).
+		Where("payment_uid = ?", payment.Uid).
+		Updates(map[string]interface{}{"status": payment.ProcessingStatus, "last_error": payment.ProcessingError}).Error
+
+	if errorDB != nil {
+		tx.Rollback()
+		return false, errorDB
+	}
+
+	errorDB = tx.Commit().Error
+
+	if errorDB != nil {
+		return false, errorDB
+	}
+
+	return true, nil
+}
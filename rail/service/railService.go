@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/processing/processor"
+	"github.com/javierjmgits/go-payment-api/rail/connector"
+	"github.com/javierjmgits/go-payment-api/rail/repository"
+	"log"
+	"strings"
+	"time"
+)
+
+// RailService is the payment processor that settles payments through rail connectors, chosen by currency.
+type RailService struct {
+	railRepository repository.RailRepository
+	fallback       connector.Connector
+	routes         map[string]connector.Connector
+	connectors     map[string]connector.Connector
+	now            func() time.Time
+}
+
+func NewRailService(railRepository repository.RailRepository, fallback connector.Connector) *RailService {
+
+	return &RailService{
+		railRepository: railRepository,
+		fallback:       fallback,
+		routes:         map[string]connector.Connector{},
+		connectors:     map[string]connector.Connector{fallback.Name(): fallback},
+		now:            time.Now,
+	}
+}
+
+// Route sends the payments in a currency through their own connector instead of the fallback one.
+func (rs *RailService) Route(currency string, railConnector connector.Connector) {
+
+	rs.routes[strings.ToUpper(currency)] = railConnector
+	rs.connectors[railConnector.Name()] = railConnector
+}
+
+// Process submits the payment; once the rail accepted it, the payment stays submitted until the rail reports whether it
+// settled.
+func (rs *RailService) Process(ctx context.Context, payment *paymentModel.Payment) error {
+
+	railConnector := rs.fallback
+
+	if routed, found := rs.routes[payment.Currency]; found {
+		railConnector = routed
+	}
+
+	submission, errorSubmit := railConnector.Submit(ctx, payment)
+
+	if errorSubmit != nil {
+		return errorSubmit
+	}
+
+	// submitting again is harmless, so a submission that could not be recorded is retried
+	errorDB := rs.railRepository.Record(payment.Uid, railConnector.Name(), submission)
+
+	if errorDB != nil {
+		return processor.Transient(errorDB)
+	}
+
+	switch submission.Status {
+
+	case connector.STATUS_SETTLED:
+		return nil
+
+	case connector.STATUS_PENDING:
+		return processor.ErrSubmitted
+	}
+
+	return railError(railConnector.Name(), submission)
+}
+
+// OnStatus is the callback for the status changes the rails report.
+func (rs *RailService) OnStatus(rail string, submission *connector.Submission) {
+
+	updated, errorDB := rs.railRepository.UpdateStatus(rail, submission, rs.settle(rail, submission))
+
+	if errorDB != nil {
+		log.Printf("Error storing status %s of %s submission %s: %v\n", submission.Status, rail, submission.Reference, errorDB)
+		return
+	}
+
+	if !updated {
+		log.Printf("Ignored status %s of unknown or final %s submission %s\n", submission.Status, rail, submission.Reference)
+	}
+}
+
+// Refresh asks the rail for the status of a submitted payment.
+func (rs *RailService) Refresh(ctx context.Context, paymentUid string) (*paymentModel.Payment, error) {
+
+	return rs.call(ctx, paymentUid, func(railConnector connector.Connector, reference string) (*connector.Submission, error) {
+		return railConnector.Status(ctx, reference)
+	})
+}
+
+// Recall cancels a submitted payment on the rail, which is only possible until it settles; the payment then fails.
+func (rs *RailService) Recall(ctx context.Context, paymentUid string) (*paymentModel.Payment, error) {
+
+	return rs.call(ctx, paymentUid, func(railConnector connector.Connector, reference string) (*connector.Submission, error) {
+		return railConnector.Cancel(ctx, reference)
+	})
+}
+
+//
+// private functions
+
+func (rs *RailService) call(ctx context.Context, paymentUid string, request func(connector.Connector, string) (*connector.Submission, error)) (*paymentModel.Payment, error) {
+
+	payment, errorDB := rs.railRepository.GetByUid(paymentUid)
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	if payment.RailReference == nil {
+		return nil, &util.ConflictError{Message: "payment has not been submitted to a rail"}
+	}
+
+	railConnector, found := rs.connectors[payment.Rail]
+
+	if !found {
+		return nil, fmt.Errorf("rail %s is not configured", payment.Rail)
+	}
+
+	submission, errorRail := request(railConnector, *payment.RailReference)
+
+	if errorRail != nil {
+		return nil, errorRail
+	}
+
+	if submission.Status != payment.RailStatus {
+
+		if _, errorDB := rs.railRepository.UpdateStatus(payment.Rail, submission, rs.settle(payment.Rail, submission)); errorDB != nil {
+			return nil, errorDB
+		}
+	}
+
+	return rs.railRepository.GetByUid(paymentUid)
+}
+
+// settle brings a submitted payment in line with the final status of its submission: processed once it settled,
+// failed when the rail rejected or cancelled it.
+func (rs *RailService) settle(rail string, submission *connector.Submission) func(*paymentModel.Payment) {
+
+	return func(payment *paymentModel.Payment) {
+
+		switch submission.Status {
+
+		case connector.STATUS_SETTLED:
+			payment.MarkAsProcessed(rs.now())
+			payment.ProcessingStatus = paymentModel.PROCESSING_STATUS_SUCCEEDED
+			payment.ProcessingError = ""
+
+		case connector.STATUS_REJECTED, connector.STATUS_CANCELLED:
+			payment.ProcessingStatus = paymentModel.PROCESSING_STATUS_FAILED
+			payment.ProcessingError = railError(rail, submission).Error()
+		}
+	}
+}
+
+func railError(rail string, submission *connector.Submission) error {
+
+	if submission.Reason == "" {
+		return fmt.Errorf("%s by %s", strings.ToLower(submission.Status), rail)
+	}
+
+	return fmt.Errorf("%s by %s: %s", strings.ToLower(submission.Status), rail, submission.Reason)
+}
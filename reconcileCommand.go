@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/javierjmgits/go-payment-api/base/config"
+	reconciliationHandler "github.com/javierjmgits/go-payment-api/reconciliation/handler"
+	reconciliationModel "github.com/javierjmgits/go-payment-api/reconciliation/model"
+	reconciliationRepository "github.com/javierjmgits/go-payment-api/reconciliation/repository"
+	reconciliationService "github.com/javierjmgits/go-payment-api/reconciliation/service"
+	"log"
+	"os"
+)
+
+func runReconcileCommand(config *config.Config, args []string) {
+
+	flags := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	format := flags.String("format", reconciliationModel.FORMAT_CAMT053, "format of the file (csv or camt.053)")
+	account := flags.String("account", "", "account of the statement, by default the one in a camt.053 file")
+	toleranceDays := flags.Int("tolerance-days", reconciliationService.DEFAULT_TOLERANCE_DAYS, "days a statement date may differ from the payment's")
+
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: go-payment-api reconcile [-format csv|camt.053] [-account <account>] [-tolerance-days <days>] <file>")
+		flags.PrintDefaults()
+	}
+
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	file, errorOpen := os.Open(flags.Arg(0))
+
+	if errorOpen != nil {
+		log.Fatal("Error opening file: ", errorOpen)
+	}
+
+	defer file.Close()
+
+	configureAccountIdentifiers(config)
+
+	db := openDB(config)
+
+	defer db.Close()
+
+	service := reconciliationService.NewReconciliationService(reconciliationRepository.NewReconciliationRepositoryImpl(db))
+
+	reconciliation, errorReconcile := service.Reconcile(*format, *account, *toleranceDays, file)
+
+	if errorReconcile != nil {
+		log.Fatal("Error reconciling statement: ", errorReconcile)
+	}
+
+	report := reconciliationHandler.NewReconciliationView(reconciliation)
+
+	fmt.Printf("Reconciliation %s: %d line(s), %d matched, %d unmatched line(s), %d unmatched payment(s)\n",
+		report.Uid, report.LineCount, report.MatchedCount, report.UnmatchedLineCount, report.UnmatchedPaymentCount)
+
+	if len(report.UnmatchedLines) > 0 {
+		fmt.Println("Unmatched statement lines:")
+	}
+
+	for _, item := range report.UnmatchedLines {
+
+		if item.Message != "" {
+			fmt.Printf("  line %d: %s\n", item.Line, item.Message)
+			continue
+		}
+
+		fmt.Printf("  line %d: %s %.2f %s -> %s on %s, reference '%s'\n", item.Line, item.Currency, item.Amount,
+			item.AccountOrigin, item.AccountTarget, item.Date.Format("2006-01-02"), item.Reference)
+	}
+
+	if len(report.UnmatchedPayments) > 0 {
+		fmt.Println("Unmatched payments:")
+	}
+
+	for _, item := range report.UnmatchedPayments {
+		fmt.Printf("  %s: %s %.2f %s -> %s on %s, reference '%s'\n", *item.PaymentUid, item.Currency, item.Amount,
+			item.AccountOrigin, item.AccountTarget, item.Date.Format("2006-01-02"), item.Reference)
+	}
+}
@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/account/identifier"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/reconciliation/model"
+	"github.com/javierjmgits/go-payment-api/reconciliation/repository"
+	"github.com/javierjmgits/go-payment-api/reconciliation/service"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ReconciliationHandler struct {
+	reconciliationRepository repository.ReconciliationRepository
+	reconciliationService    *service.ReconciliationService
+}
+
+type ReconciliationView struct {
+	Uid                   string                   `json:"uid"`
+	Format                string                   `json:"format"`
+	Account               string                   `json:"account,omitempty"`
+	ToleranceDays         int                      `json:"toleranceDays"`
+	PeriodFrom            *time.Time               `json:"periodFrom,omitempty"`
+	PeriodTo              *time.Time               `json:"periodTo,omitempty"`
+	LineCount             int                      `json:"lineCount"`
+	MatchedCount          int                      `json:"matchedCount"`
+	UnmatchedLineCount    int                      `json:"unmatchedLineCount"`
+	UnmatchedPaymentCount int                      `json:"unmatchedPaymentCount"`
+	Matched               []ReconciliationItemView `json:"matched,omitempty"`
+	UnmatchedLines        []ReconciliationItemView `json:"unmatchedLines,omitempty"`
+	UnmatchedPayments     []ReconciliationItemView `json:"unmatchedPayments,omitempty"`
+}
+
+type ReconciliationItemView struct {
+	Line          int        `json:"line,omitempty"`
+	Reference     string     `json:"reference,omitempty"`
+	Amount        float64    `json:"amount"`
+	Currency      string     `json:"currency,omitempty"`
+	Date          *time.Time `json:"date,omitempty"`
+	AccountOrigin string     `json:"accountOrigin,omitempty"`
+	AccountTarget string     `json:"accountTarget,omitempty"`
+	PaymentUid    *string    `json:"paymentUid,omitempty"`
+	MatchedBy     string     `json:"matchedBy,omitempty"`
+	Message       string     `json:"message,omitempty"`
+}
+
+func NewReconciliationHandler(reconciliationRepository repository.ReconciliationRepository) *ReconciliationHandler {
+
+	return &ReconciliationHandler{
+		reconciliationRepository: reconciliationRepository,
+		reconciliationService:    service.NewReconciliationService(reconciliationRepository),
+	}
+}
+
+func (rh *ReconciliationHandler) Register(router *mux.Router) {
+	router.HandleFunc("/api/v1/reconciliations", rh.GetReconciliations).Methods("GET")
+	router.HandleFunc("/api/v1/reconciliations/uid/{uid}", rh.GetReconciliationByUid).Methods("GET")
+	router.HandleFunc("/api/v1/reconciliations", rh.Reconcile).Methods("POST")
+}
+
+func (rh *ReconciliationHandler) GetReconciliations(w http.ResponseWriter, r *http.Request) {
+
+	reconciliations, errorDB := rh.reconciliationRepository.GetAll()
+
+	if errorDB != nil {
+		util.WriteError(w, http.StatusInternalServerError, errorDB.Error())
+		return
+	}
+
+	var results []ReconciliationView
+
+	for index := range reconciliations {
+		results = append(results, *NewReconciliationView(&reconciliations[index]))
+	}
+
+	util.WritePayload(w, http.StatusOK, results)
+}
+
+func (rh *ReconciliationHandler) GetReconciliationByUid(w http.ResponseWriter, r *http.Request) {
+
+	reconciliation, errorDB := rh.reconciliationRepository.GetByUid(mux.Vars(r)["uid"])
+
+	if errorDB != nil {
+		util.WriteErrorFor(w, errorDB)
+		return
+	}
+
+	util.WritePayload(w, http.StatusOK, NewReconciliationView(reconciliation))
+}
+
+// Reconcile takes a statement as the body, e.g. POST /api/v1/reconciliations?format=camt.053&toleranceDays=2
+func (rh *ReconciliationHandler) Reconcile(w http.ResponseWriter, r *http.Request) {
+
+	query := r.URL.Query()
+	toleranceDays := service.DEFAULT_TOLERANCE_DAYS
+
+	if value := query.Get("toleranceDays"); value != "" {
+
+		parsed, errorParse := strconv.Atoi(value)
+
+		if errorParse != nil {
+			util.WriteError(w, http.StatusBadRequest, fmt.Sprintf("invalid toleranceDays '%s'", value))
+			return
+		}
+
+		toleranceDays = parsed
+	}
+
+	reconciliation, errorReconcile := rh.reconciliationService.Reconcile(getFormat(r), query.Get("account"), toleranceDays, r.Body)
+
+	if errorReconcile != nil {
+		util.WriteErrorFor(w, errorReconcile)
+		return
+	}
+
+	util.WritePayload(w, http.StatusCreated, NewReconciliationView(reconciliation))
+}
+
+//
+// shared functions
+
+func NewReconciliationView(reconciliation *model.Reconciliation) *ReconciliationView {
+
+	view := &ReconciliationView{
+		Uid:                   reconciliation.Uid,
+		Format:                reconciliation.Format,
+		Account:               identifier.Format(reconciliation.Account),
+		ToleranceDays:         reconciliation.ToleranceDays,
+		PeriodFrom:            reconciliation.PeriodFrom,
+		PeriodTo:              reconciliation.PeriodTo,
+		LineCount:             reconciliation.LineCount,
+		MatchedCount:          reconciliation.MatchedCount,
+		UnmatchedLineCount:    reconciliation.UnmatchedLineCount,
+		UnmatchedPaymentCount: reconciliation.UnmatchedPaymentCount,
+	}
+
+	for _, item := range reconciliation.Items {
+
+		itemView := newReconciliationItemView(&item)
+
+		switch {
+
+		case item.Side == model.SIDE_PAYMENT:
+			view.UnmatchedPayments = append(view.UnmatchedPayments, itemView)
+
+		case item.PaymentUid != nil:
+			view.Matched = append(view.Matched, itemView)
+
+		default:
+			view.UnmatchedLines = append(view.UnmatchedLines, itemView)
+		}
+	}
+
+	return view
+}
+
+//
+// private functions
+
+func getFormat(r *http.Request) string {
+
+	format := r.URL.Query().Get("format")
+
+	if format != "" {
+		return format
+	}
+
+	contentType := r.Header.Get("Content-Type")
+
+	if strings.Contains(contentType, "csv") {
+		return model.FORMAT_CSV
+	}
+
+	if strings.Contains(contentType, "xml") {
+		return model.FORMAT_CAMT053
+	}
+
+	return ""
+}
+
+func newReconciliationItemView(item *model.ReconciliationItem) ReconciliationItemView {
+
+	return ReconciliationItemView{
+		Line:          item.Line,
+		Reference:     item.Reference,
+		Amount:        item.Amount,
+		Currency:      item.Currency,
+		Date:          item.Date,
+		AccountOrigin: identifier.Format(item.AccountOrigin),
+		AccountTarget: identifier.Format(item.AccountTarget),
+		PaymentUid:    item.PaymentUid,
+		MatchedBy:     item.MatchedBy,
+		Message:       item.Message,
+	}
+}
@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/reconciliation/model"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+//
+// mock data
+
+var processedDate = time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+
+const camt053Statement = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+  <BkToCstmrStmt>
+    <GrpHdr><MsgId>statement</MsgId></GrpHdr>
+    <Stmt>
+      <Id>statement</Id>
+      <Acct><Id><Othr><Id>60-16-13 31926819</Id></Othr></Id></Acct>
+      <Ntry>
+        <Amt Ccy="EUR">25.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><Sts>BOOK</Sts>
+        <BookgDt><Dt>2026-03-03</Dt></BookgDt><ValDt><Dt>2026-03-03</Dt></ValDt>
+        <NtryDtls><TxDtls><Refs><EndToEndId>INV-1</EndToEndId></Refs></TxDtls></NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">40.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><Sts>BOOK</Sts>
+        <BookgDt><Dt>2026-03-02</Dt></BookgDt><ValDt><Dt>2026-03-02</Dt></ValDt>
+        <NtryDtls><TxDtls><Refs><EndToEndId>NOTPROVIDED</EndToEndId></Refs></TxDtls></NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">10.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><Sts>PDNG</Sts>
+        <ValDt><Dt>2026-03-02</Dt></ValDt>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>`
+
+//
+// mocks
+
+type reconciliationRepositoryImplMock struct {
+	payments       []paymentModel.Payment
+	account        string
+	reconciliation *model.Reconciliation
+}
+
+func (mock *reconciliationRepositoryImplMock) GetAll() ([]model.Reconciliation, error) {
+	return []model.Reconciliation{*mock.reconciliation}, nil
+}
+
+func (mock *reconciliationRepositoryImplMock) GetByUid(uid string) (*model.Reconciliation, error) {
+	return mock.reconciliation, nil
+}
+
+func (mock *reconciliationRepositoryImplMock) GetUnreconciled(account string, from time.Time, to time.Time) ([]paymentModel.Payment, error) {
+
+	mock.account = account
+
+	return mock.payments, nil
+}
+
+func (mock *reconciliationRepositoryImplMock) Create(reconciliation *model.Reconciliation, now time.Time) (*model.Reconciliation, error) {
+
+	mock.reconciliation = reconciliation
+
+	return reconciliation, nil
+}
+
+//
+// tests
+
+func TestReconcileCamt053(t *testing.T) {
+
+	router, mockRepository := setUp()
+
+	resp := postStatement(router, "format=camt.053", camt053Statement)
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "60-16-13 31926819", mockRepository.account)
+
+	var view ReconciliationView
+	json.NewDecoder(resp.Body).Decode(&view)
+
+	assert.Equal(t, 2, view.LineCount)
+	assert.Equal(t, 1, view.MatchedCount)
+	assert.Equal(t, 1, view.UnmatchedLineCount)
+	assert.Equal(t, 1, view.UnmatchedPaymentCount)
+	assert.Equal(t, "matched", *view.Matched[0].PaymentUid)
+	assert.Equal(t, model.MATCHED_BY_REFERENCE, view.Matched[0].MatchedBy)
+	assert.Equal(t, 40.0, view.UnmatchedLines[0].Amount)
+	assert.Equal(t, "unmatched", *view.UnmatchedPayments[0].PaymentUid)
+}
+
+func TestReconcileCsv(t *testing.T) {
+
+	router, _ := setUp()
+
+	statement := "date,amount,reference,accountOrigin\n" +
+		"2026-03-01,-25.00,,60-16-13 31926819\n" +
+		"2026-03-02,not a number,,\n"
+
+	resp := postStatement(router, "format=csv&toleranceDays=1", statement)
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var view ReconciliationView
+	json.NewDecoder(resp.Body).Decode(&view)
+
+	assert.Equal(t, 1, view.MatchedCount)
+	assert.Equal(t, model.MATCHED_BY_DETAILS, view.Matched[0].MatchedBy)
+	assert.Equal(t, "invalid amount 'not a number'", view.UnmatchedLines[0].Message)
+	assert.Equal(t, 3, view.UnmatchedLines[0].Line)
+}
+
+func TestReconcileKoUnsupportedFormat(t *testing.T) {
+
+	router, _ := setUp()
+
+	resp := postStatement(router, "format=mt940", "")
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestReconcileKoInvalidDocument(t *testing.T) {
+
+	router, _ := setUp()
+
+	resp := postStatement(router, "format=camt.053", "<Document></Document>")
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+//
+// private functions
+
+func setUp() (*mux.Router, *reconciliationRepositoryImplMock) {
+
+	var router = mux.NewRouter()
+	mockRepository := &reconciliationRepositoryImplMock{
+		payments: []paymentModel.Payment{
+			{Uid: "matched", Reference: "INV-1", AccountOrigin: "60-16-13 31926819", AccountTarget: "20-00-00 55779911",
+				Amount: 25, Currency: "EUR", TargetAmount: 25, TargetCurrency: "EUR", Processed: true, ProcessedDate: &processedDate},
+			{Uid: "unmatched", AccountOrigin: "60-16-13 31926819", AccountTarget: "20-00-00 55779911",
+				Amount: 30, Currency: "EUR", TargetAmount: 30, TargetCurrency: "EUR", Processed: true, ProcessedDate: &processedDate},
+		},
+	}
+
+	NewReconciliationHandler(mockRepository).Register(router)
+
+	return router, mockRepository
+}
+
+func postStatement(router *mux.Router, query string, statement string) *http.Response {
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/reconciliations?"+query, strings.NewReader(statement))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	return w.Result()
+}
@@ -0,0 +1,159 @@
+package matcher
+
+import (
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/reconciliation/model"
+	"github.com/javierjmgits/go-payment-api/reconciliation/parser"
+	"math"
+	"time"
+)
+
+// amounts are stored as floats, anything below a cent is the same amount
+const AMOUNT_EPSILON = 0.005
+
+type Pair struct {
+	Entry     parser.Entry
+	Payment   paymentModel.Payment
+	MatchedBy string
+}
+
+type Result struct {
+	Matches           []Pair
+	UnmatchedEntries  []parser.Entry
+	UnmatchedPayments []paymentModel.Payment
+}
+
+// Match pairs every entry with at most one payment. Entries are first matched by reference, then the
+// rest by amount, accounts and date; among several candidates the closest date wins. The amount must
+// always agree and the date be within the tolerance.
+func Match(entries []parser.Entry, payments []paymentModel.Payment, tolerance time.Duration) *Result {
+
+	result := &Result{}
+	matched := make([]bool, len(payments))
+	matches := make([]*Pair, len(entries))
+
+	for _, byReference := range []bool{true, false} {
+
+		for index, entry := range entries {
+
+			if entry.Error != nil || matches[index] != nil || (byReference && entry.Reference == "") {
+				continue
+			}
+
+			best := -1
+			var bestDistance time.Duration
+
+			for candidate := range payments {
+
+				if matched[candidate] {
+					continue
+				}
+
+				distance, ok := compare(entry, &payments[candidate], byReference, tolerance)
+
+				if ok && (best < 0 || distance < bestDistance) {
+					best = candidate
+					bestDistance = distance
+				}
+			}
+
+			if best < 0 {
+				continue
+			}
+
+			matched[best] = true
+			matches[index] = &Pair{Entry: entry, Payment: payments[best], MatchedBy: model.MATCHED_BY_DETAILS}
+
+			if byReference {
+				matches[index].MatchedBy = model.MATCHED_BY_REFERENCE
+			}
+		}
+	}
+
+	for index, entry := range entries {
+
+		if matches[index] != nil {
+			result.Matches = append(result.Matches, *matches[index])
+
+		} else {
+			result.UnmatchedEntries = append(result.UnmatchedEntries, entry)
+		}
+	}
+
+	for index, payment := range payments {
+
+		if !matched[index] {
+			result.UnmatchedPayments = append(result.UnmatchedPayments, payment)
+		}
+	}
+
+	return result
+}
+
+// SettlementDate is the day a payment is expected on a statement.
+func SettlementDate(payment *paymentModel.Payment) time.Time {
+
+	if payment.ProcessedDate != nil {
+		return *payment.ProcessedDate
+	}
+
+	return payment.Date
+}
+
+//
+// private functions
+
+// compare tells whether the payment can be the entry and how far apart their dates are
+func compare(entry parser.Entry, payment *paymentModel.Payment, byReference bool, tolerance time.Duration) (time.Duration, bool) {
+
+	if byReference {
+
+		if entry.Reference != payment.Reference && entry.Reference != payment.Uid {
+			return 0, false
+		}
+
+	} else if entry.Reference != "" && payment.Reference != "" {
+		// both sides have a reference and they differ, so it is another payment
+		return 0, false
+	}
+
+	if !amountMatches(entry, payment) {
+		return 0, false
+	}
+
+	if (entry.AccountOrigin != "" && entry.AccountOrigin != payment.AccountOrigin) ||
+		(entry.AccountTarget != "" && entry.AccountTarget != payment.AccountTarget) {
+		return 0, false
+	}
+
+	distance := day(entry.Date).Sub(day(SettlementDate(payment)))
+
+	if distance < 0 {
+		distance = -distance
+	}
+
+	return distance, distance <= tolerance
+}
+
+// the origin is debited the amount and the target credited the converted amount
+func amountMatches(entry parser.Entry, payment *paymentModel.Payment) bool {
+
+	debit := math.Abs(entry.Amount-payment.Amount) < AMOUNT_EPSILON && (entry.Currency == "" || entry.Currency == payment.Currency)
+	credit := math.Abs(entry.Amount-payment.TargetAmount) < AMOUNT_EPSILON && (entry.Currency == "" || entry.Currency == payment.TargetCurrency)
+
+	switch entry.CreditDebit {
+
+	case parser.DEBIT:
+		return debit
+
+	case parser.CREDIT:
+		return credit
+	}
+
+	return debit || credit
+}
+
+func day(date time.Time) time.Time {
+
+	return date.UTC().Truncate(24 * time.Hour)
+}
@@ -0,0 +1,135 @@
+package matcher
+
+import (
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/reconciliation/model"
+	"github.com/javierjmgits/go-payment-api/reconciliation/parser"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+//
+// tests
+
+func TestMatchByReference(t *testing.T) {
+
+	payments := []paymentModel.Payment{
+		payment("first", "INV-1", 100, date(1)),
+		payment("second", "INV-2", 100, date(1)),
+	}
+
+	result := Match([]parser.Entry{{Reference: "INV-2", Amount: 100, Date: date(2)}}, payments, 48*time.Hour)
+
+	assert.Len(t, result.Matches, 1)
+	assert.Equal(t, "second", result.Matches[0].Payment.Uid)
+	assert.Equal(t, model.MATCHED_BY_REFERENCE, result.Matches[0].MatchedBy)
+	assert.Equal(t, "first", result.UnmatchedPayments[0].Uid)
+}
+
+func TestMatchByUidAsReference(t *testing.T) {
+
+	result := Match([]parser.Entry{{Reference: "first", Amount: 100, Date: date(1)}}, []paymentModel.Payment{payment("first", "", 100, date(1))}, 0)
+
+	assert.Len(t, result.Matches, 1)
+	assert.Equal(t, model.MATCHED_BY_REFERENCE, result.Matches[0].MatchedBy)
+}
+
+func TestMatchByDetailsPicksClosestDate(t *testing.T) {
+
+	payments := []paymentModel.Payment{
+		payment("early", "", 100, date(1)),
+		payment("close", "", 100, date(4)),
+		payment("other", "", 100, date(5)),
+	}
+	payments[2].AccountTarget = "other account"
+
+	result := Match([]parser.Entry{{Amount: 100, Date: date(5), AccountTarget: "target"}}, payments, 48*time.Hour)
+
+	assert.Len(t, result.Matches, 1)
+	assert.Equal(t, "close", result.Matches[0].Payment.Uid)
+	assert.Equal(t, model.MATCHED_BY_DETAILS, result.Matches[0].MatchedBy)
+}
+
+func TestMatchReferencesFirst(t *testing.T) {
+
+	// the entry without reference must not take the payment the referenced entry names
+	entries := []parser.Entry{
+		{Line: 1, Amount: 100, Date: date(1)},
+		{Line: 2, Reference: "INV-1", Amount: 100, Date: date(1)},
+	}
+	payments := []paymentModel.Payment{
+		payment("first", "INV-1", 100, date(1)),
+		payment("second", "", 100, date(1)),
+	}
+
+	result := Match(entries, payments, 0)
+
+	assert.Len(t, result.Matches, 2)
+	assert.Equal(t, "first", result.Matches[1].Payment.Uid)
+	assert.Equal(t, 2, result.Matches[1].Entry.Line)
+	assert.Equal(t, "second", result.Matches[0].Payment.Uid)
+}
+
+func TestMatchKoMismatches(t *testing.T) {
+
+	payments := []paymentModel.Payment{payment("first", "INV-1", 100, date(1))}
+
+	for name, entry := range map[string]parser.Entry{
+		"amount":          {Amount: 99.99, Date: date(1)},
+		"date":            {Amount: 100, Date: date(4)},
+		"other reference": {Reference: "INV-9", Amount: 100, Date: date(1)},
+		"account":         {Amount: 100, Date: date(1), AccountOrigin: "other account"},
+		"currency":        {Amount: 100, Currency: "USD", Date: date(1)},
+	} {
+
+		result := Match([]parser.Entry{entry}, payments, 48*time.Hour)
+
+		assert.Empty(t, result.Matches, name)
+		assert.Len(t, result.UnmatchedEntries, 1, name)
+		assert.Len(t, result.UnmatchedPayments, 1, name)
+	}
+}
+
+func TestMatchConvertedAmount(t *testing.T) {
+
+	converted := payment("first", "", 100, date(1))
+	converted.TargetCurrency = "USD"
+	converted.TargetAmount = 108.42
+
+	result := Match([]parser.Entry{{Amount: 108.42, Currency: "USD", CreditDebit: parser.CREDIT, Date: date(1)}}, []paymentModel.Payment{converted}, 0)
+
+	assert.Len(t, result.Matches, 1)
+
+	// the origin is debited the amount before conversion
+	result = Match([]parser.Entry{{Amount: 100, CreditDebit: parser.CREDIT, Date: date(1)}}, []paymentModel.Payment{converted}, 0)
+
+	assert.Empty(t, result.Matches)
+
+	result = Match([]parser.Entry{{Amount: 100, CreditDebit: parser.DEBIT, Date: date(1)}}, []paymentModel.Payment{converted}, 0)
+
+	assert.Len(t, result.Matches, 1)
+}
+
+//
+// private functions
+
+func date(dayOfMonth int) time.Time {
+	return time.Date(2026, 3, dayOfMonth, 10, 0, 0, 0, time.UTC)
+}
+
+func payment(uid string, reference string, amount float64, processedDate time.Time) paymentModel.Payment {
+
+	return paymentModel.Payment{
+		Uid:            uid,
+		AccountOrigin:  "origin",
+		AccountTarget:  "target",
+		Amount:         amount,
+		Currency:       "EUR",
+		TargetCurrency: "EUR",
+		TargetAmount:   amount,
+		Processed:      true,
+		ProcessedDate:  &processedDate,
+		Reference:      reference,
+	}
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+const (
+	FORMAT_CSV     = "csv"
+	FORMAT_CAMT053 = "camt.053"
+
+	// a statement item is a line of the file, a payment item a payment the file should have had
+	SIDE_STATEMENT = "STATEMENT"
+	SIDE_PAYMENT   = "PAYMENT"
+
+	MATCHED_BY_REFERENCE = "REFERENCE"
+	MATCHED_BY_DETAILS   = "AMOUNT_DATE_ACCOUNTS"
+)
+
+type Reconciliation struct {
+	gorm.Model
+	Uid                   string               `gorm:"unique;not null"`
+	Format                string               `gorm:"not null"`
+	Account               string               `gorm:"not null;default:''"`
+	ToleranceDays         int                  `gorm:"not null"`
+	PeriodFrom            *time.Time           `gorm:"null"`
+	PeriodTo              *time.Time           `gorm:"null"`
+	LineCount             int                  `gorm:"not null"`
+	MatchedCount          int                  `gorm:"not null"`
+	UnmatchedLineCount    int                  `gorm:"not null"`
+	UnmatchedPaymentCount int                  `gorm:"not null"`
+	Items                 []ReconciliationItem `gorm:"foreignkey:ReconciliationID"`
+}
+
+type ReconciliationItem struct {
+	gorm.Model
+	ReconciliationID uint       `gorm:"not null;index"`
+	Side             string     `gorm:"not null"`
+	Line             int        `gorm:"not null;default:0"`
+	Reference        string     `gorm:"not null;default:''"`
+	Amount           float64    `gorm:"not null;default:0"`
+	Currency         string     `gorm:"not null;default:''"`
+	Date             *time.Time `gorm:"null"`
+	AccountOrigin    string     `gorm:"not null;default:''"`
+	AccountTarget    string     `gorm:"not null;default:''"`
+	PaymentUid       *string    `gorm:"null;index"`
+	MatchedBy        string     `gorm:"not null;default:''"`
+	Message          string     `gorm:"type:text"`
+}
+
+func SetUp(db *gorm.DB) *gorm.DB {
+
+	db.AutoMigrate(&Reconciliation{}, &ReconciliationItem{})
+
+	return db
+}
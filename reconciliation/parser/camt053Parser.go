@@ -0,0 +1,150 @@
+package parser
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type camt053Parser struct {
+}
+
+type camt053Account struct {
+	IBAN  string `xml:"Id>IBAN"`
+	Other string `xml:"Id>Othr>Id"`
+}
+
+type camt053Entry struct {
+	Amount struct {
+		Currency string `xml:"Ccy,attr"`
+		Value    string `xml:",chardata"`
+	} `xml:"Amt"`
+	CreditDebit     string         `xml:"CdtDbtInd"`
+	Status          string         `xml:"Sts"`
+	BookingDate     string         `xml:"BookgDt>Dt"`
+	ValueDate       string         `xml:"ValDt>Dt"`
+	EndToEndId      string         `xml:"NtryDtls>TxDtls>Refs>EndToEndId"`
+	DebtorAccount   camt053Account `xml:"NtryDtls>TxDtls>RltdPties>DbtrAcct"`
+	CreditorAccount camt053Account `xml:"NtryDtls>TxDtls>RltdPties>CdtrAcct"`
+}
+
+// Parse reads the booked entries of bank to customer statements, including the ones this service exports.
+func (cp *camt053Parser) Parse(reader io.Reader) (*Statement, error) {
+
+	decoder := xml.NewDecoder(reader)
+
+	statement := &Statement{}
+	documentFound := false
+
+	for {
+
+		token, errorToken := decoder.Token()
+
+		if errorToken == io.EOF {
+			break
+		}
+
+		if errorToken != nil {
+			return nil, fmt.Errorf("invalid camt.053 document: %v", errorToken)
+		}
+
+		start, isStart := token.(xml.StartElement)
+
+		if !isStart {
+			continue
+		}
+
+		switch start.Name.Local {
+
+		case "BkToCstmrStmt":
+			documentFound = true
+
+		case "Acct":
+			var account camt053Account
+
+			if errorDecode := decoder.DecodeElement(&account, &start); errorDecode != nil {
+				return nil, fmt.Errorf("invalid camt.053 document: %v", errorDecode)
+			}
+
+			statement.Account = normalizeAccount(account.identifier())
+
+		case "Ntry":
+			line, _ := decoder.InputPos()
+
+			var entry camt053Entry
+
+			if errorDecode := decoder.DecodeElement(&entry, &start); errorDecode != nil {
+				return nil, fmt.Errorf("invalid camt.053 document: %v", errorDecode)
+			}
+
+			// pending entries are not settled yet, they are reconciled with the statement that books them
+			if strings.TrimSpace(entry.Status) == "PDNG" {
+				continue
+			}
+
+			parsed := newEntryFromCamt053(entry)
+			parsed.Line = line
+
+			statement.Entries = append(statement.Entries, parsed)
+		}
+	}
+
+	if !documentFound {
+		return nil, errors.New("invalid camt.053 document: BkToCstmrStmt not found")
+	}
+
+	return statement, nil
+}
+
+//
+// private functions
+
+func newEntryFromCamt053(entry camt053Entry) Entry {
+
+	amount, errorAmount := strconv.ParseFloat(strings.TrimSpace(entry.Amount.Value), 64)
+
+	if errorAmount != nil {
+		return Entry{Error: fmt.Errorf("invalid amount '%s'", entry.Amount.Value)}
+	}
+
+	dateValue := entry.BookingDate
+
+	if dateValue == "" {
+		dateValue = entry.ValueDate
+	}
+
+	date, errorDate := util.ParseDate(dateValue)
+
+	if errorDate != nil {
+		return Entry{Error: errorDate}
+	}
+
+	reference := strings.TrimSpace(entry.EndToEndId)
+
+	if reference == "NOTPROVIDED" {
+		reference = ""
+	}
+
+	return Entry{
+		Reference:     reference,
+		Amount:        amount,
+		Currency:      strings.ToUpper(strings.TrimSpace(entry.Amount.Currency)),
+		CreditDebit:   strings.TrimSpace(entry.CreditDebit),
+		Date:          date,
+		AccountOrigin: normalizeAccount(entry.DebtorAccount.identifier()),
+		AccountTarget: normalizeAccount(entry.CreditorAccount.identifier()),
+	}
+}
+
+func (ca camt053Account) identifier() string {
+
+	if ca.IBAN != "" {
+		return strings.TrimSpace(ca.IBAN)
+	}
+
+	return strings.TrimSpace(ca.Other)
+}
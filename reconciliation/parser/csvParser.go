@@ -0,0 +1,76 @@
+package parser
+
+import (
+	"fmt"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	CSV_COLUMN_AMOUNT = "amount"
+	CSV_COLUMN_DATE   = "date"
+
+	// optional columns
+	CSV_COLUMN_REFERENCE      = "reference"
+	CSV_COLUMN_CURRENCY       = "currency"
+	CSV_COLUMN_ACCOUNT_ORIGIN = "accountOrigin"
+	CSV_COLUMN_ACCOUNT_TARGET = "accountTarget"
+)
+
+type csvParser struct {
+}
+
+// Parse reads settlement files with one transaction per line; the sign of the amount is ignored.
+func (cp *csvParser) Parse(reader io.Reader) (*Statement, error) {
+
+	statement := &Statement{}
+
+	errorRead := util.ReadCSV(reader, []string{CSV_COLUMN_AMOUNT, CSV_COLUMN_DATE}, func(row *util.CSVRow) {
+
+		if row.Error != nil {
+			statement.Entries = append(statement.Entries, Entry{Line: row.Line, Error: row.Error})
+			return
+		}
+
+		entry := newEntryFromCSV(row)
+		entry.Line = row.Line
+
+		statement.Entries = append(statement.Entries, entry)
+	})
+
+	if errorRead != nil {
+		return nil, errorRead
+	}
+
+	return statement, nil
+}
+
+//
+// private functions
+
+func newEntryFromCSV(row *util.CSVRow) Entry {
+
+	amount, errorAmount := strconv.ParseFloat(row.Value(CSV_COLUMN_AMOUNT), 64)
+
+	if errorAmount != nil {
+		return Entry{Error: fmt.Errorf("invalid amount '%s'", row.Value(CSV_COLUMN_AMOUNT))}
+	}
+
+	date, errorDate := util.ParseDate(row.Value(CSV_COLUMN_DATE))
+
+	if errorDate != nil {
+		return Entry{Error: errorDate}
+	}
+
+	return Entry{
+		Reference:     row.Value(CSV_COLUMN_REFERENCE),
+		Amount:        math.Abs(amount),
+		Currency:      strings.ToUpper(row.Value(CSV_COLUMN_CURRENCY)),
+		Date:          date,
+		AccountOrigin: normalizeAccount(row.Value(CSV_COLUMN_ACCOUNT_ORIGIN)),
+		AccountTarget: normalizeAccount(row.Value(CSV_COLUMN_ACCOUNT_TARGET)),
+	}
+}
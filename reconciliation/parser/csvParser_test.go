@@ -0,0 +1,52 @@
+package parser
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+)
+
+//
+// mocks
+
+type failingReader struct {
+}
+
+func (fr *failingReader) Read(p []byte) (int, error) {
+
+	return 0, errors.New("connection reset")
+}
+
+//
+// tests
+
+func TestCsvParseKoQuoteInFirstField(t *testing.T) {
+
+	statement, errorParse := (&csvParser{}).Parse(strings.NewReader("reference,amount,date\n" +
+		"\"a\"b,1,2026-01-01\n" +
+		"E2E-1,-25,2026-01-15\n"))
+
+	// verify
+
+	assert.Nil(t, errorParse)
+	assert.Len(t, statement.Entries, 2)
+	assert.Equal(t, 2, statement.Entries[0].Line)
+	assert.NotNil(t, statement.Entries[0].Error)
+	assert.Equal(t, 3, statement.Entries[1].Line)
+	assert.Equal(t, "E2E-1", statement.Entries[1].Reference)
+	assert.Equal(t, 25.0, statement.Entries[1].Amount)
+}
+
+func TestCsvParseKoReadFailure(t *testing.T) {
+
+	reader := io.MultiReader(strings.NewReader("reference,amount,date\nE2E-1,25,2026-01-15\n"), &failingReader{})
+
+	statement, errorParse := (&csvParser{}).Parse(reader)
+
+	// verify
+
+	assert.EqualError(t, errorParse, "connection reset")
+	assert.Nil(t, statement)
+}
@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"fmt"
+	"github.com/javierjmgits/go-payment-api/account/identifier"
+	"github.com/javierjmgits/go-payment-api/reconciliation/model"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	CREDIT = "CRDT"
+	DEBIT  = "DBIT"
+)
+
+// Entry is a settled transaction of a statement; empty fields are not known and match anything.
+type Entry struct {
+	Line          int
+	Reference     string
+	Amount        float64
+	Currency      string
+	CreditDebit   string
+	Date          time.Time
+	AccountOrigin string
+	AccountTarget string
+	Error         error
+}
+
+type Statement struct {
+	Account string
+	Entries []Entry
+}
+
+type Parser interface {
+	Parse(reader io.Reader) (*Statement, error)
+}
+
+func NewParser(format string) (Parser, error) {
+
+	switch strings.ToLower(format) {
+
+	case model.FORMAT_CSV:
+		return &csvParser{}, nil
+
+	case model.FORMAT_CAMT053:
+		return &camt053Parser{}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported format '%s'", format)
+	}
+}
+
+//
+// private functions
+
+// accounts are compared in the form payments store them; a bank may use a scheme we do not accept, that one is kept as is
+func normalizeAccount(value string) string {
+
+	value = strings.TrimSpace(value)
+
+	if value == "" {
+		return ""
+	}
+
+	if normalized, errorNormalize := identifier.Normalize(value); errorNormalize == nil {
+		return normalized
+	}
+
+	return value
+}
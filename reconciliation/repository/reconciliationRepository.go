@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"fmt"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/reconciliation/model"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+type ReconciliationRepository interface {
+	GetAll() ([]model.Reconciliation, error)
+	GetByUid(uid string) (*model.Reconciliation, error)
+	GetUnreconciled(account string, from time.Time, to time.Time) ([]paymentModel.Payment, error)
+	Create(reconciliation *model.Reconciliation, now time.Time) (*model.Reconciliation, error)
+}
+
+type reconciliationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewReconciliationRepositoryImpl(db *gorm.DB) ReconciliationRepository {
+	return &reconciliationRepositoryImpl{
+		db: db,
+	}
+}
+
+func (rri *reconciliationRepositoryImpl) GetAll() ([]model.Reconciliation, error) {
+
+	var reconciliations []model.Reconciliation
+	errorDB := rri.db.Order("id").Find(&reconciliations).Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return reconciliations, nil
+}
+
+func (rri *reconciliationRepositoryImpl) GetByUid(uid string) (*model.Reconciliation, error) {
+
+	var reconciliation model.Reconciliation
+	errorFind := rri.db.Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("side DESC, line, id") }).
+		Where("uid = ?", uid).First(&reconciliation).Error
+
+	if errorFind != nil {
+		return nil, errorFind
+	}
+
+	return &reconciliation, nil
+}
+
+// GetUnreconciled returns the processed payments not reconciled yet that settled in the period, optionally of an account.
+func (rri *reconciliationRepositoryImpl) GetUnreconciled(account string, from time.Time, to time.Time) ([]paymentModel.Payment, error) {
+
+	query := rri.db.Where("processed = ? AND reconciled_at IS NULL", true).
+		Where("COALESCE(processed_date, date) >= ? AND COALESCE(processed_date, date) < ?", from, to)
+
+	if account != "" {
+		query = query.Where("account_origin = ? OR account_target = ?", account, account)
+	}
+
+	var payments []paymentModel.Payment
+	errorDB := query.Order("COALESCE(processed_date, date), id").Find(&payments).Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return payments, nil
+}
+
+// Create stores the reconciliation and marks the payments of its matched items as reconciled.
+func (rri *reconciliationRepositoryImpl) Create(reconciliation *model.Reconciliation, now time.Time) (*model.Reconciliation, error) {
+
+	tx := rri.db.Begin()
+
+	if errorDB := tx.Create(reconciliation).Error; errorDB != nil {
+		tx.Rollback()
+		return nil, errorDB
+	}
+
+	reconciledAt := now.UTC().Truncate(time.Second)
+
+	for _, item := range reconciliation.Items {
+
+		if item.Side != model.SIDE_STATEMENT || item.PaymentUid == nil {
+			continue
+		}
+
+		// conditional update, so two statements reconciled at once can not both claim a payment
+		result := tx.Model(&paymentModel.Payment{Uid: *item.PaymentUid}).
+			Where("uid = ? AND reconciled_at IS NULL", *item.PaymentUid).
+			Updates(map[string]interface{}{"reconciled_at": reconciledAt, "reconciliation_uid": reconciliation.Uid})
+
+		if result.Error != nil {
+			tx.Rollback()
+			return nil, result.Error
+		}
+
+		if result.RowsAffected != 1 {
+			tx.Rollback()
+			return nil, &util.ConflictError{Message: fmt.Sprintf("payment %s was reconciled meanwhile, try again", *item.PaymentUid)}
+		}
+	}
+
+	errorDB := tx.Commit().Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return reconciliation, nil
+}
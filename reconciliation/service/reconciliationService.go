@@ -0,0 +1,204 @@
+package service
+
+import (
+	"fmt"
+	"github.com/javierjmgits/go-payment-api/account/identifier"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/reconciliation/matcher"
+	"github.com/javierjmgits/go-payment-api/reconciliation/model"
+	"github.com/javierjmgits/go-payment-api/reconciliation/parser"
+	"github.com/javierjmgits/go-payment-api/reconciliation/repository"
+	"github.com/satori/go.uuid"
+	"io"
+	"time"
+)
+
+const DEFAULT_TOLERANCE_DAYS = 2
+
+const day = 24 * time.Hour
+
+type ReconciliationService struct {
+	reconciliationRepository repository.ReconciliationRepository
+	now                      func() time.Time
+}
+
+func NewReconciliationService(reconciliationRepository repository.ReconciliationRepository) *ReconciliationService {
+
+	return &ReconciliationService{
+		reconciliationRepository: reconciliationRepository,
+		now:                      time.Now,
+	}
+}
+
+// Reconcile matches the lines of a statement to the processed payments and marks the matched ones as reconciled.
+// Without an account, a camt.053 statement is reconciled against the payments of its own account and a CSV file
+// against all payments. Payments of the statement period that match no line are reported as unmatched.
+func (rs *ReconciliationService) Reconcile(format string, account string, toleranceDays int, reader io.Reader) (*model.Reconciliation, error) {
+
+	if toleranceDays < 0 {
+		return nil, &util.InputError{Message: "tolerance must not be negative"}
+	}
+
+	statementParser, errorParser := parser.NewParser(format)
+
+	if errorParser != nil {
+		return nil, &util.InputError{Message: errorParser.Error()}
+	}
+
+	statement, errorParse := statementParser.Parse(reader)
+
+	if errorParse != nil {
+		return nil, &util.InputError{Message: errorParse.Error()}
+	}
+
+	if account != "" {
+
+		normalized, errorAccount := identifier.Normalize(account)
+
+		if errorAccount != nil {
+			return nil, &util.InputError{Message: fmt.Sprintf("account %v", errorAccount)}
+		}
+
+		statement.Account = normalized
+	}
+
+	uuidResult, errorUuid := uuid.NewV4()
+
+	if errorUuid != nil {
+		return nil, errorUuid
+	}
+
+	reconciliation := &model.Reconciliation{
+		Uid:           uuidResult.String(),
+		Format:        format,
+		Account:       statement.Account,
+		ToleranceDays: toleranceDays,
+		LineCount:     len(statement.Entries),
+	}
+
+	var payments []paymentModel.Payment
+	from, to, found := period(statement.Entries)
+
+	if found {
+
+		reconciliation.PeriodFrom = &from
+		reconciliation.PeriodTo = &to
+
+		tolerance := time.Duration(toleranceDays) * day
+
+		var errorDB error
+		payments, errorDB = rs.reconciliationRepository.GetUnreconciled(statement.Account, from.Add(-tolerance), to.Add(tolerance))
+
+		if errorDB != nil {
+			return nil, errorDB
+		}
+	}
+
+	result := matcher.Match(statement.Entries, payments, time.Duration(toleranceDays)*day)
+
+	for _, match := range result.Matches {
+
+		paymentUid := match.Payment.Uid
+
+		item := newStatementItem(match.Entry)
+		item.PaymentUid = &paymentUid
+		item.MatchedBy = match.MatchedBy
+
+		reconciliation.Items = append(reconciliation.Items, item)
+	}
+
+	for _, entry := range result.UnmatchedEntries {
+		reconciliation.Items = append(reconciliation.Items, newStatementItem(entry))
+	}
+
+	for index := range result.UnmatchedPayments {
+
+		payment := &result.UnmatchedPayments[index]
+		settlementDate := matcher.SettlementDate(payment)
+
+		// payments only in the tolerance margin may belong to the previous or next statement
+		if settlementDate.Before(from) || !settlementDate.Before(to) {
+			continue
+		}
+
+		reconciliation.Items = append(reconciliation.Items, newPaymentItem(payment))
+		reconciliation.UnmatchedPaymentCount++
+	}
+
+	reconciliation.MatchedCount = len(result.Matches)
+	reconciliation.UnmatchedLineCount = len(result.UnmatchedEntries)
+
+	return rs.reconciliationRepository.Create(reconciliation, rs.now())
+}
+
+//
+// private functions
+
+// period returns the days the valid entries of the statement cover, the end exclusive
+func period(entries []parser.Entry) (time.Time, time.Time, bool) {
+
+	var from, to time.Time
+	found := false
+
+	for _, entry := range entries {
+
+		if entry.Error != nil {
+			continue
+		}
+
+		date := entry.Date.UTC().Truncate(day)
+
+		if !found || date.Before(from) {
+			from = date
+		}
+
+		if !found || !date.Before(to) {
+			to = date.Add(day)
+		}
+
+		found = true
+	}
+
+	return from, to, found
+}
+
+func newStatementItem(entry parser.Entry) model.ReconciliationItem {
+
+	item := model.ReconciliationItem{
+		Side:          model.SIDE_STATEMENT,
+		Line:          entry.Line,
+		Reference:     entry.Reference,
+		Amount:        entry.Amount,
+		Currency:      entry.Currency,
+		AccountOrigin: entry.AccountOrigin,
+		AccountTarget: entry.AccountTarget,
+	}
+
+	if entry.Error != nil {
+		item.Message = entry.Error.Error()
+
+	} else {
+		date := entry.Date
+		item.Date = &date
+	}
+
+	return item
+}
+
+func newPaymentItem(payment *paymentModel.Payment) model.ReconciliationItem {
+
+	paymentUid := payment.Uid
+	date := matcher.SettlementDate(payment)
+
+	return model.ReconciliationItem{
+		Side:          model.SIDE_PAYMENT,
+		Reference:     payment.Reference,
+		Amount:        payment.Amount,
+		Currency:      payment.Currency,
+		Date:          &date,
+		AccountOrigin: payment.AccountOrigin,
+		AccountTarget: payment.AccountTarget,
+		PaymentUid:    &paymentUid,
+	}
+}
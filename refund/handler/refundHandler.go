@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	paymentHandler "github.com/javierjmgits/go-payment-api/payment/handler"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/refund/model"
+	"github.com/javierjmgits/go-payment-api/refund/repository"
+	"github.com/satori/go.uuid"
+	"math"
+	"net/http"
+	"time"
+)
+
+// amounts are floats, so anything below a thousandth of a unit is rounding noise
+const AMOUNT_TOLERANCE = 0.001
+
+type RefundHandler struct {
+	refundRepository repository.RefundRepository
+}
+
+type RefundView struct {
+	Uid             string    `json:"uid"`
+	PaymentUid      string    `json:"paymentUid"`
+	CompensationUid string    `json:"compensationUid"`
+	Amount          float64   `json:"amount"`
+	Reason          string    `json:"reason"`
+	Date            time.Time `json:"date"`
+}
+
+type RefundCreate struct {
+	Amount *float64 `json:"amount"`
+	Reason string   `json:"reason"`
+}
+
+func NewRefundHandler(refundRepository repository.RefundRepository) *RefundHandler {
+
+	return &RefundHandler{
+		refundRepository: refundRepository,
+	}
+}
+
+func (rh *RefundHandler) Register(router *mux.Router) {
+	router.HandleFunc("/api/v1/payments/uid/{uid}/refunds", rh.GetRefundsByPaymentUid).Methods("GET")
+	router.HandleFunc("/api/v1/payments/uid/{uid}/refunds", rh.CreateRefund).Methods("POST")
+	router.HandleFunc("/api/v1/refunds/uid/{uid}", rh.GetRefundByUid).Methods("GET")
+}
+
+func (rh *RefundHandler) GetRefundsByPaymentUid(w http.ResponseWriter, r *http.Request) {
+
+	refunds, errorDB := rh.refundRepository.GetByPaymentUid(mux.Vars(r)["uid"])
+
+	if errorDB != nil {
+		util.WriteErrorFor(w, errorDB)
+		return
+	}
+
+	results := []RefundView{}
+
+	for index := range refunds {
+		results = append(results, *newRefundView(&refunds[index]))
+	}
+
+	util.WritePayload(w, http.StatusOK, results)
+}
+
+func (rh *RefundHandler) GetRefundByUid(w http.ResponseWriter, r *http.Request) {
+
+	refund, errorDB := rh.refundRepository.GetByUid(mux.Vars(r)["uid"])
+
+	if errorDB != nil {
+		util.WriteErrorFor(w, errorDB)
+		return
+	}
+
+	util.WritePayload(w, http.StatusOK, newRefundView(refund))
+}
+
+// CreateRefund refunds a processed payment with a compensating payment on behalf of the caller. The compensation
+// returns money the original payment moved already, so it does not go through the create hooks of new payments: no
+// risk review, limits, fees, approval or account lock.
+func (rh *RefundHandler) CreateRefund(w http.ResponseWriter, r *http.Request) {
+
+	var refundCreate RefundCreate
+
+	errorJson := json.NewDecoder(r.Body).Decode(&refundCreate)
+
+	if errorJson != nil {
+		util.WriteError(w, http.StatusBadRequest, errorJson.Error())
+		return
+	}
+
+	if refundCreate.Amount != nil && *refundCreate.Amount <= 0 {
+		util.WriteError(w, http.StatusBadRequest, "amount must be a positive number")
+		return
+	}
+
+	if refundCreate.Reason == "" {
+		util.WriteError(w, http.StatusBadRequest, "reason is mandatory")
+		return
+	}
+
+	refund, errorCreate := rh.refundRepository.Create(mux.Vars(r)["uid"], func(payment *paymentModel.Payment) (*model.Refund, *paymentModel.Payment, error) {
+		return newRefund(payment, &refundCreate, auth.FromRequest(r), time.Now())
+	})
+
+	if errorCreate != nil {
+		util.WriteErrorFor(w, errorCreate)
+		return
+	}
+
+	util.WritePayload(w, http.StatusCreated, newRefundView(refund))
+}
+
+//
+// private functions
+
+func newRefund(payment *paymentModel.Payment, refundCreate *RefundCreate, principal *auth.Principal, now time.Time) (*model.Refund, *paymentModel.Payment, error) {
+
+	if payment.RefundOfUid != nil {
+		return nil, nil, &util.ConflictError{Message: "Refunds cannot be refunded"}
+	}
+
+	if !payment.Processed {
+		return nil, nil, &util.ConflictError{Message: "Payment not processed yet"}
+	}
+
+	refundable := payment.RefundableAmount()
+
+	if refundable < AMOUNT_TOLERANCE {
+		return nil, nil, &util.ConflictError{Message: "Payment already fully refunded"}
+	}
+
+	amount := refundable
+
+	if refundCreate.Amount != nil {
+		amount = *refundCreate.Amount
+	}
+
+	if amount-refundable > AMOUNT_TOLERANCE {
+		return nil, nil, &util.InputError{Message: fmt.Sprintf("amount exceeds the refundable amount of %v", refundable)}
+	}
+
+	// the compensating movement goes back from the target to the origin and is processed like any other payment
+	compensation, errorUuid := paymentHandler.NewPayment(&paymentHandler.PaymentCreate{
+		AccountOrigin: payment.AccountTarget,
+		AccountTarget: payment.AccountOrigin,
+		Amount:        amount,
+		Date:          now.UTC().Truncate(time.Second),
+	})
+
+	if errorUuid != nil {
+		return nil, nil, errorUuid
+	}
+
+	// the movement mirrors the original one, so any FX conversion is applied in reverse
+	compensation.Currency = payment.TargetCurrency
+	compensation.TargetCurrency = payment.Currency
+	compensation.Amount = roundAmount(amount * payment.FxRate)
+	compensation.TargetAmount = amount
+	compensation.FxRate = 1 / payment.FxRate
+	compensation.RefundOfUid = &payment.Uid
+
+	// the compensation belongs to the tenant of the payment, so it shows wherever the payment does
+	compensation.Tenant = payment.Tenant
+	compensation.CreatedBy = principal.User
+
+	uuidResult, errorUuid := uuid.NewV4()
+
+	if errorUuid != nil {
+		return nil, nil, errorUuid
+	}
+
+	payment.RefundedAmount = math.Min(payment.Amount, payment.RefundedAmount+amount)
+
+	return &model.Refund{
+		Uid:             uuidResult.String(),
+		PaymentUid:      payment.Uid,
+		CompensationUid: compensation.Uid,
+		Amount:          amount,
+		Reason:          refundCreate.Reason,
+	}, compensation, nil
+}
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func newRefundView(refund *model.Refund) *RefundView {
+
+	return &RefundView{
+		Uid:             refund.Uid,
+		PaymentUid:      refund.PaymentUid,
+		CompensationUid: refund.CompensationUid,
+		Amount:          refund.Amount,
+		Reason:          refund.Reason,
+		Date:            refund.CreatedAt,
+	}
+}
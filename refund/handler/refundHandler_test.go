@@ -0,0 +1,286 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/refund/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+//
+// mocks
+
+type refundRepositoryImplMock struct {
+	mock.Mock
+	compensation *paymentModel.Payment
+}
+
+func (mock *refundRepositoryImplMock) GetByUid(uid string) (*model.Refund, error) {
+
+	args := mock.Mock.Called(uid)
+
+	result := args.Get(0)
+
+	if result != nil {
+		return result.(*model.Refund), nil
+	}
+
+	return nil, args.Get(1).(error)
+}
+
+func (mock *refundRepositoryImplMock) GetByPaymentUid(paymentUid string) ([]model.Refund, error) {
+
+	args := mock.Mock.Called(paymentUid)
+
+	results := args.Get(0)
+
+	if results != nil {
+		return results.([]model.Refund), nil
+	}
+
+	return nil, args.Get(1).(error)
+}
+
+func (mock *refundRepositoryImplMock) Create(paymentUid string, build func(*paymentModel.Payment) (*model.Refund, *paymentModel.Payment, error)) (*model.Refund, error) {
+
+	args := mock.Mock.Called(paymentUid)
+
+	result := args.Get(0)
+
+	if result == nil {
+		return nil, args.Get(1).(error)
+	}
+
+	refund, compensation, errorBuild := build(result.(*paymentModel.Payment))
+
+	mock.compensation = compensation
+
+	return refund, errorBuild
+}
+
+//
+// tests
+
+func TestCreateRefundKoNotFound(t *testing.T) {
+
+	router, mockRepository := setUp()
+	mockRepository.On("Create", "unknown").Return(nil, errors.New("record not found"))
+
+	resp := post(router, "unknown", &RefundCreate{Reason: "duplicate"})
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestCreateRefundKoMissingReason(t *testing.T) {
+
+	router, mockRepository := setUp()
+
+	resp := post(router, "myUid", &RefundCreate{})
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestCreateRefundKoNotProcessed(t *testing.T) {
+
+	router, mockRepository := setUp()
+	mockRepository.On("Create", "myUid").Return(expectedPayment(false, 0), nil)
+
+	resp := post(router, "myUid", &RefundCreate{Reason: "duplicate"})
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestCreateRefundKoExceedsRefundable(t *testing.T) {
+
+	router, mockRepository := setUp()
+	mockRepository.On("Create", "myUid").Return(expectedPayment(true, 20), nil)
+	amount := 10.0
+
+	resp := post(router, "myUid", &RefundCreate{Amount: &amount, Reason: "duplicate"})
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestCreateRefundKoFullyRefunded(t *testing.T) {
+
+	router, mockRepository := setUp()
+	mockRepository.On("Create", "myUid").Return(expectedPayment(true, 25), nil)
+
+	resp := post(router, "myUid", &RefundCreate{Reason: "duplicate"})
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestCreateRefundPartial(t *testing.T) {
+
+	router, mockRepository := setUp()
+	payment := expectedPayment(true, 10)
+	mockRepository.On("Create", "myUid").Return(payment, nil)
+	amount := 5.0
+
+	resp := post(router, "myUid", &RefundCreate{Amount: &amount, Reason: "damaged goods"})
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	var refund RefundView
+
+	json.Unmarshal(body, &refund)
+
+	// verify
+
+	assert.Equal(t, "myUid", refund.PaymentUid)
+	assert.Equal(t, 5.0, refund.Amount)
+	assert.Equal(t, 15.0, payment.RefundedAmount)
+	assert.Equal(t, 10.0, payment.RefundableAmount())
+	assert.Equal(t, refund.CompensationUid, mockRepository.compensation.Uid)
+	assert.Equal(t, "myAccountTarget", mockRepository.compensation.AccountOrigin)
+	assert.Equal(t, "myAccountOrigin", mockRepository.compensation.AccountTarget)
+	assert.Equal(t, "myUid", *mockRepository.compensation.RefundOfUid)
+	assert.Equal(t, "acme", mockRepository.compensation.Tenant)
+	assert.Equal(t, "bob", mockRepository.compensation.CreatedBy)
+}
+
+func TestCreateRefundFull(t *testing.T) {
+
+	router, mockRepository := setUp()
+	payment := expectedPayment(true, 10)
+	mockRepository.On("Create", "myUid").Return(payment, nil)
+
+	resp := post(router, "myUid", &RefundCreate{Reason: "cancelled order"})
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	// verify
+
+	assert.Equal(t, 15.0, mockRepository.compensation.Amount)
+	assert.Equal(t, 0.0, payment.RefundableAmount())
+}
+
+func TestCreateRefundConverted(t *testing.T) {
+
+	router, mockRepository := setUp()
+	payment := expectedPayment(true, 0)
+	payment.TargetCurrency = "USD"
+	payment.FxRate = 1.1
+	payment.TargetAmount = 27.5
+	mockRepository.On("Create", "myUid").Return(payment, nil)
+	amount := 10.0
+
+	resp := post(router, "myUid", &RefundCreate{Amount: &amount, Reason: "cancelled order"})
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	// verify
+
+	assert.Equal(t, "USD", mockRepository.compensation.Currency)
+	assert.Equal(t, 11.0, mockRepository.compensation.Amount)
+	assert.Equal(t, "EUR", mockRepository.compensation.TargetCurrency)
+	assert.Equal(t, 10.0, mockRepository.compensation.TargetAmount)
+}
+
+func TestGetRefundsByPaymentUidKoNotFound(t *testing.T) {
+
+	router, mockRepository := setUp()
+	mockRepository.On("GetByPaymentUid", "unknown").Return(nil, errors.New("record not found"))
+
+	resp := get(router, "unknown")
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestGetRefundsByPaymentUidNone(t *testing.T) {
+
+	router, mockRepository := setUp()
+	mockRepository.On("GetByPaymentUid", "myUid").Return([]model.Refund{}, nil)
+
+	resp := get(router, "myUid")
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	// verify
+
+	assert.JSONEq(t, "[]", string(body))
+}
+
+//
+// private functions
+
+func setUp() (*mux.Router, *refundRepositoryImplMock) {
+
+	var router = mux.NewRouter()
+	var mockRepository refundRepositoryImplMock
+
+	NewRefundHandler(&mockRepository).Register(router)
+
+	return router, &mockRepository
+}
+
+func expectedPayment(processed bool, refundedAmount float64) *paymentModel.Payment {
+
+	payment := &paymentModel.Payment{
+		Uid:            "myUid",
+		AccountOrigin:  "myAccountOrigin",
+		AccountTarget:  "myAccountTarget",
+		Amount:         25,
+		Currency:       "EUR",
+		TargetCurrency: "EUR",
+		TargetAmount:   25,
+		FxRate:         1,
+		Date:           time.Now(),
+		RefundedAmount: refundedAmount,
+		Tenant:         "acme",
+	}
+
+	if processed {
+		payment.MarkAsProcessed(time.Now())
+	}
+
+	return payment
+}
+
+func post(router *mux.Router, uid string, refundCreate *RefundCreate) *http.Response {
+
+	refundCreateAsBytes, _ := json.Marshal(refundCreate)
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/payments/uid/"+uid+"/refunds", bytes.NewReader(refundCreateAsBytes))
+	req.Header.Set(auth.HEADER_USER, "bob")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	return w.Result()
+}
+
+func get(router *mux.Router, uid string) *http.Response {
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/api/v1/payments/uid/"+uid+"/refunds", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	return w.Result()
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+type Refund struct {
+	gorm.Model
+	Uid             string  `gorm:"unique;not null"`
+	PaymentUid      string  `gorm:"not null;index"`
+	CompensationUid string  `gorm:"unique;not null"`
+	Amount          float64 `gorm:"not null"`
+	Reason          string  `gorm:"not null"`
+}
+
+func SetUp(db *gorm.DB) *gorm.DB {
+
+	db.AutoMigrate(&Refund{})
+
+	return db
+}
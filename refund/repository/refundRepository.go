@@ -0,0 +1,91 @@
+package repository
+
+import (
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/refund/model"
+	"github.com/jinzhu/gorm"
+)
+
+type RefundRepository interface {
+	GetByUid(uid string) (*model.Refund, error)
+	GetByPaymentUid(paymentUid string) ([]model.Refund, error)
+	Create(paymentUid string, build func(*paymentModel.Payment) (*model.Refund, *paymentModel.Payment, error)) (*model.Refund, error)
+}
+
+type refundRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewRefundRepositoryImpl(db *gorm.DB) RefundRepository {
+	return &refundRepositoryImpl{
+		db: db,
+	}
+}
+
+func (rri *refundRepositoryImpl) GetByUid(uid string) (*model.Refund, error) {
+
+	var refund model.Refund
+	errorFind := rri.db.Where("uid = ?", uid).First(&refund).Error
+
+	if errorFind != nil {
+		return nil, errorFind
+	}
+
+	return &refund, nil
+}
+
+// GetByPaymentUid returns the refunds of a payment, none when it has no refunds, and an error when there is no payment
+func (rri *refundRepositoryImpl) GetByPaymentUid(paymentUid string) ([]model.Refund, error) {
+
+	var payment paymentModel.Payment
+
+	if errorDB := rri.db.Unscoped().Select("id").Where("uid = ?", paymentUid).First(&payment).Error; errorDB != nil {
+		return nil, errorDB
+	}
+
+	var refunds []model.Refund
+	errorDB := rri.db.Where("payment_uid = ?", paymentUid).Order("id").Find(&refunds).Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return refunds, nil
+}
+
+func (rri *refundRepositoryImpl) Create(paymentUid string, build func(*paymentModel.Payment) (*model.Refund, *paymentModel.Payment, error)) (*model.Refund, error) {
+
+	tx := rri.db.Begin()
+
+	// the original payment stays locked so concurrent refunds cannot exceed its amount
+	var payment paymentModel.Payment
+	errorDB := tx.Set("gorm:query_option", "FOR UPDATE").Where("uid = ?", paymentUid).First(&payment).Error
+
+	if errorDB != nil {
+		tx.Rollback()
+		return nil, errorDB
+	}
+
+	refund, compensation, errorBuild := build(&payment)
+
+	if errorBuild != nil {
+		tx.Rollback()
+		return nil, errorBuild
+	}
+
+	for _, value := range []interface{}{&payment, compensation, refund} {
+
+		if errorDB := tx.Save(value).Error; errorDB != nil {
+			tx.Rollback()
+			return nil, errorDB
+		}
+	}
+
+	errorDB = tx.Commit().Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return refund, nil
+}
@@ -0,0 +1,168 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/javierjmgits/go-payment-api/account/identifier"
+	"os"
+	"strings"
+	"time"
+)
+
+type rulesFile struct {
+	Velocity *struct {
+		MaxCount int    `json:"maxCount"`
+		Window   string `json:"window"`
+		Decision string `json:"decision"`
+	} `json:"velocity"`
+
+	MaxAmount *amountRuleFile `json:"maxAmount"`
+
+	BlockedAccounts *struct {
+		Accounts []string `json:"accounts"`
+		Decision string   `json:"decision"`
+	} `json:"blockedAccounts"`
+
+	NewBeneficiary *amountRuleFile `json:"newBeneficiary"`
+
+	UnusualHours *struct {
+		From     int    `json:"from"`
+		To       int    `json:"to"`
+		Location string `json:"location"`
+		Decision string `json:"decision"`
+	} `json:"unusualHours"`
+}
+
+// amountRuleFile is an amount rule: amount applies to any payment, amounts to the tenants and currencies they name
+type amountRuleFile struct {
+	Amount   float64           `json:"amount"`
+	Amounts  []AmountThreshold `json:"amounts"`
+	Decision string            `json:"decision"`
+}
+
+// NewEngineFromFile builds the engine from a JSON file; rules missing from the file are disabled.
+func NewEngineFromFile(path string) (*Engine, error) {
+
+	file, errorOpen := os.Open(path)
+
+	if errorOpen != nil {
+		return nil, errorOpen
+	}
+
+	defer file.Close()
+
+	var config rulesFile
+
+	if errorJson := json.NewDecoder(file).Decode(&config); errorJson != nil {
+		return nil, fmt.Errorf("invalid risk rules file %s: %v", path, errorJson)
+	}
+
+	if errorDecision := config.validateDecisions(); errorDecision != nil {
+		return nil, errorDecision
+	}
+
+	var rules []Rule
+
+	if config.Velocity != nil {
+
+		window, errorWindow := time.ParseDuration(config.Velocity.Window)
+
+		if errorWindow != nil {
+			return nil, fmt.Errorf("invalid velocity window: %v", errorWindow)
+		}
+
+		rules = append(rules, NewVelocityRule(config.Velocity.MaxCount, window, config.Velocity.Decision))
+	}
+
+	if config.MaxAmount != nil {
+		rules = append(rules, NewMaxAmountRule(config.MaxAmount.thresholds(), config.MaxAmount.Decision))
+	}
+
+	if config.BlockedAccounts != nil {
+
+		var accounts []string
+
+		for _, account := range config.BlockedAccounts.Accounts {
+
+			normalized, errorAccount := identifier.Normalize(account)
+
+			if errorAccount != nil {
+				return nil, fmt.Errorf("invalid blocked account: %v", errorAccount)
+			}
+
+			accounts = append(accounts, normalized)
+		}
+
+		rules = append(rules, NewBlockedAccountsRule(accounts, config.BlockedAccounts.Decision))
+	}
+
+	if config.NewBeneficiary != nil {
+		rules = append(rules, NewNewBeneficiaryRule(config.NewBeneficiary.thresholds(), config.NewBeneficiary.Decision))
+	}
+
+	if config.UnusualHours != nil {
+
+		location, errorLocation := time.LoadLocation(config.UnusualHours.Location)
+
+		if errorLocation != nil {
+			return nil, fmt.Errorf("invalid unusual hours location: %v", errorLocation)
+		}
+
+		rules = append(rules, NewUnusualHoursRule(config.UnusualHours.From, config.UnusualHours.To, location, config.UnusualHours.Decision))
+	}
+
+	return NewEngine(rules...), nil
+}
+
+//
+// private functions
+
+func (config *rulesFile) validateDecisions() error {
+
+	decisions := map[string]string{}
+
+	if config.Velocity != nil {
+		decisions["velocity"] = config.Velocity.Decision
+	}
+
+	if config.MaxAmount != nil {
+		decisions["maxAmount"] = config.MaxAmount.Decision
+	}
+
+	if config.BlockedAccounts != nil {
+		decisions["blockedAccounts"] = config.BlockedAccounts.Decision
+	}
+
+	if config.NewBeneficiary != nil {
+		decisions["newBeneficiary"] = config.NewBeneficiary.Decision
+	}
+
+	if config.UnusualHours != nil {
+		decisions["unusualHours"] = config.UnusualHours.Decision
+	}
+
+	for rule, decision := range decisions {
+
+		if _, found := decisionSeverities[decision]; !found {
+			return fmt.Errorf("invalid decision %q for rule %s, must be ALLOW, REVIEW or DENY", decision, rule)
+		}
+	}
+
+	return nil
+}
+
+func (rule *amountRuleFile) thresholds() []AmountThreshold {
+
+	var thresholds []AmountThreshold
+
+	for _, threshold := range rule.Amounts {
+		threshold.Currency = strings.ToUpper(threshold.Currency)
+		thresholds = append(thresholds, threshold)
+	}
+
+	if rule.Amount > 0 {
+		thresholds = append(thresholds, AmountThreshold{Amount: rule.Amount})
+	}
+
+	return thresholds
+}
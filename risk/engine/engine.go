@@ -0,0 +1,75 @@
+package engine
+
+import (
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"time"
+)
+
+const (
+	DECISION_ALLOW  = "ALLOW"
+	DECISION_REVIEW = "REVIEW"
+	DECISION_DENY   = "DENY"
+)
+
+var decisionSeverities = map[string]int{
+	DECISION_ALLOW:  0,
+	DECISION_REVIEW: 1,
+	DECISION_DENY:   2,
+}
+
+type Hit struct {
+	Rule     string `json:"rule"`
+	Decision string `json:"decision"`
+	Reason   string `json:"reason"`
+}
+
+// History gives rules access to the payments already made.
+type History interface {
+	CountByOriginSince(accountOrigin string, since time.Time) (int, error)
+	ExistsBetween(accountOrigin string, accountTarget string) (bool, error)
+}
+
+type Rule interface {
+	Name() string
+	Evaluate(payment *paymentModel.Payment, history History, now time.Time) (*Hit, error)
+}
+
+type Engine struct {
+	rules []Rule
+}
+
+func NewEngine(rules ...Rule) *Engine {
+
+	return &Engine{
+		rules: rules,
+	}
+}
+
+// Evaluate runs every rule and returns the most severe decision along with all the rules triggered.
+func (engine *Engine) Evaluate(payment *paymentModel.Payment, history History, now time.Time) (string, []Hit, error) {
+
+	decision := DECISION_ALLOW
+
+	var hits []Hit
+
+	for _, rule := range engine.rules {
+
+		hit, errorRule := rule.Evaluate(payment, history, now)
+
+		if errorRule != nil {
+			return "", nil, errorRule
+		}
+
+		if hit == nil {
+			continue
+		}
+
+		hits = append(hits, *hit)
+
+		if decisionSeverities[hit.Decision] > decisionSeverities[decision] {
+			decision = hit.Decision
+		}
+	}
+
+	return decision, hits, nil
+}
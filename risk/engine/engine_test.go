@@ -0,0 +1,133 @@
+package engine
+
+import (
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+//
+// mocks
+
+type historyMock struct {
+	count  int
+	exists bool
+}
+
+func (mock *historyMock) CountByOriginSince(accountOrigin string, since time.Time) (int, error) {
+	return mock.count, nil
+}
+
+func (mock *historyMock) ExistsBetween(accountOrigin string, accountTarget string) (bool, error) {
+	return mock.exists, nil
+}
+
+//
+// tests
+
+func TestEvaluateAllowWithoutRules(t *testing.T) {
+
+	decision, hits, errorEngine := NewEngine().Evaluate(expectedPayment(100), &historyMock{}, time.Now())
+
+	assert.Nil(t, errorEngine)
+	assert.Equal(t, DECISION_ALLOW, decision)
+	assert.Empty(t, hits)
+}
+
+func TestEvaluateStrictestDecisionWins(t *testing.T) {
+
+	engine := NewEngine(
+		NewMaxAmountRule([]AmountThreshold{{Amount: 500}}, DECISION_REVIEW),
+		NewBlockedAccountsRule([]string{"GB29NWBK60161331926819"}, DECISION_DENY),
+		NewVelocityRule(10, time.Hour, DECISION_REVIEW),
+	)
+
+	decision, hits, errorEngine := engine.Evaluate(expectedPayment(1000), &historyMock{count: 3}, time.Now())
+
+	assert.Nil(t, errorEngine)
+	assert.Equal(t, DECISION_DENY, decision)
+	assert.Equal(t, 2, len(hits))
+	assert.Equal(t, "maxAmount", hits[0].Rule)
+	assert.Equal(t, "blockedAccounts", hits[1].Rule)
+}
+
+func TestVelocityRule(t *testing.T) {
+
+	rule := NewVelocityRule(3, time.Hour, DECISION_REVIEW)
+
+	hit, _ := rule.Evaluate(expectedPayment(10), &historyMock{count: 2}, time.Now())
+	assert.Nil(t, hit)
+
+	hit, _ = rule.Evaluate(expectedPayment(10), &historyMock{count: 3}, time.Now())
+	assert.NotNil(t, hit)
+	assert.Equal(t, DECISION_REVIEW, hit.Decision)
+}
+
+func TestNewBeneficiaryRule(t *testing.T) {
+
+	rule := NewNewBeneficiaryRule([]AmountThreshold{{Amount: 200}}, DECISION_REVIEW)
+
+	hit, _ := rule.Evaluate(expectedPayment(100), &historyMock{}, time.Now())
+	assert.Nil(t, hit)
+
+	hit, _ = rule.Evaluate(expectedPayment(300), &historyMock{exists: true}, time.Now())
+	assert.Nil(t, hit)
+
+	hit, _ = rule.Evaluate(expectedPayment(300), &historyMock{}, time.Now())
+	assert.NotNil(t, hit)
+}
+
+func TestMaxAmountRulePerCurrency(t *testing.T) {
+
+	rule := NewMaxAmountRule([]AmountThreshold{{Amount: 10000}, {Currency: "JPY", Amount: 1500000}}, DECISION_REVIEW)
+
+	yen := expectedPayment(20000)
+	yen.Currency = "JPY"
+
+	hit, _ := rule.Evaluate(yen, &historyMock{}, time.Now())
+	assert.Nil(t, hit)
+
+	hit, _ = rule.Evaluate(expectedPayment(20000), &historyMock{}, time.Now())
+	assert.NotNil(t, hit)
+	assert.Equal(t, "amount above 10000 EUR", hit.Reason)
+}
+
+func TestMaxAmountRuleWithoutThresholdForCurrency(t *testing.T) {
+
+	rule := NewMaxAmountRule([]AmountThreshold{{Currency: "EUR", Amount: 10000}}, DECISION_REVIEW)
+
+	dollars := expectedPayment(20000)
+	dollars.Currency = "USD"
+
+	hit, _ := rule.Evaluate(dollars, &historyMock{}, time.Now())
+	assert.Nil(t, hit)
+}
+
+func TestUnusualHoursRuleAcrossMidnight(t *testing.T) {
+
+	rule := NewUnusualHoursRule(22, 6, time.UTC, DECISION_REVIEW)
+
+	for hour, expectedHit := range map[int]bool{21: false, 22: true, 23: true, 0: true, 5: true, 6: false, 12: false} {
+
+		now := time.Date(2026, 3, 1, hour, 30, 0, 0, time.UTC)
+
+		hit, _ := rule.Evaluate(expectedPayment(10), &historyMock{}, now)
+
+		assert.Equal(t, expectedHit, hit != nil, "hour %d", hour)
+	}
+}
+
+//
+// private functions
+
+func expectedPayment(amount float64) *paymentModel.Payment {
+
+	return &paymentModel.Payment{
+		Uid:           "5d3b2c2e-b7a4-4d4c-9fb9-3d7f5c1e2a10",
+		AccountOrigin: "GB29NWBK60161331926819",
+		AccountTarget: "DE89370400440532013000",
+		Amount:        amount,
+		Currency:      "EUR",
+	}
+}
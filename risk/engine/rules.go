@@ -0,0 +1,200 @@
+package engine
+
+import (
+	"fmt"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"time"
+)
+
+// AmountThreshold is the amount above which an amount rule hits the payments of a tenant and currency; an empty
+// tenant or currency matches any. Amounts are in the currency of the payment, so each currency needs its own.
+type AmountThreshold struct {
+	Tenant   string  `json:"tenant"`
+	Currency string  `json:"currency"`
+	Amount   float64 `json:"amount"`
+}
+
+//
+// velocity
+
+type velocityRule struct {
+	maxCount int
+	window   time.Duration
+	decision string
+}
+
+func NewVelocityRule(maxCount int, window time.Duration, decision string) Rule {
+	return &velocityRule{maxCount: maxCount, window: window, decision: decision}
+}
+
+func (vr *velocityRule) Name() string {
+	return "velocity"
+}
+
+func (vr *velocityRule) Evaluate(payment *paymentModel.Payment, history History, now time.Time) (*Hit, error) {
+
+	count, errorHistory := history.CountByOriginSince(payment.AccountOrigin, now.Add(-vr.window))
+
+	if errorHistory != nil {
+		return nil, errorHistory
+	}
+
+	if count+1 <= vr.maxCount {
+		return nil, nil
+	}
+
+	return &Hit{Rule: vr.Name(), Decision: vr.decision, Reason: fmt.Sprintf("more than %d payments from the origin account in %v", vr.maxCount, vr.window)}, nil
+}
+
+//
+// max amount
+
+type maxAmountRule struct {
+	thresholds []AmountThreshold
+	decision   string
+}
+
+func NewMaxAmountRule(thresholds []AmountThreshold, decision string) Rule {
+	return &maxAmountRule{thresholds: thresholds, decision: decision}
+}
+
+func (mar *maxAmountRule) Name() string {
+	return "maxAmount"
+}
+
+func (mar *maxAmountRule) Evaluate(payment *paymentModel.Payment, history History, now time.Time) (*Hit, error) {
+
+	amount, found := thresholdFor(mar.thresholds, payment)
+
+	if !found || payment.Amount <= amount {
+		return nil, nil
+	}
+
+	return &Hit{Rule: mar.Name(), Decision: mar.decision, Reason: fmt.Sprintf("amount above %v %s", amount, payment.Currency)}, nil
+}
+
+//
+// blocked accounts
+
+type blockedAccountsRule struct {
+	accounts map[string]bool
+	decision string
+}
+
+func NewBlockedAccountsRule(accounts []string, decision string) Rule {
+
+	blocked := map[string]bool{}
+
+	for _, account := range accounts {
+		blocked[account] = true
+	}
+
+	return &blockedAccountsRule{accounts: blocked, decision: decision}
+}
+
+func (bar *blockedAccountsRule) Name() string {
+	return "blockedAccounts"
+}
+
+func (bar *blockedAccountsRule) Evaluate(payment *paymentModel.Payment, history History, now time.Time) (*Hit, error) {
+
+	for _, account := range []string{payment.AccountOrigin, payment.AccountTarget} {
+
+		if bar.accounts[account] {
+			return &Hit{Rule: bar.Name(), Decision: bar.decision, Reason: fmt.Sprintf("account %s is blocked", account)}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+//
+// new beneficiary
+
+type newBeneficiaryRule struct {
+	thresholds []AmountThreshold
+	decision   string
+}
+
+func NewNewBeneficiaryRule(thresholds []AmountThreshold, decision string) Rule {
+	return &newBeneficiaryRule{thresholds: thresholds, decision: decision}
+}
+
+func (nbr *newBeneficiaryRule) Name() string {
+	return "newBeneficiary"
+}
+
+func (nbr *newBeneficiaryRule) Evaluate(payment *paymentModel.Payment, history History, now time.Time) (*Hit, error) {
+
+	amount, found := thresholdFor(nbr.thresholds, payment)
+
+	if !found || payment.Amount <= amount {
+		return nil, nil
+	}
+
+	exists, errorHistory := history.ExistsBetween(payment.AccountOrigin, payment.AccountTarget)
+
+	if errorHistory != nil {
+		return nil, errorHistory
+	}
+
+	if exists {
+		return nil, nil
+	}
+
+	return &Hit{Rule: nbr.Name(), Decision: nbr.decision, Reason: fmt.Sprintf("first payment to the target account is above %v %s", amount, payment.Currency)}, nil
+}
+
+//
+// unusual hours
+
+type unusualHoursRule struct {
+	fromHour int
+	toHour   int
+	location *time.Location
+	decision string
+}
+
+func NewUnusualHoursRule(fromHour int, toHour int, location *time.Location, decision string) Rule {
+	return &unusualHoursRule{fromHour: fromHour, toHour: toHour, location: location, decision: decision}
+}
+
+func (uhr *unusualHoursRule) Name() string {
+	return "unusualHours"
+}
+
+func (uhr *unusualHoursRule) Evaluate(payment *paymentModel.Payment, history History, now time.Time) (*Hit, error) {
+
+	hour := now.In(uhr.location).Hour()
+
+	// the window may wrap around midnight, e.g. from 22 to 6
+	inWindow := hour >= uhr.fromHour && hour < uhr.toHour
+
+	if uhr.fromHour > uhr.toHour {
+		inWindow = hour >= uhr.fromHour || hour < uhr.toHour
+	}
+
+	if !inWindow {
+		return nil, nil
+	}
+
+	return &Hit{Rule: uhr.Name(), Decision: uhr.decision, Reason: fmt.Sprintf("created between %02d:00 and %02d:00 %s", uhr.fromHour, uhr.toHour, uhr.location)}, nil
+}
+
+//
+// private functions
+
+// thresholdFor returns the amount of the most specific threshold for the payment, or false when none fits it
+func thresholdFor(thresholds []AmountThreshold, payment *paymentModel.Payment) (float64, bool) {
+
+	index := util.MostSpecific(len(thresholds), func(index int) (string, string) {
+		return thresholds[index].Tenant, thresholds[index].Currency
+	}, payment.Tenant, payment.Currency)
+
+	if index < 0 {
+		return 0, false
+	}
+
+	return thresholds[index].Amount, true
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	paymentHandler "github.com/javierjmgits/go-payment-api/payment/handler"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/risk/service"
+	"net/http"
+)
+
+type ReviewHandler struct {
+	riskService *service.RiskService
+}
+
+func NewReviewHandler(riskService *service.RiskService) *ReviewHandler {
+
+	return &ReviewHandler{
+		riskService: riskService,
+	}
+}
+
+func (rh *ReviewHandler) Register(router *mux.Router) {
+	router.HandleFunc("/api/v1/reviews", rh.GetPendingReviews).Methods("GET")
+	router.HandleFunc("/api/v1/reviews/uid/{uid}/approve", rh.ApprovePayment).Methods("POST")
+	router.HandleFunc("/api/v1/reviews/uid/{uid}/reject", rh.RejectPayment).Methods("POST")
+}
+
+func (rh *ReviewHandler) GetPendingReviews(w http.ResponseWriter, r *http.Request) {
+
+	payments, errorDB := rh.riskService.GetPendingReview()
+
+	if errorDB != nil {
+		util.WriteError(w, http.StatusInternalServerError, errorDB.Error())
+		return
+	}
+
+	var results []paymentHandler.PaymentView
+
+	for _, item := range payments {
+		results = append(results, *paymentHandler.NewPaymentView(&item))
+	}
+
+	util.WritePayload(w, http.StatusOK, results)
+}
+
+func (rh *ReviewHandler) ApprovePayment(w http.ResponseWriter, r *http.Request) {
+	rh.decide(w, r, rh.riskService.Approve)
+}
+
+func (rh *ReviewHandler) RejectPayment(w http.ResponseWriter, r *http.Request) {
+	rh.decide(w, r, rh.riskService.Reject)
+}
+
+//
+// private functions
+
+// decide takes the reviewer from the principal, never from the body, so that nobody can review on behalf of someone else
+func (rh *ReviewHandler) decide(w http.ResponseWriter, r *http.Request, decision func(uid string, principal *auth.Principal) (*paymentModel.Payment, error)) {
+
+	payment, errorReview := decision(mux.Vars(r)["uid"], auth.FromRequest(r))
+
+	if errorReview != nil {
+		util.WriteErrorFor(w, errorReview)
+		return
+	}
+
+	util.WritePayload(w, http.StatusOK, paymentHandler.NewPaymentView(payment))
+}
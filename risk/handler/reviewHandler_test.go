@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	paymentHandler "github.com/javierjmgits/go-payment-api/payment/handler"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/risk/engine"
+	"github.com/javierjmgits/go-payment-api/risk/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+//
+// mocks
+
+type riskRepositoryImplMock struct {
+	mock.Mock
+}
+
+func (mock *riskRepositoryImplMock) CountByOriginSince(accountOrigin string, since time.Time) (int, error) {
+	return 0, nil
+}
+
+func (mock *riskRepositoryImplMock) ExistsBetween(accountOrigin string, accountTarget string) (bool, error) {
+	return true, nil
+}
+
+func (mock *riskRepositoryImplMock) GetPendingReview() ([]paymentModel.Payment, error) {
+
+	args := mock.Mock.Called()
+
+	return args.Get(0).([]paymentModel.Payment), nil
+}
+
+func (mock *riskRepositoryImplMock) GetByUid(uid string) (*paymentModel.Payment, error) {
+
+	args := mock.Mock.Called(uid)
+
+	result := args.Get(0)
+
+	if result != nil {
+		return result.(*paymentModel.Payment), nil
+	}
+
+	return nil, args.Get(1).(error)
+}
+
+func (mock *riskRepositoryImplMock) Review(uid string, status string, reviewer string, now time.Time) (bool, error) {
+
+	args := mock.Mock.Called(uid, status, reviewer)
+
+	return args.Bool(0), nil
+}
+
+//
+// tests
+
+func TestGetPendingReviews(t *testing.T) {
+
+	router, mockRepository := setUp()
+	mockRepository.On("GetPendingReview").Return([]paymentModel.Payment{*expectedPayment(paymentModel.REVIEW_STATUS_PENDING)})
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/api/v1/reviews", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	var payments []paymentHandler.PaymentView
+
+	json.Unmarshal(body, &payments)
+
+	// verify
+
+	assert.Equal(t, 1, len(payments))
+	assert.Equal(t, paymentModel.REVIEW_STATUS_PENDING, payments[0].ReviewStatus)
+	assert.Equal(t, "maxAmount", payments[0].RiskRules[0].Rule)
+}
+
+func TestApprovePayment(t *testing.T) {
+
+	router, mockRepository := setUp()
+	mockRepository.On("Review", "b9a9e3b0-2bd6-4bc4-8d33-6b3b1e8b2f6d", paymentModel.REVIEW_STATUS_APPROVED, "alice").Return(true)
+	mockRepository.On("GetByUid", "b9a9e3b0-2bd6-4bc4-8d33-6b3b1e8b2f6d").Return(expectedPayment(paymentModel.REVIEW_STATUS_APPROVED))
+
+	resp := postDecision(router, "approve", "alice")
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	var payment paymentHandler.PaymentView
+
+	json.Unmarshal(body, &payment)
+
+	// verify
+
+	assert.Equal(t, paymentModel.REVIEW_STATUS_APPROVED, payment.ReviewStatus)
+}
+
+func TestRejectPaymentKoAlreadyReviewed(t *testing.T) {
+
+	router, mockRepository := setUp()
+	mockRepository.On("Review", "b9a9e3b0-2bd6-4bc4-8d33-6b3b1e8b2f6d", paymentModel.REVIEW_STATUS_REJECTED, "alice").Return(false)
+	mockRepository.On("GetByUid", "b9a9e3b0-2bd6-4bc4-8d33-6b3b1e8b2f6d").Return(expectedPayment(paymentModel.REVIEW_STATUS_APPROVED))
+
+	resp := postDecision(router, "reject", "alice")
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestApprovePaymentKoNotFound(t *testing.T) {
+
+	router, mockRepository := setUp()
+	mockRepository.On("GetByUid", "b9a9e3b0-2bd6-4bc4-8d33-6b3b1e8b2f6d").Return(nil, errors.New("record not found"))
+
+	resp := postDecision(router, "approve", "alice")
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestApprovePaymentKoCreator(t *testing.T) {
+
+	router, mockRepository := setUp()
+	payment := expectedPayment(paymentModel.REVIEW_STATUS_PENDING)
+	payment.CreatedBy = "alice"
+	mockRepository.On("GetByUid", "b9a9e3b0-2bd6-4bc4-8d33-6b3b1e8b2f6d").Return(payment)
+
+	resp := postDecision(router, "approve", "alice")
+
+	// verify
+
+	mockRepository.AssertExpectations(t)
+	mockRepository.AssertNotCalled(t, "Review", "b9a9e3b0-2bd6-4bc4-8d33-6b3b1e8b2f6d", paymentModel.REVIEW_STATUS_APPROVED, "alice")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestApprovePaymentKoMissingReviewer(t *testing.T) {
+
+	router, mockRepository := setUp()
+
+	resp := postDecision(router, "approve", "")
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+//
+// private functions
+
+func setUp() (*mux.Router, *riskRepositoryImplMock) {
+
+	var router = mux.NewRouter()
+	var mockRepository riskRepositoryImplMock
+
+	NewReviewHandler(service.NewRiskService(engine.NewEngine(), &mockRepository)).Register(router)
+
+	return router, &mockRepository
+}
+
+func postDecision(router *mux.Router, decision string, reviewer string) *http.Response {
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/reviews/uid/b9a9e3b0-2bd6-4bc4-8d33-6b3b1e8b2f6d/"+decision, nil)
+	req.Header.Set(auth.HEADER_USER, reviewer)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	return w.Result()
+}
+
+func expectedPayment(reviewStatus string) *paymentModel.Payment {
+
+	return &paymentModel.Payment{
+		Uid:            "b9a9e3b0-2bd6-4bc4-8d33-6b3b1e8b2f6d",
+		AccountOrigin:  "GB29NWBK60161331926819",
+		AccountTarget:  "DE89370400440532013000",
+		Amount:         5000,
+		Currency:       "EUR",
+		TargetCurrency: "EUR",
+		TargetAmount:   5000,
+		FxRate:         1,
+		Date:           time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		RiskDecision:   engine.DECISION_REVIEW,
+		RiskHits:       `[{"rule":"maxAmount","decision":"REVIEW","reason":"amount above 1000"}]`,
+		ReviewStatus:   reviewStatus,
+	}
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+type RiskRepository interface {
+	CountByOriginSince(accountOrigin string, since time.Time) (int, error)
+	ExistsBetween(accountOrigin string, accountTarget string) (bool, error)
+	GetPendingReview() ([]paymentModel.Payment, error)
+	GetByUid(uid string) (*paymentModel.Payment, error)
+	Review(uid string, status string, reviewer string, now time.Time) (bool, error)
+}
+
+type riskRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewRiskRepositoryImpl(db *gorm.DB) RiskRepository {
+	return &riskRepositoryImpl{
+		db: db,
+	}
+}
+
+func (rri *riskRepositoryImpl) CountByOriginSince(accountOrigin string, since time.Time) (int, error) {
+
+	var count int
+	errorDB := rri.db.Model(&paymentModel.Payment{}).
+		Where("account_origin = ? AND created_at >= ?", accountOrigin, since).
+		Count(&count).Error
+
+	return count, errorDB
+}
+
+func (rri *riskRepositoryImpl) ExistsBetween(accountOrigin string, accountTarget string) (bool, error) {
+
+	var count int
+	errorDB := rri.db.Model(&paymentModel.Payment{}).
+		Where("account_origin = ? AND account_target = ?", accountOrigin, accountTarget).
+		Count(&count).Error
+
+	return count > 0, errorDB
+}
+
+func (rri *riskRepositoryImpl) GetPendingReview() ([]paymentModel.Payment, error) {
+
+	var payments []paymentModel.Payment
+	errorDB := rri.db.Where("review_status = ? AND cancelled_at IS NULL", paymentModel.REVIEW_STATUS_PENDING).Order("created_at").Find(&payments).Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return payments, nil
+}
+
+func (rri *riskRepositoryImpl) GetByUid(uid string) (*paymentModel.Payment, error) {
+
+	var payment paymentModel.Payment
+	errorFind := rri.db.Where("uid = ?", uid).First(&payment).Error
+
+	if errorFind != nil {
+		return nil, errorFind
+	}
+
+	return &payment, nil
+}
+
+func (rri *riskRepositoryImpl) Review(uid string, status string, reviewer string, now time.Time) (bool, error) {
+
+	reviewedAt := now.UTC().Truncate(time.Second)
+
+	// conditional update, so two reviewers can not both decide on the same payment
+	result := rri.db.Model(&paymentModel.Payment{Uid: uid}).
+		Where("uid = ? AND review_status = ? AND cancelled_at IS NULL", uid, paymentModel.REVIEW_STATUS_PENDING).
+		Updates(map[string]interface{}{"review_status": status, "reviewed_by": reviewer, "reviewed_at": reviewedAt})
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
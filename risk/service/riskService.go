@@ -0,0 +1,130 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	paymentHandler "github.com/javierjmgits/go-payment-api/payment/handler"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/risk/engine"
+	"github.com/javierjmgits/go-payment-api/risk/repository"
+	"strings"
+	"time"
+)
+
+type RiskService struct {
+	engine         *engine.Engine
+	riskRepository repository.RiskRepository
+	now            func() time.Time
+}
+
+func NewRiskService(engine *engine.Engine, riskRepository repository.RiskRepository) *RiskService {
+
+	return &RiskService{
+		engine:         engine,
+		riskRepository: riskRepository,
+		now:            time.Now,
+	}
+}
+
+// BeforeCreate evaluates the rules, rejecting denied payments and holding the ones that need a manual review.
+func (rs *RiskService) BeforeCreate(payment *paymentModel.Payment, paymentCreate *paymentHandler.PaymentCreate) error {
+
+	decision, hits, errorEngine := rs.engine.Evaluate(payment, rs.riskRepository, rs.now())
+
+	if errorEngine != nil {
+		return errorEngine
+	}
+
+	if decision == engine.DECISION_DENY {
+		return &util.RejectedError{Message: "payment denied by risk rules: " + strings.Join(ruleNames(hits), ", ")}
+	}
+
+	payment.RiskDecision = decision
+
+	if len(hits) > 0 {
+
+		hitsJson, errorJson := json.Marshal(hits)
+
+		if errorJson != nil {
+			return errorJson
+		}
+
+		payment.RiskHits = string(hitsJson)
+	}
+
+	if decision == engine.DECISION_REVIEW {
+		payment.ReviewStatus = paymentModel.REVIEW_STATUS_PENDING
+	}
+
+	return nil
+}
+
+func (rs *RiskService) GetPendingReview() ([]paymentModel.Payment, error) {
+	return rs.riskRepository.GetPendingReview()
+}
+
+func (rs *RiskService) Approve(uid string, principal *auth.Principal) (*paymentModel.Payment, error) {
+	return rs.review(uid, paymentModel.REVIEW_STATUS_APPROVED, principal)
+}
+
+func (rs *RiskService) Reject(uid string, principal *auth.Principal) (*paymentModel.Payment, error) {
+	return rs.review(uid, paymentModel.REVIEW_STATUS_REJECTED, principal)
+}
+
+//
+// private functions
+
+func (rs *RiskService) review(uid string, status string, principal *auth.Principal) (*paymentModel.Payment, error) {
+
+	if principal.User == "" {
+		return nil, &util.InputError{Message: fmt.Sprintf("reviewer must be given in the %s header", auth.HEADER_USER)}
+	}
+
+	payment, errorDB := rs.riskRepository.GetByUid(uid)
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	if payment.CreatedBy == principal.User {
+		return nil, &util.ForbiddenError{Message: "the creator of a payment cannot review it"}
+	}
+
+	reviewed, errorDB := rs.riskRepository.Review(uid, status, principal.User, rs.now())
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	payment, errorDB = rs.riskRepository.GetByUid(uid)
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	if !reviewed && payment.IsCancelled() {
+		return nil, &util.ConflictError{Message: "payment is cancelled"}
+	}
+
+	if !reviewed {
+		return nil, &util.ConflictError{Message: fmt.Sprintf("payment is not pending review, its review status is '%s'", payment.ReviewStatus)}
+	}
+
+	return payment, nil
+}
+
+func ruleNames(hits []engine.Hit) []string {
+
+	var names []string
+
+	for _, hit := range hits {
+
+		if hit.Decision == engine.DECISION_DENY {
+			names = append(names, hit.Rule)
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/scheduler/service"
+	"net/http"
+	"time"
+)
+
+type SchedulerHandler struct {
+	schedulerService *service.SchedulerService
+}
+
+type SchedulerView struct {
+	Enabled            bool       `json:"enabled"`
+	Interval           string     `json:"interval"`
+	LastRun            *time.Time `json:"lastRun"`
+	LastRunProcessed   int        `json:"lastRunProcessed"`
+	LastRunError       string     `json:"lastRunError,omitempty"`
+	NextRun            *time.Time `json:"nextRun"`
+	NextDuePaymentDate *time.Time `json:"nextDuePaymentDate"`
+}
+
+type SchedulerRunView struct {
+	Processed int `json:"processed"`
+}
+
+func NewSchedulerHandler(schedulerService *service.SchedulerService) *SchedulerHandler {
+
+	return &SchedulerHandler{
+		schedulerService: schedulerService,
+	}
+}
+
+func (sh *SchedulerHandler) Register(router *mux.Router) {
+	router.HandleFunc("/api/v1/scheduler", sh.GetScheduler).Methods("GET")
+	router.HandleFunc("/api/v1/scheduler/run", sh.RunScheduler).Methods("POST")
+}
+
+func (sh *SchedulerHandler) GetScheduler(w http.ResponseWriter, r *http.Request) {
+
+	status, errorDB := sh.schedulerService.Status()
+
+	if errorDB != nil {
+		util.WriteError(w, http.StatusInternalServerError, errorDB.Error())
+		return
+	}
+
+	util.WritePayload(w, http.StatusOK, &SchedulerView{
+		Enabled:            status.Enabled,
+		Interval:           status.Interval.String(),
+		LastRun:            status.LastRun,
+		LastRunProcessed:   status.LastRunProcessed,
+		LastRunError:       status.LastRunError,
+		NextRun:            status.NextRun,
+		NextDuePaymentDate: status.NextDuePaymentDate,
+	})
+}
+
+func (sh *SchedulerHandler) RunScheduler(w http.ResponseWriter, r *http.Request) {
+
+	processed, errorDB := sh.schedulerService.RunOnce()
+
+	if errorDB != nil {
+		util.WriteError(w, http.StatusInternalServerError, errorDB.Error())
+		return
+	}
+
+	util.WritePayload(w, http.StatusOK, &SchedulerRunView{Processed: processed})
+}
@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/base/config"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/scheduler/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+//
+// mock data
+
+var nextDueDate = time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+
+//
+// mocks
+
+type schedulerRepositoryImplMock struct {
+	mock.Mock
+}
+
+func (mock *schedulerRepositoryImplMock) GetDueUids(now time.Time, limit int) ([]string, error) {
+
+	args := mock.Mock.Called(limit)
+
+	results := args.Get(0)
+
+	if results != nil {
+		return results.([]string), nil
+	}
+
+	return nil, args.Get(1).(error)
+}
+
+func (mock *schedulerRepositoryImplMock) GetNextDueDate() (*time.Time, error) {
+
+	args := mock.Mock.Called(nil)
+
+	result := args.Get(0)
+
+	if result != nil {
+		return result.(*time.Time), nil
+	}
+
+	return nil, nil
+}
+
+type enqueuerMock struct {
+	mock.Mock
+}
+
+func (mock *enqueuerMock) Enqueue(paymentUid string) (*paymentModel.Payment, error) {
+
+	args := mock.Mock.Called(paymentUid)
+
+	if args.Get(0) != nil {
+		return nil, args.Get(0).(error)
+	}
+
+	return &paymentModel.Payment{Uid: paymentUid, ProcessingStatus: paymentModel.PROCESSING_STATUS_QUEUED}, nil
+}
+
+//
+// tests
+
+func TestRunSchedulerKoError(t *testing.T) {
+
+	router, mockRepository, _ := setUp()
+	mockRepository.On("GetDueUids", 100).Return(nil, errors.New("DB error"))
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/scheduler/run", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+}
+
+func TestRunScheduler(t *testing.T) {
+
+	router, mockRepository, mockEnqueuer := setUp()
+	mockRepository.On("GetDueUids", 100).Return([]string{"myUid1", "myUid2", "myUid3"}, nil)
+	mockEnqueuer.On("Enqueue", "myUid1").Return(nil)
+	mockEnqueuer.On("Enqueue", "myUid2").Return(&util.ConflictError{Message: "Payment is already being processed"})
+	mockEnqueuer.On("Enqueue", "myUid3").Return(nil)
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/scheduler/run", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	mockEnqueuer.AssertExpectations(t)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	var run SchedulerRunView
+
+	json.Unmarshal(body, &run)
+
+	// verify
+
+	assert.Equal(t, 2, run.Processed)
+}
+
+func TestGetScheduler(t *testing.T) {
+
+	router, mockRepository, _ := setUp()
+	mockRepository.On("GetDueUids", 100).Return([]string{}, nil)
+	mockRepository.On("GetNextDueDate", nil).Return(&nextDueDate, nil)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "http://localhost:8080/api/v1/scheduler/run", nil))
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/api/v1/scheduler", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	var scheduler SchedulerView
+
+	json.Unmarshal(body, &scheduler)
+
+	// verify
+
+	assert.True(t, scheduler.Enabled)
+	assert.Equal(t, "1m0s", scheduler.Interval)
+	assert.NotNil(t, scheduler.LastRun)
+	assert.Equal(t, scheduler.LastRun.Add(time.Minute), *scheduler.NextRun)
+	assert.Equal(t, nextDueDate, *scheduler.NextDuePaymentDate)
+}
+
+//
+// private functions
+
+func setUp() (*mux.Router, *schedulerRepositoryImplMock, *enqueuerMock) {
+
+	var router = mux.NewRouter()
+	var mockRepository schedulerRepositoryImplMock
+	var mockEnqueuer enqueuerMock
+
+	schedulerConfig := &config.SchedulerConfig{Enabled: true, Interval: time.Minute, BatchSize: 100}
+
+	NewSchedulerHandler(service.NewSchedulerService(&mockRepository, &mockEnqueuer, schedulerConfig)).Register(router)
+
+	return router, &mockRepository, &mockEnqueuer
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+// payments waiting for, or refused by, a risk review or an approval are never due, nor those not captured
+var (
+	heldReviewStatuses        = []string{paymentModel.REVIEW_STATUS_PENDING, paymentModel.REVIEW_STATUS_REJECTED}
+	heldApprovalStatuses      = []string{paymentModel.APPROVAL_STATUS_PENDING, paymentModel.APPROVAL_STATUS_REJECTED, paymentModel.APPROVAL_STATUS_EXPIRED}
+	heldAuthorizationStatuses = []string{paymentModel.AUTHORIZATION_STATUS_AUTHORIZED, paymentModel.AUTHORIZATION_STATUS_VOIDED, paymentModel.AUTHORIZATION_STATUS_EXPIRED}
+)
+
+type SchedulerRepository interface {
+	GetDueUids(now time.Time, limit int) ([]string, error)
+	GetNextDueDate() (*time.Time, error)
+}
+
+type schedulerRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewSchedulerRepositoryImpl(db *gorm.DB) SchedulerRepository {
+	return &schedulerRepositoryImpl{
+		db: db,
+	}
+}
+
+// GetDueUids returns the payments whose date has come and that nothing holds back, oldest first. Queuing them is left
+// to the processing, which locks each payment and refuses the ones another instance queued meanwhile.
+func (sri *schedulerRepositoryImpl) GetDueUids(now time.Time, limit int) ([]string, error) {
+
+	var uids []string
+	errorDB := sri.db.Model(&paymentModel.Payment{}).
+		Where("processed = ? AND date <= ?", false, now).
+		Where("review_status NOT IN (?)", heldReviewStatuses).
+		Where("approval_status NOT IN (?)", heldApprovalStatuses).
+		Where("authorization_status NOT IN (?)", heldAuthorizationStatuses).
+		Where("cancelled_at IS NULL AND processing_status = ?", "").
+		Order("date, id").
+		Limit(limit).
+		Pluck("uid", &uids).Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return uids, nil
+}
+
+func (sri *schedulerRepositoryImpl) GetNextDueDate() (*time.Time, error) {
+
+	var payment paymentModel.Payment
+	errorDB := sri.db.Where("processed = ?", false).
+		Where("review_status NOT IN (?)", heldReviewStatuses).
+		Where("approval_status NOT IN (?)", heldApprovalStatuses).
+		Where("authorization_status NOT IN (?)", heldAuthorizationStatuses).
+		Where("cancelled_at IS NULL AND processing_status = ?", "").
+		Order("date, id").First(&payment).Error
+
+	if gorm.IsRecordNotFoundError(errorDB) {
+		return nil, nil
+	}
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return &payment.Date, nil
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"github.com/javierjmgits/go-payment-api/base/config"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/scheduler/repository"
+	"log"
+	"sync"
+	"time"
+)
+
+// Enqueuer queues a payment for the processing workers, e.g. the processing service.
+type Enqueuer interface {
+	Enqueue(paymentUid string) (*paymentModel.Payment, error)
+}
+
+type Generator interface {
+	Generate(now time.Time) (int, error)
+}
+
+// Sweeper expires or releases stale state (e.g. approval requests) and returns how many items it changed.
+type Sweeper interface {
+	Sweep(now time.Time) (int, error)
+}
+
+type SchedulerStatus struct {
+	Enabled            bool
+	Interval           time.Duration
+	LastRun            *time.Time
+	LastRunProcessed   int
+	LastRunError       string
+	NextRun            *time.Time
+	NextDuePaymentDate *time.Time
+}
+
+type SchedulerService struct {
+	schedulerRepository repository.SchedulerRepository
+	enqueuer            Enqueuer
+	config              *config.SchedulerConfig
+	now                 func() time.Time
+	generators          []Generator
+	sweepers            []Sweeper
+
+	mutex            sync.Mutex
+	lastRun          *time.Time
+	lastRunProcessed int
+	lastRunError     error
+	nextRun          *time.Time
+	stop             chan struct{}
+}
+
+func NewSchedulerService(schedulerRepository repository.SchedulerRepository, enqueuer Enqueuer, config *config.SchedulerConfig) *SchedulerService {
+
+	return &SchedulerService{
+		schedulerRepository: schedulerRepository,
+		enqueuer:            enqueuer,
+		config:              config,
+		now:                 time.Now,
+	}
+}
+
+// AddGenerator registers a source of payments (e.g. standing orders) that is run before due payments are processed.
+func (ss *SchedulerService) AddGenerator(generator Generator) {
+
+	ss.generators = append(ss.generators, generator)
+}
+
+// AddSweeper registers a clean-up task that is run first on every scheduler run.
+func (ss *SchedulerService) AddSweeper(sweeper Sweeper) {
+
+	ss.sweepers = append(ss.sweepers, sweeper)
+}
+
+func (ss *SchedulerService) Start() {
+
+	if !ss.config.Enabled {
+		log.Println("Scheduler disabled")
+		return
+	}
+
+	ss.mutex.Lock()
+	ss.stop = make(chan struct{})
+	stop := ss.stop
+	ss.mutex.Unlock()
+
+	log.Printf("Scheduler polling every %v\n", ss.config.Interval)
+
+	go func() {
+
+		ticker := time.NewTicker(ss.config.Interval)
+		defer ticker.Stop()
+
+		ss.RunOnce()
+
+		for {
+			select {
+
+			case <-ticker.C:
+				ss.RunOnce()
+
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+func (ss *SchedulerService) Stop() {
+
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	if ss.stop != nil {
+		close(ss.stop)
+		ss.stop = nil
+	}
+}
+
+func (ss *SchedulerService) RunOnce() (int, error) {
+
+	now := ss.now()
+
+	for _, sweeper := range ss.sweepers {
+
+		swept, errorSweep := sweeper.Sweep(now)
+
+		if errorSweep != nil {
+			log.Printf("Scheduler sweeper failed: %v\n", errorSweep)
+			continue
+		}
+
+		if swept > 0 {
+			log.Printf("Scheduler swept %d item(s)\n", swept)
+		}
+	}
+
+	for _, generator := range ss.generators {
+
+		generated, errorGenerate := generator.Generate(now)
+
+		if errorGenerate != nil {
+			log.Printf("Scheduler generator failed: %v\n", errorGenerate)
+			continue
+		}
+
+		if generated > 0 {
+			log.Printf("Scheduler generated %d payment(s)\n", generated)
+		}
+	}
+
+	queued, errorQueue := ss.queueDue(now)
+
+	nextRun := now.Add(ss.config.Interval)
+
+	ss.mutex.Lock()
+	ss.lastRun = &now
+	ss.lastRunProcessed = queued
+	ss.lastRunError = errorQueue
+	ss.nextRun = &nextRun
+	ss.mutex.Unlock()
+
+	if errorQueue != nil {
+		log.Printf("Scheduler run failed: %v\n", errorQueue)
+		return queued, errorQueue
+	}
+
+	if queued > 0 {
+		log.Printf("Scheduler queued %d payment(s)\n", queued)
+	}
+
+	return queued, nil
+}
+
+func (ss *SchedulerService) Status() (*SchedulerStatus, error) {
+
+	nextDueDate, errorDB := ss.schedulerRepository.GetNextDueDate()
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	status := &SchedulerStatus{
+		Enabled:            ss.config.Enabled,
+		Interval:           ss.config.Interval,
+		LastRun:            ss.lastRun,
+		LastRunProcessed:   ss.lastRunProcessed,
+		NextRun:            ss.nextRun,
+		NextDuePaymentDate: nextDueDate,
+	}
+
+	if ss.lastRunError != nil {
+		status.LastRunError = ss.lastRunError.Error()
+	}
+
+	return status, nil
+}
+
+//
+// private functions
+
+// queueDue hands the due payments to the processing workers, which process them like any other queued payment
+func (ss *SchedulerService) queueDue(now time.Time) (int, error) {
+
+	uids, errorDB := ss.schedulerRepository.GetDueUids(now, ss.config.BatchSize)
+
+	if errorDB != nil {
+		return 0, errorDB
+	}
+
+	queued := 0
+
+	var errorQueue error
+
+	for _, uid := range uids {
+
+		_, errorEnqueue := ss.enqueuer.Enqueue(uid)
+
+		if errorEnqueue == nil {
+			queued++
+			continue
+		}
+
+		// queued by another instance, or changed since it was found due
+		if _, isConflict := errorEnqueue.(*util.ConflictError); isConflict {
+			continue
+		}
+
+		errorQueue = errorEnqueue
+	}
+
+	return queued, errorQueue
+}
@@ -0,0 +1,324 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/account/identifier"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	paymentHandler "github.com/javierjmgits/go-payment-api/payment/handler"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/standingorder/model"
+	"github.com/javierjmgits/go-payment-api/standingorder/recurrence"
+	"github.com/javierjmgits/go-payment-api/standingorder/repository"
+	"github.com/javierjmgits/go-payment-api/standingorder/service"
+	"github.com/satori/go.uuid"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type StandingOrderHandler struct {
+	standingOrderRepository repository.StandingOrderRepository
+}
+
+type StandingOrderView struct {
+	Uid            string     `json:"uid"`
+	AccountOrigin  string     `json:"accountOrigin"`
+	AccountTarget  string     `json:"accountTarget"`
+	Amount         float64    `json:"amount"`
+	Currency       string     `json:"currency"`
+	Rule           string     `json:"rule"`
+	StartDate      time.Time  `json:"startDate"`
+	EndDate        *time.Time `json:"endDate"`
+	MissedPolicy   string     `json:"missedPolicy"`
+	LastOccurrence *time.Time `json:"lastOccurrence"`
+	NextOccurrence *time.Time `json:"nextOccurrence"`
+	CreatedBy      string     `json:"createdBy,omitempty"`
+	Tenant         string     `json:"tenant,omitempty"`
+}
+
+type StandingOrderCreate struct {
+	AccountOrigin string     `json:"accountOrigin"`
+	AccountTarget string     `json:"accountTarget"`
+	Amount        float64    `json:"amount"`
+	Currency      string     `json:"currency"`
+	Rule          string     `json:"rule"`
+	StartDate     time.Time  `json:"startDate"`
+	EndDate       *time.Time `json:"endDate"`
+	MissedPolicy  string     `json:"missedPolicy"`
+}
+
+type StandingOrderUpdate struct {
+	Amount       float64    `json:"amount"`
+	Currency     string     `json:"currency"`
+	Rule         string     `json:"rule"`
+	EndDate      *time.Time `json:"endDate"`
+	MissedPolicy string     `json:"missedPolicy"`
+}
+
+func NewStandingOrderHandler(standingOrderRepository repository.StandingOrderRepository) *StandingOrderHandler {
+
+	return &StandingOrderHandler{
+		standingOrderRepository: standingOrderRepository,
+	}
+}
+
+func (soh *StandingOrderHandler) Register(router *mux.Router) {
+	router.HandleFunc("/api/v1/standing-orders", soh.GetStandingOrders).Methods("GET")
+	router.HandleFunc("/api/v1/standing-orders/uid/{uid}", soh.GetStandingOrderByUid).Methods("GET")
+	router.HandleFunc("/api/v1/standing-orders", soh.CreateStandingOrder).Methods("POST")
+	router.HandleFunc("/api/v1/standing-orders/uid/{uid}", soh.UpdateStandingOrderByUid).Methods("PUT")
+	router.HandleFunc("/api/v1/standing-orders/uid/{uid}", soh.DeleteStandingOrderByUid).Methods("DELETE")
+}
+
+func (soh *StandingOrderHandler) GetStandingOrders(w http.ResponseWriter, r *http.Request) {
+
+	standingOrders, errorDB := soh.standingOrderRepository.GetAll()
+
+	if errorDB != nil {
+		util.WriteError(w, http.StatusInternalServerError, errorDB.Error())
+		return
+	}
+
+	var results []StandingOrderView
+
+	for index := range standingOrders {
+		results = append(results, *newStandingOrderView(&standingOrders[index]))
+	}
+
+	util.WritePayload(w, http.StatusOK, results)
+}
+
+func (soh *StandingOrderHandler) GetStandingOrderByUid(w http.ResponseWriter, r *http.Request) {
+
+	standingOrder, responseGenerated := soh.getAndCheckStandingOrderByUid(w, r)
+
+	if responseGenerated {
+		return
+	}
+
+	util.WritePayload(w, http.StatusOK, newStandingOrderView(standingOrder))
+}
+
+func (soh *StandingOrderHandler) CreateStandingOrder(w http.ResponseWriter, r *http.Request) {
+
+	var standingOrderCreate StandingOrderCreate
+
+	errorJson := json.NewDecoder(r.Body).Decode(&standingOrderCreate)
+
+	if errorJson != nil {
+		util.WriteError(w, http.StatusBadRequest, errorJson.Error())
+		return
+	}
+
+	paymentCreate := &paymentHandler.PaymentCreate{
+		AccountOrigin: standingOrderCreate.AccountOrigin,
+		AccountTarget: standingOrderCreate.AccountTarget,
+		Amount:        standingOrderCreate.Amount,
+	}
+
+	errorValidation := paymentHandler.ValidatePaymentCreate(paymentCreate)
+
+	if errorValidation != nil {
+		util.WriteError(w, http.StatusBadRequest, errorValidation.Error())
+		return
+	}
+
+	if standingOrderCreate.StartDate.IsZero() {
+		util.WriteError(w, http.StatusBadRequest, "start date is mandatory")
+		return
+	}
+
+	uuidResult, errorUuid := uuid.NewV4()
+
+	if errorUuid != nil {
+		util.WriteError(w, http.StatusInternalServerError, errorUuid.Error())
+		return
+	}
+
+	principal := auth.FromRequest(r)
+
+	standingOrder := &model.StandingOrder{
+		Uid:           uuidResult.String(),
+		AccountOrigin: paymentCreate.AccountOrigin,
+		AccountTarget: paymentCreate.AccountTarget,
+		StartDate:     standingOrderCreate.StartDate,
+		CreatedBy:     principal.User,
+		Tenant:        principal.Tenant,
+	}
+
+	errorSchedule := applySchedule(standingOrder, &StandingOrderUpdate{
+		Amount:       standingOrderCreate.Amount,
+		Currency:     standingOrderCreate.Currency,
+		Rule:         standingOrderCreate.Rule,
+		EndDate:      standingOrderCreate.EndDate,
+		MissedPolicy: standingOrderCreate.MissedPolicy,
+	})
+
+	if errorSchedule != nil {
+		util.WriteError(w, http.StatusBadRequest, errorSchedule.Error())
+		return
+	}
+
+	standingOrderSaved, errorDB := soh.standingOrderRepository.Create(standingOrder)
+
+	if errorDB != nil {
+		util.WriteError(w, http.StatusInternalServerError, errorDB.Error())
+		return
+	}
+
+	util.WritePayload(w, http.StatusCreated, newStandingOrderView(standingOrderSaved))
+}
+
+func (soh *StandingOrderHandler) UpdateStandingOrderByUid(w http.ResponseWriter, r *http.Request) {
+
+	standingOrder, responseGenerated := soh.getAndCheckStandingOrderByUid(w, r)
+
+	if responseGenerated {
+		return
+	}
+
+	var standingOrderUpdate StandingOrderUpdate
+
+	errorJson := json.NewDecoder(r.Body).Decode(&standingOrderUpdate)
+
+	if errorJson != nil {
+		util.WriteError(w, http.StatusBadRequest, errorJson.Error())
+		return
+	}
+
+	if standingOrderUpdate.Amount <= 0 {
+		util.WriteError(w, http.StatusBadRequest, "amount must be a positive number")
+		return
+	}
+
+	errorSchedule := applySchedule(standingOrder, &standingOrderUpdate)
+
+	if errorSchedule != nil {
+		util.WriteError(w, http.StatusBadRequest, errorSchedule.Error())
+		return
+	}
+
+	standingOrder, errorDB := soh.standingOrderRepository.Update(standingOrder)
+
+	if errorDB != nil {
+		util.WriteError(w, http.StatusInternalServerError, errorDB.Error())
+		return
+	}
+
+	util.WritePayload(w, http.StatusOK, newStandingOrderView(standingOrder))
+}
+
+func (soh *StandingOrderHandler) DeleteStandingOrderByUid(w http.ResponseWriter, r *http.Request) {
+
+	standingOrder, responseGenerated := soh.getAndCheckStandingOrderByUid(w, r)
+
+	if responseGenerated {
+		return
+	}
+
+	errorDB := soh.standingOrderRepository.Delete(standingOrder)
+
+	if errorDB != nil {
+		util.WriteError(w, http.StatusInternalServerError, errorDB.Error())
+		return
+	}
+
+	util.WritePayload(w, http.StatusNoContent, map[string]string{})
+}
+
+//
+// private functions
+
+func (soh *StandingOrderHandler) getAndCheckStandingOrderByUid(w http.ResponseWriter, r *http.Request) (standingOrder *model.StandingOrder, responseGenerated bool) {
+
+	uid := mux.Vars(r)["uid"]
+
+	standingOrder, errorDB := soh.standingOrderRepository.GetByUid(uid)
+
+	if errorDB != nil {
+
+		if strings.Contains(errorDB.Error(), "not found") {
+			util.WriteError(w, http.StatusNotFound, errorDB.Error())
+
+		} else {
+			util.WriteError(w, http.StatusInternalServerError, errorDB.Error())
+		}
+
+		return nil, true
+	}
+
+	return standingOrder, false
+}
+
+func applySchedule(standingOrder *model.StandingOrder, standingOrderUpdate *StandingOrderUpdate) error {
+
+	// as for payments, the currency defaults to the default one and is stored uppercase
+	currency := strings.ToUpper(strings.TrimSpace(standingOrderUpdate.Currency))
+
+	if currency == "" {
+		currency = paymentModel.DEFAULT_CURRENCY
+	}
+
+	if !util.IsCurrencyCode(currency) {
+		return errors.New("currency must be an ISO 4217 code")
+	}
+
+	rule, errorRule := recurrence.Parse(standingOrderUpdate.Rule)
+
+	if errorRule != nil {
+		return errors.New("invalid rule: " + errorRule.Error())
+	}
+
+	if standingOrderUpdate.EndDate != nil && standingOrderUpdate.EndDate.Before(standingOrder.StartDate) {
+		return errors.New("end date must not be before start date")
+	}
+
+	missedPolicy := standingOrderUpdate.MissedPolicy
+
+	if missedPolicy == "" {
+		missedPolicy = model.MISSED_POLICY_CATCH_UP
+	}
+
+	if missedPolicy != model.MISSED_POLICY_CATCH_UP && missedPolicy != model.MISSED_POLICY_SKIP {
+		return errors.New("missed policy must be CATCH_UP or SKIP")
+	}
+
+	standingOrder.Amount = standingOrderUpdate.Amount
+	standingOrder.Currency = currency
+	standingOrder.Rule = standingOrderUpdate.Rule
+	standingOrder.EndDate = standingOrderUpdate.EndDate
+	standingOrder.MissedPolicy = missedPolicy
+
+	// occurrences already materialized are never generated again
+	after := standingOrder.StartDate.Add(-time.Nanosecond)
+
+	if standingOrder.LastOccurrence != nil {
+		after = *standingOrder.LastOccurrence
+	}
+
+	standingOrder.NextOccurrence = service.NextOccurrence(rule, standingOrder, after)
+
+	return nil
+}
+
+func newStandingOrderView(standingOrder *model.StandingOrder) *StandingOrderView {
+
+	return &StandingOrderView{
+		Uid:            standingOrder.Uid,
+		AccountOrigin:  identifier.Format(standingOrder.AccountOrigin),
+		AccountTarget:  identifier.Format(standingOrder.AccountTarget),
+		Amount:         standingOrder.Amount,
+		Currency:       standingOrder.Currency,
+		Rule:           standingOrder.Rule,
+		StartDate:      standingOrder.StartDate,
+		EndDate:        standingOrder.EndDate,
+		MissedPolicy:   standingOrder.MissedPolicy,
+		LastOccurrence: standingOrder.LastOccurrence,
+		NextOccurrence: standingOrder.NextOccurrence,
+		CreatedBy:      standingOrder.CreatedBy,
+		Tenant:         standingOrder.Tenant,
+	}
+}
@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	"github.com/javierjmgits/go-payment-api/standingorder/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+//
+// mock data
+
+var startDate = time.Date(2026, 1, 15, 9, 0, 0, 0, time.UTC)
+
+//
+// mocks
+
+type standingOrderRepositoryImplMock struct {
+	mock.Mock
+}
+
+func (mock *standingOrderRepositoryImplMock) GetAll() ([]model.StandingOrder, error) {
+
+	args := mock.Mock.Called(nil)
+
+	results := args.Get(0)
+
+	if results != nil {
+		return results.([]model.StandingOrder), nil
+	}
+
+	return nil, args.Get(1).(error)
+}
+
+func (mock *standingOrderRepositoryImplMock) GetByUid(uid string) (*model.StandingOrder, error) {
+
+	args := mock.Mock.Called(uid)
+
+	result := args.Get(0)
+
+	if result != nil {
+		return result.(*model.StandingOrder), nil
+	}
+
+	return nil, args.Get(1).(error)
+}
+
+func (mock *standingOrderRepositoryImplMock) Create(standingOrder *model.StandingOrder) (*model.StandingOrder, error) {
+
+	mock.Mock.Called(standingOrder)
+
+	return standingOrder, nil
+}
+
+func (mock *standingOrderRepositoryImplMock) Update(standingOrder *model.StandingOrder) (*model.StandingOrder, error) {
+
+	mock.Mock.Called(standingOrder)
+
+	return standingOrder, nil
+}
+
+func (mock *standingOrderRepositoryImplMock) Delete(standingOrder *model.StandingOrder) error {
+
+	mock.Mock.Called(standingOrder)
+
+	return nil
+}
+
+func (mock *standingOrderRepositoryImplMock) GetDueUids(now time.Time) ([]string, error) {
+
+	args := mock.Mock.Called(now)
+
+	return args.Get(0).([]string), nil
+}
+
+func (mock *standingOrderRepositoryImplMock) Materialize(uid string, generate func(*model.StandingOrder) (int, error)) (int, error) {
+
+	args := mock.Mock.Called(uid)
+
+	return generate(args.Get(0).(*model.StandingOrder))
+}
+
+//
+// tests
+
+func TestGetStandingOrderByUidKoNotFound(t *testing.T) {
+
+	router, mockRepository := setUp()
+	mockRepository.On("GetByUid", "unknown").Return(nil, errors.New("record not found"))
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/api/v1/standing-orders/uid/unknown", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestCreateStandingOrderKoInvalidRule(t *testing.T) {
+
+	router, mockRepository := setUp()
+	standingOrderCreate := expectedStandingOrderCreate()
+	standingOrderCreate.Rule = "FREQ=HOURLY"
+
+	resp := post(router, standingOrderCreate)
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestCreateStandingOrderKoInvalidCurrency(t *testing.T) {
+
+	router, mockRepository := setUp()
+	standingOrderCreate := expectedStandingOrderCreate()
+	standingOrderCreate.Currency = "euro"
+
+	resp := post(router, standingOrderCreate)
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestCreateStandingOrder(t *testing.T) {
+
+	router, mockRepository := setUp()
+	standingOrderCreate := expectedStandingOrderCreate()
+	mockRepository.On("Create", mock.MatchedBy(func(passed *model.StandingOrder) bool {
+		return passed.Uid != "" && passed.MissedPolicy == model.MISSED_POLICY_CATCH_UP && passed.CreatedBy == "alice" && passed.Tenant == "acme"
+	})).Return(nil)
+
+	resp := post(router, standingOrderCreate)
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	var standingOrder StandingOrderView
+
+	json.Unmarshal(body, &standingOrder)
+
+	// verify
+
+	assert.NotEmpty(t, standingOrder.Uid)
+	assert.Equal(t, "EUR", standingOrder.Currency)
+	assert.Equal(t, "alice", standingOrder.CreatedBy)
+	assert.Nil(t, standingOrder.LastOccurrence)
+	assert.Equal(t, time.Date(2026, 2, 1, 9, 0, 0, 0, time.UTC), *standingOrder.NextOccurrence)
+}
+
+func TestCreateStandingOrderCurrency(t *testing.T) {
+
+	for currency, expected := range map[string]string{"": "EUR", "gbp": "GBP", " usd ": "USD"} {
+
+		router, mockRepository := setUp()
+		standingOrderCreate := expectedStandingOrderCreate()
+		standingOrderCreate.Currency = currency
+		mockRepository.On("Create", mock.MatchedBy(func(passed *model.StandingOrder) bool {
+			return passed.Currency == expected
+		})).Return(nil)
+
+		resp := post(router, standingOrderCreate)
+
+		// verify
+
+		mockRepository.AssertExpectations(t)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode, currency)
+	}
+}
+
+func TestUpdateStandingOrderByUidKeepsMaterializedOccurrences(t *testing.T) {
+
+	router, mockRepository := setUp()
+	lastOccurrence := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	expectedStandingOrder := &model.StandingOrder{Uid: "myUid", StartDate: startDate, LastOccurrence: &lastOccurrence}
+	mockRepository.On("GetByUid", "myUid").Return(expectedStandingOrder, nil)
+	mockRepository.On("Update", expectedStandingOrder).Return(nil)
+
+	standingOrderUpdate := StandingOrderUpdate{Amount: 50, Currency: "EUR", Rule: "FREQ=MONTHLY;BYMONTHDAY=-1"}
+	standingOrderUpdateAsBytes, _ := json.Marshal(standingOrderUpdate)
+
+	req := httptest.NewRequest("PUT", "http://localhost:8080/api/v1/standing-orders/uid/myUid", bytes.NewReader(standingOrderUpdateAsBytes))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	mockRepository.AssertExpectations(t)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// verify
+
+	assert.Equal(t, 50.0, expectedStandingOrder.Amount)
+	assert.Equal(t, time.Date(2026, 3, 31, 9, 0, 0, 0, time.UTC), *expectedStandingOrder.NextOccurrence)
+}
+
+//
+// private functions
+
+func setUp() (*mux.Router, *standingOrderRepositoryImplMock) {
+
+	var router = mux.NewRouter()
+	var mockRepository standingOrderRepositoryImplMock
+
+	NewStandingOrderHandler(&mockRepository).Register(router)
+
+	return router, &mockRepository
+}
+
+func expectedStandingOrderCreate() *StandingOrderCreate {
+
+	return &StandingOrderCreate{
+		AccountOrigin: "60-16-13 31926819",
+		AccountTarget: "20-00-00 55779911",
+		Amount:        25,
+		Currency:      "EUR",
+		Rule:          "FREQ=MONTHLY;BYMONTHDAY=1",
+		StartDate:     startDate,
+	}
+}
+
+func post(router *mux.Router, standingOrderCreate *StandingOrderCreate) *http.Response {
+
+	standingOrderCreateAsBytes, _ := json.Marshal(standingOrderCreate)
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/api/v1/standing-orders", bytes.NewReader(standingOrderCreateAsBytes))
+	req.Header.Set(auth.HEADER_USER, "alice")
+	req.Header.Set(auth.HEADER_TENANT, "acme")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	return w.Result()
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+const (
+	MISSED_POLICY_CATCH_UP = "CATCH_UP"
+	MISSED_POLICY_SKIP     = "SKIP"
+)
+
+type StandingOrder struct {
+	gorm.Model
+	Uid            string     `gorm:"unique;not null"`
+	AccountOrigin  string     `gorm:"not null"`
+	AccountTarget  string     `gorm:"not null"`
+	Amount         float64    `gorm:"not null"`
+	Currency       string     `gorm:"not null"`
+	Rule           string     `gorm:"not null"`
+	StartDate      time.Time  `gorm:"not null"`
+	EndDate        *time.Time `gorm:"null"`
+	MissedPolicy   string     `gorm:"not null"`
+	LastOccurrence *time.Time `gorm:"null"`
+	NextOccurrence *time.Time `gorm:"null;index"`
+
+	// the principal that set the standing order up, on whose behalf its payments are created
+	CreatedBy string `gorm:"not null;default:''"`
+	Tenant    string `gorm:"not null;default:''"`
+}
+
+func SetUp(db *gorm.DB) *gorm.DB {
+
+	db.AutoMigrate(&StandingOrder{})
+
+	return db
+}
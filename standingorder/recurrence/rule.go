@@ -0,0 +1,276 @@
+package recurrence
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FREQUENCY_DAILY   = "DAILY"
+	FREQUENCY_WEEKLY  = "WEEKLY"
+	FREQUENCY_MONTHLY = "MONTHLY"
+	FREQUENCY_YEARLY  = "YEARLY"
+
+	// safety bound on the periods scanned when looking for the next occurrence
+	MAX_PERIODS = 10000
+)
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// Rule is the subset of RFC 5545 RRULE supported for standing orders:
+// FREQ, INTERVAL, BYDAY (daily/weekly) and BYMONTHDAY (monthly, negative values count from the end of the month).
+// Days beyond the end of a month are clamped to its last day, so BYMONTHDAY=31 pays on 28/29 February.
+type Rule struct {
+	Frequency  string
+	Interval   int
+	ByDay      []time.Weekday
+	ByMonthDay []int
+}
+
+func Parse(value string) (*Rule, error) {
+
+	rule := &Rule{Interval: 1}
+
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+
+	for _, part := range strings.Split(value, ";") {
+
+		keyValue := strings.SplitN(strings.TrimSpace(part), "=", 2)
+
+		if len(keyValue) != 2 {
+			return nil, fmt.Errorf("invalid rule part '%s'", part)
+		}
+
+		key, partValue := strings.ToUpper(keyValue[0]), strings.ToUpper(keyValue[1])
+
+		switch key {
+
+		case "FREQ":
+			switch partValue {
+			case FREQUENCY_DAILY, FREQUENCY_WEEKLY, FREQUENCY_MONTHLY, FREQUENCY_YEARLY:
+				rule.Frequency = partValue
+			default:
+				return nil, fmt.Errorf("unsupported frequency '%s'", partValue)
+			}
+
+		case "INTERVAL":
+			interval, errorInterval := strconv.Atoi(partValue)
+
+			if errorInterval != nil || interval < 1 {
+				return nil, fmt.Errorf("interval must be a positive integer")
+			}
+
+			rule.Interval = interval
+
+		case "BYDAY":
+			for _, day := range strings.Split(partValue, ",") {
+
+				weekday, found := weekdays[day]
+
+				if !found {
+					return nil, fmt.Errorf("invalid day '%s'", day)
+				}
+
+				rule.ByDay = append(rule.ByDay, weekday)
+			}
+
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(partValue, ",") {
+
+				monthDay, errorMonthDay := strconv.Atoi(day)
+
+				if errorMonthDay != nil || monthDay == 0 || monthDay < -31 || monthDay > 31 {
+					return nil, fmt.Errorf("invalid month day '%s'", day)
+				}
+
+				rule.ByMonthDay = append(rule.ByMonthDay, monthDay)
+			}
+
+		default:
+			return nil, fmt.Errorf("unsupported rule part '%s'", key)
+		}
+	}
+
+	if rule.Frequency == "" {
+		return nil, fmt.Errorf("FREQ is mandatory")
+	}
+
+	if len(rule.ByDay) > 0 && rule.Frequency != FREQUENCY_DAILY && rule.Frequency != FREQUENCY_WEEKLY {
+		return nil, fmt.Errorf("BYDAY is only supported with DAILY or WEEKLY frequency")
+	}
+
+	if len(rule.ByMonthDay) > 0 && rule.Frequency != FREQUENCY_MONTHLY {
+		return nil, fmt.Errorf("BYMONTHDAY is only supported with MONTHLY frequency")
+	}
+
+	return rule, nil
+}
+
+// Next returns the first occurrence strictly after "after" of the series starting at "start".
+func (rule *Rule) Next(start time.Time, after time.Time) (time.Time, bool) {
+
+	firstPeriod := rule.periodsBetween(start, after) - 1
+
+	if firstPeriod < 0 {
+		firstPeriod = 0
+	}
+
+	for period := firstPeriod; period < firstPeriod+MAX_PERIODS; period++ {
+
+		for _, candidate := range rule.candidates(start, period) {
+
+			if !candidate.Before(start) && candidate.After(after) {
+				return candidate, true
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
+
+//
+// private functions
+
+func (rule *Rule) periodsBetween(start time.Time, after time.Time) int {
+
+	if !after.After(start) {
+		return 0
+	}
+
+	switch rule.Frequency {
+
+	case FREQUENCY_DAILY:
+		return int(after.Sub(start).Hours()/24) / rule.Interval
+
+	case FREQUENCY_WEEKLY:
+		return int(after.Sub(start).Hours()/24/7) / rule.Interval
+
+	case FREQUENCY_MONTHLY:
+		return ((after.Year()-start.Year())*12 + int(after.Month()) - int(start.Month())) / rule.Interval
+
+	default:
+		return (after.Year() - start.Year()) / rule.Interval
+	}
+}
+
+func (rule *Rule) candidates(start time.Time, period int) []time.Time {
+
+	var results []time.Time
+
+	switch rule.Frequency {
+
+	case FREQUENCY_DAILY:
+		day := start.AddDate(0, 0, period*rule.Interval)
+
+		if len(rule.ByDay) == 0 || containsWeekday(rule.ByDay, day.Weekday()) {
+			results = append(results, day)
+		}
+
+	case FREQUENCY_WEEKLY:
+		weekStart := start.AddDate(0, 0, period*rule.Interval*7)
+
+		if len(rule.ByDay) == 0 {
+			results = append(results, weekStart)
+			break
+		}
+
+		monday := weekStart.AddDate(0, 0, -((int(weekStart.Weekday()) + 6) % 7))
+
+		for offset := 0; offset < 7; offset++ {
+
+			day := monday.AddDate(0, 0, offset)
+
+			if containsWeekday(rule.ByDay, day.Weekday()) {
+				results = append(results, day)
+			}
+		}
+
+	case FREQUENCY_MONTHLY:
+		year, month := start.Year(), int(start.Month())-1+period*rule.Interval
+		year, month = year+month/12, month%12+1
+
+		monthDays := rule.ByMonthDay
+
+		if len(monthDays) == 0 {
+			monthDays = []int{start.Day()}
+		}
+
+		lastDay := daysIn(year, time.Month(month))
+
+		for _, monthDay := range monthDays {
+
+			day := monthDay
+
+			if day < 0 {
+				day = lastDay + 1 + day
+			}
+
+			if day < 1 {
+				continue
+			}
+
+			if day > lastDay {
+				day = lastDay
+			}
+
+			results = append(results, dateAt(start, year, time.Month(month), day))
+		}
+
+	default:
+		year := start.Year() + period*rule.Interval
+		day := start.Day()
+
+		if lastDay := daysIn(year, start.Month()); day > lastDay {
+			day = lastDay
+		}
+
+		results = append(results, dateAt(start, year, start.Month(), day))
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Before(results[j])
+	})
+
+	// clamped month days may collapse into the same date (e.g. BYMONTHDAY=30,31 in February)
+	var unique []time.Time
+
+	for _, result := range results {
+
+		if len(unique) == 0 || !unique[len(unique)-1].Equal(result) {
+			unique = append(unique, result)
+		}
+	}
+
+	return unique
+}
+
+func dateAt(start time.Time, year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func containsWeekday(days []time.Weekday, day time.Weekday) bool {
+
+	for _, item := range days {
+
+		if item == day {
+			return true
+		}
+	}
+
+	return false
+}
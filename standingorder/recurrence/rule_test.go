@@ -0,0 +1,101 @@
+package recurrence
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+//
+// tests
+
+func TestParseKoInvalidRules(t *testing.T) {
+
+	for _, value := range []string{"", "INTERVAL=2", "FREQ=HOURLY", "FREQ=MONTHLY;INTERVAL=0", "FREQ=MONTHLY;BYDAY=MO", "FREQ=WEEKLY;BYMONTHDAY=1", "FREQ=MONTHLY;BYMONTHDAY=32"} {
+
+		_, errorParse := Parse(value)
+
+		assert.NotNil(t, errorParse, value)
+	}
+}
+
+func TestNextMonthlyFirstDay(t *testing.T) {
+
+	rule, _ := Parse("FREQ=MONTHLY;BYMONTHDAY=1")
+	start := date(2026, 1, 15)
+
+	assert.Equal(t, []time.Time{date(2026, 2, 1), date(2026, 3, 1), date(2026, 4, 1)}, occurrences(rule, start, 3))
+}
+
+func TestNextMonthlyEndOfMonth(t *testing.T) {
+
+	rule, _ := Parse("FREQ=MONTHLY")
+	start := date(2026, 1, 31)
+
+	assert.Equal(t, []time.Time{date(2026, 1, 31), date(2026, 2, 28), date(2026, 3, 31), date(2026, 4, 30)}, occurrences(rule, start, 4))
+}
+
+func TestNextMonthlyLastDay(t *testing.T) {
+
+	rule, _ := Parse("RRULE:FREQ=MONTHLY;BYMONTHDAY=-1")
+	start := date(2028, 1, 1)
+
+	assert.Equal(t, []time.Time{date(2028, 1, 31), date(2028, 2, 29), date(2028, 3, 31)}, occurrences(rule, start, 3))
+}
+
+func TestNextWeeklyByDay(t *testing.T) {
+
+	rule, _ := Parse("FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR")
+
+	// 2026-01-07 is a Wednesday
+	start := date(2026, 1, 7)
+
+	assert.Equal(t, []time.Time{date(2026, 1, 9), date(2026, 1, 19), date(2026, 1, 23)}, occurrences(rule, start, 3))
+}
+
+func TestNextYearlyLeapDay(t *testing.T) {
+
+	rule, _ := Parse("FREQ=YEARLY")
+	start := date(2028, 2, 29)
+
+	assert.Equal(t, []time.Time{date(2028, 2, 29), date(2029, 2, 28)}, occurrences(rule, start, 2))
+}
+
+func TestNextAfterLongDowntime(t *testing.T) {
+
+	rule, _ := Parse("FREQ=DAILY;INTERVAL=3")
+	start := date(2026, 1, 1)
+
+	next, found := rule.Next(start, date(2027, 1, 1))
+
+	assert.True(t, found)
+	assert.Equal(t, date(2027, 1, 2), next)
+}
+
+//
+// private functions
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 9, 0, 0, 0, time.UTC)
+}
+
+func occurrences(rule *Rule, start time.Time, count int) []time.Time {
+
+	var results []time.Time
+
+	after := start.Add(-time.Nanosecond)
+
+	for len(results) < count {
+
+		next, found := rule.Next(start, after)
+
+		if !found {
+			break
+		}
+
+		results = append(results, next)
+		after = next
+	}
+
+	return results
+}
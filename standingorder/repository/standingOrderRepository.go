@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"github.com/javierjmgits/go-payment-api/standingorder/model"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+type StandingOrderRepository interface {
+	GetAll() ([]model.StandingOrder, error)
+	GetByUid(uid string) (*model.StandingOrder, error)
+	Create(*model.StandingOrder) (*model.StandingOrder, error)
+	Update(*model.StandingOrder) (*model.StandingOrder, error)
+	Delete(*model.StandingOrder) error
+	GetDueUids(now time.Time) ([]string, error)
+	Materialize(uid string, generate func(*model.StandingOrder) (int, error)) (int, error)
+}
+
+type standingOrderRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewStandingOrderRepositoryImpl(db *gorm.DB) StandingOrderRepository {
+	return &standingOrderRepositoryImpl{
+		db: db,
+	}
+}
+
+func (sori *standingOrderRepositoryImpl) GetAll() ([]model.StandingOrder, error) {
+
+	var standingOrders []model.StandingOrder
+	errorDB := sori.db.Find(&standingOrders).Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return standingOrders, nil
+}
+
+func (sori *standingOrderRepositoryImpl) GetByUid(uid string) (*model.StandingOrder, error) {
+
+	var standingOrder model.StandingOrder
+	errorFind := sori.db.Where("uid = ?", uid).First(&standingOrder).Error
+
+	if errorFind != nil {
+		return nil, errorFind
+	}
+
+	return &standingOrder, nil
+}
+
+func (sori *standingOrderRepositoryImpl) Create(standingOrder *model.StandingOrder) (*model.StandingOrder, error) {
+
+	errorDB := sori.db.Create(standingOrder).Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return standingOrder, nil
+}
+
+func (sori *standingOrderRepositoryImpl) Update(standingOrder *model.StandingOrder) (*model.StandingOrder, error) {
+
+	errorDB := sori.db.Save(standingOrder).Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return standingOrder, nil
+}
+
+func (sori *standingOrderRepositoryImpl) Delete(standingOrder *model.StandingOrder) error {
+
+	return sori.db.Delete(standingOrder).Error
+}
+
+func (sori *standingOrderRepositoryImpl) GetDueUids(now time.Time) ([]string, error) {
+
+	var uids []string
+	errorDB := sori.db.Model(&model.StandingOrder{}).
+		Where("next_occurrence IS NOT NULL AND next_occurrence <= ?", now).
+		Order("next_occurrence").
+		Pluck("uid", &uids).Error
+
+	if errorDB != nil {
+		return nil, errorDB
+	}
+
+	return uids, nil
+}
+
+// Materialize locks the standing order while generate creates its due payments, then saves the occurrences generate
+// moved it to.
+func (sori *standingOrderRepositoryImpl) Materialize(uid string, generate func(*model.StandingOrder) (int, error)) (int, error) {
+
+	tx := sori.db.Begin()
+
+	// the lock makes concurrent instances materialize each occurrence only once
+	var standingOrder model.StandingOrder
+	errorDB := tx.Set("gorm:query_option", "FOR UPDATE").Where("uid = ?", uid).First(&standingOrder).Error
+
+	if errorDB != nil {
+		tx.Rollback()
+		return 0, errorDB
+	}
+
+	generated, errorGenerate := generate(&standingOrder)
+
+	if errorGenerate != nil {
+		tx.Rollback()
+		return 0, errorGenerate
+	}
+
+	if errorDB := tx.Save(&standingOrder).Error; errorDB != nil {
+		tx.Rollback()
+		return 0, errorDB
+	}
+
+	errorDB = tx.Commit().Error
+
+	if errorDB != nil {
+		return 0, errorDB
+	}
+
+	return generated, nil
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"fmt"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/payment/handler"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/standingorder/model"
+	"github.com/javierjmgits/go-payment-api/standingorder/recurrence"
+	"github.com/javierjmgits/go-payment-api/standingorder/repository"
+	"log"
+	"time"
+)
+
+type StandingOrderService struct {
+	standingOrderRepository repository.StandingOrderRepository
+	paymentCreator          PaymentCreator
+}
+
+// PaymentCreator creates a payment the way the API does, e.g. the payment handler, so that the payments of a standing
+// order go through the same hooks and limits as any other.
+type PaymentCreator interface {
+	Create(paymentCreate *handler.PaymentCreate, principal *auth.Principal) (*paymentModel.Payment, error)
+}
+
+func NewStandingOrderService(standingOrderRepository repository.StandingOrderRepository, paymentCreator PaymentCreator) *StandingOrderService {
+
+	return &StandingOrderService{
+		standingOrderRepository: standingOrderRepository,
+		paymentCreator:          paymentCreator,
+	}
+}
+
+func (sos *StandingOrderService) Generate(now time.Time) (int, error) {
+
+	uids, errorDB := sos.standingOrderRepository.GetDueUids(now)
+
+	if errorDB != nil {
+		return 0, errorDB
+	}
+
+	total := 0
+
+	for _, uid := range uids {
+
+		generated, errorMaterialize := sos.standingOrderRepository.Materialize(uid, func(standingOrder *model.StandingOrder) (int, error) {
+			return sos.createPayments(standingOrder, now)
+		})
+
+		if errorMaterialize != nil {
+			log.Printf("Error materializing standing order %s: %v\n", uid, errorMaterialize)
+			continue
+		}
+
+		total += generated
+	}
+
+	return total, nil
+}
+
+// GeneratePayments gives the payments to create for every occurrence up to now and moves the standing order to its next
+// occurrence. Each payment has an idempotency key of its occurrence, so that creating it again is harmless.
+func GeneratePayments(standingOrder *model.StandingOrder, now time.Time) ([]*handler.PaymentCreate, error) {
+
+	rule, errorRule := recurrence.Parse(standingOrder.Rule)
+
+	if errorRule != nil {
+		return nil, errorRule
+	}
+
+	var occurrences []time.Time
+
+	next := standingOrder.NextOccurrence
+
+	for next != nil && !next.After(now) {
+		occurrences = append(occurrences, *next)
+		next = NextOccurrence(rule, standingOrder, *next)
+	}
+
+	if len(occurrences) == 0 {
+		return nil, nil
+	}
+
+	// occurrences missed while the service was down are either all paid or collapsed into the latest one
+	if standingOrder.MissedPolicy == model.MISSED_POLICY_SKIP {
+		occurrences = occurrences[len(occurrences)-1:]
+	}
+
+	var paymentCreates []*handler.PaymentCreate
+
+	for _, occurrence := range occurrences {
+
+		paymentCreates = append(paymentCreates, &handler.PaymentCreate{
+			AccountOrigin:    standingOrder.AccountOrigin,
+			AccountTarget:    standingOrder.AccountTarget,
+			Amount:           standingOrder.Amount,
+			Currency:         standingOrder.Currency,
+			Date:             occurrence,
+			IdempotencyKey:   fmt.Sprintf("standing-order:%s:%d", standingOrder.Uid, occurrence.Unix()),
+			StandingOrderUid: standingOrder.Uid,
+		})
+	}
+
+	lastOccurrence := occurrences[len(occurrences)-1]
+
+	standingOrder.LastOccurrence = &lastOccurrence
+	standingOrder.NextOccurrence = next
+
+	return paymentCreates, nil
+}
+
+func NextOccurrence(rule *recurrence.Rule, standingOrder *model.StandingOrder, after time.Time) *time.Time {
+
+	next, found := rule.Next(standingOrder.StartDate, after)
+
+	if !found {
+		return nil
+	}
+
+	if standingOrder.EndDate != nil && next.After(*standingOrder.EndDate) {
+		return nil
+	}
+
+	return &next
+}
+
+//
+// private functions
+
+// createPayments creates the payments due up to now on behalf of the creator of the standing order; a payment refused, e.g. by a limit, is logged and its occurrence
+// skipped, while any other error is returned so that the next run materializes the standing order again.
+func (sos *StandingOrderService) createPayments(standingOrder *model.StandingOrder, now time.Time) (int, error) {
+
+	paymentCreates, errorGenerate := GeneratePayments(standingOrder, now)
+
+	if errorGenerate != nil {
+		return 0, errorGenerate
+	}
+
+	principal := &auth.Principal{User: standingOrder.CreatedBy, Tenant: standingOrder.Tenant}
+
+	created := 0
+
+	for _, paymentCreate := range paymentCreates {
+
+		_, errorCreate := sos.paymentCreator.Create(paymentCreate, principal)
+
+		if errorCreate == nil {
+			created++
+			continue
+		}
+
+		if !util.IsRefusal(errorCreate) {
+			return 0, errorCreate
+		}
+
+		log.Printf("Payment of standing order %s on %s refused: %v\n", standingOrder.Uid, paymentCreate.Date.Format(time.RFC3339), errorCreate)
+	}
+
+	return created, nil
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"errors"
+	"github.com/javierjmgits/go-payment-api/base/auth"
+	"github.com/javierjmgits/go-payment-api/base/util"
+	"github.com/javierjmgits/go-payment-api/payment/handler"
+	paymentModel "github.com/javierjmgits/go-payment-api/payment/model"
+	"github.com/javierjmgits/go-payment-api/standingorder/model"
+	"github.com/javierjmgits/go-payment-api/standingorder/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+//
+// mocks
+
+type standingOrderRepositoryMock struct {
+	repository.StandingOrderRepository
+	mock.Mock
+}
+
+func (mock *standingOrderRepositoryMock) GetDueUids(now time.Time) ([]string, error) {
+
+	args := mock.Mock.Called(now)
+
+	return args.Get(0).([]string), nil
+}
+
+func (mock *standingOrderRepositoryMock) Materialize(uid string, generate func(*model.StandingOrder) (int, error)) (int, error) {
+
+	args := mock.Mock.Called(uid)
+
+	return generate(args.Get(0).(*model.StandingOrder))
+}
+
+type paymentCreatorMock struct {
+	mock.Mock
+}
+
+func (mock *paymentCreatorMock) Create(paymentCreate *handler.PaymentCreate, principal *auth.Principal) (*paymentModel.Payment, error) {
+
+	args := mock.Mock.Called(paymentCreate.Date, principal.User)
+
+	if args.Get(1) != nil {
+		return nil, args.Get(1).(error)
+	}
+
+	return &paymentModel.Payment{Date: paymentCreate.Date}, nil
+}
+
+//
+// tests
+
+func TestGenerateSkipsRefusedPayments(t *testing.T) {
+
+	now := date(2026, 4, 15)
+	standingOrder := expectedStandingOrder(model.MISSED_POLICY_CATCH_UP)
+	standingOrder.CreatedBy = "alice"
+	mockRepository := &standingOrderRepositoryMock{}
+	mockRepository.On("GetDueUids", now).Return([]string{"myUid"})
+	mockRepository.On("Materialize", "myUid").Return(standingOrder)
+	mockCreator := &paymentCreatorMock{}
+	mockCreator.On("Create", date(2026, 2, 1), "alice").Return(nil, nil)
+	mockCreator.On("Create", date(2026, 3, 1), "alice").Return(nil, &util.RejectedError{Message: "daily limit exceeded"})
+	mockCreator.On("Create", date(2026, 4, 1), "alice").Return(nil, nil)
+
+	generated, errorGenerate := NewStandingOrderService(mockRepository, mockCreator).Generate(now)
+
+	// verify
+
+	mockRepository.AssertExpectations(t)
+	mockCreator.AssertExpectations(t)
+	assert.Nil(t, errorGenerate)
+	assert.Equal(t, 2, generated)
+	assert.Equal(t, date(2026, 5, 1), *standingOrder.NextOccurrence)
+}
+
+func TestGenerateStopsOnFailure(t *testing.T) {
+
+	now := date(2026, 4, 15)
+	mockRepository := &standingOrderRepositoryMock{}
+	mockRepository.On("GetDueUids", now).Return([]string{"myUid"})
+	mockRepository.On("Materialize", "myUid").Return(expectedStandingOrder(model.MISSED_POLICY_CATCH_UP))
+	mockCreator := &paymentCreatorMock{}
+	mockCreator.On("Create", date(2026, 2, 1), "").Return(nil, errors.New("connection refused"))
+
+	generated, errorGenerate := NewStandingOrderService(mockRepository, mockCreator).Generate(now)
+
+	// verify
+
+	mockCreator.AssertExpectations(t)
+	assert.Nil(t, errorGenerate)
+	assert.Equal(t, 0, generated)
+}
+
+func TestGeneratePaymentsCatchUp(t *testing.T) {
+
+	standingOrder := expectedStandingOrder(model.MISSED_POLICY_CATCH_UP)
+
+	payments, errorGenerate := GeneratePayments(standingOrder, date(2026, 4, 15))
+
+	assert.Nil(t, errorGenerate)
+	assert.Len(t, payments, 3)
+	assert.Equal(t, date(2026, 2, 1), payments[0].Date)
+	assert.Equal(t, date(2026, 4, 1), payments[2].Date)
+	assert.Equal(t, "myUid", payments[0].StandingOrderUid)
+	assert.NotEqual(t, payments[0].IdempotencyKey, payments[1].IdempotencyKey)
+	assert.Equal(t, date(2026, 4, 1), *standingOrder.LastOccurrence)
+	assert.Equal(t, date(2026, 5, 1), *standingOrder.NextOccurrence)
+}
+
+func TestGeneratePaymentsSkip(t *testing.T) {
+
+	standingOrder := expectedStandingOrder(model.MISSED_POLICY_SKIP)
+
+	payments, errorGenerate := GeneratePayments(standingOrder, date(2026, 4, 15))
+
+	assert.Nil(t, errorGenerate)
+	assert.Len(t, payments, 1)
+	assert.Equal(t, date(2026, 4, 1), payments[0].Date)
+	assert.Equal(t, date(2026, 5, 1), *standingOrder.NextOccurrence)
+}
+
+func TestGeneratePaymentsUntilEndDate(t *testing.T) {
+
+	standingOrder := expectedStandingOrder(model.MISSED_POLICY_CATCH_UP)
+	endDate := date(2026, 3, 10)
+	standingOrder.EndDate = &endDate
+
+	payments, _ := GeneratePayments(standingOrder, date(2026, 4, 15))
+
+	assert.Len(t, payments, 2)
+	assert.Nil(t, standingOrder.NextOccurrence)
+}
+
+//
+// private functions
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 9, 0, 0, 0, time.UTC)
+}
+
+func expectedStandingOrder(missedPolicy string) *model.StandingOrder {
+
+	nextOccurrence := date(2026, 2, 1)
+
+	return &model.StandingOrder{
+		Uid:            "myUid",
+		AccountOrigin:  "60-16-13 31926819",
+		AccountTarget:  "20-00-00 55779911",
+		Amount:         25,
+		Currency:       "EUR",
+		Rule:           "FREQ=MONTHLY;BYMONTHDAY=1",
+		StartDate:      date(2026, 1, 15),
+		MissedPolicy:   missedPolicy,
+		NextOccurrence: &nextOccurrence,
+	}
+}